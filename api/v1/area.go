@@ -0,0 +1,26 @@
+package v1
+
+type AreaChildrenRequest struct {
+	ParentID int `json:"parent_id"`
+}
+
+type AreaItem struct {
+	ID       int    `json:"id"`
+	ParentID int    `json:"parent_id"`
+	Level    int    `json:"level"`
+	Name     string `json:"name"`
+}
+
+type AreaListResponseData struct {
+	List []AreaItem `json:"list"`
+}
+
+type AreaByLocationRequest struct {
+	Longitude float64 `json:"longitude" binding:"required,min=-180,max=180"`
+	Latitude  float64 `json:"latitude" binding:"required,min=-90,max=90"`
+}
+
+type AreaByLocationResponseData struct {
+	// Areas runs from the province down to the finest level found, empty when none is near.
+	Areas []AreaItem `json:"areas"`
+}
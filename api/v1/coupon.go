@@ -0,0 +1,69 @@
+package v1
+
+import "github.com/go-nunu/nunu-layout-advanced/internal/model"
+
+type CouponMyRequest struct {
+	Status   int `json:"status"`
+	PageNum  int `json:"page_num"`
+	PageSize int `json:"page_size"`
+}
+
+type CouponInfo struct {
+	ID                int64         `json:"id"`
+	TemplateID        int64         `json:"template_id"`
+	Title             string        `json:"title"`
+	ProductType       int           `json:"product_type"`
+	DiscountAmount    model.Decimal `json:"discount_amount"`
+	MinAmount         model.Decimal `json:"min_amount"`
+	FirstRechargeOnly bool          `json:"first_recharge_only"`
+	StartAt           string        `json:"start_at"`
+	EndAt             string        `json:"end_at"`
+	Status            int           `json:"status"`
+	OrderID           int64         `json:"order_id"`
+	UsedAt            string        `json:"used_at"`
+}
+
+type CouponMyResponseData struct {
+	List  []CouponInfo `json:"list"`
+	Total int64        `json:"total"`
+}
+
+type CouponTemplateInfo struct {
+	ID                int64         `json:"id"`
+	Title             string        `json:"title"`
+	ProductType       int           `json:"product_type"`
+	DiscountAmount    model.Decimal `json:"discount_amount"`
+	MinAmount         model.Decimal `json:"min_amount"`
+	FirstRechargeOnly bool          `json:"first_recharge_only"`
+	ValidDays         int           `json:"valid_days"`
+	TotalNum          int           `json:"total_num"`
+	IssuedNum         int           `json:"issued_num"`
+	ClaimStartAt      string        `json:"claim_start_at"`
+	ClaimEndAt        string        `json:"claim_end_at"`
+	Claimed           bool          `json:"claimed"`
+}
+
+type CouponClaimableResponseData struct {
+	List []CouponTemplateInfo `json:"list"`
+}
+
+type CouponClaimRequest struct {
+	TemplateID int64 `json:"template_id" binding:"required"`
+}
+
+type AdminCouponTemplateCreateRequest struct {
+	Title             string        `json:"title" binding:"required"`
+	ProductType       int           `json:"product_type"`
+	DiscountAmount    model.Decimal `json:"discount_amount"`
+	MinAmount         model.Decimal `json:"min_amount"`
+	FirstRechargeOnly bool          `json:"first_recharge_only"`
+	ValidDays         int           `json:"valid_days" binding:"required"`
+	TotalNum          int           `json:"total_num"`
+	ClaimStartAt      string        `json:"claim_start_at"`
+	ClaimEndAt        string        `json:"claim_end_at"`
+}
+
+type AdminCouponIssueRequest struct {
+	TemplateID int64 `json:"template_id" binding:"required"`
+	UserID     int64 `json:"user_id" binding:"required"`
+}
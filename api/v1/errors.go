@@ -10,7 +10,20 @@ var (
 	ErrInternalServerError = newError(500, "Internal Server Error")
 
 	// more biz errors
-	ErrEmailAlreadyUse = newError(1001, "The email is already in use.")
-	ErrInsufficientVoucher = newError(1002, "Insufficient contact voucher.")
-	ErrAmountMismatch      = newError(1003, "Amount mismatch.")
+	ErrEmailAlreadyUse      = newError(1001, "The email is already in use.")
+	ErrInsufficientVoucher  = newError(1002, "Insufficient contact voucher.")
+	ErrAmountMismatch       = newError(1003, "Amount mismatch.")
+	ErrProductUnavailable   = newError(1004, "Product unavailable.")
+	ErrOrderNotPending      = newError(1005, "Order is not pending.")
+	ErrOrderNotRefundable   = newError(1006, "Order is not refundable.")
+	ErrInvalidRefundAmount  = newError(1007, "Invalid refund amount.")
+	ErrIdempotencyKeyReused = newError(1008, "Idempotency key reused with a different request.")
+	ErrJobNotActive         = newError(1009, "Job is not active.")
+	ErrInsufficientIntegral = newError(1010, "Insufficient points.")
+	ErrAlreadyCheckedIn     = newError(1011, "Already checked in today.")
+	ErrRefreshLimitExceeded = newError(1012, "Free refreshes used up for today.")
+	ErrCouponUnavailable    = newError(1013, "Coupon unavailable.")
+	ErrCouponClaimed        = newError(1014, "Coupon already claimed.")
+	ErrOrderNotInvoiceable  = newError(1015, "Order is not invoiceable.")
+	ErrInvoiceNotPending    = newError(1016, "Invoice is not pending.")
 )
@@ -0,0 +1,49 @@
+package v1
+
+type IntegralChangeResponseData struct {
+	Change   int64 `json:"change"`
+	Integral int64 `json:"integral"`
+}
+
+type IntegralRecordsRequest struct {
+	PageNum  int `json:"page_num"`
+	PageSize int `json:"page_size"`
+}
+
+type IntegralRecordsResponseData struct {
+	Integral  int64                 `json:"integral"`
+	List      []IntegralRecordsItem `json:"list"`
+	ListTotal int64                 `json:"list_total"`
+}
+
+type IntegralRecordType string
+
+const (
+	IntegralRecordCheckIn         IntegralRecordType = "checkin"
+	IntegralRecordFirstJob        IntegralRecordType = "first_job"
+	IntegralRecordProfileComplete IntegralRecordType = "profile_complete"
+	IntegralRecordInvite          IntegralRecordType = "invite"
+	IntegralRecordRedeemVoucher   IntegralRecordType = "redeem_voucher"
+	IntegralRecordRedeemRefresh   IntegralRecordType = "redeem_refresh"
+)
+
+type IntegralRecordsItem struct {
+	ID        int64              `json:"id"`
+	Type      IntegralRecordType `json:"type"`
+	Title     string             `json:"title"`
+	ChangeNum int64              `json:"change_num"`
+	CreateAt  string             `json:"create_at"`
+}
+
+type IntegralRedeemType string
+
+const (
+	IntegralRedeemContactVoucher IntegralRedeemType = "contact_voucher"
+	IntegralRedeemRefresh        IntegralRedeemType = "refresh"
+)
+
+type IntegralRedeemRequest struct {
+	Type  IntegralRedeemType `json:"type" binding:"required,oneof=contact_voucher refresh"`
+	Num   int                `json:"num"`
+	JobID int64              `json:"job_id"`
+}
@@ -0,0 +1,61 @@
+package v1
+
+import "github.com/go-nunu/nunu-layout-advanced/internal/model"
+
+type InvoiceRequestRequest struct {
+	OrderNos  []string `json:"order_nos" binding:"required,min=1,max=50"`
+	TitleType int      `json:"title_type" binding:"required,oneof=1 2"`
+	Title     string   `json:"title" binding:"required,max=100"`
+	TaxNo     string   `json:"tax_no"`
+	Email     string   `json:"email" binding:"required,email"`
+}
+
+type InvoiceMyRequest struct {
+	Status   int `json:"status"`
+	PageNum  int `json:"page_num"`
+	PageSize int `json:"page_size"`
+}
+
+type InvoiceOrderInfo struct {
+	OrderID int64         `json:"order_id"`
+	OrderNo string        `json:"order_no"`
+	Amount  model.Decimal `json:"amount"`
+}
+
+type InvoiceInfo struct {
+	ID           int64              `json:"id"`
+	UserID       int64              `json:"user_id"`
+	TitleType    int                `json:"title_type"`
+	Title        string             `json:"title"`
+	TaxNo        string             `json:"tax_no"`
+	Email        string             `json:"email"`
+	Amount       model.Decimal      `json:"amount"`
+	Status       int                `json:"status"`
+	PdfURL       string             `json:"pdf_url"`
+	RejectReason string             `json:"reject_reason"`
+	HandledAt    string             `json:"handled_at"`
+	CreateAt     string             `json:"create_at"`
+	Orders       []InvoiceOrderInfo `json:"orders"`
+}
+
+type InvoiceListResponseData struct {
+	List  []InvoiceInfo `json:"list"`
+	Total int64         `json:"total"`
+}
+
+type AdminInvoiceListRequest struct {
+	UserID   int64 `json:"user_id"`
+	Status   int   `json:"status"`
+	PageNum  int   `json:"page_num"`
+	PageSize int   `json:"page_size"`
+}
+
+type AdminInvoiceIssueRequest struct {
+	InvoiceID int64  `json:"invoice_id" binding:"required"`
+	PdfURL    string `json:"pdf_url"`
+}
+
+type AdminInvoiceRejectRequest struct {
+	InvoiceID int64  `json:"invoice_id" binding:"required"`
+	Reason    string `json:"reason" binding:"required"`
+}
@@ -52,9 +52,9 @@ type JobUpdateRequest struct {
 }
 
 type JobTopRequest struct {
-	JobID   int64   `json:"job_id" binding:"required"`
-	TopHour int     `json:"top_hour" binding:"required"`
-	Price   float64 `json:"price" binding:"required"`
+	JobID    int64 `json:"job_id" binding:"required"`
+	SkuID    int64 `json:"sku_id" binding:"required"`
+	CouponID int64 `json:"coupon_id"`
 }
 
 type JobRefreshRequest struct {
@@ -78,15 +78,19 @@ type JobInfoRequest struct {
 }
 
 type JobFilter struct {
+	Keyword         string   `json:"keyword" binding:"max=50"`
 	Positions       string   `json:"positions"`
 	City            string   `json:"city"`
+	AreaID          int      `json:"area_id"`
 	SalaryMin       int      `json:"salary_min"`
 	SalaryMax       int      `json:"salary_max"`
 	BasicProtection []string `json:"basic_protection"`
 	SalaryBenefits  []string `json:"salary_benefits"`
 	AttendanceLeave []string `json:"attendance_leave"`
+	TagMatch        string   `json:"tag_match" binding:"omitempty,oneof=all any"`
 	Longitude       float64  `json:"longitude"`
 	Latitude        float64  `json:"latitude"`
+	RadiusKm        float64  `json:"radius_km" binding:"min=0,max=100"`
 }
 
 type JobListRequest struct {
@@ -105,6 +109,7 @@ type JobListItem struct {
 	Latitude          float64         `json:"latitude"`
 	Address           string          `json:"address"`
 	Contact           string          `json:"contact"`
+	ContactUnlocked   int             `json:"contact_unlocked"`
 	ContactPersonName string          `json:"contact_person_name"`
 	Description       string          `json:"description"`
 	PhotoURLs         []string        `json:"photo_urls"`
@@ -125,6 +130,9 @@ type JobListItem struct {
 	TopStartTime      string          `json:"top_start_time"`
 	TopEndTime        string          `json:"top_end_time"`
 	LastRefreshTime   string          `json:"last_refresh_time,omitempty"`
+	// Distance is in meters from the position in the list filter; null without one.
+	Distance *int      `json:"distance"`
+	Tags     []TagItem `json:"tags,omitempty"`
 }
 
 type JobListResponseData struct {
@@ -0,0 +1,15 @@
+package v1
+
+type MembershipBuyRequest struct {
+	SkuID    int64 `json:"sku_id" binding:"required"`
+	CouponID int64 `json:"coupon_id"`
+}
+
+type MembershipInfoResponseData struct {
+	Active           bool   `json:"active"`
+	EndAt            string `json:"end_at"`
+	JobLimit         int64  `json:"job_limit"`
+	FreeRefreshDaily int    `json:"free_refresh_daily"`
+	MonthlyVouchers  int    `json:"monthly_vouchers"`
+	TopPricePercent  int    `json:"top_price_percent"`
+}
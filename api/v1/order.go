@@ -1,21 +1,16 @@
 package v1
 
-type WechatPayRequest struct {
-	OrderID int64   `json:"order_id"`
-	OrderNo string  `json:"order_no"`
-	Price   float64 `json:"price"`
-}
+import "github.com/go-nunu/nunu-layout-advanced/internal/model"
 
-type WechatPayNotifyRequest struct {
-	OrderNo    string  `json:"order_no" binding:"required"`
-	Amount     float64 `json:"amount"`
-	PayChannel string  `json:"pay_channel"`
-	PayTradeNo string  `json:"pay_trade_no"`
+// WechatPayNotifyResponse is the acknowledgement body WeChat Pay expects from notify_url.
+type WechatPayNotifyResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
 }
 
 type ContactVoucherBuyRequest struct {
-	Price             float64 `json:"price" binding:"required"`
-	ContactVoucherNum int     `json:"contact_voucher_num" binding:"required"`
+	SkuID    int64 `json:"sku_id" binding:"required"`
+	CouponID int64 `json:"coupon_id"`
 }
 
 type PayParams struct {
@@ -27,36 +22,65 @@ type PayParams struct {
 }
 
 type PayOrderResponseData struct {
-	OrderID   int64     `json:"order_id"`
-	OrderNo   string    `json:"order_no"`
-	Amount    float64   `json:"amount"`
-	PayParams PayParams `json:"pay_params"`
+	OrderID   int64         `json:"order_id"`
+	OrderNo   string        `json:"order_no"`
+	Amount    model.Decimal `json:"amount"`
+	PayParams PayParams     `json:"pay_params"`
+}
+
+type OrderCreateRequest struct {
+	Items    []OrderCreateItem `json:"items" binding:"required,min=1,max=10,dive"`
+	CouponID int64             `json:"coupon_id"`
+}
+
+// OrderCreateItem is one cart line; job_id is required for top and refresh SKUs.
+type OrderCreateItem struct {
+	SkuID int64 `json:"sku_id" binding:"required"`
+	JobID int64 `json:"job_id"`
 }
 
 type JobRefreshPayRequest struct {
-	JobID int64   `json:"job_id" binding:"required"`
-	Price float64 `json:"price" binding:"required"`
+	JobID    int64 `json:"job_id" binding:"required"`
+	SkuID    int64 `json:"sku_id" binding:"required"`
+	CouponID int64 `json:"coupon_id"`
 }
 
 type ContactVoucherCostRequest struct {
-	PurposeID        *int64  `json:"purpose_id"`
-	PurposeType      *int    `json:"purpose_type"`
-	PurposeUserID    *int64  `json:"purpose_user_id"`
-	PurposeUserName  *string `json:"purpose_user_name"`
-	PurposeUserPhone *string `json:"purpose_user_phone"`
+	PurposeID   int64 `json:"purpose_id" binding:"required"`
+	PurposeType int   `json:"purpose_type"`
+}
+
+type ContactVoucherCostResponseData struct {
+	Contact           string `json:"contact"`
+	Charged           bool   `json:"charged"`
+	ContactVoucherNum int    `json:"contact_voucher_num"`
 }
 
 type ContactVoucherRecordsResponseData struct {
 	ContactVoucherNum int                         `json:"contact_voucher_num"`
+	Batches           []ContactVoucherBatchItem   `json:"batches"`
 	List              []ContactVoucherRecordsItem `json:"list"`
 	ListTotal         int64                       `json:"list_total"`
 }
 
+type ContactVoucherBatchItem struct {
+	ID        int64  `json:"id"`
+	TotalNum  int    `json:"total_num"`
+	RemainNum int    `json:"remain_num"`
+	ExpireAt  string `json:"expire_at"`
+	CreateAt  string `json:"create_at"`
+}
+
 type ContactVoucherRecordType string
 
 const (
-	ContactVoucherRecordBuy  ContactVoucherRecordType = "buy"
-	ContactVoucherRecordCost ContactVoucherRecordType = "cost"
+	ContactVoucherRecordBuy        ContactVoucherRecordType = "buy"
+	ContactVoucherRecordCost       ContactVoucherRecordType = "cost"
+	ContactVoucherRecordRefund     ContactVoucherRecordType = "refund"
+	ContactVoucherRecordExpired    ContactVoucherRecordType = "expired"
+	ContactVoucherRecordInvite     ContactVoucherRecordType = "invite"
+	ContactVoucherRecordIntegral   ContactVoucherRecordType = "integral"
+	ContactVoucherRecordMembership ContactVoucherRecordType = "membership"
 )
 
 type ContactVoucherRecordsItem struct {
@@ -66,3 +90,66 @@ type ContactVoucherRecordsItem struct {
 	ChangeNum int                      `json:"change_num"`
 	CreateAt  string                   `json:"create_at"`
 }
+
+type OrderConfirmRequest struct {
+	OrderNo string `json:"order_no" binding:"required"`
+}
+
+type OrderConfirmResponseData struct {
+	OrderID int64  `json:"order_id"`
+	OrderNo string `json:"order_no"`
+	Status  int    `json:"status"`
+}
+
+type OrderCancelRequest struct {
+	OrderNo string `json:"order_no" binding:"required"`
+}
+
+type OrderMyRequest struct {
+	Status   int `json:"status"`
+	PageNum  int `json:"page_num"`
+	PageSize int `json:"page_size"`
+}
+
+type OrderInfoRequest struct {
+	OrderNo string `json:"order_no" binding:"required"`
+}
+
+type OrderItemInfo struct {
+	ID                int64         `json:"id"`
+	ProductType       int           `json:"product_type"`
+	Title             string        `json:"title"`
+	UnitPrice         model.Decimal `json:"unit_price"`
+	TopHour           int           `json:"top_hour"`
+	ContactVoucherNum int           `json:"contact_voucher_num"`
+	MembershipDays    int           `json:"membership_days"`
+	JobID             int64         `json:"job_id"`
+	JobPositions      string        `json:"job_positions"`
+}
+
+type OrderInfo struct {
+	OrderID        int64           `json:"order_id"`
+	OrderNo        string          `json:"order_no"`
+	AmountTotal    model.Decimal   `json:"amount_total"`
+	AmountPaid     model.Decimal   `json:"amount_paid"`
+	CouponID       int64           `json:"coupon_id"`
+	DiscountAmount model.Decimal   `json:"discount_amount"`
+	Status         int             `json:"status"`
+	PayChannel     string          `json:"pay_channel"`
+	PayTradeNo     string          `json:"pay_trade_no"`
+	PaidAt         string          `json:"paid_at"`
+	CanceledAt     string          `json:"canceled_at"`
+	RefundedAt     string          `json:"refunded_at"`
+	Remark         string          `json:"remark"`
+	CreateAt       string          `json:"create_at"`
+	Items          []OrderItemInfo `json:"items"`
+	// InvoiceID and InvoiceStatus describe the latest invoice request for the order;
+	// 0 when it has none.
+	InvoiceID     int64 `json:"invoice_id"`
+	InvoiceStatus int   `json:"invoice_status"`
+}
+
+type OrderMyResponseData struct {
+	List  []OrderInfo `json:"list"`
+	Total int64       `json:"total"`
+}
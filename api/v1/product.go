@@ -0,0 +1,21 @@
+package v1
+
+import "github.com/go-nunu/nunu-layout-advanced/internal/model"
+
+type ProductListRequest struct {
+	ProductType model.ProductType `json:"product_type"`
+}
+
+type ProductItem struct {
+	SkuID             int64             `json:"sku_id"`
+	ProductType       model.ProductType `json:"product_type"`
+	Title             string            `json:"title"`
+	Price             model.Decimal     `json:"price"`
+	TopHour           int               `json:"top_hour"`
+	ContactVoucherNum int               `json:"contact_voucher_num"`
+	MembershipDays    int               `json:"membership_days"`
+}
+
+type ProductListResponseData struct {
+	List []ProductItem `json:"list"`
+}
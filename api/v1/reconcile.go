@@ -0,0 +1,41 @@
+package v1
+
+import "github.com/go-nunu/nunu-layout-advanced/internal/model"
+
+type AdminReconcileListRequest struct {
+	BillDate string `json:"bill_date"`
+	Status   int    `json:"status"`
+	PageNum  int    `json:"page_num"`
+	PageSize int    `json:"page_size"`
+}
+
+type ReconcileDiscrepancyInfo struct {
+	ID          int64         `json:"id"`
+	BillDate    string        `json:"bill_date"`
+	Channel     string        `json:"channel"`
+	Type        int           `json:"type"`
+	OrderNo     string        `json:"order_no"`
+	OrderID     int64         `json:"order_id"`
+	TradeNo     string        `json:"trade_no"`
+	BillAmount  model.Decimal `json:"bill_amount"`
+	OrderAmount model.Decimal `json:"order_amount"`
+	Status      int           `json:"status"`
+	Remark      string        `json:"remark"`
+	UpdateAt    string        `json:"update_at"`
+}
+
+type AdminReconcileListResponseData struct {
+	List  []ReconcileDiscrepancyInfo `json:"list"`
+	Total int64                      `json:"total"`
+}
+
+type AdminReconcileRunRequest struct {
+	BillDate string `json:"bill_date" binding:"required"`
+}
+
+type AdminReconcileRunResponseData struct {
+	BillDate      string `json:"bill_date"`
+	Records       int    `json:"records"`
+	Discrepancies int    `json:"discrepancies"`
+	Fixed         int    `json:"fixed"`
+}
@@ -0,0 +1,19 @@
+package v1
+
+import "github.com/go-nunu/nunu-layout-advanced/internal/model"
+
+type AdminRefundCreateRequest struct {
+	OrderNo string        `json:"order_no" binding:"required"`
+	Amount  model.Decimal `json:"amount"`
+	Reason  string        `json:"reason"`
+}
+
+type RefundResponseData struct {
+	RefundNo           string        `json:"refund_no"`
+	OrderNo            string        `json:"order_no"`
+	Amount             model.Decimal `json:"amount"`
+	Status             int           `json:"status"`
+	RollbackVoucherNum int           `json:"rollback_voucher_num"`
+	RollbackTopHour    int           `json:"rollback_top_hour"`
+	RollbackMemberDays int           `json:"rollback_member_days"`
+}
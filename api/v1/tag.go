@@ -0,0 +1,15 @@
+package v1
+
+type TagListRequest struct {
+	Category int `json:"category"`
+}
+
+type TagItem struct {
+	Category int    `json:"category"`
+	Code     string `json:"code"`
+	Label    string `json:"label"`
+}
+
+type TagListResponseData struct {
+	List []TagItem `json:"list"`
+}
@@ -1,11 +1,12 @@
 package v1
 
 type UserInfoResponseData struct {
-	UserID int64  `json:"user_id"`
-	Avatar string `json:"avatar"`
-	Name   string `json:"name"`
-	Sex    int    `json:"sex"`
-	Phone  string `json:"phone"`
+	UserID   int64  `json:"user_id"`
+	Avatar   string `json:"avatar"`
+	Name     string `json:"name"`
+	Sex      int    `json:"sex"`
+	Phone    string `json:"phone"`
+	Integral uint64 `json:"integral"`
 }
 
 type UserInfoResponse struct {
@@ -28,3 +29,27 @@ type UpdateUserGeoRequest struct {
 	Longitude    *float64 `json:"longitude"`
 	Latitude     *float64 `json:"latitude"`
 }
+
+type UserInvitesRequest struct {
+	PageNum  int `json:"page_num"`
+	PageSize int `json:"page_size"`
+}
+
+type UserInvitesResponseData struct {
+	InviteCode string            `json:"invite_code"`
+	SharePath  string            `json:"share_path"`
+	InviteNum  uint64            `json:"invite_num"`
+	List       []UserInvitesItem `json:"list"`
+	Total      int64             `json:"total"`
+}
+
+type UserInvitesItem struct {
+	UserID        int64  `json:"user_id"`
+	Name          string `json:"name"`
+	Avatar        string `json:"avatar"`
+	Phone         string `json:"phone"`
+	RewardStatus  int    `json:"reward_status"`
+	InviterReward int    `json:"inviter_reward"`
+	InviteeReward int    `json:"invitee_reward"`
+	CreateAt      string `json:"create_at"`
+}
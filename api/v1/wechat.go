@@ -13,7 +13,9 @@ type WechatLoginUserInfo struct {
 }
 
 type WechatRegisterRequest struct {
-	PhoneCode string `json:"phone_code" binding:"required"`
-	LoginCode string `json:"login_code" binding:"required"`
-	InviterID int64  `json:"inviter_id"`
+	PhoneCode  string `json:"phone_code" binding:"required"`
+	LoginCode  string `json:"login_code" binding:"required"`
+	InviterID  int64  `json:"inviter_id"`
+	InviteCode string `json:"invite_code"`
+	DeviceID   string `json:"device_id"`
 }
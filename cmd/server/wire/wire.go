@@ -32,12 +32,30 @@ var repositorySet = wire.NewSet(
 	repository.NewOrderRepository,
 	repository.NewOrderItemRepository,
 	repository.NewContactVoucherHistoryRepository,
+	repository.NewProductRepository,
+	repository.NewRefundRepository,
+	repository.NewIdempotencyKeyRepository,
+	repository.NewNotifyNonceRepository,
+	repository.NewContactUnlockRepository,
+	repository.NewContactVoucherBatchRepository,
+	repository.NewInviteRepository,
+	repository.NewIntegralHistoryRepository,
+	repository.NewMembershipRepository,
+	repository.NewCouponTemplateRepository,
+	repository.NewUserCouponRepository,
+	repository.NewReconcileDiscrepancyRepository,
+	repository.NewInvoiceRepository,
+	repository.NewNotificationRepository,
+	repository.NewJobRefreshRepository,
+	repository.NewTagRepository,
+	repository.NewJobTagRepository,
 )
 
 var serviceSet = wire.NewSet(
 	service.NewService,
 	service.NewUserService,
 	service.NewJobService,
+	service.NewJobSearcher,
 	service.NewCollectService,
 	service.NewContactHistoryService,
 	service.NewOrderService,
@@ -46,6 +64,18 @@ var serviceSet = wire.NewSet(
 	service.NewWechatService,
 	service.NewUploadService,
 	service.NewPayService,
+	service.NewPaymentProvider,
+	service.NewRefundService,
+	service.NewProductService,
+	service.NewContactUnlockService,
+	service.NewInviteService,
+	service.NewIntegralService,
+	service.NewMembershipService,
+	service.NewCouponService,
+	service.NewReconcileService,
+	service.NewInvoiceService,
+	service.NewTagService,
+	service.NewAreaService,
 )
 
 var handlerSet = wire.NewSet(
@@ -57,6 +87,16 @@ var handlerSet = wire.NewSet(
 	handler.NewContactVoucherHistoryHandler,
 	handler.NewWechatHandler,
 	handler.NewUploadHandler,
+	handler.NewProductHandler,
+	handler.NewOrderHandler,
+	handler.NewRefundHandler,
+	handler.NewIntegralHandler,
+	handler.NewMembershipHandler,
+	handler.NewCouponHandler,
+	handler.NewReconcileHandler,
+	handler.NewInvoiceHandler,
+	handler.NewTagHandler,
+	handler.NewAreaHandler,
 )
 
 var jobSet = wire.NewSet(
@@ -89,6 +129,7 @@ func NewWire(*viper.Viper, *log.Logger) (*app.App, func(), error) {
 		serverSet,
 		wire.Struct(new(router.RouterDeps), "*"),
 		sid.NewSid,
+		wire.Bind(new(service.IDGenerator), new(*sid.Sid)),
 		jwt.NewJwt,
 		newApp,
 	))
@@ -33,28 +33,68 @@ func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), err
 	sidSid := sid.NewSid()
 	serviceService := service.NewService(transaction, logger, sidSid, jwtJWT)
 	userRepository := repository.NewUserRepository(repositoryRepository)
-	userService := service.NewUserService(serviceService, userRepository)
-	userHandler := handler.NewUserHandler(handlerHandler, userService)
+	integralHistoryRepository := repository.NewIntegralHistoryRepository(repositoryRepository)
 	jobRepository := repository.NewJobRepository(repositoryRepository)
-	jobService := service.NewJobService(serviceService, jobRepository)
-	payService := service.NewPayService(viperViper)
+	contactVoucherHistoryRepository := repository.NewContactVoucherHistoryRepository(repositoryRepository)
+	contactVoucherBatchRepository := repository.NewContactVoucherBatchRepository(repositoryRepository)
+	contactVoucherHistoryService := service.NewContactVoucherHistoryService(serviceService, contactVoucherHistoryRepository, contactVoucherBatchRepository, userRepository, viperViper)
+	integralService := service.NewIntegralService(serviceService, integralHistoryRepository, userRepository, jobRepository, contactVoucherHistoryService, viperViper)
+	userService := service.NewUserService(serviceService, userRepository, integralService)
+	inviteRepository := repository.NewInviteRepository(repositoryRepository)
+	inviteService := service.NewInviteService(serviceService, inviteRepository, userRepository, contactVoucherHistoryService, integralService, viperViper)
+	userHandler := handler.NewUserHandler(handlerHandler, userService, inviteService)
+	jobRefreshRepository := repository.NewJobRefreshRepository(repositoryRepository)
+	membershipRepository := repository.NewMembershipRepository(repositoryRepository)
+	membershipService := service.NewMembershipService(serviceService, membershipRepository, contactVoucherHistoryService, viperViper)
+	tagRepository := repository.NewTagRepository(repositoryRepository)
+	jobTagRepository := repository.NewJobTagRepository(repositoryRepository)
+	tagService := service.NewTagService(serviceService, tagRepository, jobTagRepository)
+	areaService := service.NewAreaService(serviceService, viperViper)
+	jobSearcher := service.NewJobSearcher(jobRepository, viperViper)
+	jobService := service.NewJobService(serviceService, jobRepository, jobRefreshRepository, integralService, membershipService, tagService, areaService, jobSearcher)
 	orderRepository := repository.NewOrderRepository(repositoryRepository)
 	orderItemRepository := repository.NewOrderItemRepository(repositoryRepository)
-	contactVoucherHistoryRepository := repository.NewContactVoucherHistoryRepository(repositoryRepository)
-	orderService := service.NewOrderService(serviceService, orderRepository, orderItemRepository, jobRepository, userRepository, contactVoucherHistoryRepository)
-	jobHandler := handler.NewJobHandler(handlerHandler, jobService, orderService, payService)
+	invoiceRepository := repository.NewInvoiceRepository(repositoryRepository)
+	notificationRepository := repository.NewNotificationRepository(repositoryRepository)
+	couponTemplateRepository := repository.NewCouponTemplateRepository(repositoryRepository)
+	userCouponRepository := repository.NewUserCouponRepository(repositoryRepository)
+	couponService := service.NewCouponService(serviceService, couponTemplateRepository, userCouponRepository, userRepository)
+	idempotencyKeyRepository := repository.NewIdempotencyKeyRepository(repositoryRepository)
+	productRepository := repository.NewProductRepository(repositoryRepository)
+	productService := service.NewProductService(serviceService, productRepository)
+	notifyNonceRepository := repository.NewNotifyNonceRepository(repositoryRepository)
+	paymentProvider := service.NewPaymentProvider(viperViper, notifyNonceRepository)
+	refundRepository := repository.NewRefundRepository(repositoryRepository)
+	refundService := service.NewRefundService(serviceService, orderRepository, orderItemRepository, refundRepository, jobRepository, contactVoucherHistoryService, membershipService, paymentProvider)
+	orderService := service.NewOrderService(serviceService, orderRepository, orderItemRepository, jobRepository, invoiceRepository, notificationRepository, contactVoucherHistoryService, membershipService, couponService, idempotencyKeyRepository, productService, paymentProvider, refundService, viperViper)
+	payService := service.NewPayService(viperViper, paymentProvider, userRepository, notifyNonceRepository)
+	contactUnlockRepository := repository.NewContactUnlockRepository(repositoryRepository)
+	contactHistoryRepository := repository.NewContactHistoryRepository(repositoryRepository)
+	contactHistoryService := service.NewContactHistoryService(serviceService, contactHistoryRepository, jobRepository, userRepository)
+	contactUnlockService := service.NewContactUnlockService(serviceService, contactUnlockRepository, jobRepository, contactVoucherHistoryService, contactHistoryService)
+	jobHandler := handler.NewJobHandler(handlerHandler, jobService, orderService, payService, contactUnlockService, tagService)
 	collectRepository := repository.NewCollectRepository(repositoryRepository)
 	collectService := service.NewCollectService(serviceService, collectRepository, jobRepository)
 	collectHandler := handler.NewCollectHandler(handlerHandler, collectService)
-	contactHistoryRepository := repository.NewContactHistoryRepository(repositoryRepository)
-	contactHistoryService := service.NewContactHistoryService(serviceService, contactHistoryRepository, jobRepository, userRepository)
 	contactHistoryHandler := handler.NewContactHistoryHandler(handlerHandler, contactHistoryService)
-	contactVoucherHistoryService := service.NewContactVoucherHistoryService(serviceService, contactVoucherHistoryRepository, userRepository)
-	contactVoucherHistoryHandler := handler.NewContactVoucherHistoryHandler(handlerHandler, contactVoucherHistoryService, orderService, contactHistoryService, payService)
-	wechatService := service.NewWechatService(logger, viperViper, jwtJWT, userRepository)
-	wechatHandler := handler.NewWechatHandler(handlerHandler, orderService, wechatService)
+	contactVoucherHistoryHandler := handler.NewContactVoucherHistoryHandler(handlerHandler, contactVoucherHistoryService, orderService, contactUnlockService, payService)
+	wechatService := service.NewWechatService(logger, viperViper, jwtJWT, userRepository, inviteService)
+	wechatHandler := handler.NewWechatHandler(handlerHandler, orderService, wechatService, payService, refundService)
 	uploadService := service.NewUploadService(viperViper)
 	uploadHandler := handler.NewUploadHandler(handlerHandler, uploadService)
+	productHandler := handler.NewProductHandler(handlerHandler, productService)
+	orderHandler := handler.NewOrderHandler(handlerHandler, orderService, payService)
+	refundHandler := handler.NewRefundHandler(handlerHandler, refundService)
+	integralHandler := handler.NewIntegralHandler(handlerHandler, integralService)
+	membershipHandler := handler.NewMembershipHandler(handlerHandler, membershipService, orderService, payService)
+	couponHandler := handler.NewCouponHandler(handlerHandler, couponService)
+	reconcileDiscrepancyRepository := repository.NewReconcileDiscrepancyRepository(repositoryRepository)
+	reconcileService := service.NewReconcileService(serviceService, orderRepository, reconcileDiscrepancyRepository, orderService, paymentProvider)
+	reconcileHandler := handler.NewReconcileHandler(handlerHandler, reconcileService)
+	invoiceService := service.NewInvoiceService(serviceService, invoiceRepository, orderRepository, refundRepository)
+	invoiceHandler := handler.NewInvoiceHandler(handlerHandler, invoiceService, uploadService)
+	tagHandler := handler.NewTagHandler(handlerHandler, tagService)
+	areaHandler := handler.NewAreaHandler(handlerHandler, areaService)
 	routerDeps := router.RouterDeps{
 		Logger:                       logger,
 		Config:                       viperViper,
@@ -66,6 +106,17 @@ func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), err
 		ContactVoucherHistoryHandler: contactVoucherHistoryHandler,
 		WechatHandler:                wechatHandler,
 		UploadHandler:                uploadHandler,
+		ProductHandler:               productHandler,
+		OrderHandler:                 orderHandler,
+		RefundHandler:                refundHandler,
+		IntegralHandler:              integralHandler,
+		MembershipHandler:            membershipHandler,
+		CouponHandler:                couponHandler,
+		ReconcileHandler:             reconcileHandler,
+		InvoiceHandler:               invoiceHandler,
+		TagHandler:                   tagHandler,
+		AreaHandler:                  areaHandler,
+		UserService:                  userService,
 	}
 	httpServer := server.NewHTTPServer(routerDeps)
 	jobJob := job.NewJob(transaction, logger, sidSid)
@@ -78,11 +129,11 @@ func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), err
 
 // wire.go:
 
-var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewJobRepository, repository.NewCollectRepository, repository.NewContactHistoryRepository, repository.NewOrderRepository, repository.NewOrderItemRepository, repository.NewContactVoucherHistoryRepository)
+var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewJobRepository, repository.NewCollectRepository, repository.NewContactHistoryRepository, repository.NewOrderRepository, repository.NewOrderItemRepository, repository.NewContactVoucherHistoryRepository, repository.NewProductRepository, repository.NewRefundRepository, repository.NewIdempotencyKeyRepository, repository.NewNotifyNonceRepository, repository.NewContactUnlockRepository, repository.NewContactVoucherBatchRepository, repository.NewInviteRepository, repository.NewIntegralHistoryRepository, repository.NewMembershipRepository, repository.NewCouponTemplateRepository, repository.NewUserCouponRepository, repository.NewReconcileDiscrepancyRepository, repository.NewInvoiceRepository, repository.NewNotificationRepository, repository.NewJobRefreshRepository, repository.NewTagRepository, repository.NewJobTagRepository)
 
-var serviceSet = wire.NewSet(service.NewService, service.NewUserService, service.NewJobService, service.NewCollectService, service.NewContactHistoryService, service.NewOrderService, service.NewOrderItemService, service.NewContactVoucherHistoryService, service.NewWechatService, service.NewUploadService, service.NewPayService)
+var serviceSet = wire.NewSet(service.NewService, service.NewUserService, service.NewJobService, service.NewJobSearcher, service.NewCollectService, service.NewContactHistoryService, service.NewOrderService, service.NewOrderItemService, service.NewContactVoucherHistoryService, service.NewWechatService, service.NewUploadService, service.NewPayService, service.NewPaymentProvider, service.NewRefundService, service.NewProductService, service.NewContactUnlockService, service.NewInviteService, service.NewIntegralService, service.NewMembershipService, service.NewCouponService, service.NewReconcileService, service.NewInvoiceService, service.NewTagService, service.NewAreaService)
 
-var handlerSet = wire.NewSet(handler.NewHandler, handler.NewUserHandler, handler.NewJobHandler, handler.NewCollectHandler, handler.NewContactHistoryHandler, handler.NewContactVoucherHistoryHandler, handler.NewWechatHandler, handler.NewUploadHandler)
+var handlerSet = wire.NewSet(handler.NewHandler, handler.NewUserHandler, handler.NewJobHandler, handler.NewCollectHandler, handler.NewContactHistoryHandler, handler.NewContactVoucherHistoryHandler, handler.NewWechatHandler, handler.NewUploadHandler, handler.NewProductHandler, handler.NewOrderHandler, handler.NewRefundHandler, handler.NewIntegralHandler, handler.NewMembershipHandler, handler.NewCouponHandler, handler.NewReconcileHandler, handler.NewInvoiceHandler, handler.NewTagHandler, handler.NewAreaHandler)
 
 var jobSet = wire.NewSet(job.NewJob, job.NewUserJob)
 
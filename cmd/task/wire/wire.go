@@ -6,8 +6,10 @@ package wire
 import (
 	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
 	"github.com/go-nunu/nunu-layout-advanced/internal/server"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
 	"github.com/go-nunu/nunu-layout-advanced/internal/task"
 	"github.com/go-nunu/nunu-layout-advanced/pkg/app"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
 	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
 	"github.com/go-nunu/nunu-layout-advanced/pkg/sid"
 	"github.com/google/wire"
@@ -20,11 +22,43 @@ var repositorySet = wire.NewSet(
 	repository.NewRepository,
 	repository.NewTransaction,
 	repository.NewUserRepository,
+	repository.NewJobRepository,
+	repository.NewOrderRepository,
+	repository.NewOrderItemRepository,
+	repository.NewContactVoucherHistoryRepository,
+	repository.NewProductRepository,
+	repository.NewIdempotencyKeyRepository,
+	repository.NewNotifyNonceRepository,
+	repository.NewContactVoucherBatchRepository,
+	repository.NewMembershipRepository,
+	repository.NewCouponTemplateRepository,
+	repository.NewUserCouponRepository,
+	repository.NewReconcileDiscrepancyRepository,
+	repository.NewInvoiceRepository,
+	repository.NewNotificationRepository,
+	repository.NewRefundRepository,
+)
+
+var serviceSet = wire.NewSet(
+	service.NewService,
+	service.NewOrderService,
+	service.NewContactVoucherHistoryService,
+	service.NewProductService,
+	service.NewPaymentProvider,
+	service.NewPayService,
+	service.NewMembershipService,
+	service.NewCouponService,
+	service.NewReconcileService,
+	service.NewRefundService,
 )
 
 var taskSet = wire.NewSet(
 	task.NewTask,
-	task.NewUserTask,
+	task.NewOrderTask,
+	task.NewVoucherTask,
+	task.NewMembershipTask,
+	task.NewReconcileTask,
+	task.NewPayTask,
 )
 var serverSet = wire.NewSet(
 	server.NewTaskServer,
@@ -43,9 +77,12 @@ func newApp(
 func NewWire(*viper.Viper, *log.Logger) (*app.App, func(), error) {
 	panic(wire.Build(
 		repositorySet,
+		serviceSet,
 		taskSet,
 		serverSet,
 		newApp,
 		sid.NewSid,
+		wire.Bind(new(service.IDGenerator), new(*sid.Sid)),
+		jwt.NewJwt,
 	))
 }
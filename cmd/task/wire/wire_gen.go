@@ -9,8 +9,10 @@ package wire
 import (
 	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
 	"github.com/go-nunu/nunu-layout-advanced/internal/server"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
 	"github.com/go-nunu/nunu-layout-advanced/internal/task"
 	"github.com/go-nunu/nunu-layout-advanced/pkg/app"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
 	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
 	"github.com/go-nunu/nunu-layout-advanced/pkg/sid"
 	"github.com/google/wire"
@@ -25,9 +27,39 @@ func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), err
 	transaction := repository.NewTransaction(repositoryRepository)
 	sidSid := sid.NewSid()
 	taskTask := task.NewTask(transaction, logger, sidSid)
+	jwtJWT := jwt.NewJwt(viperViper)
+	serviceService := service.NewService(transaction, logger, sidSid, jwtJWT)
+	orderRepository := repository.NewOrderRepository(repositoryRepository)
+	orderItemRepository := repository.NewOrderItemRepository(repositoryRepository)
+	jobRepository := repository.NewJobRepository(repositoryRepository)
+	invoiceRepository := repository.NewInvoiceRepository(repositoryRepository)
+	notificationRepository := repository.NewNotificationRepository(repositoryRepository)
+	contactVoucherHistoryRepository := repository.NewContactVoucherHistoryRepository(repositoryRepository)
+	contactVoucherBatchRepository := repository.NewContactVoucherBatchRepository(repositoryRepository)
 	userRepository := repository.NewUserRepository(repositoryRepository)
-	userTask := task.NewUserTask(taskTask, userRepository)
-	taskServer := server.NewTaskServer(logger, userTask)
+	contactVoucherHistoryService := service.NewContactVoucherHistoryService(serviceService, contactVoucherHistoryRepository, contactVoucherBatchRepository, userRepository, viperViper)
+	membershipRepository := repository.NewMembershipRepository(repositoryRepository)
+	membershipService := service.NewMembershipService(serviceService, membershipRepository, contactVoucherHistoryService, viperViper)
+	couponTemplateRepository := repository.NewCouponTemplateRepository(repositoryRepository)
+	userCouponRepository := repository.NewUserCouponRepository(repositoryRepository)
+	couponService := service.NewCouponService(serviceService, couponTemplateRepository, userCouponRepository, userRepository)
+	idempotencyKeyRepository := repository.NewIdempotencyKeyRepository(repositoryRepository)
+	productRepository := repository.NewProductRepository(repositoryRepository)
+	productService := service.NewProductService(serviceService, productRepository)
+	notifyNonceRepository := repository.NewNotifyNonceRepository(repositoryRepository)
+	paymentProvider := service.NewPaymentProvider(viperViper, notifyNonceRepository)
+	refundRepository := repository.NewRefundRepository(repositoryRepository)
+	refundService := service.NewRefundService(serviceService, orderRepository, orderItemRepository, refundRepository, jobRepository, contactVoucherHistoryService, membershipService, paymentProvider)
+	orderService := service.NewOrderService(serviceService, orderRepository, orderItemRepository, jobRepository, invoiceRepository, notificationRepository, contactVoucherHistoryService, membershipService, couponService, idempotencyKeyRepository, productService, paymentProvider, refundService, viperViper)
+	orderTask := task.NewOrderTask(taskTask, viperViper, orderService)
+	voucherTask := task.NewVoucherTask(taskTask, contactVoucherHistoryService)
+	membershipTask := task.NewMembershipTask(taskTask, membershipService)
+	reconcileDiscrepancyRepository := repository.NewReconcileDiscrepancyRepository(repositoryRepository)
+	reconcileService := service.NewReconcileService(serviceService, orderRepository, reconcileDiscrepancyRepository, orderService, paymentProvider)
+	reconcileTask := task.NewReconcileTask(taskTask, reconcileService)
+	payService := service.NewPayService(viperViper, paymentProvider, userRepository, notifyNonceRepository)
+	payTask := task.NewPayTask(taskTask, payService)
+	taskServer := server.NewTaskServer(logger, orderTask, voucherTask, membershipTask, reconcileTask, payTask)
 	appApp := newApp(taskServer)
 	return appApp, func() {
 	}, nil
@@ -35,9 +67,11 @@ func NewWire(viperViper *viper.Viper, logger *log.Logger) (*app.App, func(), err
 
 // wire.go:
 
-var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository)
+var repositorySet = wire.NewSet(repository.NewDB, repository.NewRepository, repository.NewTransaction, repository.NewUserRepository, repository.NewJobRepository, repository.NewOrderRepository, repository.NewOrderItemRepository, repository.NewContactVoucherHistoryRepository, repository.NewProductRepository, repository.NewIdempotencyKeyRepository, repository.NewNotifyNonceRepository, repository.NewContactVoucherBatchRepository, repository.NewMembershipRepository, repository.NewCouponTemplateRepository, repository.NewUserCouponRepository, repository.NewReconcileDiscrepancyRepository, repository.NewInvoiceRepository, repository.NewNotificationRepository, repository.NewRefundRepository)
 
-var taskSet = wire.NewSet(task.NewTask, task.NewUserTask)
+var serviceSet = wire.NewSet(service.NewService, service.NewOrderService, service.NewContactVoucherHistoryService, service.NewProductService, service.NewPaymentProvider, service.NewPayService, service.NewMembershipService, service.NewCouponService, service.NewReconcileService, service.NewRefundService)
+
+var taskSet = wire.NewSet(task.NewTask, task.NewOrderTask, task.NewVoucherTask, task.NewMembershipTask, task.NewReconcileTask, task.NewPayTask)
 
 var serverSet = wire.NewSet(server.NewTaskServer)
 
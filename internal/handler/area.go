@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/area"
+	"go.uber.org/zap"
+)
+
+type AreaHandler struct {
+	*Handler
+	areaService service.AreaService
+}
+
+func NewAreaHandler(
+	handler *Handler,
+	areaService service.AreaService,
+) *AreaHandler {
+	return &AreaHandler{
+		Handler:     handler,
+		areaService: areaService,
+	}
+}
+
+// Children godoc
+// @Summary 下级地区列表
+// @Description parent_id 不传或为 0 时返回全部省份
+// @Tags 通用接口
+// @Accept json
+// @Produce json
+// @Param request body v1.AreaChildrenRequest true "params"
+// @Success 200 {object} v1.AreaListResponseData
+// @Router /areas/children [post]
+func (h *AreaHandler) Children(ctx *gin.Context) {
+	var req v1.AreaChildrenRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	areas, err := h.areaService.Children(ctx, req.ParentID)
+	if err != nil {
+		if err == service.ErrInvalidArea {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+			return
+		}
+		h.logger.WithContext(ctx).Error("areaService.Children error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	v1.HandleSuccess(ctx, v1.AreaListResponseData{List: buildAreaItems(areas)})
+}
+
+// ByLocation godoc
+// @Summary 根据经纬度获取地区
+// @Description 按最近的行政中心估算所在的省、市、区县、街道，靠近边界时可能不准
+// @Tags 通用接口
+// @Accept json
+// @Produce json
+// @Param request body v1.AreaByLocationRequest true "params"
+// @Success 200 {object} v1.AreaByLocationResponseData
+// @Router /areas/by_location [post]
+func (h *AreaHandler) ByLocation(ctx *gin.Context) {
+	var req v1.AreaByLocationRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	areas, err := h.areaService.ByLocation(ctx, req.Latitude, req.Longitude)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("areaService.ByLocation error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	v1.HandleSuccess(ctx, v1.AreaByLocationResponseData{Areas: buildAreaItems(areas)})
+}
+
+func buildAreaItems(areas []*area.Area) []v1.AreaItem {
+	items := make([]v1.AreaItem, 0, len(areas))
+	for _, a := range areas {
+		items = append(items, v1.AreaItem{
+			ID:       a.ID,
+			ParentID: a.ParentID,
+			Level:    a.Level,
+			Name:     a.Name,
+		})
+	}
+	return items
+}
@@ -2,7 +2,6 @@ package handler
 
 import (
 	"errors"
-	"io"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
@@ -10,13 +9,14 @@ import (
 	"github.com/go-nunu/nunu-layout-advanced/internal/model"
 	"github.com/go-nunu/nunu-layout-advanced/internal/service"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type ContactVoucherHistoryHandler struct {
 	*Handler
 	contactVoucherHistoryService service.ContactVoucherHistoryService
 	orderService                 service.OrderService
-	contactHistoryService        service.ContactHistoryService
+	contactUnlockService         service.ContactUnlockService
 	payService                   service.PayService
 }
 
@@ -24,14 +24,14 @@ func NewContactVoucherHistoryHandler(
 	handler *Handler,
 	contactVoucherHistoryService service.ContactVoucherHistoryService,
 	orderService service.OrderService,
-	contactHistoryService service.ContactHistoryService,
+	contactUnlockService service.ContactUnlockService,
 	payService service.PayService,
 ) *ContactVoucherHistoryHandler {
 	return &ContactVoucherHistoryHandler{
 		Handler:                      handler,
 		contactVoucherHistoryService: contactVoucherHistoryService,
 		orderService:                 orderService,
-		contactHistoryService:        contactHistoryService,
+		contactUnlockService:         contactUnlockService,
 		payService:                   payService,
 	}
 }
@@ -60,34 +60,28 @@ func (h *ContactVoucherHistoryHandler) Buy(ctx *gin.Context) {
 		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
 		return
 	}
-	order, _, err := h.orderService.CreateContactVoucherOrder(ctx, userID, req.Price, req.ContactVoucherNum)
-	if err != nil {
-		h.logger.WithContext(ctx).Error("orderService.CreateContactVoucherOrder error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+	idempotencyKey, ok := getIdempotencyKey(ctx)
+	if !ok {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, "invalid Idempotency-Key")
 		return
 	}
-	params, err := h.payService.BuildJSAPIPayParams(ctx, order.OrderNo, req.Price)
+	order, item, err := h.orderService.CreateContactVoucherOrder(ctx, userID, req.SkuID, req.CouponID, idempotencyKey)
 	if err != nil {
-		h.logger.WithContext(ctx).Error("payService.BuildJSAPIPayParams error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		h.handleCreateOrderError(ctx, "orderService.CreateContactVoucherOrder", err)
 		return
 	}
-	v1.HandleSuccess(ctx, v1.PayOrderResponseData{
-		OrderID:   order.ID,
-		OrderNo:   order.OrderNo,
-		Amount:    req.Price,
-		PayParams: params,
-	})
+	h.respondPayOrder(ctx, h.payService, order, item.TitleSnapshot)
 }
 
 // Cost godoc
-// @Summary 联系券消费
+// @Summary 联系券消费（解锁联系电话）
+// @Description 同一用户同一条招聘只扣一次券，重复调用直接返回电话
 // @Tags 联系券模块
 // @Accept json
 // @Produce json
 // @Security Bearer
 // @Param request body v1.ContactVoucherCostRequest true "params"
-// @Success 200 {object} v1.Response
+// @Success 200 {object} v1.ContactVoucherCostResponseData
 // @Router /contact_voucher/cost [post]
 func (h *ContactVoucherHistoryHandler) Cost(ctx *gin.Context) {
 	userID := GetUserIdFromCtx(ctx)
@@ -96,31 +90,40 @@ func (h *ContactVoucherHistoryHandler) Cost(ctx *gin.Context) {
 		return
 	}
 	var req v1.ContactVoucherCostRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+	if err := ctx.ShouldBindJSON(&req); err != nil {
 		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
 		return
 	}
-	_, err := h.contactVoucherHistoryService.AdjustVoucher(ctx, userID, model.ContactVoucherHistoryCost, -1, "拨打电话")
+	if req.PurposeType == 0 {
+		req.PurposeType = model.ContactPurposeJob
+	}
+	result, err := h.contactUnlockService.Unlock(ctx, userID, req.PurposeType, req.PurposeID)
 	if err != nil {
-		h.logger.WithContext(ctx).Error("contactVoucherHistoryService.AdjustVoucher error", zap.Error(err))
+		h.logger.WithContext(ctx).Error("contactUnlockService.Unlock error", zap.Error(err))
 		if err == service.ErrInsufficientVoucher {
 			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrInsufficientVoucher, err.Error())
 			return
 		}
+		if err == service.ErrUnsupportedPurpose {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+			return
+		}
+		if err == service.ErrJobNotActive {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrJobNotActive, err.Error())
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, err.Error())
+			return
+		}
 		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
 		return
 	}
-	if req.PurposeID != nil && req.PurposeType != nil {
-		_, _ = h.contactHistoryService.Create(ctx, service.ContactHistoryCreateInput{
-			UserID:           userID,
-			PurposeID:        *req.PurposeID,
-			PurposeType:      *req.PurposeType,
-			PurposeUserID:    getInt64(req.PurposeUserID),
-			PurposeUserName:  getString(req.PurposeUserName),
-			PurposeUserPhone: getString(req.PurposeUserPhone),
-		})
-	}
-	v1.HandleSuccess(ctx, nil)
+	v1.HandleSuccess(ctx, v1.ContactVoucherCostResponseData{
+		Contact:           result.Phone,
+		Charged:           result.Charged,
+		ContactVoucherNum: result.ContactVoucherNum,
+	})
 }
 
 // Records godoc
@@ -155,17 +158,49 @@ func (h *ContactVoucherHistoryHandler) Records(ctx *gin.Context) {
 		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
 		return
 	}
+	batches, err := h.contactVoucherHistoryService.ListBatches(ctx, userID)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("contactVoucherHistoryService.ListBatches error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
 	resp := v1.ContactVoucherRecordsResponseData{
 		ContactVoucherNum: num,
+		Batches:           make([]v1.ContactVoucherBatchItem, 0, len(batches)),
 		List:              make([]v1.ContactVoucherRecordsItem, 0, len(histories)),
 		ListTotal:         total,
 	}
+	for _, batch := range batches {
+		resp.Batches = append(resp.Batches, v1.ContactVoucherBatchItem{
+			ID:        batch.ID,
+			TotalNum:  batch.TotalNum,
+			RemainNum: batch.RemainNum,
+			ExpireAt:  formatOptionalTime(batch.ExpireAt),
+			CreateAt:  formatTime(batch.CreateAt),
+		})
+	}
 	for _, history := range histories {
 		itemType := v1.ContactVoucherRecordCost
 		title := "拨打电话"
-		if history.BizType == model.ContactVoucherHistoryBuy {
+		switch history.BizType {
+		case model.ContactVoucherHistoryBuy:
 			itemType = v1.ContactVoucherRecordBuy
 			title = "购买"
+		case model.ContactVoucherHistoryRefund:
+			itemType = v1.ContactVoucherRecordRefund
+			title = "退款扣回"
+		case model.ContactVoucherHistoryExpired:
+			itemType = v1.ContactVoucherRecordExpired
+			title = "过期"
+		case model.ContactVoucherHistoryInvite:
+			itemType = v1.ContactVoucherRecordInvite
+			title = "邀请奖励"
+		case model.ContactVoucherHistoryIntegral:
+			itemType = v1.ContactVoucherRecordIntegral
+			title = "积分兑换"
+		case model.ContactVoucherHistoryMembership:
+			itemType = v1.ContactVoucherRecordMembership
+			title = "会员赠送"
 		}
 		resp.List = append(resp.List, v1.ContactVoucherRecordsItem{
 			ID:        history.ID,
@@ -177,17 +212,3 @@ func (h *ContactVoucherHistoryHandler) Records(ctx *gin.Context) {
 	}
 	v1.HandleSuccess(ctx, resp)
 }
-
-func getInt64(ptr *int64) int64 {
-	if ptr == nil {
-		return 0
-	}
-	return *ptr
-}
-
-func getString(ptr *string) string {
-	if ptr == nil {
-		return ""
-	}
-	return *ptr
-}
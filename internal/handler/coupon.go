@@ -0,0 +1,258 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"go.uber.org/zap"
+)
+
+type CouponHandler struct {
+	*Handler
+	couponService service.CouponService
+}
+
+func NewCouponHandler(handler *Handler, couponService service.CouponService) *CouponHandler {
+	return &CouponHandler{
+		Handler:       handler,
+		couponService: couponService,
+	}
+}
+
+// My godoc
+// @Summary 我的优惠券
+// @Description status: 1=未使用 2=已锁定（待支付订单占用） 3=已使用，不传返回全部
+// @Tags 优惠券模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CouponMyRequest true "params"
+// @Success 200 {object} v1.CouponMyResponseData
+// @Router /coupons/my [post]
+func (h *CouponHandler) My(ctx *gin.Context) {
+	userID := GetUserIdFromCtx(ctx)
+	if userID == 0 {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, v1.ErrUnauthorized.Error())
+		return
+	}
+	var req v1.CouponMyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	coupons, total, err := h.couponService.ListByUser(ctx, userID, model.UserCouponStatus(req.Status), req.PageNum, req.PageSize)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("couponService.ListByUser error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	resp := v1.CouponMyResponseData{
+		List:  make([]v1.CouponInfo, 0, len(coupons)),
+		Total: total,
+	}
+	for _, coupon := range coupons {
+		resp.List = append(resp.List, buildCouponInfo(coupon))
+	}
+	v1.HandleSuccess(ctx, resp)
+}
+
+// Claimable godoc
+// @Summary 可领取的优惠券
+// @Tags 优惠券模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.CouponClaimableResponseData
+// @Router /coupons/claimable [post]
+func (h *CouponHandler) Claimable(ctx *gin.Context) {
+	userID := GetUserIdFromCtx(ctx)
+	if userID == 0 {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, v1.ErrUnauthorized.Error())
+		return
+	}
+	list, err := h.couponService.ListClaimable(ctx, userID)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("couponService.ListClaimable error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	resp := v1.CouponClaimableResponseData{
+		List: make([]v1.CouponTemplateInfo, 0, len(list)),
+	}
+	for _, item := range list {
+		info := buildCouponTemplateInfo(item.Template)
+		info.Claimed = item.Claimed
+		resp.List = append(resp.List, info)
+	}
+	v1.HandleSuccess(ctx, resp)
+}
+
+// Claim godoc
+// @Summary 领取优惠券
+// @Tags 优惠券模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.CouponClaimRequest true "params"
+// @Success 200 {object} v1.CouponInfo
+// @Router /coupons/claim [post]
+func (h *CouponHandler) Claim(ctx *gin.Context) {
+	userID := GetUserIdFromCtx(ctx)
+	if userID == 0 {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, v1.ErrUnauthorized.Error())
+		return
+	}
+	var req v1.CouponClaimRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	coupon, err := h.couponService.Claim(ctx, userID, req.TemplateID)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("couponService.Claim error", zap.Error(err))
+		h.handleIssueError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, buildCouponInfo(coupon))
+}
+
+// AdminCreateTemplate godoc
+// @Summary 创建优惠券模板（管理员）
+// @Description product_type 0 表示全部商品可用；claim_start_at/claim_end_at 格式 2006-01-02 15:04:05，不传表示不限
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.AdminCouponTemplateCreateRequest true "params"
+// @Success 200 {object} v1.CouponTemplateInfo
+// @Router /admin/coupons/templates/create [post]
+func (h *CouponHandler) AdminCreateTemplate(ctx *gin.Context) {
+	var req v1.AdminCouponTemplateCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	claimStartAt, err := parseOptionalTime(req.ClaimStartAt)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	claimEndAt, err := parseOptionalTime(req.ClaimEndAt)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	template, err := h.couponService.CreateTemplate(ctx, service.CouponTemplateInput{
+		Title:             req.Title,
+		ProductType:       model.ProductType(req.ProductType),
+		DiscountAmount:    req.DiscountAmount,
+		MinAmount:         req.MinAmount,
+		FirstRechargeOnly: req.FirstRechargeOnly,
+		ValidDays:         req.ValidDays,
+		TotalNum:          req.TotalNum,
+		ClaimStartAt:      claimStartAt,
+		ClaimEndAt:        claimEndAt,
+	})
+	if err != nil {
+		h.logger.WithContext(ctx).Error("couponService.CreateTemplate error", zap.Error(err))
+		if err == service.ErrInvalidCoupon {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+			return
+		}
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	v1.HandleSuccess(ctx, buildCouponTemplateInfo(template))
+}
+
+// AdminIssue godoc
+// @Summary 发放优惠券（管理员）
+// @Description 不受模板上下线和领取时间限制，每个用户每个模板仍只能持有一张
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.AdminCouponIssueRequest true "params"
+// @Success 200 {object} v1.CouponInfo
+// @Router /admin/coupons/issue [post]
+func (h *CouponHandler) AdminIssue(ctx *gin.Context) {
+	var req v1.AdminCouponIssueRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	coupon, err := h.couponService.Issue(ctx, req.UserID, req.TemplateID)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("couponService.Issue error", zap.Error(err))
+		h.handleIssueError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, buildCouponInfo(coupon))
+}
+
+func (h *CouponHandler) handleIssueError(ctx *gin.Context, err error) {
+	if err == service.ErrCouponUnavailable {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrCouponUnavailable, err.Error())
+		return
+	}
+	if err == service.ErrCouponClaimed {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrCouponClaimed, err.Error())
+		return
+	}
+	if err == v1.ErrNotFound {
+		v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, err.Error())
+		return
+	}
+	v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+}
+
+func buildCouponInfo(coupon *model.UserCoupon) v1.CouponInfo {
+	return v1.CouponInfo{
+		ID:                coupon.ID,
+		TemplateID:        coupon.TemplateID,
+		Title:             coupon.Title,
+		ProductType:       int(coupon.ProductType),
+		DiscountAmount:    coupon.DiscountAmount,
+		MinAmount:         coupon.MinAmount,
+		FirstRechargeOnly: coupon.FirstRechargeOnly,
+		StartAt:           formatTime(coupon.StartAt),
+		EndAt:             formatTime(coupon.EndAt),
+		Status:            int(coupon.Status),
+		OrderID:           coupon.OrderID,
+		UsedAt:            formatOptionalTime(coupon.UsedAt),
+	}
+}
+
+func buildCouponTemplateInfo(template *model.CouponTemplate) v1.CouponTemplateInfo {
+	return v1.CouponTemplateInfo{
+		ID:                template.ID,
+		Title:             template.Title,
+		ProductType:       int(template.ProductType),
+		DiscountAmount:    template.DiscountAmount,
+		MinAmount:         template.MinAmount,
+		FirstRechargeOnly: template.FirstRechargeOnly,
+		ValidDays:         template.ValidDays,
+		TotalNum:          template.TotalNum,
+		IssuedNum:         template.IssuedNum,
+		ClaimStartAt:      formatOptionalTime(template.ClaimStartAt),
+		ClaimEndAt:        formatOptionalTime(template.ClaimEndAt),
+	}
+}
+
+// parseOptionalTime reads a "2006-01-02 15:04:05" time in the server's zone; empty means none.
+func parseOptionalTime(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	t, err := time.ParseInLocation("2006-01-02 15:04:05", value, time.Local)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
@@ -1,10 +1,18 @@
 package handler
 
 import (
+	"errors"
+	"net/http"
+	"strconv"
+
 	"github.com/gin-gonic/gin"
+	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
 	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
 	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
-	"strconv"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type Handler struct {
@@ -19,14 +27,26 @@ func NewHandler(
 	}
 }
 func GetUserIdFromCtx(ctx *gin.Context) int64 {
+	if userID := getUserIdFromClaims(ctx); userID > 0 {
+		return userID
+	}
+	return getUserIdFromHeader(ctx)
+}
+
+// getUserIdFromClaims only trusts a verified token, for public routes that reveal more to
+// the signed-in user; it returns 0 for anonymous callers.
+func getUserIdFromClaims(ctx *gin.Context) int64 {
 	v, exists := ctx.Get("claims")
 	if !exists {
-		return getUserIdFromHeader(ctx)
+		return 0
 	}
-	userID := v.(*jwt.MyCustomClaims).UserId
-	parsed, err := strconv.ParseInt(userID, 10, 64)
+	claims, ok := v.(*jwt.MyCustomClaims)
+	if !ok {
+		return 0
+	}
+	parsed, err := strconv.ParseInt(claims.UserId, 10, 64)
 	if err != nil || parsed <= 0 {
-		return getUserIdFromHeader(ctx)
+		return 0
 	}
 	return parsed
 }
@@ -42,3 +62,54 @@ func getUserIdFromHeader(ctx *gin.Context) int64 {
 	}
 	return parsed
 }
+
+// getIdempotencyKey reads the optional Idempotency-Key header; ok is false when it is too long.
+func getIdempotencyKey(ctx *gin.Context) (string, bool) {
+	key := ctx.GetHeader("Idempotency-Key")
+	if len(key) > 64 {
+		return "", false
+	}
+	return key, true
+}
+
+// handleCreateOrderError answers a failed OrderService Create* call, logged under op.
+func (h *Handler) handleCreateOrderError(ctx *gin.Context, op string, err error) {
+	h.logger.WithContext(ctx).Error(op+" error", zap.Error(err))
+	switch {
+	case err == service.ErrInvalidOrderLines:
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+	case err == service.ErrForbidden:
+		v1.HandleError(ctx, http.StatusForbidden, v1.ErrForbidden, err.Error())
+	case err == service.ErrProductNotFound:
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrProductUnavailable, err.Error())
+	case err == service.ErrIdempotencyKeyReused:
+		v1.HandleError(ctx, http.StatusUnprocessableEntity, v1.ErrIdempotencyKeyReused, err.Error())
+	case err == service.ErrCouponUnavailable:
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrCouponUnavailable, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, err.Error())
+	default:
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+	}
+}
+
+// respondPayOrder answers a created order with the JSAPI params to pay it. A replayed
+// Idempotency-Key may return an order that is no longer payable; it comes back without them.
+func (h *Handler) respondPayOrder(ctx *gin.Context, payService service.PayService, order *model.Order, description string) {
+	var params v1.PayParams
+	if order.Status == model.OrderStatusPending {
+		var err error
+		params, err = payService.BuildJSAPIPayParams(ctx, order, description)
+		if err != nil {
+			h.logger.WithContext(ctx).Error("payService.BuildJSAPIPayParams error", zap.Error(err))
+			v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+			return
+		}
+	}
+	v1.HandleSuccess(ctx, v1.PayOrderResponseData{
+		OrderID:   order.ID,
+		OrderNo:   order.OrderNo,
+		Amount:    order.AmountTotal,
+		PayParams: params,
+	})
+}
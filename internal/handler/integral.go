@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type IntegralHandler struct {
+	*Handler
+	integralService service.IntegralService
+}
+
+func NewIntegralHandler(handler *Handler, integralService service.IntegralService) *IntegralHandler {
+	return &IntegralHandler{
+		Handler:         handler,
+		integralService: integralService,
+	}
+}
+
+// CheckIn godoc
+// @Summary 每日签到
+// @Tags 积分模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.IntegralChangeResponseData
+// @Router /integral/checkin [post]
+func (h *IntegralHandler) CheckIn(ctx *gin.Context) {
+	userID := GetUserIdFromCtx(ctx)
+	if userID == 0 {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, v1.ErrUnauthorized.Error())
+		return
+	}
+	result, err := h.integralService.CheckIn(ctx, userID)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("integralService.CheckIn error", zap.Error(err))
+		if err == service.ErrAlreadyCheckedIn {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrAlreadyCheckedIn, err.Error())
+			return
+		}
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	v1.HandleSuccess(ctx, v1.IntegralChangeResponseData{
+		Change:   result.Change,
+		Integral: result.Integral,
+	})
+}
+
+// Records godoc
+// @Summary 积分明细
+// @Tags 积分模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.IntegralRecordsRequest true "params"
+// @Success 200 {object} v1.IntegralRecordsResponseData
+// @Router /integral/records [post]
+func (h *IntegralHandler) Records(ctx *gin.Context) {
+	userID := GetUserIdFromCtx(ctx)
+	if userID == 0 {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, v1.ErrUnauthorized.Error())
+		return
+	}
+	var req v1.IntegralRecordsRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	histories, total, err := h.integralService.ListByUser(ctx, userID, req.PageNum, req.PageSize)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("integralService.ListByUser error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	balance, err := h.integralService.GetBalance(ctx, userID)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("integralService.GetBalance error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	resp := v1.IntegralRecordsResponseData{
+		Integral:  balance,
+		List:      make([]v1.IntegralRecordsItem, 0, len(histories)),
+		ListTotal: total,
+	}
+	for _, history := range histories {
+		itemType := v1.IntegralRecordCheckIn
+		title := "每日签到"
+		switch history.BizType {
+		case model.IntegralHistoryFirstJob:
+			itemType = v1.IntegralRecordFirstJob
+			title = "首次发布招聘"
+		case model.IntegralHistoryProfileComplete:
+			itemType = v1.IntegralRecordProfileComplete
+			title = "完善资料"
+		case model.IntegralHistoryInvite:
+			itemType = v1.IntegralRecordInvite
+			title = "邀请好友"
+		case model.IntegralHistoryRedeemVoucher:
+			itemType = v1.IntegralRecordRedeemVoucher
+			title = "兑换联系券"
+		case model.IntegralHistoryRedeemRefresh:
+			itemType = v1.IntegralRecordRedeemRefresh
+			title = "兑换刷新"
+		}
+		resp.List = append(resp.List, v1.IntegralRecordsItem{
+			ID:        history.ID,
+			Type:      itemType,
+			Title:     title,
+			ChangeNum: history.ChangeNum,
+			CreateAt:  formatTime(history.CreateAt),
+		})
+	}
+	v1.HandleSuccess(ctx, resp)
+}
+
+// Redeem godoc
+// @Summary 积分兑换
+// @Description type=contact_voucher 兑换 num 张联系券；type=refresh 兑换一次 job_id 的刷新
+// @Tags 积分模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.IntegralRedeemRequest true "params"
+// @Success 200 {object} v1.IntegralChangeResponseData
+// @Router /integral/redeem [post]
+func (h *IntegralHandler) Redeem(ctx *gin.Context) {
+	userID := GetUserIdFromCtx(ctx)
+	if userID == 0 {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, v1.ErrUnauthorized.Error())
+		return
+	}
+	var req v1.IntegralRedeemRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	input := service.IntegralRedeemInput{
+		UserID:  userID,
+		BizType: model.IntegralHistoryRedeemVoucher,
+		Num:     req.Num,
+	}
+	if req.Type == v1.IntegralRedeemRefresh {
+		input.BizType = model.IntegralHistoryRedeemRefresh
+		input.JobID = req.JobID
+	}
+	result, err := h.integralService.Redeem(ctx, input)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("integralService.Redeem error", zap.Error(err))
+		if err == service.ErrInsufficientIntegral {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrInsufficientIntegral, err.Error())
+			return
+		}
+		if err == service.ErrInvalidRedeem {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+			return
+		}
+		if err == service.ErrForbidden {
+			v1.HandleError(ctx, http.StatusForbidden, v1.ErrForbidden, err.Error())
+			return
+		}
+		if err == service.ErrJobNotActive {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrJobNotActive, err.Error())
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, err.Error())
+			return
+		}
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	v1.HandleSuccess(ctx, v1.IntegralChangeResponseData{
+		Change:   result.Change,
+		Integral: result.Integral,
+	})
+}
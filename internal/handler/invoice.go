@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type InvoiceHandler struct {
+	*Handler
+	invoiceService service.InvoiceService
+	uploadService  service.UploadService
+}
+
+func NewInvoiceHandler(
+	handler *Handler,
+	invoiceService service.InvoiceService,
+	uploadService service.UploadService,
+) *InvoiceHandler {
+	return &InvoiceHandler{
+		Handler:        handler,
+		invoiceService: invoiceService,
+		uploadService:  uploadService,
+	}
+}
+
+// Request godoc
+// @Summary 申请开票
+// @Description 一次可合并多笔已支付订单；已在开票中或已开票的订单不能重复申请，被驳回后可重新申请。title_type: 1=个人 2=企业（企业须填税号）
+// @Tags 发票模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.InvoiceRequestRequest true "params"
+// @Success 200 {object} v1.InvoiceInfo
+// @Router /invoices/request [post]
+func (h *InvoiceHandler) Request(ctx *gin.Context) {
+	userID := GetUserIdFromCtx(ctx)
+	if userID == 0 {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, v1.ErrUnauthorized.Error())
+		return
+	}
+	var req v1.InvoiceRequestRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	detail, err := h.invoiceService.Request(ctx, service.InvoiceRequestInput{
+		UserID:    userID,
+		OrderNos:  req.OrderNos,
+		TitleType: model.InvoiceTitleType(req.TitleType),
+		Title:     req.Title,
+		TaxNo:     req.TaxNo,
+		Email:     req.Email,
+	})
+	if err != nil {
+		h.logger.WithContext(ctx).Error("invoiceService.Request error", zap.Error(err))
+		if err == service.ErrInvalidInvoice {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+			return
+		}
+		if err == service.ErrOrderNotInvoiceable {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrOrderNotInvoiceable, err.Error())
+			return
+		}
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	v1.HandleSuccess(ctx, buildInvoiceInfo(detail))
+}
+
+// My godoc
+// @Summary 我的发票
+// @Description status: 1=待开票 2=已开票 3=已驳回，不传返回全部
+// @Tags 发票模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.InvoiceMyRequest true "params"
+// @Success 200 {object} v1.InvoiceListResponseData
+// @Router /invoices/my [post]
+func (h *InvoiceHandler) My(ctx *gin.Context) {
+	userID := GetUserIdFromCtx(ctx)
+	if userID == 0 {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, v1.ErrUnauthorized.Error())
+		return
+	}
+	var req v1.InvoiceMyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	h.list(ctx, userID, req.Status, req.PageNum, req.PageSize)
+}
+
+// AdminList godoc
+// @Summary 发票申请列表（管理员）
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.AdminInvoiceListRequest true "params"
+// @Success 200 {object} v1.InvoiceListResponseData
+// @Router /admin/invoices/list [post]
+func (h *InvoiceHandler) AdminList(ctx *gin.Context) {
+	var req v1.AdminInvoiceListRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	h.list(ctx, req.UserID, req.Status, req.PageNum, req.PageSize)
+}
+
+func (h *InvoiceHandler) list(ctx *gin.Context, userID int64, status, pageNum, pageSize int) {
+	details, total, err := h.invoiceService.List(ctx, userID, model.InvoiceStatus(status), pageNum, pageSize)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("invoiceService.List error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	resp := v1.InvoiceListResponseData{
+		List:  make([]v1.InvoiceInfo, 0, len(details)),
+		Total: total,
+	}
+	for _, detail := range details {
+		resp.List = append(resp.List, buildInvoiceInfo(detail))
+	}
+	v1.HandleSuccess(ctx, resp)
+}
+
+// AdminUpload godoc
+// @Summary 上传发票 PDF（管理员）
+// @Description 返回的 url 用于 /admin/invoices/issue 的 pdf_url
+// @Tags 管理模块
+// @Accept multipart/form-data
+// @Produce json
+// @Security Bearer
+// @Param file formData file true "file"
+// @Success 200 {object} v1.UploadImageResponseData
+// @Router /admin/invoices/upload [post]
+func (h *InvoiceHandler) AdminUpload(ctx *gin.Context) {
+	file, err := ctx.FormFile("file")
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	const maxSize = 10 * 1024 * 1024
+	if file.Size > maxSize || strings.ToLower(filepath.Ext(file.Filename)) != ".pdf" {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, "only PDF files up to 10MB are accepted")
+		return
+	}
+	localFile, err := file.Open()
+	if err != nil {
+		h.logger.WithContext(ctx).Error("upload Open file error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	defer localFile.Close()
+
+	filename := fmt.Sprintf("invoice_%d_%d.pdf", GetUserIdFromCtx(ctx), time.Now().UnixNano())
+	url, err := h.uploadService.Upload(ctx, localFile, filename)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("uploadService.Upload error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	v1.HandleSuccess(ctx, v1.UploadImageResponseData{URL: url})
+}
+
+// AdminIssue godoc
+// @Summary 开具发票（管理员）
+// @Description pdf_url 可选，先通过 /admin/invoices/upload 上传
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.AdminInvoiceIssueRequest true "params"
+// @Success 200 {object} v1.InvoiceInfo
+// @Router /admin/invoices/issue [post]
+func (h *InvoiceHandler) AdminIssue(ctx *gin.Context) {
+	var req v1.AdminInvoiceIssueRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	detail, err := h.invoiceService.Issue(ctx, GetUserIdFromCtx(ctx), req.InvoiceID, req.PdfURL)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("invoiceService.Issue error", zap.Error(err))
+		h.handleFinishError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, buildInvoiceInfo(detail))
+}
+
+// AdminReject godoc
+// @Summary 驳回发票申请（管理员）
+// @Description 驳回后订单可重新申请开票
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.AdminInvoiceRejectRequest true "params"
+// @Success 200 {object} v1.InvoiceInfo
+// @Router /admin/invoices/reject [post]
+func (h *InvoiceHandler) AdminReject(ctx *gin.Context) {
+	var req v1.AdminInvoiceRejectRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	detail, err := h.invoiceService.Reject(ctx, GetUserIdFromCtx(ctx), req.InvoiceID, req.Reason)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("invoiceService.Reject error", zap.Error(err))
+		h.handleFinishError(ctx, err)
+		return
+	}
+	v1.HandleSuccess(ctx, buildInvoiceInfo(detail))
+}
+
+func (h *InvoiceHandler) handleFinishError(ctx *gin.Context, err error) {
+	if err == service.ErrInvoiceNotPending {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrInvoiceNotPending, err.Error())
+		return
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, err.Error())
+		return
+	}
+	v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+}
+
+func buildInvoiceInfo(detail *service.InvoiceDetail) v1.InvoiceInfo {
+	invoice := detail.Invoice
+	info := v1.InvoiceInfo{
+		ID:           invoice.ID,
+		UserID:       invoice.UserID,
+		TitleType:    int(invoice.TitleType),
+		Title:        invoice.Title,
+		TaxNo:        invoice.TaxNo,
+		Email:        invoice.Email,
+		Amount:       invoice.Amount,
+		Status:       int(invoice.Status),
+		PdfURL:       invoice.PdfURL,
+		RejectReason: invoice.RejectReason,
+		HandledAt:    formatOptionalTime(invoice.HandledAt),
+		CreateAt:     formatTime(invoice.CreateAt),
+		Orders:       make([]v1.InvoiceOrderInfo, 0, len(detail.Orders)),
+	}
+	for _, order := range detail.Orders {
+		info.Orders = append(info.Orders, v1.InvoiceOrderInfo{
+			OrderID: order.OrderID,
+			OrderNo: order.OrderNo,
+			Amount:  order.Amount,
+		})
+	}
+	return info
+}
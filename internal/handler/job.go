@@ -2,6 +2,7 @@ package handler
 
 import (
 	"fmt"
+	"math"
 	"net/http"
 	"strings"
 	"time"
@@ -11,14 +12,18 @@ import (
 	"github.com/go-nunu/nunu-layout-advanced/internal/model"
 	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
 	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/area"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/geo"
 	"go.uber.org/zap"
 )
 
 type JobHandler struct {
 	*Handler
-	jobService   service.JobService
-	orderService service.OrderService
-	payService   service.PayService
+	jobService           service.JobService
+	orderService         service.OrderService
+	payService           service.PayService
+	contactUnlockService service.ContactUnlockService
+	tagService           service.TagService
 }
 
 func NewJobHandler(
@@ -26,12 +31,16 @@ func NewJobHandler(
 	jobService service.JobService,
 	orderService service.OrderService,
 	payService service.PayService,
+	contactUnlockService service.ContactUnlockService,
+	tagService service.TagService,
 ) *JobHandler {
 	return &JobHandler{
-		Handler:      handler,
-		jobService:   jobService,
-		orderService: orderService,
-		payService:   payService,
+		Handler:              handler,
+		jobService:           jobService,
+		orderService:         orderService,
+		payService:           payService,
+		contactUnlockService: contactUnlockService,
+		tagService:           tagService,
 	}
 }
 
@@ -79,13 +88,13 @@ func (h *JobHandler) Create(ctx *gin.Context) {
 		FourAreaDes:        req.FourAreaDes,
 		SalaryMin:          req.SalaryMin,
 		SalaryMax:          req.SalaryMax,
-		BasicProtection:    strings.Join(req.BasicProtection, ","),
-		SalaryBenefits:     strings.Join(req.SalaryBenefits, ","),
-		AttendanceLeave:    strings.Join(req.AttendanceLeave, ","),
+		BasicProtection:    req.BasicProtection,
+		SalaryBenefits:     req.SalaryBenefits,
+		AttendanceLeave:    req.AttendanceLeave,
 	}
 	if _, err := h.jobService.Create(ctx, userID, input); err != nil {
 		h.logger.WithContext(ctx).Error("jobService.Create error", zap.Error(err))
-		if err == service.ErrJobLimitExceeded {
+		if err == service.ErrJobLimitExceeded || err == service.ErrInvalidTag || err == service.ErrInvalidArea {
 			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
 			return
 		}
@@ -145,24 +154,19 @@ func (h *JobHandler) Update(ctx *gin.Context) {
 		joined := strings.Join(req.PhotoURLs, ",")
 		input.PhotoURLs = &joined
 	}
-	if req.BasicProtection != nil {
-		joined := strings.Join(req.BasicProtection, ",")
-		input.BasicProtection = &joined
-	}
-	if req.SalaryBenefits != nil {
-		joined := strings.Join(req.SalaryBenefits, ",")
-		input.SalaryBenefits = &joined
-	}
-	if req.AttendanceLeave != nil {
-		joined := strings.Join(req.AttendanceLeave, ",")
-		input.AttendanceLeave = &joined
-	}
+	input.BasicProtection = req.BasicProtection
+	input.SalaryBenefits = req.SalaryBenefits
+	input.AttendanceLeave = req.AttendanceLeave
 	if err := h.jobService.Update(ctx, userID, input); err != nil {
 		h.logger.WithContext(ctx).Error("jobService.Update error", zap.Error(err))
 		if err == service.ErrForbidden {
 			v1.HandleError(ctx, http.StatusForbidden, v1.ErrForbidden, err.Error())
 			return
 		}
+		if err == service.ErrInvalidTag || err == service.ErrInvalidArea {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+			return
+		}
 		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
 		return
 	}
@@ -202,6 +206,10 @@ func (h *JobHandler) Refresh(ctx *gin.Context) {
 			v1.HandleError(ctx, http.StatusForbidden, v1.ErrForbidden, err.Error())
 			return
 		}
+		if err == service.ErrRefreshLimitExceeded {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrRefreshLimitExceeded, err.Error())
+			return
+		}
 		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
 		return
 	}
@@ -228,28 +236,17 @@ func (h *JobHandler) RefreshPay(ctx *gin.Context) {
 		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
 		return
 	}
-	order, _, err := h.orderService.CreateRefreshOrder(ctx, userID, req.JobID, req.Price)
-	if err != nil {
-		h.logger.WithContext(ctx).Error("orderService.CreateRefreshOrder error", zap.Error(err))
-		if err == service.ErrForbidden {
-			v1.HandleError(ctx, http.StatusForbidden, v1.ErrForbidden, err.Error())
-			return
-		}
-		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+	idempotencyKey, ok := getIdempotencyKey(ctx)
+	if !ok {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, "invalid Idempotency-Key")
 		return
 	}
-	params, err := h.payService.BuildJSAPIPayParams(ctx, order.OrderNo, req.Price)
+	order, item, err := h.orderService.CreateRefreshOrder(ctx, userID, req.JobID, req.SkuID, req.CouponID, idempotencyKey)
 	if err != nil {
-		h.logger.WithContext(ctx).Error("payService.BuildJSAPIPayParams error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		h.handleCreateOrderError(ctx, "orderService.CreateRefreshOrder", err)
 		return
 	}
-	v1.HandleSuccess(ctx, v1.PayOrderResponseData{
-		OrderID:   order.ID,
-		OrderNo:   order.OrderNo,
-		Amount:    req.Price,
-		PayParams: params,
-	})
+	h.respondPayOrder(ctx, h.payService, order, item.TitleSnapshot)
 }
 
 // Close godoc
@@ -298,27 +295,60 @@ func (h *JobHandler) List(ctx *gin.Context) {
 		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
 		return
 	}
+	if req.Filter.RadiusKm > 0 && req.Filter.Longitude == 0 && req.Filter.Latitude == 0 {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, "radius_km needs longitude and latitude")
+		return
+	}
+	if req.Filter.AreaID != 0 && area.Level(req.Filter.AreaID) == 0 {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, "invalid area_id")
+		return
+	}
 	salaryMax := req.Filter.SalaryMax
 	if salaryMax < 0 {
 		salaryMax = 0
 	}
 	query := repository.JobListQuery{
 		QueryType:       req.QueryType,
+		Keyword:         req.Filter.Keyword,
 		Positions:       req.Filter.Positions,
 		City:            req.Filter.City,
+		AreaID:          req.Filter.AreaID,
 		SalaryMin:       req.Filter.SalaryMin,
 		SalaryMax:       salaryMax,
 		BasicProtection: req.Filter.BasicProtection,
 		SalaryBenefits:  req.Filter.SalaryBenefits,
 		AttendanceLeave: req.Filter.AttendanceLeave,
+		TagMatchAny:     req.Filter.TagMatch == "any",
 		Longitude:       req.Filter.Longitude,
 		Latitude:        req.Filter.Latitude,
+		RadiusKm:        req.Filter.RadiusKm,
 		PageNum:         req.PageNum,
 		PageSize:        req.PageSize,
 	}
 	jobs, total, err := h.jobService.List(ctx, query)
 	if err != nil {
 		h.logger.WithContext(ctx).Error("jobService.List error", zap.Error(err))
+		if err == service.ErrInvalidTag {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+			return
+		}
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	userID := getUserIdFromClaims(ctx)
+	jobIDs := make([]int64, 0, len(jobs))
+	for _, job := range jobs {
+		jobIDs = append(jobIDs, job.ID)
+	}
+	unlocked, err := h.contactUnlockService.UnlockedJobIDs(ctx, userID, jobIDs)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("contactUnlockService.UnlockedJobIDs error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	jobTags, err := h.tagService.JobTags(ctx, jobIDs)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("tagService.JobTags error", zap.Error(err))
 		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
 		return
 	}
@@ -326,8 +356,17 @@ func (h *JobHandler) List(ctx *gin.Context) {
 		Jobs:  make([]v1.JobListItem, 0, len(jobs)),
 		Total: total,
 	}
+	// Distances come from the same plane the list filtered and ordered by, so none of
+	// them exceeds the radius and they rise down a nearby list.
+	plane := geo.NewPlane(query.Latitude, query.Longitude)
 	for _, job := range jobs {
-		resp.Jobs = append(resp.Jobs, buildJobListItem(job))
+		item := buildJobListItem(job, unlocked[job.ID] || job.UserID == userID)
+		item.Tags = buildTagItems(jobTags[job.ID])
+		if query.HasLocation() {
+			distance := int(math.Round(plane.Distance(job.Latitude, job.Longitude)))
+			item.Distance = &distance
+		}
+		resp.Jobs = append(resp.Jobs, item)
 	}
 	v1.HandleSuccess(ctx, resp)
 }
@@ -352,7 +391,21 @@ func (h *JobHandler) Info(ctx *gin.Context) {
 		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
 		return
 	}
-	item := buildJobListItem(job)
+	userID := getUserIdFromClaims(ctx)
+	unlocked, err := h.contactUnlockService.UnlockedJobIDs(ctx, userID, []int64{job.ID})
+	if err != nil {
+		h.logger.WithContext(ctx).Error("contactUnlockService.UnlockedJobIDs error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	jobTags, err := h.tagService.JobTags(ctx, []int64{job.ID})
+	if err != nil {
+		h.logger.WithContext(ctx).Error("tagService.JobTags error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	item := buildJobListItem(job, unlocked[job.ID] || job.UserID == userID)
+	item.Tags = buildTagItems(jobTags[job.ID])
 	v1.HandleSuccess(ctx, item)
 }
 
@@ -424,36 +477,29 @@ func (h *JobHandler) Top(ctx *gin.Context) {
 		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
 		return
 	}
-	if req.TopHour <= 0 || req.Price <= 0 {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, "top_hour and price must be positive")
-		return
-	}
-	order, _, err := h.orderService.CreateTopOrder(ctx, userID, req.JobID, req.TopHour, req.Price)
-	if err != nil {
-		h.logger.WithContext(ctx).Error("orderService.CreateTopOrder error", zap.Error(err))
-		if err == service.ErrForbidden {
-			v1.HandleError(ctx, http.StatusForbidden, v1.ErrForbidden, err.Error())
-			return
-		}
-		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+	idempotencyKey, ok := getIdempotencyKey(ctx)
+	if !ok {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, "invalid Idempotency-Key")
 		return
 	}
-	params, err := h.payService.BuildJSAPIPayParams(ctx, order.OrderNo, req.Price)
+	order, item, err := h.orderService.CreateTopOrder(ctx, userID, req.JobID, req.SkuID, req.CouponID, idempotencyKey)
 	if err != nil {
-		h.logger.WithContext(ctx).Error("payService.BuildJSAPIPayParams error", zap.Error(err))
-		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		h.handleCreateOrderError(ctx, "orderService.CreateTopOrder", err)
 		return
 	}
-	v1.HandleSuccess(ctx, v1.PayOrderResponseData{
-		OrderID:   order.ID,
-		OrderNo:   order.OrderNo,
-		Amount:    req.Price,
-		PayParams: params,
-	})
+	h.respondPayOrder(ctx, h.payService, order, item.TitleSnapshot)
 }
 
-func buildJobListItem(job *model.Job) v1.JobListItem {
+// buildJobListItem masks the contact phone unless the caller owns the job or has unlocked
+// it through /contact_voucher/cost.
+func buildJobListItem(job *model.Job, contactUnlocked bool) v1.JobListItem {
 	photos := splitCSV(job.PhotoURLs)
+	contact := maskPhone(job.Contact)
+	unlocked := 0
+	if contactUnlocked {
+		contact = job.Contact
+		unlocked = 1
+	}
 	item := v1.JobListItem{
 		ID:                job.ID,
 		UserID:            job.UserID,
@@ -461,7 +507,8 @@ func buildJobListItem(job *model.Job) v1.JobListItem {
 		Longitude:         job.Longitude,
 		Latitude:          job.Latitude,
 		Address:           job.Address,
-		Contact:           job.Contact,
+		Contact:           contact,
+		ContactUnlocked:   unlocked,
 		ContactPersonName: job.ContactPersonName,
 		Description:       job.Description,
 		PhotoURLs:         photos,
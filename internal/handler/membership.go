@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"go.uber.org/zap"
+)
+
+type MembershipHandler struct {
+	*Handler
+	membershipService service.MembershipService
+	orderService      service.OrderService
+	payService        service.PayService
+}
+
+func NewMembershipHandler(
+	handler *Handler,
+	membershipService service.MembershipService,
+	orderService service.OrderService,
+	payService service.PayService,
+) *MembershipHandler {
+	return &MembershipHandler{
+		Handler:           handler,
+		membershipService: membershipService,
+		orderService:      orderService,
+		payService:        payService,
+	}
+}
+
+// Info godoc
+// @Summary 我的会员
+// @Description 非会员返回普通用户的额度
+// @Tags 会员模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Success 200 {object} v1.MembershipInfoResponseData
+// @Router /membership/info [get]
+func (h *MembershipHandler) Info(ctx *gin.Context) {
+	userID := GetUserIdFromCtx(ctx)
+	if userID == 0 {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, v1.ErrUnauthorized.Error())
+		return
+	}
+	benefits, err := h.membershipService.GetBenefits(ctx, userID)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("membershipService.GetBenefits error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	v1.HandleSuccess(ctx, v1.MembershipInfoResponseData{
+		Active:           benefits.Active,
+		EndAt:            formatOptionalTime(benefits.EndAt),
+		JobLimit:         benefits.JobLimit,
+		FreeRefreshDaily: benefits.FreeRefreshDaily,
+		MonthlyVouchers:  benefits.MonthlyVouchers,
+		TopPricePercent:  benefits.TopPricePercent,
+	})
+}
+
+// Buy godoc
+// @Summary 购买会员
+// @Description 会员有效期内续费顺延到期时间
+// @Tags 会员模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.MembershipBuyRequest true "params"
+// @Success 200 {object} v1.PayOrderResponseData
+// @Router /membership/buy [post]
+func (h *MembershipHandler) Buy(ctx *gin.Context) {
+	userID := GetUserIdFromCtx(ctx)
+	if userID == 0 {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, v1.ErrUnauthorized.Error())
+		return
+	}
+	var req v1.MembershipBuyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	idempotencyKey, ok := getIdempotencyKey(ctx)
+	if !ok {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, "invalid Idempotency-Key")
+		return
+	}
+	order, item, err := h.orderService.CreateMembershipOrder(ctx, userID, req.SkuID, req.CouponID, idempotencyKey)
+	if err != nil {
+		h.handleCreateOrderError(ctx, "orderService.CreateMembershipOrder", err)
+		return
+	}
+	h.respondPayOrder(ctx, h.payService, order, item.TitleSnapshot)
+}
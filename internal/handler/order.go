@@ -1,25 +1,309 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
+	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
 	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type OrderHandler struct {
 	*Handler
 	orderService service.OrderService
+	payService   service.PayService
 }
 
 func NewOrderHandler(
-    handler *Handler,
-    orderService service.OrderService,
+	handler *Handler,
+	orderService service.OrderService,
+	payService service.PayService,
 ) *OrderHandler {
 	return &OrderHandler{
 		Handler:      handler,
 		orderService: orderService,
+		payService:   payService,
+	}
+}
+
+// Create godoc
+// @Summary 购物车下单
+// @Description 多个商品合并为一个订单一次支付，最多 10 项；置顶、刷新商品需传 job_id。价格由服务端计算
+// @Tags 订单模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.OrderCreateRequest true "params"
+// @Success 200 {object} v1.PayOrderResponseData
+// @Router /orders/create [post]
+func (h *OrderHandler) Create(ctx *gin.Context) {
+	userID := GetUserIdFromCtx(ctx)
+	if userID == 0 {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, v1.ErrUnauthorized.Error())
+		return
+	}
+	var req v1.OrderCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	idempotencyKey, ok := getIdempotencyKey(ctx)
+	if !ok {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, "invalid Idempotency-Key")
+		return
+	}
+	lines := make([]service.LineItem, 0, len(req.Items))
+	for _, item := range req.Items {
+		lines = append(lines, service.LineItem{SkuID: item.SkuID, JobID: item.JobID})
+	}
+	order, items, err := h.orderService.CreateOrder(ctx, userID, lines, req.CouponID, idempotencyKey)
+	if err != nil {
+		h.handleCreateOrderError(ctx, "orderService.CreateOrder", err)
+		return
+	}
+	h.respondPayOrder(ctx, h.payService, order, orderDescription(items))
+}
+
+// orderDescription names a cart on the payment page after its first line.
+func orderDescription(items []*model.OrderItem) string {
+	if len(items) == 1 {
+		return items[0].TitleSnapshot
+	}
+	return fmt.Sprintf("%s等%d件", items[0].TitleSnapshot, len(items))
+}
+
+// Confirm godoc
+// @Summary 支付结果确认
+// @Tags 订单模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.OrderConfirmRequest true "params"
+// @Success 200 {object} v1.OrderConfirmResponseData
+// @Router /orders/confirm [post]
+func (h *OrderHandler) Confirm(ctx *gin.Context) {
+	userID := GetUserIdFromCtx(ctx)
+	if userID == 0 {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, v1.ErrUnauthorized.Error())
+		return
+	}
+	var req v1.OrderConfirmRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
 	}
+	order, err := h.orderService.ConfirmOrder(ctx, userID, req.OrderNo)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("orderService.ConfirmOrder error", zap.Error(err))
+		if err == service.ErrForbidden {
+			v1.HandleError(ctx, http.StatusForbidden, v1.ErrForbidden, err.Error())
+			return
+		}
+		if err == service.ErrAmountMismatch {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrAmountMismatch, err.Error())
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, err.Error())
+			return
+		}
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	v1.HandleSuccess(ctx, v1.OrderConfirmResponseData{
+		OrderID: order.ID,
+		OrderNo: order.OrderNo,
+		Status:  int(order.Status),
+	})
 }
 
-func (h *OrderHandler) GetOrder(ctx *gin.Context) {
+// Cancel godoc
+// @Summary 取消订单
+// @Tags 订单模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.OrderCancelRequest true "params"
+// @Success 200 {object} v1.Response
+// @Router /orders/cancel [post]
+func (h *OrderHandler) Cancel(ctx *gin.Context) {
+	userID := GetUserIdFromCtx(ctx)
+	if userID == 0 {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, v1.ErrUnauthorized.Error())
+		return
+	}
+	var req v1.OrderCancelRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	if _, err := h.orderService.CancelOrder(ctx, userID, req.OrderNo); err != nil {
+		h.logger.WithContext(ctx).Error("orderService.CancelOrder error", zap.Error(err))
+		if err == service.ErrForbidden {
+			v1.HandleError(ctx, http.StatusForbidden, v1.ErrForbidden, err.Error())
+			return
+		}
+		if err == service.ErrOrderNotPending {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrOrderNotPending, err.Error())
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, err.Error())
+			return
+		}
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	v1.HandleSuccess(ctx, nil)
+}
 
+// My godoc
+// @Summary 我的订单
+// @Tags 订单模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.OrderMyRequest true "params"
+// @Success 200 {object} v1.OrderMyResponseData
+// @Router /orders/my [post]
+func (h *OrderHandler) My(ctx *gin.Context) {
+	userID := GetUserIdFromCtx(ctx)
+	if userID == 0 {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, v1.ErrUnauthorized.Error())
+		return
+	}
+	var req v1.OrderMyRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	details, total, err := h.orderService.ListByUser(ctx, userID, model.OrderStatus(req.Status), req.PageNum, req.PageSize)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("orderService.ListByUser error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	resp := v1.OrderMyResponseData{
+		List:  make([]v1.OrderInfo, 0, len(details)),
+		Total: total,
+	}
+	for _, detail := range details {
+		resp.List = append(resp.List, buildOrderInfo(detail))
+	}
+	v1.HandleSuccess(ctx, resp)
+}
+
+// Info godoc
+// @Summary 订单详情
+// @Tags 订单模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.OrderInfoRequest true "params"
+// @Success 200 {object} v1.OrderInfo
+// @Router /orders/info [post]
+func (h *OrderHandler) Info(ctx *gin.Context) {
+	userID := GetUserIdFromCtx(ctx)
+	if userID == 0 {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, v1.ErrUnauthorized.Error())
+		return
+	}
+	var req v1.OrderInfoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	detail, err := h.orderService.GetMyOrder(ctx, userID, req.OrderNo)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("orderService.GetMyOrder error", zap.Error(err))
+		if err == service.ErrForbidden {
+			v1.HandleError(ctx, http.StatusForbidden, v1.ErrForbidden, err.Error())
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, err.Error())
+			return
+		}
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	v1.HandleSuccess(ctx, buildOrderInfo(detail))
+}
+
+// AdminInfo godoc
+// @Summary 订单详情（管理员）
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.OrderInfoRequest true "params"
+// @Success 200 {object} v1.OrderInfo
+// @Router /admin/orders/info [post]
+func (h *OrderHandler) AdminInfo(ctx *gin.Context) {
+	var req v1.OrderInfoRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	detail, err := h.orderService.GetOrder(ctx, req.OrderNo)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("orderService.GetOrder error", zap.Error(err))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, err.Error())
+			return
+		}
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	v1.HandleSuccess(ctx, buildOrderInfo(detail))
+}
+
+func buildOrderInfo(detail *service.OrderDetail) v1.OrderInfo {
+	order := detail.Order
+	info := v1.OrderInfo{
+		OrderID:        order.ID,
+		OrderNo:        order.OrderNo,
+		AmountTotal:    order.AmountTotal,
+		AmountPaid:     order.AmountPaid,
+		CouponID:       order.CouponID,
+		DiscountAmount: order.DiscountAmount,
+		Status:         int(order.Status),
+		PayChannel:     order.PayChannel,
+		PayTradeNo:     order.PayTradeNo,
+		PaidAt:         formatOptionalTime(order.PaidAt),
+		CanceledAt:     formatOptionalTime(order.CanceledAt),
+		RefundedAt:     formatOptionalTime(order.RefundedAt),
+		Remark:         order.Remark,
+		CreateAt:       formatTime(order.CreateAt),
+		Items:          make([]v1.OrderItemInfo, 0, len(detail.Items)),
+	}
+	if detail.Invoice != nil {
+		info.InvoiceID = detail.Invoice.ID
+		info.InvoiceStatus = int(detail.Invoice.Status)
+	}
+	for _, item := range detail.Items {
+		itemInfo := v1.OrderItemInfo{
+			ID:                item.ID,
+			ProductType:       int(item.ProductType),
+			Title:             item.TitleSnapshot,
+			UnitPrice:         item.UnitPriceSnapshot,
+			TopHour:           item.TopHour,
+			ContactVoucherNum: item.ContactVoucherNum,
+			MembershipDays:    item.MembershipDays,
+		}
+		if item.TargetType == model.OrderTargetJob {
+			itemInfo.JobID = item.TargetID
+			if job, ok := detail.Jobs[item.TargetID]; ok {
+				itemInfo.JobPositions = job.Positions
+			}
+		}
+		info.Items = append(info.Items, itemInfo)
+	}
+	return info
 }
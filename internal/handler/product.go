@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"go.uber.org/zap"
+)
+
+type ProductHandler struct {
+	*Handler
+	productService service.ProductService
+}
+
+func NewProductHandler(
+	handler *Handler,
+	productService service.ProductService,
+) *ProductHandler {
+	return &ProductHandler{
+		Handler:        handler,
+		productService: productService,
+	}
+}
+
+// List godoc
+// @Summary 商品套餐列表
+// @Tags 商品模块
+// @Accept json
+// @Produce json
+// @Param request body v1.ProductListRequest true "params"
+// @Success 200 {object} v1.ProductListResponseData
+// @Router /products/list [post]
+func (h *ProductHandler) List(ctx *gin.Context) {
+	var req v1.ProductListRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	products, err := h.productService.List(ctx, req.ProductType)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("productService.List error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	resp := v1.ProductListResponseData{
+		List: make([]v1.ProductItem, 0, len(products)),
+	}
+	for _, product := range products {
+		resp.List = append(resp.List, v1.ProductItem{
+			SkuID:             product.ID,
+			ProductType:       product.ProductType,
+			Title:             product.Title,
+			Price:             product.Price,
+			TopHour:           product.TopHour,
+			ContactVoucherNum: product.ContactVoucherNum,
+			MembershipDays:    product.MembershipDays,
+		})
+	}
+	v1.HandleSuccess(ctx, resp)
+}
@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"go.uber.org/zap"
+)
+
+type ReconcileHandler struct {
+	*Handler
+	reconcileService service.ReconcileService
+}
+
+func NewReconcileHandler(handler *Handler, reconcileService service.ReconcileService) *ReconcileHandler {
+	return &ReconcileHandler{
+		Handler:          handler,
+		reconcileService: reconcileService,
+	}
+}
+
+// AdminList godoc
+// @Summary 对账差异列表（管理员）
+// @Description type: 1=漏单（已按账单补单时 status=2） 2=金额不一致 3=支付单号不一致 4=账单中订单不存在 5=已支付但账单中无此支付；status: 1=待处理 2=已修复
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.AdminReconcileListRequest true "params"
+// @Success 200 {object} v1.AdminReconcileListResponseData
+// @Router /admin/reconcile/list [post]
+func (h *ReconcileHandler) AdminList(ctx *gin.Context) {
+	var req v1.AdminReconcileListRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	discrepancies, total, err := h.reconcileService.ListDiscrepancies(ctx, req.BillDate,
+		model.ReconcileDiscrepancyStatus(req.Status), req.PageNum, req.PageSize)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("reconcileService.ListDiscrepancies error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	resp := v1.AdminReconcileListResponseData{
+		List:  make([]v1.ReconcileDiscrepancyInfo, 0, len(discrepancies)),
+		Total: total,
+	}
+	for _, discrepancy := range discrepancies {
+		resp.List = append(resp.List, v1.ReconcileDiscrepancyInfo{
+			ID:          discrepancy.ID,
+			BillDate:    discrepancy.BillDate,
+			Channel:     discrepancy.Channel,
+			Type:        int(discrepancy.Type),
+			OrderNo:     discrepancy.OrderNo,
+			OrderID:     discrepancy.OrderID,
+			TradeNo:     discrepancy.TradeNo,
+			BillAmount:  discrepancy.BillAmount,
+			OrderAmount: discrepancy.OrderAmount,
+			Status:      int(discrepancy.Status),
+			Remark:      discrepancy.Remark,
+			UpdateAt:    formatTime(discrepancy.UpdateAt),
+		})
+	}
+	v1.HandleSuccess(ctx, resp)
+}
+
+// AdminRun godoc
+// @Summary 手动对账（管理员）
+// @Description 下载 bill_date（格式 2006-01-02，须早于今天）的交易账单重新对账，可重复执行
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.AdminReconcileRunRequest true "params"
+// @Success 200 {object} v1.AdminReconcileRunResponseData
+// @Router /admin/reconcile/run [post]
+func (h *ReconcileHandler) AdminRun(ctx *gin.Context) {
+	var req v1.AdminReconcileRunRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	billDate, err := time.ParseInLocation("2006-01-02", req.BillDate, time.Local)
+	if err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	// The provider only issues a day's bill once the day is over.
+	if !billDate.AddDate(0, 0, 1).Before(time.Now()) {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, "bill_date must be before today")
+		return
+	}
+	result, err := h.reconcileService.Reconcile(ctx, billDate)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("reconcileService.Reconcile error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	v1.HandleSuccess(ctx, v1.AdminReconcileRunResponseData{
+		BillDate:      result.BillDate,
+		Records:       result.Records,
+		Discrepancies: result.Discrepancies,
+		Fixed:         result.Fixed,
+	})
+}
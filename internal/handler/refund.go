@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type RefundHandler struct {
+	*Handler
+	refundService service.RefundService
+}
+
+func NewRefundHandler(handler *Handler, refundService service.RefundService) *RefundHandler {
+	return &RefundHandler{
+		Handler:       handler,
+		refundService: refundService,
+	}
+}
+
+// Create godoc
+// @Summary 订单退款（管理员）
+// @Tags 管理模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.AdminRefundCreateRequest true "params"
+// @Success 200 {object} v1.RefundResponseData
+// @Router /admin/refunds/create [post]
+func (h *RefundHandler) Create(ctx *gin.Context) {
+	userID := GetUserIdFromCtx(ctx)
+	if userID == 0 {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, v1.ErrUnauthorized.Error())
+		return
+	}
+	var req v1.AdminRefundCreateRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	refund, err := h.refundService.CreateRefund(ctx, userID, req.OrderNo, req.Amount, req.Reason)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("refundService.CreateRefund error", zap.Error(err))
+		if err == service.ErrOrderNotRefundable {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrOrderNotRefundable, err.Error())
+			return
+		}
+		if err == service.ErrInvalidRefundAmount {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrInvalidRefundAmount, err.Error())
+			return
+		}
+		if errors.Is(err, service.ErrRefundRejected) {
+			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, err.Error())
+			return
+		}
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	v1.HandleSuccess(ctx, v1.RefundResponseData{
+		RefundNo:           refund.RefundNo,
+		OrderNo:            refund.OrderNo,
+		Amount:             refund.Amount,
+		Status:             int(refund.Status),
+		RollbackVoucherNum: refund.RollbackVoucherNum,
+		RollbackTopHour:    refund.RollbackTopHour,
+		RollbackMemberDays: refund.RollbackMemberDays,
+	})
+}
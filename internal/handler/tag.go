@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"go.uber.org/zap"
+)
+
+type TagHandler struct {
+	*Handler
+	tagService service.TagService
+}
+
+func NewTagHandler(
+	handler *Handler,
+	tagService service.TagService,
+) *TagHandler {
+	return &TagHandler{
+		Handler:    handler,
+		tagService: tagService,
+	}
+}
+
+// List godoc
+// @Summary 招聘标签列表
+// @Description category：1 基础保障 2 薪酬福利 3 考勤休假，不传返回全部
+// @Tags 通用接口
+// @Accept json
+// @Produce json
+// @Param request body v1.TagListRequest true "params"
+// @Success 200 {object} v1.TagListResponseData
+// @Router /tags/list [post]
+func (h *TagHandler) List(ctx *gin.Context) {
+	var req v1.TagListRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	tags, err := h.tagService.List(ctx, model.TagCategory(req.Category))
+	if err != nil {
+		h.logger.WithContext(ctx).Error("tagService.List error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	v1.HandleSuccess(ctx, v1.TagListResponseData{List: buildTagItems(tags)})
+}
+
+func buildTagItems(tags []*model.Tag) []v1.TagItem {
+	items := make([]v1.TagItem, 0, len(tags))
+	for _, tag := range tags {
+		items = append(items, v1.TagItem{
+			Category: int(tag.Category),
+			Code:     tag.Code,
+			Label:    tag.Label,
+		})
+	}
+	return items
+}
@@ -10,13 +10,15 @@ import (
 
 type UserHandler struct {
 	*Handler
-	userService service.UserService
+	userService   service.UserService
+	inviteService service.InviteService
 }
 
-func NewUserHandler(handler *Handler, userService service.UserService) *UserHandler {
+func NewUserHandler(handler *Handler, userService service.UserService, inviteService service.InviteService) *UserHandler {
 	return &UserHandler{
-		Handler:     handler,
-		userService: userService,
+		Handler:       handler,
+		userService:   userService,
+		inviteService: inviteService,
 	}
 }
 
@@ -41,11 +43,12 @@ func (h *UserHandler) GetInfo(ctx *gin.Context) {
 		return
 	}
 	v1.HandleSuccess(ctx, v1.UserInfoResponseData{
-		UserID: user.ID,
-		Avatar: user.Avatar,
-		Name:   user.Name,
-		Sex:    user.Sex,
-		Phone:  user.Phone,
+		UserID:   user.ID,
+		Avatar:   user.Avatar,
+		Name:     user.Name,
+		Sex:      user.Sex,
+		Phone:    user.Phone,
+		Integral: user.Integral,
 	})
 }
 
@@ -118,3 +121,58 @@ func (h *UserHandler) UpdateInfo(ctx *gin.Context) {
 	}
 	v1.HandleSuccess(ctx, nil)
 }
+
+// Invites godoc
+// @Summary 我的邀请
+// @Description 返回邀请码、分享路径及邀请记录，首次调用时生成邀请码
+// @Tags 用户模块
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param request body v1.UserInvitesRequest true "params"
+// @Success 200 {object} v1.UserInvitesResponseData
+// @Router /user/invites [post]
+func (h *UserHandler) Invites(ctx *gin.Context) {
+	userID := GetUserIdFromCtx(ctx)
+	if userID == 0 {
+		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, v1.ErrUnauthorized.Error())
+		return
+	}
+	var req v1.UserInvitesRequest
+	if err := ctx.ShouldBindJSON(&req); err != nil {
+		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+		return
+	}
+	info, err := h.inviteService.GetInviteInfo(ctx, userID)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("inviteService.GetInviteInfo error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	records, total, err := h.inviteService.ListInvites(ctx, userID, req.PageNum, req.PageSize)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("inviteService.ListInvites error", zap.Error(err))
+		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		return
+	}
+	resp := v1.UserInvitesResponseData{
+		InviteCode: info.InviteCode,
+		SharePath:  info.SharePath,
+		InviteNum:  info.InviteNum,
+		List:       make([]v1.UserInvitesItem, 0, len(records)),
+		Total:      total,
+	}
+	for _, record := range records {
+		resp.List = append(resp.List, v1.UserInvitesItem{
+			UserID:        record.Invitee.ID,
+			Name:          record.Invitee.Name,
+			Avatar:        record.Invitee.Avatar,
+			Phone:         maskPhone(record.Invitee.Phone),
+			RewardStatus:  int(record.Invite.RewardStatus),
+			InviterReward: record.Invite.InviterReward,
+			InviteeReward: record.Invite.InviteeReward,
+			CreateAt:      formatTime(record.Invite.CreateAt),
+		})
+	}
+	v1.HandleSuccess(ctx, resp)
+}
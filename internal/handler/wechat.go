@@ -1,26 +1,32 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
-	"time"
 
 	"github.com/gin-gonic/gin"
 	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
 	"github.com/go-nunu/nunu-layout-advanced/internal/service"
 	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
 type WechatHandler struct {
 	*Handler
 	orderService  service.OrderService
 	wechatService service.WechatService
+	payService    service.PayService
+	refundService service.RefundService
 }
 
-func NewWechatHandler(handler *Handler, orderService service.OrderService, wechatService service.WechatService) *WechatHandler {
+func NewWechatHandler(handler *Handler, orderService service.OrderService, wechatService service.WechatService, payService service.PayService, refundService service.RefundService) *WechatHandler {
 	return &WechatHandler{
 		Handler:       handler,
 		orderService:  orderService,
 		wechatService: wechatService,
+		payService:    payService,
+		refundService: refundService,
 	}
 }
 
@@ -38,7 +44,13 @@ func (h *WechatHandler) Register(ctx *gin.Context) {
 		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
 		return
 	}
-	token, user, err := h.wechatService.Register(ctx, req.PhoneCode, req.LoginCode, req.InviterID)
+	token, user, err := h.wechatService.Register(ctx, service.WechatRegisterInput{
+		PhoneCode:  req.PhoneCode,
+		LoginCode:  req.LoginCode,
+		InviterID:  req.InviterID,
+		InviteCode: req.InviteCode,
+		DeviceID:   req.DeviceID,
+	})
 	if err != nil {
 		h.logger.WithContext(ctx).Error("wechatService.Register error", zap.Error(err))
 		if err == service.ErrUserExists {
@@ -84,70 +96,99 @@ func (h *WechatHandler) Login(ctx *gin.Context) {
 	})
 }
 
-// Pay godoc
-// @Summary 微信支付
+// PayNotify godoc
+// @Summary 微信支付回调
 // @Tags 支付模块
 // @Accept json
 // @Produce json
-// @Security Bearer
-// @Param request body v1.WechatPayRequest true "params"
-// @Success 200 {object} v1.Response
-// @Router /wechat/pay [post]
-func (h *WechatHandler) Pay(ctx *gin.Context) {
-	userID := GetUserIdFromCtx(ctx)
-	if userID == 0 {
-		v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, v1.ErrUnauthorized.Error())
+// @Param Wechatpay-Signature header string true "平台签名"
+// @Param Wechatpay-Timestamp header string true "时间戳"
+// @Param Wechatpay-Nonce header string true "随机串"
+// @Param Wechatpay-Serial header string true "平台证书序列号"
+// @Success 200 {object} v1.WechatPayNotifyResponse
+// @Router /wechat/pay/notify [post]
+func (h *WechatHandler) PayNotify(ctx *gin.Context) {
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		payNotifyAck(ctx, http.StatusBadRequest, err.Error())
 		return
 	}
-	var req v1.WechatPayRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+	notify, err := h.payService.ParsePayNotify(ctx, ctx.Request.Header, body)
+	if err != nil {
+		h.logger.WithContext(ctx).Error("payService.ParsePayNotify error", zap.Error(err))
+		if errors.Is(err, service.ErrInvalidNotify) {
+			payNotifyAck(ctx, http.StatusUnauthorized, "签名验证失败")
+			return
+		}
+		payNotifyAck(ctx, http.StatusInternalServerError, "系统错误")
+		return
+	}
+	if !notify.Success {
+		payNotifyAck(ctx, http.StatusOK, "")
 		return
 	}
-	_, err := h.orderService.PayOrder(ctx, userID, req.OrderID, req.OrderNo, req.Price, "wxpay", "wxpay_"+time.Now().Format("20060102150405"))
+	_, err = h.orderService.PayOrderByNotify(ctx, notify.OrderNo, notify.Amount, notify.Channel, notify.TradeNo)
 	if err != nil {
-		h.logger.WithContext(ctx).Error("orderService.PayOrder error", zap.Error(err))
-		if err == service.ErrForbidden {
-			v1.HandleError(ctx, http.StatusForbidden, v1.ErrForbidden, err.Error())
+		h.logger.WithContext(ctx).Error("orderService.PayOrderByNotify error", zap.Error(err), zap.String("order_no", notify.OrderNo))
+		if err == service.ErrAmountMismatch {
+			// The payment is on record and refunded; a retry would change nothing.
+			payNotifyAck(ctx, http.StatusOK, "")
 			return
 		}
-		if err == service.ErrAmountMismatch {
-			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrAmountMismatch, err.Error())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			payNotifyAck(ctx, http.StatusNotFound, "订单不存在")
 			return
 		}
-		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		payNotifyAck(ctx, http.StatusInternalServerError, "系统错误")
 		return
 	}
-	v1.HandleSuccess(ctx, nil)
+	payNotifyAck(ctx, http.StatusOK, "")
 }
 
-// PayNotify godoc
-// @Summary 微信支付回调
+// RefundNotify godoc
+// @Summary 微信退款回调
 // @Tags 支付模块
 // @Accept json
 // @Produce json
-// @Param request body v1.WechatPayNotifyRequest true "params"
-// @Success 200 {object} v1.Response
-// @Router /wechat/pay/notify [post]
-func (h *WechatHandler) PayNotify(ctx *gin.Context) {
-	var req v1.WechatPayNotifyRequest
-	if err := ctx.ShouldBindJSON(&req); err != nil {
-		v1.HandleError(ctx, http.StatusBadRequest, v1.ErrBadRequest, err.Error())
+// @Param Wechatpay-Signature header string true "平台签名"
+// @Param Wechatpay-Timestamp header string true "时间戳"
+// @Param Wechatpay-Nonce header string true "随机串"
+// @Param Wechatpay-Serial header string true "平台证书序列号"
+// @Success 200 {object} v1.WechatPayNotifyResponse
+// @Router /wechat/refund/notify [post]
+func (h *WechatHandler) RefundNotify(ctx *gin.Context) {
+	body, err := io.ReadAll(ctx.Request.Body)
+	if err != nil {
+		payNotifyAck(ctx, http.StatusBadRequest, err.Error())
 		return
 	}
-	order, err := h.orderService.PayOrderByNotify(ctx, req.OrderNo, req.Amount, req.PayChannel, req.PayTradeNo)
+	result, err := h.payService.ParseRefundNotify(ctx, ctx.Request.Header, body)
 	if err != nil {
-		h.logger.WithContext(ctx).Error("orderService.PayOrderByNotify error", zap.Error(err))
-		if err == service.ErrAmountMismatch {
-			v1.HandleError(ctx, http.StatusBadRequest, v1.ErrAmountMismatch, err.Error())
+		h.logger.WithContext(ctx).Error("payService.ParseRefundNotify error", zap.Error(err))
+		if errors.Is(err, service.ErrInvalidNotify) {
+			payNotifyAck(ctx, http.StatusUnauthorized, "签名验证失败")
 			return
 		}
-		v1.HandleError(ctx, http.StatusInternalServerError, v1.ErrInternalServerError, err.Error())
+		payNotifyAck(ctx, http.StatusInternalServerError, "系统错误")
 		return
 	}
-	if order == nil {
-		v1.HandleError(ctx, http.StatusNotFound, v1.ErrNotFound, "order not found")
+	if _, err := h.refundService.ApplyRefundResult(ctx, result); err != nil {
+		h.logger.WithContext(ctx).Error("refundService.ApplyRefundResult error", zap.Error(err), zap.String("refund_no", result.RefundNo))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			payNotifyAck(ctx, http.StatusNotFound, "退款单不存在")
+			return
+		}
+		payNotifyAck(ctx, http.StatusInternalServerError, "系统错误")
+		return
+	}
+	payNotifyAck(ctx, http.StatusOK, "")
+}
+
+// payNotifyAck answers in the format WeChat Pay expects; anything but 2xx makes it retry.
+func payNotifyAck(ctx *gin.Context, status int, message string) {
+	if status == http.StatusOK {
+		ctx.JSON(status, v1.WechatPayNotifyResponse{Code: "SUCCESS", Message: "成功"})
 		return
 	}
-	v1.HandleSuccess(ctx, nil)
+	ctx.JSON(status, v1.WechatPayNotifyResponse{Code: "FAIL", Message: message})
 }
@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
+	"go.uber.org/zap"
+)
+
+// AdminAuth runs after StrictAuth and only lets admin users through. Unlike StrictAuth it
+// requires a token: the user_id header alone is not trusted here.
+func AdminAuth(userService service.UserService, logger *log.Logger) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		v, exists := ctx.Get("claims")
+		claims, ok := v.(*jwt.MyCustomClaims)
+		if !exists || !ok {
+			v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, "missing token")
+			ctx.Abort()
+			return
+		}
+		userID, err := strconv.ParseInt(claims.UserId, 10, 64)
+		if err != nil {
+			v1.HandleError(ctx, http.StatusUnauthorized, v1.ErrUnauthorized, err.Error())
+			ctx.Abort()
+			return
+		}
+		user, err := userService.GetInfo(ctx, userID)
+		if err != nil {
+			logger.WithContext(ctx).Error("userService.GetInfo error", zap.Error(err))
+			v1.HandleError(ctx, http.StatusForbidden, v1.ErrForbidden, err.Error())
+			ctx.Abort()
+			return
+		}
+		if user.Type != model.UserTypeAdmin {
+			v1.HandleError(ctx, http.StatusForbidden, v1.ErrForbidden, v1.ErrForbidden.Error())
+			ctx.Abort()
+			return
+		}
+		ctx.Next()
+	}
+}
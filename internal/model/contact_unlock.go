@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// Contact purpose types shared by contact_history and contact_unlock.
+const (
+	ContactPurposeJob    = 1
+	ContactPurposeResume = 2
+	ContactPurposeRent   = 3
+)
+
+// ContactUnlock records that a user paid to see the phone behind a purpose; one row per
+// (user, purpose_type, purpose_id) so the voucher is only spent once.
+type ContactUnlock struct {
+	ID          int64     `gorm:"primaryKey;column:id"`
+	UserID      int64     `gorm:"column:user_id;uniqueIndex:uk_user_purpose"`
+	PurposeType int       `gorm:"column:purpose_type;uniqueIndex:uk_user_purpose"`
+	PurposeID   int64     `gorm:"column:purpose_id;uniqueIndex:uk_user_purpose"`
+	CreateAt    time.Time `gorm:"column:create_at"`
+}
+
+func (m *ContactUnlock) TableName() string {
+	return "contact_unlock"
+}
@@ -0,0 +1,22 @@
+package model
+
+import "time"
+
+// ContactVoucherBatch is one purchase or grant of contact vouchers. Vouchers are spent from
+// the batch that expires first; User.ContactVoucherNum caches the sum of usable batches.
+// A nil ExpireAt never expires (balances from before batches existed).
+type ContactVoucherBatch struct {
+	ID        int64                        `gorm:"primaryKey;column:id"`
+	UserID    int64                        `gorm:"column:user_id;index:idx_user_expire"`
+	BizType   ContactVoucherHistoryBizType `gorm:"column:biz_type"`
+	OrderID   int64                        `gorm:"column:order_id;index:idx_order_id"`
+	TotalNum  int                          `gorm:"column:total_num"`
+	RemainNum int                          `gorm:"column:remain_num"`
+	ExpireAt  *time.Time                   `gorm:"column:expire_at;index:idx_user_expire;index:idx_expire_at"`
+	CreateAt  time.Time                    `gorm:"column:create_at"`
+	UpdateAt  time.Time                    `gorm:"column:update_at"`
+}
+
+func (m *ContactVoucherBatch) TableName() string {
+	return "contact_voucher_batch"
+}
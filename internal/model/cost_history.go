@@ -5,8 +5,13 @@ import "time"
 type ContactVoucherHistoryBizType int
 
 const (
-	ContactVoucherHistoryBuy  ContactVoucherHistoryBizType = 1
-	ContactVoucherHistoryCost ContactVoucherHistoryBizType = 2
+	ContactVoucherHistoryBuy        ContactVoucherHistoryBizType = 1
+	ContactVoucherHistoryCost       ContactVoucherHistoryBizType = 2
+	ContactVoucherHistoryRefund     ContactVoucherHistoryBizType = 3
+	ContactVoucherHistoryExpired    ContactVoucherHistoryBizType = 4
+	ContactVoucherHistoryInvite     ContactVoucherHistoryBizType = 5
+	ContactVoucherHistoryIntegral   ContactVoucherHistoryBizType = 6
+	ContactVoucherHistoryMembership ContactVoucherHistoryBizType = 7
 )
 
 type ContactVoucherHistory struct {
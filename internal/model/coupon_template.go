@@ -0,0 +1,35 @@
+package model
+
+import "time"
+
+type CouponTemplateStatus int
+
+const (
+	CouponTemplateStatusOnline  CouponTemplateStatus = 1
+	CouponTemplateStatusOffline CouponTemplateStatus = 2
+)
+
+// CouponTemplate describes a campaign, e.g. "10 yuan off top packages". Users claim it while
+// it is online and inside [ClaimStartAt, ClaimEndAt); each claim is valid for ValidDays.
+// ProductType 0 applies to every product; FirstRechargeOnly limits it to users who have
+// never paid an order.
+type CouponTemplate struct {
+	ID                int64                `gorm:"primaryKey;column:id"`
+	Title             string               `gorm:"column:title;size:64"`
+	ProductType       ProductType          `gorm:"column:product_type"`
+	DiscountAmount    Decimal              `gorm:"column:discount_amount;type:decimal(10,2)"`
+	MinAmount         Decimal              `gorm:"column:min_amount;type:decimal(10,2)"`
+	FirstRechargeOnly bool                 `gorm:"column:first_recharge_only"`
+	ValidDays         int                  `gorm:"column:valid_days"`
+	TotalNum          int                  `gorm:"column:total_num"`
+	IssuedNum         int                  `gorm:"column:issued_num"`
+	ClaimStartAt      *time.Time           `gorm:"column:claim_start_at"`
+	ClaimEndAt        *time.Time           `gorm:"column:claim_end_at"`
+	Status            CouponTemplateStatus `gorm:"column:status"`
+	CreateAt          time.Time            `gorm:"column:create_at"`
+	UpdateAt          time.Time            `gorm:"column:update_at"`
+}
+
+func (m *CouponTemplate) TableName() string {
+	return "coupon_template"
+}
@@ -4,88 +4,363 @@ import (
 	"database/sql/driver"
 	"errors"
 	"fmt"
+	"math"
 	"strconv"
 	"strings"
 )
 
+// Decimal is an exact decimal amount, stored as an integer coefficient and the number of
+// digits after the point. The zero value is 0. Values carry at most MaxDecimalScale
+// fractional digits. Parsed and scanned values are held to the decimal(10,2) range, so
+// the sums and products amounts go through stay far inside int64; arithmetic that would
+// still leave it panics.
 type Decimal struct {
-	value string
+	coef  int64
+	scale int32
 }
 
-func NewDecimalFromFloat64(f float64) Decimal {
-	return Decimal{value: fmt.Sprintf("%.2f", f)}
+const (
+	// MaxDecimalScale is the most fractional digits a Decimal keeps.
+	MaxDecimalScale = 8
+	// MaxDecimalIntDigits is the most whole digits a parsed value may have, the eight a
+	// decimal(10,2) column holds in front of the point.
+	MaxDecimalIntDigits = 8
+)
+
+var (
+	ErrInvalidDecimal  = errors.New("invalid decimal")
+	ErrDecimalOverflow = errors.New("decimal overflow")
+)
+
+// RoundingMode decides which way Round and DivInt go when digits are dropped.
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds to the nearest neighbour, ties away from zero (the usual cash rounding).
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds to the nearest neighbour, ties to the even one.
+	RoundHalfEven
+	// RoundDown truncates toward zero.
+	RoundDown
+	// RoundUp rounds away from zero.
+	RoundUp
+)
+
+var pow10 = [...]int64{1, 10, 100, 1000, 10000, 100000, 1000000, 10000000, 100000000}
+
+// NewDecimal returns coef × 10^-scale, e.g. NewDecimal(995, 2) is 9.95.
+func NewDecimal(coef int64, scale int32) Decimal {
+	if scale < 0 || scale > MaxDecimalScale {
+		panic(fmt.Sprintf("decimal scale %d out of range", scale))
+	}
+	return Decimal{coef: coef, scale: scale}
 }
 
-func NewDecimalFromString(s string) Decimal {
-	return Decimal{value: s}
+func NewDecimalFromInt(n int64) Decimal {
+	return Decimal{coef: n}
 }
 
 func NewDecimalFromCents(cents int64) Decimal {
+	return Decimal{coef: cents, scale: 2}
+}
+
+// NewDecimalFromString parses a plain decimal literal such as "12", "-0.5" or "9.99".
+// Exponents, blanks, a bare point, more than MaxDecimalScale fractional digits and more
+// than MaxDecimalIntDigits whole digits are rejected.
+func NewDecimalFromString(s string) (Decimal, error) {
+	body := s
+	negative := false
+	if body != "" && (body[0] == '-' || body[0] == '+') {
+		negative = body[0] == '-'
+		body = body[1:]
+	}
+	whole, frac, hasPoint := strings.Cut(body, ".")
+	if whole == "" || (hasPoint && frac == "") || len(frac) > MaxDecimalScale || !isDigits(whole) || !isDigits(frac) {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	if len(strings.TrimLeft(whole, "0")) > MaxDecimalIntDigits {
+		return Decimal{}, fmt.Errorf("%w: %q is out of range", ErrInvalidDecimal, s)
+	}
+	coef, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return Decimal{}, fmt.Errorf("%w: %q", ErrInvalidDecimal, s)
+	}
+	if negative {
+		coef = -coef
+	}
+	return Decimal{coef: coef, scale: int32(len(frac))}, nil
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// String renders the value with at least two decimal places, the way amounts are shown
+// and stored.
+func (d Decimal) String() string {
 	sign := ""
-	if cents < 0 {
+	if d.coef < 0 {
 		sign = "-"
-		cents = -cents
 	}
-	return Decimal{value: fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)}
+	// Pad in text rather than through rescale, which a coefficient near the int64 limit
+	// would overflow.
+	digits := strconv.FormatUint(absUint(d.coef), 10)
+	scale := int(d.scale)
+	if scale < 2 {
+		digits += strings.Repeat("0", 2-scale)
+		scale = 2
+	}
+	if pad := scale + 1 - len(digits); pad > 0 {
+		digits = strings.Repeat("0", pad) + digits
+	}
+	point := len(digits) - scale
+	return sign + digits[:point] + "." + digits[point:]
 }
 
-func (d Decimal) String() string {
-	return d.value
+func absUint(n int64) uint64 {
+	if n < 0 {
+		return uint64(-(n + 1)) + 1
+	}
+	return uint64(n)
+}
+
+func (d Decimal) Sign() int {
+	switch {
+	case d.coef > 0:
+		return 1
+	case d.coef < 0:
+		return -1
+	}
+	return 0
+}
+
+func (d Decimal) IsZero() bool {
+	return d.coef == 0
+}
+
+// Cmp returns -1, 0 or 1 as d is less than, equal to or greater than o. It compares
+// the whole and fractional parts apart, so it never has to rescale.
+func (d Decimal) Cmp(o Decimal) int {
+	dw, df := d.split()
+	ow, of := o.split()
+	switch {
+	case dw < ow, dw == ow && df < of:
+		return -1
+	case dw > ow, dw == ow && df > of:
+		return 1
+	}
+	return 0
+}
+
+// split returns the whole part and the fractional part in units of 10^-MaxDecimalScale,
+// both carrying the sign of d.
+func (d Decimal) split() (int64, int64) {
+	unit := pow10[d.scale]
+	return d.coef / unit, d.coef % unit * pow10[MaxDecimalScale-d.scale]
+}
+
+func (d Decimal) Equal(o Decimal) bool {
+	return d.Cmp(o) == 0
+}
+
+func (d Decimal) Add(o Decimal) Decimal {
+	a, b, err := align(d, o)
+	if err != nil {
+		panic(err)
+	}
+	sum := a.coef + b.coef
+	if (sum > a.coef) != (b.coef > 0) {
+		panic(ErrDecimalOverflow)
+	}
+	return Decimal{coef: sum, scale: a.scale}
+}
+
+func (d Decimal) Sub(o Decimal) Decimal {
+	return d.Add(o.Neg())
+}
+
+func (d Decimal) Neg() Decimal {
+	if d.coef == math.MinInt64 {
+		panic(ErrDecimalOverflow)
+	}
+	return Decimal{coef: -d.coef, scale: d.scale}
+}
+
+// MulInt multiplies by a whole quantity, e.g. a unit price by a count.
+func (d Decimal) MulInt(n int64) Decimal {
+	coef, err := mulInt64(d.coef, n)
+	if err != nil {
+		panic(err)
+	}
+	return Decimal{coef: coef, scale: d.scale}
+}
+
+// DivInt divides by n and rounds the result to places fractional digits.
+func (d Decimal) DivInt(n int64, places int32, mode RoundingMode) Decimal {
+	if n == 0 {
+		panic("decimal division by zero")
+	}
+	if places < 0 || places > MaxDecimalScale {
+		panic(fmt.Sprintf("decimal scale %d out of range", places))
+	}
+	num, den := d.coef, n
+	var err error
+	if places >= d.scale {
+		num, err = mulInt64(num, pow10[places-d.scale])
+	} else {
+		den, err = mulInt64(den, pow10[d.scale-places])
+	}
+	if err != nil {
+		panic(err)
+	}
+	return Decimal{coef: divRound(num, den, mode), scale: places}
+}
+
+// Round keeps places fractional digits, dropping the rest by mode.
+func (d Decimal) Round(places int32, mode RoundingMode) Decimal {
+	if places >= d.scale {
+		return d
+	}
+	if places < 0 {
+		panic(fmt.Sprintf("decimal scale %d out of range", places))
+	}
+	return Decimal{coef: divRound(d.coef, pow10[d.scale-places], mode), scale: places}
+}
+
+// ToCents converts to an integer number of cents. Values with non-zero digits below a
+// cent are rejected rather than silently rounded.
+func (d Decimal) ToCents() (int64, error) {
+	rounded := d.Round(2, RoundDown)
+	if !rounded.Equal(d) {
+		return 0, fmt.Errorf("%w: %s is not a whole number of cents", ErrInvalidDecimal, d)
+	}
+	cents, err := rounded.rescale(2)
+	if err != nil {
+		return 0, err
+	}
+	return cents.coef, nil
 }
 
 func (d Decimal) Value() (driver.Value, error) {
-	return d.value, nil
+	return d.String(), nil
 }
 
+// Scan reads a database value. NULL is read as zero; anything that isn't a plain
+// decimal is an error.
 func (d *Decimal) Scan(value interface{}) error {
+	var (
+		parsed Decimal
+		err    error
+	)
 	switch v := value.(type) {
 	case nil:
-		d.value = "0.00"
-		return nil
+		parsed = Decimal{}
 	case []byte:
-		d.value = string(v)
-		return nil
+		parsed, err = NewDecimalFromString(string(v))
 	case string:
-		d.value = v
-		return nil
-	case float64:
-		d.value = fmt.Sprintf("%.2f", v)
-		return nil
+		parsed, err = NewDecimalFromString(v)
 	case int64:
-		d.value = fmt.Sprintf("%d.00", v)
-		return nil
+		parsed, err = NewDecimalFromString(strconv.FormatInt(v, 10))
+	case float64:
+		// Drivers without a decimal type (sqlite) hand back the column as a float; its
+		// shortest representation is the literal that was stored.
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("%w: %v", ErrInvalidDecimal, v)
+		}
+		parsed, err = NewDecimalFromString(strconv.FormatFloat(v, 'f', -1, 64))
 	default:
-		return errors.New("unsupported decimal type")
+		return fmt.Errorf("%w: unsupported type %T", ErrInvalidDecimal, value)
+	}
+	if err != nil {
+		return err
 	}
+	*d = parsed
+	return nil
 }
 
-func (d Decimal) ToCents() (int64, error) {
-	val := strings.TrimSpace(d.value)
-	if val == "" {
-		return 0, nil
+// MarshalJSON writes the value as a JSON number, e.g. 9.90.
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a quoted decimal string; null leaves d unchanged.
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s := string(data)
+	if s == "null" {
+		return nil
 	}
-	sign := int64(1)
-	if strings.HasPrefix(val, "-") {
-		sign = -1
-		val = strings.TrimPrefix(val, "-")
+	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
+		s = s[1 : len(s)-1]
 	}
-	parts := strings.SplitN(val, ".", 2)
-	whole, err := strconv.ParseInt(parts[0], 10, 64)
+	parsed, err := NewDecimalFromString(s)
 	if err != nil {
-		return 0, err
+		return err
 	}
-	frac := "00"
-	if len(parts) > 1 {
-		frac = parts[1]
+	*d = parsed
+	return nil
+}
+
+// align brings both values to the larger scale.
+func align(a, b Decimal) (Decimal, Decimal, error) {
+	var err error
+	if a.scale < b.scale {
+		a, err = a.rescale(b.scale)
+	} else if b.scale < a.scale {
+		b, err = b.rescale(a.scale)
 	}
-	if len(frac) == 1 {
-		frac += "0"
-	} else if len(frac) > 2 {
-		frac = frac[:2]
+	return a, b, err
+}
+
+// rescale adds trailing zeros up to scale; it never drops digits, and reports
+// ErrDecimalOverflow when the coefficient would leave int64.
+func (d Decimal) rescale(scale int32) (Decimal, error) {
+	if scale <= d.scale {
+		return d, nil
 	}
-	fracVal, err := strconv.ParseInt(frac, 10, 64)
+	coef, err := mulInt64(d.coef, pow10[scale-d.scale])
 	if err != nil {
-		return 0, err
+		return Decimal{}, err
+	}
+	return Decimal{coef: coef, scale: scale}, nil
+}
+
+func mulInt64(a, b int64) (int64, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt64) || (b == -1 && a == math.MinInt64) {
+		return 0, ErrDecimalOverflow
+	}
+	return product, nil
+}
+
+// divRound returns num/den rounded to an integer by mode.
+func divRound(num, den int64, mode RoundingMode) int64 {
+	q, r := num/den, num%den
+	if r == 0 {
+		return q
+	}
+	// The direction away from zero for this quotient.
+	away := int64(1)
+	if (num < 0) != (den < 0) {
+		away = -1
+	}
+	switch mode {
+	case RoundDown:
+		return q
+	case RoundUp:
+		return q + away
+	}
+	twice := absUint(r) * 2
+	half := absUint(den)
+	if twice > half || (twice == half && (mode == RoundHalfUp || q%2 != 0)) {
+		return q + away
 	}
-	return sign * (whole*100 + fracVal), nil
+	return q
 }
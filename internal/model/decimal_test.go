@@ -0,0 +1,92 @@
+package model_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func mustDecimal(t *testing.T, s string) model.Decimal {
+	d, err := model.NewDecimalFromString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d
+}
+
+func TestDecimal_Parse(t *testing.T) {
+	for _, s := range []string{"", " 1", "1.", ".5", "1e3", "1.2.3", "abc", "--1", "0.123456789", "99999999999999999999"} {
+		_, err := model.NewDecimalFromString(s)
+		assert.ErrorIs(t, err, model.ErrInvalidDecimal, s)
+	}
+	assert.Equal(t, "12.00", mustDecimal(t, "12").String())
+	assert.Equal(t, "-0.50", mustDecimal(t, "-0.5").String())
+	assert.Equal(t, "0.001", mustDecimal(t, "+0.001").String())
+
+	// decimal(10,2) holds eight whole digits; leading zeros don't count.
+	for _, s := range []string{"100000000", "-100000000.00", "999999999999999999", "9223372036854775807"} {
+		_, err := model.NewDecimalFromString(s)
+		assert.ErrorIs(t, err, model.ErrInvalidDecimal, s)
+	}
+	assert.Equal(t, "99999999.99", mustDecimal(t, "99999999.99").String())
+	assert.Equal(t, "1.50", mustDecimal(t, "000000001.5").String())
+
+	var d model.Decimal
+	assert.NoError(t, d.Scan([]byte("19.90")))
+	assert.Equal(t, "19.90", d.String())
+	assert.NoError(t, d.Scan(9.9))
+	assert.Equal(t, "9.90", d.String())
+	assert.NoError(t, d.Scan(nil))
+	assert.True(t, d.IsZero())
+	assert.Error(t, d.Scan("1,5"))
+	assert.Error(t, d.Scan(true))
+	assert.Error(t, d.Scan(int64(1e12)))
+}
+
+func TestDecimal_Arithmetic(t *testing.T) {
+	price := mustDecimal(t, "19.90")
+	assert.Equal(t, "59.70", price.MulInt(3).String())
+	assert.Equal(t, "9.91", price.Sub(mustDecimal(t, "9.99")).String())
+	assert.Equal(t, "20.000", price.Add(mustDecimal(t, "0.1")).Add(mustDecimal(t, "0.000")).String())
+	assert.Equal(t, 0, mustDecimal(t, "1.5").Cmp(mustDecimal(t, "1.50000")))
+	assert.Equal(t, -1, mustDecimal(t, "-2").Cmp(mustDecimal(t, "1")))
+	assert.Equal(t, -1, mustDecimal(t, "-1.5").Cmp(mustDecimal(t, "-1.2")))
+	// Comparing doesn't rescale, so a coefficient near the int64 limit can't overflow it.
+	assert.Equal(t, 1, model.NewDecimalFromInt(1<<62).Cmp(mustDecimal(t, "0.00000001")))
+
+	// 19.90 × 80% = 15.92; 0.05 / 2 = 0.025 shows each tie rule.
+	assert.Equal(t, "15.92", price.MulInt(80).DivInt(100, 2, model.RoundHalfUp).String())
+	half := mustDecimal(t, "0.05")
+	assert.Equal(t, "0.03", half.DivInt(2, 2, model.RoundHalfUp).String())
+	assert.Equal(t, "0.02", half.DivInt(2, 2, model.RoundHalfEven).String())
+	assert.Equal(t, "0.02", half.DivInt(2, 2, model.RoundDown).String())
+	assert.Equal(t, "-0.03", half.Neg().DivInt(2, 2, model.RoundUp).String())
+	assert.Equal(t, "-1.24", mustDecimal(t, "-1.235").Round(2, model.RoundHalfEven).String())
+	assert.Equal(t, "-1.24", mustDecimal(t, "-1.235").Round(2, model.RoundHalfUp).String())
+
+	cents, err := mustDecimal(t, "5.1").ToCents()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(510), cents)
+	_, err = mustDecimal(t, "5.001").ToCents()
+	assert.ErrorIs(t, err, model.ErrInvalidDecimal)
+}
+
+func TestDecimal_JSON(t *testing.T) {
+	var body struct {
+		Amount model.Decimal  `json:"amount"`
+		Quoted model.Decimal  `json:"quoted"`
+		Absent *model.Decimal `json:"absent"`
+	}
+	assert.NoError(t, json.Unmarshal([]byte(`{"amount": 5.1, "quoted": "0.30", "absent": null}`), &body))
+	assert.Equal(t, "5.10", body.Amount.String())
+	assert.Equal(t, "0.30", body.Quoted.String())
+	assert.Nil(t, body.Absent)
+	assert.Error(t, json.Unmarshal([]byte(`{"amount": 1e2}`), &body))
+	assert.ErrorIs(t, json.Unmarshal([]byte(`{"amount": 999999999999999999}`), &body), model.ErrInvalidDecimal)
+
+	raw, err := json.Marshal(body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"amount": 5.10, "quoted": 0.30, "absent": null}`, string(raw))
+}
@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+type IdempotencyKey struct {
+	ID          int64     `gorm:"primaryKey;column:id"`
+	UserID      int64     `gorm:"column:user_id;uniqueIndex:uk_user_key"`
+	IdemKey     string    `gorm:"column:idem_key;size:64;uniqueIndex:uk_user_key"`
+	Scope       string    `gorm:"column:scope"`
+	RequestHash string    `gorm:"column:request_hash"`
+	OrderID     int64     `gorm:"column:order_id"`
+	ExpireAt    time.Time `gorm:"column:expire_at;index"`
+	CreateAt    time.Time `gorm:"column:create_at"`
+}
+
+func (m *IdempotencyKey) TableName() string {
+	return "idempotency_key"
+}
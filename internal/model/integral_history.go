@@ -0,0 +1,32 @@
+package model
+
+import "time"
+
+type IntegralHistoryBizType int
+
+const (
+	IntegralHistoryCheckIn         IntegralHistoryBizType = 1
+	IntegralHistoryFirstJob        IntegralHistoryBizType = 2
+	IntegralHistoryProfileComplete IntegralHistoryBizType = 3
+	IntegralHistoryInvite          IntegralHistoryBizType = 4
+	IntegralHistoryRedeemVoucher   IntegralHistoryBizType = 5
+	IntegralHistoryRedeemRefresh   IntegralHistoryBizType = 6
+)
+
+// IntegralHistory is one change of a user's points. BizKey makes a reward one-off (for
+// example "checkin:20260116" or "first_job"); redemptions leave it NULL.
+type IntegralHistory struct {
+	ID        int64                  `gorm:"primaryKey;column:id"`
+	UserID    int64                  `gorm:"column:user_id;uniqueIndex:uk_user_biz_key"`
+	BizType   IntegralHistoryBizType `gorm:"column:biz_type"`
+	BizKey    string                 `gorm:"column:biz_key;size:64;default:null;uniqueIndex:uk_user_biz_key"`
+	ChangeNum int64                  `gorm:"column:change_num"`
+	LastNum   int64                  `gorm:"column:last_num"`
+	NextNum   int64                  `gorm:"column:next_num"`
+	Remark    string                 `gorm:"column:remark"`
+	CreateAt  time.Time              `gorm:"column:create_at"`
+}
+
+func (m *IntegralHistory) TableName() string {
+	return "integral_history"
+}
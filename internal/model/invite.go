@@ -0,0 +1,29 @@
+package model
+
+import "time"
+
+type InviteRewardStatus int
+
+const (
+	InviteRewardStatusGranted  InviteRewardStatus = 1
+	InviteRewardStatusRejected InviteRewardStatus = 2
+)
+
+// Invite is one registration through a user's invite code. Each invitee can only be
+// invited once; the phone and device snapshots back the one-reward-per-phone/device check.
+type Invite struct {
+	ID            int64              `gorm:"primaryKey;column:id"`
+	InviterID     int64              `gorm:"column:inviter_id;index:idx_inviter_create"`
+	InviteeID     int64              `gorm:"column:invitee_id;uniqueIndex:uk_invitee_id"`
+	InviteePhone  string             `gorm:"column:invitee_phone;size:32;index"`
+	DeviceID      string             `gorm:"column:device_id;size:64;index"`
+	RewardStatus  InviteRewardStatus `gorm:"column:reward_status"`
+	InviterReward int                `gorm:"column:inviter_reward"`
+	InviteeReward int                `gorm:"column:invitee_reward"`
+	Remark        string             `gorm:"column:remark"`
+	CreateAt      time.Time          `gorm:"column:create_at;index:idx_inviter_create"`
+}
+
+func (m *Invite) TableName() string {
+	return "invite"
+}
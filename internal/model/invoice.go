@@ -0,0 +1,56 @@
+package model
+
+import "time"
+
+type InvoiceTitleType int
+
+const (
+	InvoiceTitlePersonal InvoiceTitleType = 1
+	InvoiceTitleCompany  InvoiceTitleType = 2
+)
+
+type InvoiceStatus int
+
+const (
+	InvoiceStatusRequested InvoiceStatus = 1
+	InvoiceStatusIssued    InvoiceStatus = 2
+	InvoiceStatusRejected  InvoiceStatus = 3
+)
+
+// Invoice is a user's request for a fapiao over one or more paid orders. Amount is the
+// sum the orders were paid.
+type Invoice struct {
+	ID           int64            `gorm:"primaryKey;column:id"`
+	UserID       int64            `gorm:"column:user_id;index"`
+	TitleType    InvoiceTitleType `gorm:"column:title_type"`
+	Title        string           `gorm:"column:title;size:100"`
+	TaxNo        string           `gorm:"column:tax_no;size:20"`
+	Email        string           `gorm:"column:email;size:100"`
+	Amount       Decimal          `gorm:"column:amount;type:decimal(10,2)"`
+	Status       InvoiceStatus    `gorm:"column:status;index"`
+	PdfURL       string           `gorm:"column:pdf_url;size:512"`
+	RejectReason string           `gorm:"column:reject_reason"`
+	OperatorID   int64            `gorm:"column:operator_id"`
+	HandledAt    *time.Time       `gorm:"column:handled_at"`
+	CreateAt     time.Time        `gorm:"column:create_at"`
+	UpdateAt     time.Time        `gorm:"column:update_at"`
+}
+
+func (m *Invoice) TableName() string {
+	return "invoice"
+}
+
+// InvoiceOrder links an invoice to an order it covers. Rows stay after a rejection so the
+// invoice keeps its history; orders.invoice_id is what stops an order being invoiced twice.
+type InvoiceOrder struct {
+	ID        int64     `gorm:"primaryKey;column:id"`
+	InvoiceID int64     `gorm:"column:invoice_id;index"`
+	OrderID   int64     `gorm:"column:order_id;index"`
+	OrderNo   string    `gorm:"column:order_no;size:64"`
+	Amount    Decimal   `gorm:"column:amount;type:decimal(10,2)"`
+	CreateAt  time.Time `gorm:"column:create_at"`
+}
+
+func (m *InvoiceOrder) TableName() string {
+	return "invoice_order"
+}
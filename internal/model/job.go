@@ -11,26 +11,29 @@ const (
 	JobStatusDeleted       JobStatus = 4
 )
 
+// Job is a posting. IsTop caches whether its top placement is running so the list can
+// order by it through idx_status_top_refresh; it is set when a placement is bought and
+// cleared by the task once TopEndTime passes.
 type Job struct {
 	ID                int64      `gorm:"primaryKey;column:id"`
 	UserID            int64      `gorm:"column:user_id"`
 	Positions         string     `gorm:"column:positions"`
 	CompanyName       string     `gorm:"column:company_name"`
-	Longitude         float64    `gorm:"column:longitude"`
-	Latitude          float64    `gorm:"column:latitude"`
+	Longitude         float64    `gorm:"column:longitude;index:idx_job_lat_lng,priority:2"`
+	Latitude          float64    `gorm:"column:latitude;index:idx_job_lat_lng,priority:1"`
 	Address           string     `gorm:"column:address"`
 	ContactPersonName string     `gorm:"column:contact_person_name"`
 	Contact           string     `gorm:"column:contact"`
 	Description       string     `gorm:"column:description"`
 	PhotoURLs         string     `gorm:"column:photo_urls"`
-	Status            JobStatus  `gorm:"column:status"`
-	FirstAreaID       int        `gorm:"column:first_area_id"`
+	Status            JobStatus  `gorm:"column:status;index:idx_status_top_refresh,priority:1"`
+	FirstAreaID       int        `gorm:"column:first_area_id;index:idx_job_first_area"`
 	FirstAreaDes      string     `gorm:"column:first_area_des"`
-	SecondAreaID      int        `gorm:"column:second_area_id"`
+	SecondAreaID      int        `gorm:"column:second_area_id;index:idx_job_second_area"`
 	SecondAreaDes     string     `gorm:"column:second_area_des"`
-	ThirdAreaID       int        `gorm:"column:third_area_id"`
+	ThirdAreaID       int        `gorm:"column:third_area_id;index:idx_job_third_area"`
 	ThirdAreaDes      string     `gorm:"column:third_area_des"`
-	FourAreaID        int        `gorm:"column:four_area_id"`
+	FourAreaID        int        `gorm:"column:four_area_id;index:idx_job_four_area"`
 	FourAreaDes       string     `gorm:"column:four_area_des"`
 	SalaryMin         int        `gorm:"column:salary_min"`
 	SalaryMax         int        `gorm:"column:salary_max"`
@@ -38,10 +41,11 @@ type Job struct {
 	SalaryBenefits    string     `gorm:"column:salary_benefits"`
 	AttendanceLeave   string     `gorm:"column:attendance_leave"`
 	CreateAt          time.Time  `gorm:"column:create_at"`
-	UpdateAt          time.Time  `gorm:"column:update_at"`
-	RefreshTime       *time.Time `gorm:"column:refresh_time"`
+	UpdateAt          time.Time  `gorm:"column:update_at;index:idx_job_update_at"`
+	RefreshTime       *time.Time `gorm:"column:refresh_time;index:idx_status_top_refresh,priority:3"`
 	TopStartTime      *time.Time `gorm:"column:top_start_time"`
 	TopEndTime        *time.Time `gorm:"column:top_end_time"`
+	IsTop             bool       `gorm:"column:is_top;not null;default:false;index:idx_status_top_refresh,priority:2"`
 }
 
 func (m *Job) TableName() string {
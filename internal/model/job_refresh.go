@@ -0,0 +1,18 @@
+package model
+
+import "time"
+
+// JobRefresh records a free refresh. Seq numbers a user's refreshes within RefreshDay, so
+// the unique index stops concurrent requests from going past the daily allowance.
+type JobRefresh struct {
+	ID         int64     `gorm:"primaryKey;column:id"`
+	UserID     int64     `gorm:"column:user_id;uniqueIndex:uk_user_day_seq"`
+	JobID      int64     `gorm:"column:job_id"`
+	RefreshDay string    `gorm:"column:refresh_day;size:8;uniqueIndex:uk_user_day_seq"`
+	Seq        int       `gorm:"column:seq;uniqueIndex:uk_user_day_seq"`
+	CreateAt   time.Time `gorm:"column:create_at"`
+}
+
+func (m *JobRefresh) TableName() string {
+	return "job_refresh"
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// JobTag attaches a Tag to a job. Category repeats the tag's so one field of a job can be
+// replaced without looking its tags up first.
+type JobTag struct {
+	ID       int64       `gorm:"primaryKey;column:id"`
+	JobID    int64       `gorm:"column:job_id;uniqueIndex:uk_job_tag,priority:1;index:idx_tag_job,priority:2"`
+	TagID    int64       `gorm:"column:tag_id;uniqueIndex:uk_job_tag,priority:2;index:idx_tag_job,priority:1"`
+	Category TagCategory `gorm:"column:category"`
+	CreateAt time.Time   `gorm:"column:create_at"`
+}
+
+func (m *JobTag) TableName() string {
+	return "job_tag"
+}
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+// Membership is a merchant's paid membership, one row per user. Buying again while it is
+// active pushes EndAt back; the monthly voucher allowance is due whenever NextGrantAt passes.
+type Membership struct {
+	ID          int64     `gorm:"primaryKey;column:id"`
+	UserID      int64     `gorm:"column:user_id;uniqueIndex:uk_user_id"`
+	StartAt     time.Time `gorm:"column:start_at"`
+	EndAt       time.Time `gorm:"column:end_at;index"`
+	NextGrantAt time.Time `gorm:"column:next_grant_at;index"`
+	CreateAt    time.Time `gorm:"column:create_at"`
+	UpdateAt    time.Time `gorm:"column:update_at"`
+}
+
+func (m *Membership) TableName() string {
+	return "membership"
+}
+
+func (m *Membership) Active(now time.Time) bool {
+	return m != nil && m.EndAt.After(now)
+}
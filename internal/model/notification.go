@@ -0,0 +1,26 @@
+package model
+
+import "time"
+
+type NotificationType int
+
+const (
+	NotificationTopExpired NotificationType = 1
+)
+
+// Notification is a message to a user about something that happened to their account or
+// their postings. TargetID is the job, order, etc. it concerns.
+type Notification struct {
+	ID       int64            `gorm:"primaryKey;column:id"`
+	UserID   int64            `gorm:"column:user_id;index:idx_notification_user,priority:1"`
+	Type     NotificationType `gorm:"column:type"`
+	TargetID int64            `gorm:"column:target_id"`
+	Title    string           `gorm:"column:title;size:64"`
+	Content  string           `gorm:"column:content;size:512"`
+	ReadAt   *time.Time       `gorm:"column:read_at"`
+	CreateAt time.Time        `gorm:"column:create_at;index:idx_notification_user,priority:2"`
+}
+
+func (m *Notification) TableName() string {
+	return "notification"
+}
@@ -0,0 +1,17 @@
+package model
+
+import "time"
+
+// NotifyNonce is a payment notification nonce seen inside the notify window. Keeping them
+// in the database lets every server instance refuse a notification another one already took.
+type NotifyNonce struct {
+	ID       int64     `gorm:"primaryKey;column:id"`
+	Channel  string    `gorm:"column:channel;size:16;uniqueIndex:uk_channel_nonce"`
+	Nonce    string    `gorm:"column:nonce;size:64;uniqueIndex:uk_channel_nonce"`
+	ExpireAt time.Time `gorm:"column:expire_at;index"`
+	CreateAt time.Time `gorm:"column:create_at"`
+}
+
+func (m *NotifyNonce) TableName() string {
+	return "notify_nonce"
+}
@@ -8,6 +8,7 @@ const (
 	ProductTypeTop            ProductType = 1
 	ProductTypeContactVoucher ProductType = 2
 	ProductTypeRefresh        ProductType = 3
+	ProductTypeMembership     ProductType = 4
 )
 
 type OrderTargetType int
@@ -21,9 +22,10 @@ type OrderItem struct {
 	OrderID           int64           `gorm:"column:order_id"`
 	ProductType       ProductType     `gorm:"column:product_type"`
 	TitleSnapshot     string          `gorm:"column:title_snapshot"`
-	UnitPriceSnapshot float64         `gorm:"column:unit_price_snapshot"`
+	UnitPriceSnapshot Decimal         `gorm:"column:unit_price_snapshot;type:decimal(10,2)"`
 	TopHour           int             `gorm:"column:top_hour"`
 	ContactVoucherNum int             `gorm:"column:contact_voucher_num"`
+	MembershipDays    int             `gorm:"column:membership_days"`
 	TargetType        OrderTargetType `gorm:"column:target_type"`
 	TargetID          int64           `gorm:"column:target_id"`
 	CreateAt          time.Time       `gorm:"column:create_at"`
@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+type ProductStatus int
+
+const (
+	ProductStatusOnline  ProductStatus = 1
+	ProductStatusOffline ProductStatus = 2
+)
+
+// Product is a sellable SKU, e.g. one top package, one voucher bundle, the refresh price
+// or a membership period.
+type Product struct {
+	ID                int64         `gorm:"primaryKey;column:id"`
+	ProductType       ProductType   `gorm:"column:product_type"`
+	Title             string        `gorm:"column:title"`
+	Price             Decimal       `gorm:"column:price;type:decimal(10,2)"`
+	TopHour           int           `gorm:"column:top_hour"`
+	ContactVoucherNum int           `gorm:"column:contact_voucher_num"`
+	MembershipDays    int           `gorm:"column:membership_days"`
+	Sort              int           `gorm:"column:sort"`
+	Status            ProductStatus `gorm:"column:status"`
+	CreateAt          time.Time     `gorm:"column:create_at"`
+	UpdateAt          time.Time     `gorm:"column:update_at"`
+}
+
+func (m *Product) TableName() string {
+	return "product"
+}
@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+type ReconcileDiscrepancyType int
+
+const (
+	// ReconcileMissedPayment: the provider took the money but the order isn't paid here.
+	ReconcileMissedPayment ReconcileDiscrepancyType = 1
+	// ReconcileAmountMismatch: the billed amount differs from the order's.
+	ReconcileAmountMismatch ReconcileDiscrepancyType = 2
+	// ReconcileTradeNoMismatch: the order is paid here under another provider trade.
+	ReconcileTradeNoMismatch ReconcileDiscrepancyType = 3
+	// ReconcileUnknownOrder: the bill lists an order number we don't have.
+	ReconcileUnknownOrder ReconcileDiscrepancyType = 4
+	// ReconcileMissingInBill: the order is paid here but the provider doesn't know the payment.
+	ReconcileMissingInBill ReconcileDiscrepancyType = 5
+)
+
+type ReconcileDiscrepancyStatus int
+
+const (
+	ReconcileDiscrepancyOpen  ReconcileDiscrepancyStatus = 1
+	ReconcileDiscrepancyFixed ReconcileDiscrepancyStatus = 2
+)
+
+// ReconcileDiscrepancy is a difference found between a day's trade bill and our orders.
+// Re-running a day updates its rows in place instead of adding new ones.
+type ReconcileDiscrepancy struct {
+	ID          int64                      `gorm:"primaryKey;column:id"`
+	BillDate    string                     `gorm:"column:bill_date;size:10;uniqueIndex:uk_date_order_type;index:idx_date_status"`
+	Channel     string                     `gorm:"column:channel;size:32"`
+	Type        ReconcileDiscrepancyType   `gorm:"column:type;uniqueIndex:uk_date_order_type"`
+	OrderNo     string                     `gorm:"column:order_no;size:64;uniqueIndex:uk_date_order_type"`
+	OrderID     int64                      `gorm:"column:order_id"`
+	TradeNo     string                     `gorm:"column:trade_no;size:64"`
+	BillAmount  Decimal                    `gorm:"column:bill_amount;type:decimal(10,2)"`
+	OrderAmount Decimal                    `gorm:"column:order_amount;type:decimal(10,2)"`
+	Status      ReconcileDiscrepancyStatus `gorm:"column:status;index:idx_date_status"`
+	Remark      string                     `gorm:"column:remark"`
+	CreateAt    time.Time                  `gorm:"column:create_at"`
+	UpdateAt    time.Time                  `gorm:"column:update_at"`
+}
+
+func (m *ReconcileDiscrepancy) TableName() string {
+	return "reconcile_discrepancy"
+}
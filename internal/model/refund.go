@@ -0,0 +1,34 @@
+package model
+
+import "time"
+
+type RefundStatus int
+
+const (
+	RefundStatusProcessing RefundStatus = 1
+	RefundStatusSuccess    RefundStatus = 2
+	RefundStatusFailed     RefundStatus = 3
+)
+
+type Refund struct {
+	ID                 int64        `gorm:"primaryKey;column:id"`
+	RefundNo           string       `gorm:"column:refund_no;uniqueIndex"`
+	OrderID            int64        `gorm:"column:order_id;index"`
+	OrderNo            string       `gorm:"column:order_no"`
+	UserID             int64        `gorm:"column:user_id"`
+	Amount             Decimal      `gorm:"column:amount;type:decimal(10,2)"`
+	Reason             string       `gorm:"column:reason"`
+	Status             RefundStatus `gorm:"column:status"`
+	PayRefundNo        string       `gorm:"column:pay_refund_no"`
+	OperatorID         int64        `gorm:"column:operator_id"`
+	RollbackVoucherNum int          `gorm:"column:rollback_voucher_num"`
+	RollbackTopHour    int          `gorm:"column:rollback_top_hour"`
+	RollbackMemberDays int          `gorm:"column:rollback_member_days"`
+	SuccessAt          *time.Time   `gorm:"column:success_at"`
+	CreateAt           time.Time    `gorm:"column:create_at"`
+	UpdateAt           time.Time    `gorm:"column:update_at"`
+}
+
+func (m *Refund) TableName() string {
+	return "refund"
+}
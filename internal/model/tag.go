@@ -0,0 +1,37 @@
+package model
+
+import "time"
+
+// TagCategory is the job field a tag belongs to.
+type TagCategory int
+
+const (
+	TagCategoryBasicProtection TagCategory = 1
+	TagCategorySalaryBenefits  TagCategory = 2
+	TagCategoryAttendanceLeave TagCategory = 3
+)
+
+type TagStatus int
+
+const (
+	TagStatusOnline TagStatus = 1
+	// TagStatusOffline tags are no longer offered, but jobs that carry them keep them.
+	TagStatusOffline TagStatus = 2
+)
+
+// Tag is one option of a job benefit, e.g. 五险一金 under basic protection. Clients send
+// and filter by Code; Label is what users see.
+type Tag struct {
+	ID       int64       `gorm:"primaryKey;column:id"`
+	Category TagCategory `gorm:"column:category;uniqueIndex:uk_category_code,priority:1"`
+	Code     string      `gorm:"column:code;size:64;uniqueIndex:uk_category_code,priority:2"`
+	Label    string      `gorm:"column:label;size:64"`
+	Sort     int         `gorm:"column:sort"`
+	Status   TagStatus   `gorm:"column:status"`
+	CreateAt time.Time   `gorm:"column:create_at"`
+	UpdateAt time.Time   `gorm:"column:update_at"`
+}
+
+func (m *Tag) TableName() string {
+	return "tag"
+}
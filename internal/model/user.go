@@ -2,6 +2,12 @@ package model
 
 import "time"
 
+const (
+	UserTypeNormal   = 0
+	UserTypeMerchant = 1
+	UserTypeAdmin    = 2
+)
+
 type User struct {
 	ID                int64     `gorm:"primaryKey;column:id"`
 	Avatar            string    `gorm:"column:avatar"`
@@ -27,6 +33,7 @@ type User struct {
 	BuyNum            uint64    `gorm:"column:buy_num"`
 	InviteID          int64     `gorm:"column:invite_id"`
 	InviteNum         uint64    `gorm:"column:invite_num"`
+	InviteCode        string    `gorm:"column:invite_code;size:16;default:null;uniqueIndex:uk_invite_code"`
 	FirstRecharge     string    `gorm:"column:first_recharge"`
 	TotalRecharge     float64   `gorm:"column:total_recharge"`
 	DeviceModel       string    `gorm:"column:device_model"`
@@ -0,0 +1,46 @@
+package model
+
+import "time"
+
+type UserCouponStatus int
+
+const (
+	UserCouponStatusUnused UserCouponStatus = 1
+	// UserCouponStatusLocked is held by a pending order until it is paid or canceled.
+	UserCouponStatusLocked UserCouponStatus = 2
+	UserCouponStatusUsed   UserCouponStatus = 3
+)
+
+// UserCoupon is a coupon held by a user. The discount terms are copied from the template
+// when it is issued, so later template edits don't change coupons already handed out.
+type UserCoupon struct {
+	ID                int64            `gorm:"primaryKey;column:id"`
+	UserID            int64            `gorm:"column:user_id;uniqueIndex:uk_user_template;index:idx_user_status"`
+	TemplateID        int64            `gorm:"column:template_id;uniqueIndex:uk_user_template"`
+	Title             string           `gorm:"column:title;size:64"`
+	ProductType       ProductType      `gorm:"column:product_type"`
+	DiscountAmount    Decimal          `gorm:"column:discount_amount;type:decimal(10,2)"`
+	MinAmount         Decimal          `gorm:"column:min_amount;type:decimal(10,2)"`
+	FirstRechargeOnly bool             `gorm:"column:first_recharge_only"`
+	StartAt           time.Time        `gorm:"column:start_at"`
+	EndAt             time.Time        `gorm:"column:end_at"`
+	Status            UserCouponStatus `gorm:"column:status;index:idx_user_status"`
+	OrderID           int64            `gorm:"column:order_id;index"`
+	UsedAt            *time.Time       `gorm:"column:used_at"`
+	CreateAt          time.Time        `gorm:"column:create_at"`
+	UpdateAt          time.Time        `gorm:"column:update_at"`
+}
+
+func (m *UserCoupon) TableName() string {
+	return "user_coupon"
+}
+
+// Usable reports whether the coupon can be put on a new order at now.
+func (m *UserCoupon) Usable(now time.Time) bool {
+	return m.Status == UserCouponStatusUnused && !now.Before(m.StartAt) && now.Before(m.EndAt)
+}
+
+// AppliesTo reports whether the coupon covers the product type.
+func (m *UserCoupon) AppliesTo(productType ProductType) bool {
+	return m.ProductType == 0 || m.ProductType == productType
+}
@@ -0,0 +1,56 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"gorm.io/gorm"
+)
+
+type ContactUnlockRepository interface {
+	Create(ctx context.Context, unlock *model.ContactUnlock) error
+	Get(ctx context.Context, userID int64, purposeType int, purposeID int64) (*model.ContactUnlock, error)
+	ListPurposeIDs(ctx context.Context, userID int64, purposeType int, purposeIDs []int64) ([]int64, error)
+}
+
+func NewContactUnlockRepository(
+	repository *Repository,
+) ContactUnlockRepository {
+	return &contactUnlockRepository{
+		Repository: repository,
+	}
+}
+
+type contactUnlockRepository struct {
+	*Repository
+}
+
+func (r *contactUnlockRepository) Create(ctx context.Context, unlock *model.ContactUnlock) error {
+	return r.DB(ctx).Create(unlock).Error
+}
+
+func (r *contactUnlockRepository) Get(ctx context.Context, userID int64, purposeType int, purposeID int64) (*model.ContactUnlock, error) {
+	var unlock model.ContactUnlock
+	if err := r.DB(ctx).Where("user_id = ? AND purpose_type = ? AND purpose_id = ?", userID, purposeType, purposeID).First(&unlock).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &unlock, nil
+}
+
+// ListPurposeIDs returns the subset of purposeIDs the user has unlocked.
+func (r *contactUnlockRepository) ListPurposeIDs(ctx context.Context, userID int64, purposeType int, purposeIDs []int64) ([]int64, error) {
+	if len(purposeIDs) == 0 {
+		return []int64{}, nil
+	}
+	var ids []int64
+	if err := r.DB(ctx).Model(&model.ContactUnlock{}).
+		Where("user_id = ? AND purpose_type = ? AND purpose_id IN ?", userID, purposeType, purposeIDs).
+		Pluck("purpose_id", &ids).Error; err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ContactVoucherBatchRepository interface {
+	Create(ctx context.Context, batch *model.ContactVoucherBatch) error
+	ListUsableForUpdate(ctx context.Context, userID int64, now time.Time) ([]*model.ContactVoucherBatch, error)
+	ListUsable(ctx context.Context, userID int64, now time.Time) ([]*model.ContactVoucherBatch, error)
+	ListExpired(ctx context.Context, now time.Time, limit int) ([]*model.ContactVoucherBatch, error)
+	ListByOrderIDForUpdate(ctx context.Context, orderID int64) ([]*model.ContactVoucherBatch, error)
+	Consume(ctx context.Context, id int64, num int) (bool, error)
+	Expire(ctx context.Context, id int64, remainNum int) (bool, error)
+	SetExpireAt(ctx context.Context, id int64, expireAt time.Time) error
+}
+
+func NewContactVoucherBatchRepository(
+	repository *Repository,
+) ContactVoucherBatchRepository {
+	return &contactVoucherBatchRepository{
+		Repository: repository,
+	}
+}
+
+type contactVoucherBatchRepository struct {
+	*Repository
+}
+
+func (r *contactVoucherBatchRepository) Create(ctx context.Context, batch *model.ContactVoucherBatch) error {
+	return r.DB(ctx).Create(batch).Error
+}
+
+// ListUsableForUpdate locks the user's unexpired batches in spending order: earliest expiry
+// first, batches that never expire last.
+func (r *contactVoucherBatchRepository) ListUsableForUpdate(ctx context.Context, userID int64, now time.Time) ([]*model.ContactVoucherBatch, error) {
+	return r.listUsable(r.DB(ctx).Clauses(clause.Locking{Strength: "UPDATE"}), userID, now)
+}
+
+func (r *contactVoucherBatchRepository) ListUsable(ctx context.Context, userID int64, now time.Time) ([]*model.ContactVoucherBatch, error) {
+	return r.listUsable(r.DB(ctx), userID, now)
+}
+
+func (r *contactVoucherBatchRepository) listUsable(db *gorm.DB, userID int64, now time.Time) ([]*model.ContactVoucherBatch, error) {
+	var batches []*model.ContactVoucherBatch
+	if err := db.
+		Where("user_id = ? AND remain_num > 0 AND (expire_at IS NULL OR expire_at > ?)", userID, now).
+		Order("expire_at IS NULL").
+		Order("expire_at ASC").
+		Order("id ASC").
+		Find(&batches).Error; err != nil {
+		return nil, err
+	}
+	return batches, nil
+}
+
+func (r *contactVoucherBatchRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]*model.ContactVoucherBatch, error) {
+	var batches []*model.ContactVoucherBatch
+	if err := r.DB(ctx).
+		Where("remain_num > 0 AND expire_at IS NOT NULL AND expire_at <= ?", now).
+		Order("expire_at ASC").
+		Order("id ASC").
+		Limit(limit).
+		Find(&batches).Error; err != nil {
+		return nil, err
+	}
+	return batches, nil
+}
+
+// ListByOrderIDForUpdate locks the batches an order granted that still have vouchers left.
+func (r *contactVoucherBatchRepository) ListByOrderIDForUpdate(ctx context.Context, orderID int64) ([]*model.ContactVoucherBatch, error) {
+	var batches []*model.ContactVoucherBatch
+	if err := r.DB(ctx).
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("order_id = ? AND remain_num > 0", orderID).
+		Order("id ASC").
+		Find(&batches).Error; err != nil {
+		return nil, err
+	}
+	return batches, nil
+}
+
+// Consume takes num vouchers from the batch if it still has them.
+func (r *contactVoucherBatchRepository) Consume(ctx context.Context, id int64, num int) (bool, error) {
+	result := r.DB(ctx).Model(&model.ContactVoucherBatch{}).
+		Where("id = ? AND remain_num >= ?", id, num).
+		Updates(map[string]interface{}{
+			"remain_num": gorm.Expr("remain_num - ?", num),
+			"update_at":  time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Expire zeroes the batch only if nothing was spent from it since remainNum was read.
+func (r *contactVoucherBatchRepository) Expire(ctx context.Context, id int64, remainNum int) (bool, error) {
+	result := r.DB(ctx).Model(&model.ContactVoucherBatch{}).
+		Where("id = ? AND remain_num = ?", id, remainNum).
+		Updates(map[string]interface{}{
+			"remain_num": 0,
+			"update_at":  time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *contactVoucherBatchRepository) SetExpireAt(ctx context.Context, id int64, expireAt time.Time) error {
+	return r.DB(ctx).Model(&model.ContactVoucherBatch{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"expire_at": expireAt,
+			"update_at": time.Now(),
+		}).Error
+}
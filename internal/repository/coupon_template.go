@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"gorm.io/gorm"
+)
+
+type CouponTemplateRepository interface {
+	Create(ctx context.Context, template *model.CouponTemplate) error
+	GetByID(ctx context.Context, id int64) (*model.CouponTemplate, error)
+	ListClaimable(ctx context.Context, now time.Time) ([]*model.CouponTemplate, error)
+	IncrIssued(ctx context.Context, id int64) (bool, error)
+}
+
+func NewCouponTemplateRepository(
+	repository *Repository,
+) CouponTemplateRepository {
+	return &couponTemplateRepository{
+		Repository: repository,
+	}
+}
+
+type couponTemplateRepository struct {
+	*Repository
+}
+
+func (r *couponTemplateRepository) Create(ctx context.Context, template *model.CouponTemplate) error {
+	return r.DB(ctx).Create(template).Error
+}
+
+func (r *couponTemplateRepository) GetByID(ctx context.Context, id int64) (*model.CouponTemplate, error) {
+	var template model.CouponTemplate
+	if err := r.DB(ctx).Where("id = ?", id).First(&template).Error; err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// ListClaimable returns the online templates whose claim window contains now and that have
+// coupons left.
+func (r *couponTemplateRepository) ListClaimable(ctx context.Context, now time.Time) ([]*model.CouponTemplate, error) {
+	var templates []*model.CouponTemplate
+	if err := r.DB(ctx).
+		Where("status = ?", model.CouponTemplateStatusOnline).
+		Where("claim_start_at IS NULL OR claim_start_at <= ?", now).
+		Where("claim_end_at IS NULL OR claim_end_at > ?", now).
+		Where("total_num = 0 OR issued_num < total_num").
+		Order("id DESC").
+		Find(&templates).Error; err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// IncrIssued counts one more coupon handed out, unless the template's total is used up.
+func (r *couponTemplateRepository) IncrIssued(ctx context.Context, id int64) (bool, error) {
+	result := r.DB(ctx).Model(&model.CouponTemplate{}).
+		Where("id = ? AND (total_num = 0 OR issued_num < total_num)", id).
+		Updates(map[string]interface{}{
+			"issued_num": gorm.Expr("issued_num + 1"),
+			"update_at":  time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,53 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+)
+
+type IdempotencyKeyRepository interface {
+	Create(ctx context.Context, key *model.IdempotencyKey) error
+	Update(ctx context.Context, key *model.IdempotencyKey) error
+	Get(ctx context.Context, userID int64, idemKey string) (*model.IdempotencyKey, error)
+	Delete(ctx context.Context, id int64) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+func NewIdempotencyKeyRepository(
+	repository *Repository,
+) IdempotencyKeyRepository {
+	return &idempotencyKeyRepository{
+		Repository: repository,
+	}
+}
+
+type idempotencyKeyRepository struct {
+	*Repository
+}
+
+func (r *idempotencyKeyRepository) Create(ctx context.Context, key *model.IdempotencyKey) error {
+	return r.DB(ctx).Create(key).Error
+}
+
+func (r *idempotencyKeyRepository) Update(ctx context.Context, key *model.IdempotencyKey) error {
+	return r.DB(ctx).Save(key).Error
+}
+
+func (r *idempotencyKeyRepository) Get(ctx context.Context, userID int64, idemKey string) (*model.IdempotencyKey, error) {
+	var key model.IdempotencyKey
+	if err := r.DB(ctx).Where("user_id = ? AND idem_key = ?", userID, idemKey).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *idempotencyKeyRepository) Delete(ctx context.Context, id int64) error {
+	return r.DB(ctx).Where("id = ?", id).Delete(&model.IdempotencyKey{}).Error
+}
+
+func (r *idempotencyKeyRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB(ctx).Where("expire_at < ?", before).Delete(&model.IdempotencyKey{})
+	return result.RowsAffected, result.Error
+}
@@ -0,0 +1,61 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+)
+
+type IntegralHistoryRepository interface {
+	Create(ctx context.Context, history *model.IntegralHistory) error
+	ListByUser(ctx context.Context, userID int64, pageNum, pageSize int) ([]*model.IntegralHistory, int64, error)
+	ExistsBizKey(ctx context.Context, userID int64, bizKey string) (bool, error)
+}
+
+func NewIntegralHistoryRepository(
+	repository *Repository,
+) IntegralHistoryRepository {
+	return &integralHistoryRepository{
+		Repository: repository,
+	}
+}
+
+type integralHistoryRepository struct {
+	*Repository
+}
+
+func (r *integralHistoryRepository) Create(ctx context.Context, history *model.IntegralHistory) error {
+	return r.DB(ctx).Create(history).Error
+}
+
+func (r *integralHistoryRepository) ListByUser(ctx context.Context, userID int64, pageNum, pageSize int) ([]*model.IntegralHistory, int64, error) {
+	var (
+		histories []*model.IntegralHistory
+		total     int64
+	)
+	db := r.DB(ctx).Model(&model.IntegralHistory{}).Where("user_id = ?", userID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if pageNum <= 0 {
+		pageNum = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	offset := (pageNum - 1) * pageSize
+	if err := db.Order("create_at DESC").Order("id DESC").Offset(offset).Limit(pageSize).Find(&histories).Error; err != nil {
+		return nil, 0, err
+	}
+	return histories, total, nil
+}
+
+func (r *integralHistoryRepository) ExistsBizKey(ctx context.Context, userID int64, bizKey string) (bool, error) {
+	var count int64
+	if err := r.DB(ctx).Model(&model.IntegralHistory{}).
+		Where("user_id = ? AND biz_key = ?", userID, bizKey).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
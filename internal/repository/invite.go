@@ -0,0 +1,79 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+)
+
+type InviteRepository interface {
+	Create(ctx context.Context, invite *model.Invite) error
+	ListByInviter(ctx context.Context, inviterID int64, pageNum, pageSize int) ([]*model.Invite, int64, error)
+	CountGrantedByPhoneOrDevice(ctx context.Context, phone, deviceID string) (int64, error)
+	CountGrantedSince(ctx context.Context, inviterID int64, since time.Time) (int64, error)
+}
+
+func NewInviteRepository(
+	repository *Repository,
+) InviteRepository {
+	return &inviteRepository{
+		Repository: repository,
+	}
+}
+
+type inviteRepository struct {
+	*Repository
+}
+
+func (r *inviteRepository) Create(ctx context.Context, invite *model.Invite) error {
+	return r.DB(ctx).Create(invite).Error
+}
+
+func (r *inviteRepository) ListByInviter(ctx context.Context, inviterID int64, pageNum, pageSize int) ([]*model.Invite, int64, error) {
+	var (
+		invites []*model.Invite
+		total   int64
+	)
+	db := r.DB(ctx).Model(&model.Invite{}).Where("inviter_id = ?", inviterID)
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if pageNum <= 0 {
+		pageNum = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	offset := (pageNum - 1) * pageSize
+	if err := db.Order("create_at DESC").Order("id DESC").Offset(offset).Limit(pageSize).Find(&invites).Error; err != nil {
+		return nil, 0, err
+	}
+	return invites, total, nil
+}
+
+// CountGrantedByPhoneOrDevice counts rewarded invites of the same phone or device; an empty
+// deviceID only matches on phone.
+func (r *inviteRepository) CountGrantedByPhoneOrDevice(ctx context.Context, phone, deviceID string) (int64, error) {
+	var count int64
+	db := r.DB(ctx).Model(&model.Invite{}).Where("reward_status = ?", model.InviteRewardStatusGranted)
+	if deviceID != "" {
+		db = db.Where("invitee_phone = ? OR device_id = ?", phone, deviceID)
+	} else {
+		db = db.Where("invitee_phone = ?", phone)
+	}
+	if err := db.Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
+
+func (r *inviteRepository) CountGrantedSince(ctx context.Context, inviterID int64, since time.Time) (int64, error) {
+	var count int64
+	if err := r.DB(ctx).Model(&model.Invite{}).
+		Where("inviter_id = ? AND reward_status = ? AND create_at >= ?", inviterID, model.InviteRewardStatusGranted, since).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+)
+
+type InvoiceRepository interface {
+	Create(ctx context.Context, invoice *model.Invoice) error
+	GetByID(ctx context.Context, id int64) (*model.Invoice, error)
+	CreateOrders(ctx context.Context, orders []*model.InvoiceOrder) error
+	ListOrders(ctx context.Context, invoiceIDs []int64) ([]*model.InvoiceOrder, error)
+	List(ctx context.Context, userID int64, status model.InvoiceStatus, pageNum, pageSize int) ([]*model.Invoice, int64, error)
+	Finish(ctx context.Context, invoice *model.Invoice) (bool, error)
+	LatestByOrderIDs(ctx context.Context, orderIDs []int64) (map[int64]*model.Invoice, error)
+}
+
+func NewInvoiceRepository(
+	repository *Repository,
+) InvoiceRepository {
+	return &invoiceRepository{
+		Repository: repository,
+	}
+}
+
+type invoiceRepository struct {
+	*Repository
+}
+
+func (r *invoiceRepository) Create(ctx context.Context, invoice *model.Invoice) error {
+	return r.DB(ctx).Create(invoice).Error
+}
+
+func (r *invoiceRepository) GetByID(ctx context.Context, id int64) (*model.Invoice, error) {
+	var invoice model.Invoice
+	if err := r.DB(ctx).Where("id = ?", id).First(&invoice).Error; err != nil {
+		return nil, err
+	}
+	return &invoice, nil
+}
+
+func (r *invoiceRepository) CreateOrders(ctx context.Context, orders []*model.InvoiceOrder) error {
+	return r.DB(ctx).Create(orders).Error
+}
+
+func (r *invoiceRepository) ListOrders(ctx context.Context, invoiceIDs []int64) ([]*model.InvoiceOrder, error) {
+	var orders []*model.InvoiceOrder
+	if len(invoiceIDs) == 0 {
+		return orders, nil
+	}
+	if err := r.DB(ctx).Where("invoice_id IN ?", invoiceIDs).Order("id ASC").Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// List pages invoices newest first; userID 0 lists every user's and status 0 every status.
+func (r *invoiceRepository) List(ctx context.Context, userID int64, status model.InvoiceStatus, pageNum, pageSize int) ([]*model.Invoice, int64, error) {
+	var (
+		invoices []*model.Invoice
+		total    int64
+	)
+	db := r.DB(ctx).Model(&model.Invoice{})
+	if userID > 0 {
+		db = db.Where("user_id = ?", userID)
+	}
+	if status > 0 {
+		db = db.Where("status = ?", status)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if pageNum <= 0 {
+		pageNum = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	offset := (pageNum - 1) * pageSize
+	if err := db.Order("id DESC").Offset(offset).Limit(pageSize).Find(&invoices).Error; err != nil {
+		return nil, 0, err
+	}
+	return invoices, total, nil
+}
+
+// Finish moves a requested invoice to its final state and reports whether it did, so
+// two admins handling the same request can't both win.
+func (r *invoiceRepository) Finish(ctx context.Context, invoice *model.Invoice) (bool, error) {
+	result := r.DB(ctx).Model(&model.Invoice{}).
+		Where("id = ? AND status = ?", invoice.ID, model.InvoiceStatusRequested).
+		Updates(map[string]interface{}{
+			"status":        invoice.Status,
+			"pdf_url":       invoice.PdfURL,
+			"reject_reason": invoice.RejectReason,
+			"operator_id":   invoice.OperatorID,
+			"handled_at":    invoice.HandledAt,
+			"update_at":     invoice.UpdateAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// LatestByOrderIDs returns, per order, the newest invoice request that covered it.
+func (r *invoiceRepository) LatestByOrderIDs(ctx context.Context, orderIDs []int64) (map[int64]*model.Invoice, error) {
+	latest := make(map[int64]*model.Invoice)
+	if len(orderIDs) == 0 {
+		return latest, nil
+	}
+	var links []*model.InvoiceOrder
+	if err := r.DB(ctx).Where("order_id IN ?", orderIDs).Find(&links).Error; err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return latest, nil
+	}
+	invoiceIDs := make([]int64, 0, len(links))
+	for _, link := range links {
+		invoiceIDs = append(invoiceIDs, link.InvoiceID)
+	}
+	var invoices []*model.Invoice
+	if err := r.DB(ctx).Where("id IN ?", invoiceIDs).Find(&invoices).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[int64]*model.Invoice, len(invoices))
+	for _, invoice := range invoices {
+		byID[invoice.ID] = invoice
+	}
+	for _, link := range links {
+		invoice, ok := byID[link.InvoiceID]
+		if !ok {
+			continue
+		}
+		if current, ok := latest[link.OrderID]; !ok || invoice.ID > current.ID {
+			latest[link.OrderID] = invoice
+		}
+	}
+	return latest, nil
+}
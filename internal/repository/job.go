@@ -2,19 +2,32 @@ package repository
 
 import (
 	"context"
-	"fmt"
+	"math"
+	"time"
 
 	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/area"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/geo"
+	"gorm.io/gorm/clause"
 )
 
 type JobRepository interface {
 	Create(ctx context.Context, job *model.Job) error
 	Update(ctx context.Context, job *model.Job) error
 	GetByID(ctx context.Context, id int64) (*model.Job, error)
+	GetByIDForUpdate(ctx context.Context, id int64) (*model.Job, error)
 	List(ctx context.Context, query JobListQuery) ([]*model.Job, int64, error)
 	ListByUser(ctx context.Context, userID int64, bizType int, pageNum, pageSize int) ([]*model.Job, int64, error)
 	ListByIDs(ctx context.Context, ids []int64) ([]*model.Job, error)
+	// ListActiveAfterID pages through active jobs by ascending ID.
+	ListActiveAfterID(ctx context.Context, afterID int64, limit int) ([]*model.Job, error)
+	// ListUpdatedSince pages by ascending ID through the jobs of any status whose
+	// update_at is not before since.
+	ListUpdatedSince(ctx context.Context, since time.Time, afterID int64, limit int) ([]*model.Job, error)
 	CountByUser(ctx context.Context, userID int64, status model.JobStatus) (int64, error)
+	// ListTopExpired returns jobs still flagged is_top whose placement ended by now.
+	ListTopExpired(ctx context.Context, now time.Time, limit int) ([]*model.Job, error)
+	ClearTop(ctx context.Context, id int64, now time.Time) (bool, error)
 }
 
 func NewJobRepository(
@@ -29,26 +42,45 @@ type jobRepository struct {
 	*Repository
 }
 
+// JobListQuery filters the active jobs. Keyword is matched by JobSearcher and the tag
+// codes in BasicProtection, SalaryBenefits and AttendanceLeave are turned into TagIDs by
+// JobService; the repository only reads the rest. TagIDs keeps jobs carrying all of the
+// tags, or any of them with TagMatchAny. AreaID is an area code of any level. RadiusKm
+// keeps jobs within that many kilometers of Longitude/Latitude, up to geo.PlaneMaxRadius,
+// and IDs, when set, limits the list to those jobs.
 type JobListQuery struct {
 	QueryType       int
+	Keyword         string
 	Positions       string
 	City            string
+	AreaID          int
 	SalaryMin       int
 	SalaryMax       int
 	BasicProtection []string
 	SalaryBenefits  []string
 	AttendanceLeave []string
+	TagIDs          []int64
+	TagMatchAny     bool
 	Longitude       float64
 	Latitude        float64
+	RadiusKm        float64
+	IDs             []int64
 	PageNum         int
 	PageSize        int
 }
 
+// HasLocation reports whether the query carries the searcher's position.
+func (q JobListQuery) HasLocation() bool {
+	return q.Longitude != 0 || q.Latitude != 0
+}
+
 func (r *jobRepository) Create(ctx context.Context, job *model.Job) error {
 	return r.DB(ctx).Create(job).Error
 }
 
+// Update saves the whole job and stamps update_at, which the searcher syncs by.
 func (r *jobRepository) Update(ctx context.Context, job *model.Job) error {
+	job.UpdateAt = time.Now()
 	return r.DB(ctx).Save(job).Error
 }
 
@@ -60,6 +92,15 @@ func (r *jobRepository) GetByID(ctx context.Context, id int64) (*model.Job, erro
 	return &job, nil
 }
 
+// GetByIDForUpdate reads the job with a row lock; it must run inside a transaction.
+func (r *jobRepository) GetByIDForUpdate(ctx context.Context, id int64) (*model.Job, error) {
+	var job model.Job
+	if err := r.DB(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&job).Error; err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
 func (r *jobRepository) List(ctx context.Context, query JobListQuery) ([]*model.Job, int64, error) {
 	var (
 		jobs  []*model.Job
@@ -67,37 +108,61 @@ func (r *jobRepository) List(ctx context.Context, query JobListQuery) ([]*model.
 	)
 	db := r.DB(ctx).Model(&model.Job{}).Where("status = ?", model.JobStatusActive)
 
+	if len(query.IDs) > 0 {
+		db = db.Where("id IN ?", query.IDs)
+	}
 	if query.Positions != "" {
 		db = db.Where("positions LIKE ?", "%"+query.Positions+"%")
 	}
 	if query.City != "" {
 		db = db.Where("second_area_des LIKE ?", "%"+query.City+"%")
 	}
+	switch area.Level(query.AreaID) {
+	case area.LevelProvince:
+		db = db.Where("first_area_id = ?", query.AreaID)
+	case area.LevelCity:
+		db = db.Where("second_area_id = ?", query.AreaID)
+	case area.LevelDistrict:
+		db = db.Where("third_area_id = ?", query.AreaID)
+	case area.LevelStreet:
+		db = db.Where("four_area_id = ?", query.AreaID)
+	}
 	if query.SalaryMin > 0 {
 		db = db.Where("salary_max >= ?", query.SalaryMin)
 	}
 	if query.SalaryMax > 0 {
 		db = db.Where("salary_min <= ?", query.SalaryMax)
 	}
-	for _, item := range query.BasicProtection {
-		db = db.Where("basic_protection LIKE ?", "%"+item+"%")
-	}
-	for _, item := range query.SalaryBenefits {
-		db = db.Where("salary_benefits LIKE ?", "%"+item+"%")
+	if len(query.TagIDs) > 0 {
+		tagged := r.DB(ctx).Model(&model.JobTag{}).Select("job_id").Where("tag_id IN ?", query.TagIDs)
+		if !query.TagMatchAny {
+			// (job_id, tag_id) is unique, so a job with every tag has one row per tag.
+			tagged = tagged.Group("job_id").Having("COUNT(*) = ?", len(query.TagIDs))
+		}
+		db = db.Where("id IN (?)", tagged)
 	}
-	for _, item := range query.AttendanceLeave {
-		db = db.Where("attendance_leave LIKE ?", "%"+item+"%")
+	var distance clause.Expr
+	if query.HasLocation() {
+		distance = squaredDistance(geo.NewPlane(query.Latitude, query.Longitude))
+		if query.RadiusKm > 0 {
+			// Past PlaneMaxRadius the plane drifts from the great circle; wider radii are capped.
+			radius := math.Min(query.RadiusKm*1000, geo.PlaneMaxRadius)
+			// The box narrows the scan through idx_job_lat_lng; the distance check trims its corners.
+			box := geo.BoundingBox(query.Latitude, query.Longitude, radius)
+			db = db.Where("latitude BETWEEN ? AND ? AND longitude BETWEEN ? AND ?", box.MinLat, box.MaxLat, box.MinLng, box.MaxLng).
+				Where(clause.Expr{SQL: "? <= ?", Vars: []interface{}{distance, radius * radius}})
+		}
 	}
 
 	switch query.QueryType {
 	case 1:
-		orderClause := "CASE WHEN top_start_time IS NOT NULL AND top_end_time IS NOT NULL AND top_start_time <= NOW() AND top_end_time >= NOW() THEN 1 ELSE 0 END DESC"
-		db = db.Order(orderClause).
+		// Runs down idx_status_top_refresh; is_top lags an ended placement until the task clears it.
+		db = db.Order("is_top DESC").
 			Order("refresh_time DESC")
 	case 2:
-		if query.Longitude != 0 || query.Latitude != 0 {
-			db = db.Order(fmt.Sprintf("((longitude-%f)*(longitude-%f)+(latitude-%f)*(latitude-%f)) ASC",
-				query.Longitude, query.Longitude, query.Latitude, query.Latitude))
+		if query.HasLocation() {
+			// A later Order() would replace an expression ORDER BY, so the tie-break lives inside it.
+			db = db.Order(clause.OrderBy{Expression: clause.Expr{SQL: "? ASC, id DESC", Vars: []interface{}{distance}, WithoutParentheses: true}})
 		}
 	case 3:
 		db = db.Order("create_at DESC")
@@ -122,6 +187,19 @@ func (r *jobRepository) List(ctx context.Context, query JobListQuery) ([]*model.
 	return jobs, total, nil
 }
 
+// squaredDistance is the squared distance in square meters from the plane's center to a job,
+// written as plain arithmetic so it means the same on every database NewDB supports. It
+// must stay the square of geo.Plane.Distance, which the handler reports.
+func squaredDistance(plane geo.Plane) clause.Expr {
+	return clause.Expr{
+		SQL: "((latitude - ?) * ?) * ((latitude - ?) * ?) + ((longitude - ?) * ?) * ((longitude - ?) * ?)",
+		Vars: []interface{}{
+			plane.Lat, plane.LatScale, plane.Lat, plane.LatScale,
+			plane.Lng, plane.LngScale, plane.Lng, plane.LngScale,
+		},
+	}
+}
+
 func (r *jobRepository) ListByUser(ctx context.Context, userID int64, bizType int, pageNum, pageSize int) ([]*model.Job, int64, error) {
 	var (
 		jobs  []*model.Job
@@ -155,6 +233,24 @@ func (r *jobRepository) ListByIDs(ctx context.Context, ids []int64) ([]*model.Jo
 	return jobs, nil
 }
 
+func (r *jobRepository) ListActiveAfterID(ctx context.Context, afterID int64, limit int) ([]*model.Job, error) {
+	var jobs []*model.Job
+	if err := r.DB(ctx).Where("status = ? AND id > ?", model.JobStatusActive, afterID).
+		Order("id ASC").Limit(limit).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+func (r *jobRepository) ListUpdatedSince(ctx context.Context, since time.Time, afterID int64, limit int) ([]*model.Job, error) {
+	var jobs []*model.Job
+	if err := r.DB(ctx).Where("update_at >= ? AND id > ?", since, afterID).
+		Order("id ASC").Limit(limit).Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
 func (r *jobRepository) CountByUser(ctx context.Context, userID int64, status model.JobStatus) (int64, error) {
 	var total int64
 	if err := r.DB(ctx).Model(&model.Job{}).
@@ -164,3 +260,30 @@ func (r *jobRepository) CountByUser(ctx context.Context, userID int64, status mo
 	}
 	return total, nil
 }
+
+func (r *jobRepository) ListTopExpired(ctx context.Context, now time.Time, limit int) ([]*model.Job, error) {
+	var jobs []*model.Job
+	if err := r.DB(ctx).
+		Where("is_top = ? AND (top_end_time IS NULL OR top_end_time <= ?)", true, now).
+		Order("id ASC").
+		Limit(limit).
+		Find(&jobs).Error; err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// ClearTop drops the is_top flag only if the placement is still over, so one extended
+// since it was listed stays on top.
+func (r *jobRepository) ClearTop(ctx context.Context, id int64, now time.Time) (bool, error) {
+	result := r.DB(ctx).Model(&model.Job{}).
+		Where("id = ? AND is_top = ? AND (top_end_time IS NULL OR top_end_time <= ?)", id, true, now).
+		Updates(map[string]interface{}{
+			"is_top":    false,
+			"update_at": now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,38 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+)
+
+type JobRefreshRepository interface {
+	Create(ctx context.Context, refresh *model.JobRefresh) error
+	CountByUserDay(ctx context.Context, userID int64, day string) (int64, error)
+}
+
+func NewJobRefreshRepository(
+	repository *Repository,
+) JobRefreshRepository {
+	return &jobRefreshRepository{
+		Repository: repository,
+	}
+}
+
+type jobRefreshRepository struct {
+	*Repository
+}
+
+func (r *jobRefreshRepository) Create(ctx context.Context, refresh *model.JobRefresh) error {
+	return r.DB(ctx).Create(refresh).Error
+}
+
+func (r *jobRefreshRepository) CountByUserDay(ctx context.Context, userID int64, day string) (int64, error) {
+	var count int64
+	if err := r.DB(ctx).Model(&model.JobRefresh{}).
+		Where("user_id = ? AND refresh_day = ?", userID, day).
+		Count(&count).Error; err != nil {
+		return 0, err
+	}
+	return count, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+)
+
+type JobTagRepository interface {
+	// Replace sets the job's tags of one category to tagIDs.
+	Replace(ctx context.Context, jobID int64, category model.TagCategory, tagIDs []int64) error
+	ListByJobIDs(ctx context.Context, jobIDs []int64) ([]*model.JobTag, error)
+}
+
+func NewJobTagRepository(
+	repository *Repository,
+) JobTagRepository {
+	return &jobTagRepository{
+		Repository: repository,
+	}
+}
+
+type jobTagRepository struct {
+	*Repository
+}
+
+func (r *jobTagRepository) Replace(ctx context.Context, jobID int64, category model.TagCategory, tagIDs []int64) error {
+	return r.Transaction(ctx, func(ctx context.Context) error {
+		if err := r.DB(ctx).Where("job_id = ? AND category = ?", jobID, category).Delete(&model.JobTag{}).Error; err != nil {
+			return err
+		}
+		if len(tagIDs) == 0 {
+			return nil
+		}
+		now := time.Now()
+		rows := make([]*model.JobTag, 0, len(tagIDs))
+		for _, tagID := range tagIDs {
+			rows = append(rows, &model.JobTag{JobID: jobID, TagID: tagID, Category: category, CreateAt: now})
+		}
+		return r.DB(ctx).Create(&rows).Error
+	})
+}
+
+func (r *jobTagRepository) ListByJobIDs(ctx context.Context, jobIDs []int64) ([]*model.JobTag, error) {
+	if len(jobIDs) == 0 {
+		return []*model.JobTag{}, nil
+	}
+	var jobTags []*model.JobTag
+	if err := r.DB(ctx).Where("job_id IN ?", jobIDs).Order("id ASC").Find(&jobTags).Error; err != nil {
+		return nil, err
+	}
+	return jobTags, nil
+}
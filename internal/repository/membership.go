@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type MembershipRepository interface {
+	Create(ctx context.Context, membership *model.Membership) error
+	Update(ctx context.Context, membership *model.Membership) error
+	GetByUserID(ctx context.Context, userID int64) (*model.Membership, error)
+	GetByUserIDForUpdate(ctx context.Context, userID int64) (*model.Membership, error)
+	ListGrantDue(ctx context.Context, now time.Time, limit int) ([]*model.Membership, error)
+	AdvanceNextGrant(ctx context.Context, id int64, from, to time.Time) (bool, error)
+}
+
+func NewMembershipRepository(
+	repository *Repository,
+) MembershipRepository {
+	return &membershipRepository{
+		Repository: repository,
+	}
+}
+
+type membershipRepository struct {
+	*Repository
+}
+
+func (r *membershipRepository) Create(ctx context.Context, membership *model.Membership) error {
+	return r.DB(ctx).Create(membership).Error
+}
+
+func (r *membershipRepository) Update(ctx context.Context, membership *model.Membership) error {
+	return r.DB(ctx).Save(membership).Error
+}
+
+// GetByUserID returns nil when the user has never been a member.
+func (r *membershipRepository) GetByUserID(ctx context.Context, userID int64) (*model.Membership, error) {
+	var membership model.Membership
+	if err := r.DB(ctx).Where("user_id = ?", userID).First(&membership).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &membership, nil
+}
+
+func (r *membershipRepository) GetByUserIDForUpdate(ctx context.Context, userID int64) (*model.Membership, error) {
+	var membership model.Membership
+	if err := r.DB(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("user_id = ?", userID).First(&membership).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &membership, nil
+}
+
+// ListGrantDue returns active memberships whose monthly allowance is due.
+func (r *membershipRepository) ListGrantDue(ctx context.Context, now time.Time, limit int) ([]*model.Membership, error) {
+	var memberships []*model.Membership
+	if err := r.DB(ctx).
+		Where("end_at > ? AND next_grant_at <= ?", now, now).
+		Order("next_grant_at ASC").
+		Limit(limit).
+		Find(&memberships).Error; err != nil {
+		return nil, err
+	}
+	return memberships, nil
+}
+
+// AdvanceNextGrant moves the next allowance from `from` to `to`; false means another
+// run already did.
+func (r *membershipRepository) AdvanceNextGrant(ctx context.Context, id int64, from, to time.Time) (bool, error) {
+	result := r.DB(ctx).Model(&model.Membership{}).
+		Where("id = ? AND next_grant_at = ?", id, from).
+		Updates(map[string]interface{}{
+			"next_grant_at": to,
+			"update_at":     time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+)
+
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *model.Notification) error
+}
+
+func NewNotificationRepository(
+	repository *Repository,
+) NotificationRepository {
+	return &notificationRepository{
+		Repository: repository,
+	}
+}
+
+type notificationRepository struct {
+	*Repository
+}
+
+func (r *notificationRepository) Create(ctx context.Context, notification *model.Notification) error {
+	return r.DB(ctx).Create(notification).Error
+}
@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"gorm.io/gorm/clause"
+)
+
+type NotifyNonceRepository interface {
+	// Add stores the nonce and reports false when an unexpired copy is already there.
+	Add(ctx context.Context, nonce *model.NotifyNonce) (bool, error)
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+func NewNotifyNonceRepository(
+	repository *Repository,
+) NotifyNonceRepository {
+	return &notifyNonceRepository{
+		Repository: repository,
+	}
+}
+
+type notifyNonceRepository struct {
+	*Repository
+}
+
+func (r *notifyNonceRepository) Add(ctx context.Context, nonce *model.NotifyNonce) (bool, error) {
+	added := false
+	err := r.Transaction(ctx, func(ctx context.Context) error {
+		// An expired copy the purge hasn't reached yet doesn't count as a replay.
+		if err := r.DB(ctx).
+			Where("channel = ? AND nonce = ? AND expire_at < ?", nonce.Channel, nonce.Nonce, nonce.CreateAt).
+			Delete(&model.NotifyNonce{}).Error; err != nil {
+			return err
+		}
+		result := r.DB(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(nonce)
+		if result.Error != nil {
+			return result.Error
+		}
+		added = result.RowsAffected > 0
+		return nil
+	})
+	return added, err
+}
+
+func (r *notifyNonceRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	result := r.DB(ctx).Where("expire_at < ?", before).Delete(&model.NotifyNonce{})
+	return result.RowsAffected, result.Error
+}
@@ -2,7 +2,10 @@ package repository
 
 import (
 	"context"
+	"time"
+
 	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"gorm.io/gorm/clause"
 )
 
 type OrderRepository interface {
@@ -10,6 +13,15 @@ type OrderRepository interface {
 	Update(ctx context.Context, order *model.Order) error
 	GetByID(ctx context.Context, id int64) (*model.Order, error)
 	GetByOrderNo(ctx context.Context, orderNo string) (*model.Order, error)
+	GetByIDForUpdate(ctx context.Context, id int64) (*model.Order, error)
+	MarkPaid(ctx context.Context, order *model.Order, from model.OrderStatus) (bool, error)
+	Cancel(ctx context.Context, id int64, canceledAt time.Time, remark string) (bool, error)
+	ListPendingBefore(ctx context.Context, before time.Time, limit int) ([]*model.Order, error)
+	ListByUser(ctx context.Context, userID int64, status model.OrderStatus, pageNum, pageSize int) ([]*model.Order, int64, error)
+	ListPaidBetween(ctx context.Context, payChannel string, start, end time.Time) ([]*model.Order, error)
+	ListByOrderNos(ctx context.Context, orderNos []string) ([]*model.Order, error)
+	SetInvoice(ctx context.Context, orderIDs []int64, invoiceID int64) (bool, error)
+	ClearInvoice(ctx context.Context, invoiceID int64) error
 }
 
 func NewOrderRepository(
@@ -28,8 +40,9 @@ func (r *orderRepository) Create(ctx context.Context, order *model.Order) error
 	return r.DB(ctx).Create(order).Error
 }
 
+// Update saves the order except invoice_id, which only SetInvoice and ClearInvoice move.
 func (r *orderRepository) Update(ctx context.Context, order *model.Order) error {
-	return r.DB(ctx).Save(order).Error
+	return r.DB(ctx).Omit("invoice_id").Save(order).Error
 }
 
 func (r *orderRepository) GetByID(ctx context.Context, id int64) (*model.Order, error) {
@@ -47,3 +60,128 @@ func (r *orderRepository) GetByOrderNo(ctx context.Context, orderNo string) (*mo
 	}
 	return &order, nil
 }
+
+// GetByIDForUpdate reads the order with a row lock; it must run inside a transaction.
+func (r *orderRepository) GetByIDForUpdate(ctx context.Context, id int64) (*model.Order, error) {
+	var order model.Order
+	if err := r.DB(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&order).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// MarkPaid writes the payment fields of order only while it is still in status from and
+// reports whether it did, so a payment can't be applied twice even without row locks.
+func (r *orderRepository) MarkPaid(ctx context.Context, order *model.Order, from model.OrderStatus) (bool, error) {
+	result := r.DB(ctx).Model(&model.Order{}).
+		Where("id = ? AND status = ?", order.ID, from).
+		Updates(map[string]interface{}{
+			"status":       model.OrderStatusPaid,
+			"amount_paid":  order.AmountPaid,
+			"pay_channel":  order.PayChannel,
+			"pay_trade_no": order.PayTradeNo,
+			"paid_at":      order.PaidAt,
+			"remark":       order.Remark,
+			"update_at":    order.UpdateAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Cancel moves a pending order to canceled and reports whether it did; an order
+// paid concurrently is left alone.
+func (r *orderRepository) Cancel(ctx context.Context, id int64, canceledAt time.Time, remark string) (bool, error) {
+	result := r.DB(ctx).Model(&model.Order{}).
+		Where("id = ? AND status = ?", id, model.OrderStatusPending).
+		Updates(map[string]interface{}{
+			"status":      model.OrderStatusCanceled,
+			"canceled_at": canceledAt,
+			"remark":      remark,
+			"update_at":   canceledAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+func (r *orderRepository) ListPendingBefore(ctx context.Context, before time.Time, limit int) ([]*model.Order, error) {
+	var orders []*model.Order
+	if err := r.DB(ctx).
+		Where("status = ? AND create_at < ?", model.OrderStatusPending, before).
+		Order("id ASC").
+		Limit(limit).
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// ListPaidBetween lists the orders paid through payChannel in [start, end), refunded
+// ones included.
+func (r *orderRepository) ListPaidBetween(ctx context.Context, payChannel string, start, end time.Time) ([]*model.Order, error) {
+	var orders []*model.Order
+	if err := r.DB(ctx).
+		Where("status IN ? AND pay_channel = ? AND paid_at >= ? AND paid_at < ?",
+			[]model.OrderStatus{model.OrderStatusPaid, model.OrderStatusRefunded}, payChannel, start, end).
+		Order("id ASC").
+		Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+func (r *orderRepository) ListByOrderNos(ctx context.Context, orderNos []string) ([]*model.Order, error) {
+	var orders []*model.Order
+	if err := r.DB(ctx).Where("order_no IN ?", orderNos).Order("id ASC").Find(&orders).Error; err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// SetInvoice hands the paid, not yet invoiced orders to the invoice and reports whether
+// it got every one of them; on false the caller must roll back.
+func (r *orderRepository) SetInvoice(ctx context.Context, orderIDs []int64, invoiceID int64) (bool, error) {
+	result := r.DB(ctx).Model(&model.Order{}).
+		Where("id IN ? AND status = ? AND invoice_id = 0", orderIDs, model.OrderStatusPaid).
+		Update("invoice_id", invoiceID)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected == int64(len(orderIDs)), nil
+}
+
+// ClearInvoice frees the orders held by the invoice so they can be invoiced again.
+func (r *orderRepository) ClearInvoice(ctx context.Context, invoiceID int64) error {
+	return r.DB(ctx).Model(&model.Order{}).
+		Where("invoice_id = ?", invoiceID).
+		Update("invoice_id", 0).Error
+}
+
+// ListByUser pages the user's orders, newest first; status 0 lists every status.
+func (r *orderRepository) ListByUser(ctx context.Context, userID int64, status model.OrderStatus, pageNum, pageSize int) ([]*model.Order, int64, error) {
+	var (
+		orders []*model.Order
+		total  int64
+	)
+	db := r.DB(ctx).Model(&model.Order{}).Where("user_id = ?", userID)
+	if status > 0 {
+		db = db.Where("status = ?", status)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if pageNum <= 0 {
+		pageNum = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	offset := (pageNum - 1) * pageSize
+	if err := db.Order("create_at DESC").Order("id DESC").Offset(offset).Limit(pageSize).Find(&orders).Error; err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
@@ -8,6 +8,7 @@ import (
 type OrderItemRepository interface {
 	Create(ctx context.Context, item *model.OrderItem) error
 	ListByOrderID(ctx context.Context, orderID int64) ([]*model.OrderItem, error)
+	ListByOrderIDs(ctx context.Context, orderIDs []int64) ([]*model.OrderItem, error)
 }
 
 func NewOrderItemRepository(
@@ -33,3 +34,14 @@ func (r *orderItemRepository) ListByOrderID(ctx context.Context, orderID int64)
 	}
 	return items, nil
 }
+
+func (r *orderItemRepository) ListByOrderIDs(ctx context.Context, orderIDs []int64) ([]*model.OrderItem, error) {
+	var items []*model.OrderItem
+	if len(orderIDs) == 0 {
+		return items, nil
+	}
+	if err := r.DB(ctx).Where("order_id IN ?", orderIDs).Order("id ASC").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	return items, nil
+}
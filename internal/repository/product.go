@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+)
+
+type ProductRepository interface {
+	GetByID(ctx context.Context, id int64) (*model.Product, error)
+	ListOnline(ctx context.Context, productType model.ProductType) ([]*model.Product, error)
+}
+
+func NewProductRepository(
+	repository *Repository,
+) ProductRepository {
+	return &productRepository{
+		Repository: repository,
+	}
+}
+
+type productRepository struct {
+	*Repository
+}
+
+func (r *productRepository) GetByID(ctx context.Context, id int64) (*model.Product, error) {
+	var product model.Product
+	if err := r.DB(ctx).Where("id = ?", id).First(&product).Error; err != nil {
+		return nil, err
+	}
+	return &product, nil
+}
+
+func (r *productRepository) ListOnline(ctx context.Context, productType model.ProductType) ([]*model.Product, error) {
+	var products []*model.Product
+	db := r.DB(ctx).Where("status = ?", model.ProductStatusOnline)
+	if productType > 0 {
+		db = db.Where("product_type = ?", productType)
+	}
+	if err := db.Order("product_type ASC").Order("sort ASC").Order("id ASC").Find(&products).Error; err != nil {
+		return nil, err
+	}
+	return products, nil
+}
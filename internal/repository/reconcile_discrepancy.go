@@ -0,0 +1,74 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"gorm.io/gorm"
+)
+
+type ReconcileDiscrepancyRepository interface {
+	// Save stores the discrepancy, replacing the one already recorded for the same bill
+	// date, order and type.
+	Save(ctx context.Context, discrepancy *model.ReconcileDiscrepancy) error
+	List(ctx context.Context, billDate string, status model.ReconcileDiscrepancyStatus, pageNum, pageSize int) ([]*model.ReconcileDiscrepancy, int64, error)
+}
+
+func NewReconcileDiscrepancyRepository(
+	repository *Repository,
+) ReconcileDiscrepancyRepository {
+	return &reconcileDiscrepancyRepository{
+		Repository: repository,
+	}
+}
+
+type reconcileDiscrepancyRepository struct {
+	*Repository
+}
+
+func (r *reconcileDiscrepancyRepository) Save(ctx context.Context, discrepancy *model.ReconcileDiscrepancy) error {
+	var existing model.ReconcileDiscrepancy
+	err := r.DB(ctx).
+		Where("bill_date = ? AND order_no = ? AND type = ?", discrepancy.BillDate, discrepancy.OrderNo, discrepancy.Type).
+		First(&existing).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return r.DB(ctx).Create(discrepancy).Error
+	}
+	if err != nil {
+		return err
+	}
+	discrepancy.ID = existing.ID
+	discrepancy.CreateAt = existing.CreateAt
+	return r.DB(ctx).Save(discrepancy).Error
+}
+
+// List pages the discrepancies, newest bill first; an empty billDate or zero status
+// doesn't filter.
+func (r *reconcileDiscrepancyRepository) List(ctx context.Context, billDate string, status model.ReconcileDiscrepancyStatus, pageNum, pageSize int) ([]*model.ReconcileDiscrepancy, int64, error) {
+	var (
+		discrepancies []*model.ReconcileDiscrepancy
+		total         int64
+	)
+	db := r.DB(ctx).Model(&model.ReconcileDiscrepancy{})
+	if billDate != "" {
+		db = db.Where("bill_date = ?", billDate)
+	}
+	if status > 0 {
+		db = db.Where("status = ?", status)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if pageNum <= 0 {
+		pageNum = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	offset := (pageNum - 1) * pageSize
+	if err := db.Order("bill_date DESC").Order("id ASC").Offset(offset).Limit(pageSize).Find(&discrepancies).Error; err != nil {
+		return nil, 0, err
+	}
+	return discrepancies, total, nil
+}
@@ -0,0 +1,68 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+)
+
+type RefundRepository interface {
+	Create(ctx context.Context, refund *model.Refund) error
+	GetByRefundNo(ctx context.Context, refundNo string) (*model.Refund, error)
+	ListByOrderID(ctx context.Context, orderID int64) ([]*model.Refund, error)
+	Update(ctx context.Context, refund *model.Refund) error
+	Finish(ctx context.Context, refund *model.Refund) (bool, error)
+}
+
+func NewRefundRepository(
+	repository *Repository,
+) RefundRepository {
+	return &refundRepository{
+		Repository: repository,
+	}
+}
+
+type refundRepository struct {
+	*Repository
+}
+
+func (r *refundRepository) Create(ctx context.Context, refund *model.Refund) error {
+	return r.DB(ctx).Create(refund).Error
+}
+
+func (r *refundRepository) Update(ctx context.Context, refund *model.Refund) error {
+	return r.DB(ctx).Save(refund).Error
+}
+
+func (r *refundRepository) GetByRefundNo(ctx context.Context, refundNo string) (*model.Refund, error) {
+	var refund model.Refund
+	if err := r.DB(ctx).Where("refund_no = ?", refundNo).First(&refund).Error; err != nil {
+		return nil, err
+	}
+	return &refund, nil
+}
+
+func (r *refundRepository) ListByOrderID(ctx context.Context, orderID int64) ([]*model.Refund, error) {
+	var refunds []*model.Refund
+	if err := r.DB(ctx).Where("order_id = ?", orderID).Order("id ASC").Find(&refunds).Error; err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+// Finish stores the final state of a processing refund and reports whether it did,
+// so a notify delivered twice is applied once.
+func (r *refundRepository) Finish(ctx context.Context, refund *model.Refund) (bool, error) {
+	result := r.DB(ctx).Model(&model.Refund{}).
+		Where("id = ? AND status = ?", refund.ID, model.RefundStatusProcessing).
+		Updates(map[string]interface{}{
+			"status":        refund.Status,
+			"pay_refund_no": refund.PayRefundNo,
+			"success_at":    refund.SuccessAt,
+			"update_at":     refund.UpdateAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
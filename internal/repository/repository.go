@@ -60,7 +60,12 @@ func (r *Repository) DB(ctx context.Context) *gorm.DB {
 	return r.db.WithContext(ctx)
 }
 
+// Transaction runs fn in a transaction. Called inside another Transaction it joins the
+// outer one, so services can compose without committing halfway.
 func (r *Repository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if _, ok := ctx.Value(ctxTxKey).(*gorm.DB); ok {
+		return fn(ctx)
+	}
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
 		ctx = context.WithValue(ctx, ctxTxKey, tx)
 		return fn(ctx)
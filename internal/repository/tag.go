@@ -0,0 +1,67 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+)
+
+type TagRepository interface {
+	Create(ctx context.Context, tag *model.Tag) error
+	ListOnline(ctx context.Context, category model.TagCategory) ([]*model.Tag, error)
+	ListByIDs(ctx context.Context, ids []int64) ([]*model.Tag, error)
+	// FindByValues returns the category's tags whose code or label is one of values.
+	FindByValues(ctx context.Context, category model.TagCategory, values []string) ([]*model.Tag, error)
+}
+
+func NewTagRepository(
+	repository *Repository,
+) TagRepository {
+	return &tagRepository{
+		Repository: repository,
+	}
+}
+
+type tagRepository struct {
+	*Repository
+}
+
+func (r *tagRepository) Create(ctx context.Context, tag *model.Tag) error {
+	return r.DB(ctx).Create(tag).Error
+}
+
+func (r *tagRepository) ListOnline(ctx context.Context, category model.TagCategory) ([]*model.Tag, error) {
+	var tags []*model.Tag
+	db := r.DB(ctx).Where("status = ?", model.TagStatusOnline)
+	if category > 0 {
+		db = db.Where("category = ?", category)
+	}
+	if err := db.Order("category ASC").Order("sort ASC").Order("id ASC").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (r *tagRepository) ListByIDs(ctx context.Context, ids []int64) ([]*model.Tag, error) {
+	if len(ids) == 0 {
+		return []*model.Tag{}, nil
+	}
+	var tags []*model.Tag
+	if err := r.DB(ctx).Where("id IN ?", ids).Order("category ASC").Order("sort ASC").Order("id ASC").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+func (r *tagRepository) FindByValues(ctx context.Context, category model.TagCategory, values []string) ([]*model.Tag, error) {
+	if len(values) == 0 {
+		return []*model.Tag{}, nil
+	}
+	var tags []*model.Tag
+	if err := r.DB(ctx).Where("category = ?", category).
+		Where("code IN ? OR label IN ?", values, values).
+		Find(&tags).Error; err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
@@ -3,18 +3,28 @@ package repository
 import (
 	"context"
 	"errors"
+	"time"
+
 	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
 	"github.com/go-nunu/nunu-layout-advanced/internal/model"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type UserRepository interface {
 	Create(ctx context.Context, user *model.User) error
 	Update(ctx context.Context, user *model.User) error
 	GetByID(ctx context.Context, id int64) (*model.User, error)
+	GetByIDForUpdate(ctx context.Context, id int64) (*model.User, error)
 	GetByPhone(ctx context.Context, phone string) (*model.User, error)
 	GetByOpenID(ctx context.Context, openID string) (*model.User, error)
 	ListByIDs(ctx context.Context, ids []int64) ([]*model.User, error)
+	IncrVoucher(ctx context.Context, userID int64, delta int) (int, error)
+	IncrIntegral(ctx context.Context, userID int64, delta int64) (int64, error)
+	IncrInviteNum(ctx context.Context, userID int64) error
+	GetByInviteCode(ctx context.Context, code string) (*model.User, error)
+	SetInviteCode(ctx context.Context, userID int64, code string) (bool, error)
+	SetFirstRecharge(ctx context.Context, userID int64, value string) (bool, error)
 }
 
 func NewUserRepository(
@@ -36,8 +46,10 @@ func (r *userRepository) Create(ctx context.Context, user *model.User) error {
 	return nil
 }
 
+// Update saves the profile. Counters, the invite code and the first recharge only move through their own
+// methods so a stale copy of the user can't overwrite them.
 func (r *userRepository) Update(ctx context.Context, user *model.User) error {
-	if err := r.DB(ctx).Save(user).Error; err != nil {
+	if err := r.DB(ctx).Omit("contact_voucher_num", "integral", "invite_num", "invite_code", "first_recharge").Save(user).Error; err != nil {
 		return err
 	}
 	return nil
@@ -54,6 +66,18 @@ func (r *userRepository) GetByID(ctx context.Context, userId int64) (*model.User
 	return &user, nil
 }
 
+// GetByIDForUpdate reads the user with a row lock; it must run inside a transaction.
+func (r *userRepository) GetByIDForUpdate(ctx context.Context, id int64) (*model.User, error) {
+	var user model.User
+	if err := r.DB(ctx).Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, v1.ErrNotFound
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *userRepository) GetByPhone(ctx context.Context, phone string) (*model.User, error) {
 	var user model.User
 	if err := r.DB(ctx).Where("phone = ?", phone).First(&user).Error; err != nil {
@@ -86,3 +110,103 @@ func (r *userRepository) ListByIDs(ctx context.Context, ids []int64) ([]*model.U
 	}
 	return users, nil
 }
+
+// IncrVoucher adds delta to the voucher balance in one conditional update and returns the
+// new balance. A balance that would go negative is left untouched and reported with
+// v1.ErrInsufficientVoucher.
+func (r *userRepository) IncrVoucher(ctx context.Context, userID int64, delta int) (int, error) {
+	var next int
+	err := r.Transaction(ctx, func(ctx context.Context) error {
+		result := r.DB(ctx).Model(&model.User{}).
+			Where("id = ? AND contact_voucher_num + ? >= 0", userID, delta).
+			Updates(map[string]interface{}{
+				"contact_voucher_num": gorm.Expr("contact_voucher_num + ?", delta),
+				"update_at":           time.Now(),
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		// The update holds the row lock until commit, so this read sees our own write.
+		user, err := r.GetByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		next = user.ContactVoucherNum
+		if result.RowsAffected == 0 {
+			return v1.ErrInsufficientVoucher
+		}
+		return nil
+	})
+	return next, err
+}
+
+// IncrIntegral is IncrVoucher for points; an overdraft returns v1.ErrInsufficientIntegral.
+func (r *userRepository) IncrIntegral(ctx context.Context, userID int64, delta int64) (int64, error) {
+	var next int64
+	err := r.Transaction(ctx, func(ctx context.Context) error {
+		// The column is unsigned, so compare instead of letting integral + delta go negative.
+		db := r.DB(ctx).Model(&model.User{}).Where("id = ?", userID)
+		if delta < 0 {
+			db = db.Where("integral >= ?", -delta)
+		}
+		result := db.Updates(map[string]interface{}{
+			"integral":  gorm.Expr("integral + ?", delta),
+			"update_at": time.Now(),
+		})
+		if result.Error != nil {
+			return result.Error
+		}
+		user, err := r.GetByID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		next = int64(user.Integral)
+		if result.RowsAffected == 0 {
+			return v1.ErrInsufficientIntegral
+		}
+		return nil
+	})
+	return next, err
+}
+
+func (r *userRepository) IncrInviteNum(ctx context.Context, userID int64) error {
+	return r.DB(ctx).Model(&model.User{}).
+		Where("id = ?", userID).
+		Updates(map[string]interface{}{
+			"invite_num": gorm.Expr("invite_num + 1"),
+			"update_at":  time.Now(),
+		}).Error
+}
+
+func (r *userRepository) GetByInviteCode(ctx context.Context, code string) (*model.User, error) {
+	var user model.User
+	if err := r.DB(ctx).Where("invite_code = ?", code).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+// SetInviteCode gives the user a code unless they already have one.
+func (r *userRepository) SetInviteCode(ctx context.Context, userID int64, code string) (bool, error) {
+	result := r.DB(ctx).Model(&model.User{}).
+		Where("id = ? AND (invite_code IS NULL OR invite_code = '')", userID).
+		Update("invite_code", code)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// SetFirstRecharge records the user's first paid order unless one is already recorded.
+func (r *userRepository) SetFirstRecharge(ctx context.Context, userID int64, value string) (bool, error) {
+	result := r.DB(ctx).Model(&model.User{}).
+		Where("id = ? AND (first_recharge IS NULL OR first_recharge = '')", userID).
+		Update("first_recharge", value)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,127 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+)
+
+type UserCouponRepository interface {
+	Create(ctx context.Context, coupon *model.UserCoupon) error
+	GetByID(ctx context.Context, id int64) (*model.UserCoupon, error)
+	ExistsByTemplate(ctx context.Context, userID, templateID int64) (bool, error)
+	ListByUser(ctx context.Context, userID int64, status model.UserCouponStatus, pageNum, pageSize int) ([]*model.UserCoupon, int64, error)
+	Lock(ctx context.Context, id, orderID int64, now time.Time) (bool, error)
+	Release(ctx context.Context, id, orderID int64) (bool, error)
+	MarkUsed(ctx context.Context, id, orderID int64, usedAt time.Time) (bool, error)
+}
+
+func NewUserCouponRepository(
+	repository *Repository,
+) UserCouponRepository {
+	return &userCouponRepository{
+		Repository: repository,
+	}
+}
+
+type userCouponRepository struct {
+	*Repository
+}
+
+func (r *userCouponRepository) Create(ctx context.Context, coupon *model.UserCoupon) error {
+	return r.DB(ctx).Create(coupon).Error
+}
+
+func (r *userCouponRepository) GetByID(ctx context.Context, id int64) (*model.UserCoupon, error) {
+	var coupon model.UserCoupon
+	if err := r.DB(ctx).Where("id = ?", id).First(&coupon).Error; err != nil {
+		return nil, err
+	}
+	return &coupon, nil
+}
+
+func (r *userCouponRepository) ExistsByTemplate(ctx context.Context, userID, templateID int64) (bool, error) {
+	var count int64
+	if err := r.DB(ctx).Model(&model.UserCoupon{}).
+		Where("user_id = ? AND template_id = ?", userID, templateID).
+		Count(&count).Error; err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// ListByUser pages the user's coupons, those expiring first on top; status 0 lists every status.
+func (r *userCouponRepository) ListByUser(ctx context.Context, userID int64, status model.UserCouponStatus, pageNum, pageSize int) ([]*model.UserCoupon, int64, error) {
+	var (
+		coupons []*model.UserCoupon
+		total   int64
+	)
+	db := r.DB(ctx).Model(&model.UserCoupon{}).Where("user_id = ?", userID)
+	if status > 0 {
+		db = db.Where("status = ?", status)
+	}
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+	if pageNum <= 0 {
+		pageNum = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	offset := (pageNum - 1) * pageSize
+	if err := db.Order("end_at ASC").Order("id ASC").Offset(offset).Limit(pageSize).Find(&coupons).Error; err != nil {
+		return nil, 0, err
+	}
+	return coupons, total, nil
+}
+
+// Lock reserves an unused, unexpired coupon for the order and reports whether it did.
+func (r *userCouponRepository) Lock(ctx context.Context, id, orderID int64, now time.Time) (bool, error) {
+	result := r.DB(ctx).Model(&model.UserCoupon{}).
+		Where("id = ? AND status = ? AND start_at <= ? AND end_at > ?", id, model.UserCouponStatusUnused, now, now).
+		Updates(map[string]interface{}{
+			"status":    model.UserCouponStatusLocked,
+			"order_id":  orderID,
+			"update_at": now,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Release hands a coupon locked by the order back to its owner.
+func (r *userCouponRepository) Release(ctx context.Context, id, orderID int64) (bool, error) {
+	result := r.DB(ctx).Model(&model.UserCoupon{}).
+		Where("id = ? AND status = ? AND order_id = ?", id, model.UserCouponStatusLocked, orderID).
+		Updates(map[string]interface{}{
+			"status":    model.UserCouponStatusUnused,
+			"order_id":  0,
+			"update_at": time.Now(),
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// MarkUsed spends the coupon on the order. Besides the normal case of a coupon the order
+// locked, it takes a coupon released by a cancel the payment later revived, as long as no
+// other order has locked it since.
+func (r *userCouponRepository) MarkUsed(ctx context.Context, id, orderID int64, usedAt time.Time) (bool, error) {
+	result := r.DB(ctx).Model(&model.UserCoupon{}).
+		Where("id = ? AND ((status = ? AND order_id = ?) OR status = ?)",
+			id, model.UserCouponStatusLocked, orderID, model.UserCouponStatusUnused).
+		Updates(map[string]interface{}{
+			"status":    model.UserCouponStatusUsed,
+			"order_id":  orderID,
+			"used_at":   usedAt,
+			"update_at": usedAt,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,25 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-nunu/nunu-layout-advanced/internal/middleware"
+)
+
+func InitAdminRouter(deps RouterDeps, r *gin.RouterGroup) {
+	adminRouter := r.Group("/admin").Use(
+		middleware.StrictAuth(deps.JWT, deps.Logger),
+		middleware.AdminAuth(deps.UserService, deps.Logger),
+	)
+	{
+		adminRouter.POST("/refunds/create", deps.RefundHandler.Create)
+		adminRouter.POST("/orders/info", deps.OrderHandler.AdminInfo)
+		adminRouter.POST("/coupons/templates/create", deps.CouponHandler.AdminCreateTemplate)
+		adminRouter.POST("/coupons/issue", deps.CouponHandler.AdminIssue)
+		adminRouter.POST("/reconcile/list", deps.ReconcileHandler.AdminList)
+		adminRouter.POST("/reconcile/run", deps.ReconcileHandler.AdminRun)
+		adminRouter.POST("/invoices/list", deps.InvoiceHandler.AdminList)
+		adminRouter.POST("/invoices/upload", deps.InvoiceHandler.AdminUpload)
+		adminRouter.POST("/invoices/issue", deps.InvoiceHandler.AdminIssue)
+		adminRouter.POST("/invoices/reject", deps.InvoiceHandler.AdminReject)
+	}
+}
@@ -0,0 +1,13 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+func InitAreaRouter(deps RouterDeps, r *gin.RouterGroup) {
+	noAuthRouter := r.Group("/")
+	{
+		noAuthRouter.POST("/areas/children", deps.AreaHandler.Children)
+		noAuthRouter.POST("/areas/by_location", deps.AreaHandler.ByLocation)
+	}
+}
@@ -0,0 +1,15 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-nunu/nunu-layout-advanced/internal/middleware"
+)
+
+func InitCouponRouter(deps RouterDeps, r *gin.RouterGroup) {
+	strictAuthRouter := r.Group("/").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.POST("/coupons/my", deps.CouponHandler.My)
+		strictAuthRouter.POST("/coupons/claimable", deps.CouponHandler.Claimable)
+		strictAuthRouter.POST("/coupons/claim", deps.CouponHandler.Claim)
+	}
+}
@@ -0,0 +1,15 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-nunu/nunu-layout-advanced/internal/middleware"
+)
+
+func InitIntegralRouter(deps RouterDeps, r *gin.RouterGroup) {
+	strictAuthRouter := r.Group("/").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.POST("/integral/checkin", deps.IntegralHandler.CheckIn)
+		strictAuthRouter.POST("/integral/records", deps.IntegralHandler.Records)
+		strictAuthRouter.POST("/integral/redeem", deps.IntegralHandler.Redeem)
+	}
+}
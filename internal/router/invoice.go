@@ -0,0 +1,14 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-nunu/nunu-layout-advanced/internal/middleware"
+)
+
+func InitInvoiceRouter(deps RouterDeps, r *gin.RouterGroup) {
+	strictAuthRouter := r.Group("/").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.POST("/invoices/request", deps.InvoiceHandler.Request)
+		strictAuthRouter.POST("/invoices/my", deps.InvoiceHandler.My)
+	}
+}
@@ -6,7 +6,8 @@ import (
 )
 
 func InitJobRouter(deps RouterDeps, r *gin.RouterGroup) {
-	noAuthRouter := r.Group("/")
+	// Optional token: signed-in callers see the phones they have unlocked.
+	noAuthRouter := r.Group("/").Use(middleware.NoStrictAuth(deps.JWT, deps.Logger))
 	{
 		noAuthRouter.POST("/jobs/list", deps.JobHandler.List)
 		noAuthRouter.POST("/jobs/info", deps.JobHandler.Info)
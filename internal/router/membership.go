@@ -0,0 +1,14 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-nunu/nunu-layout-advanced/internal/middleware"
+)
+
+func InitMembershipRouter(deps RouterDeps, r *gin.RouterGroup) {
+	strictAuthRouter := r.Group("/").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.GET("/membership/info", deps.MembershipHandler.Info)
+		strictAuthRouter.POST("/membership/buy", deps.MembershipHandler.Buy)
+	}
+}
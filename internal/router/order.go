@@ -0,0 +1,17 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/go-nunu/nunu-layout-advanced/internal/middleware"
+)
+
+func InitOrderRouter(deps RouterDeps, r *gin.RouterGroup) {
+	strictAuthRouter := r.Group("/").Use(middleware.StrictAuth(deps.JWT, deps.Logger))
+	{
+		strictAuthRouter.POST("/orders/create", deps.OrderHandler.Create)
+		strictAuthRouter.POST("/orders/confirm", deps.OrderHandler.Confirm)
+		strictAuthRouter.POST("/orders/cancel", deps.OrderHandler.Cancel)
+		strictAuthRouter.POST("/orders/my", deps.OrderHandler.My)
+		strictAuthRouter.POST("/orders/info", deps.OrderHandler.Info)
+	}
+}
@@ -0,0 +1,12 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+func InitProductRouter(deps RouterDeps, r *gin.RouterGroup) {
+	noAuthRouter := r.Group("/")
+	{
+		noAuthRouter.POST("/products/list", deps.ProductHandler.List)
+	}
+}
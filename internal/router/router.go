@@ -2,6 +2,7 @@ package router
 
 import (
 	"github.com/go-nunu/nunu-layout-advanced/internal/handler"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
 	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
 	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
 	"github.com/spf13/viper"
@@ -18,4 +19,15 @@ type RouterDeps struct {
 	ContactVoucherHistoryHandler *handler.ContactVoucherHistoryHandler
 	WechatHandler                *handler.WechatHandler
 	UploadHandler                *handler.UploadHandler
+	ProductHandler               *handler.ProductHandler
+	OrderHandler                 *handler.OrderHandler
+	RefundHandler                *handler.RefundHandler
+	IntegralHandler              *handler.IntegralHandler
+	MembershipHandler            *handler.MembershipHandler
+	CouponHandler                *handler.CouponHandler
+	ReconcileHandler             *handler.ReconcileHandler
+	InvoiceHandler               *handler.InvoiceHandler
+	TagHandler                   *handler.TagHandler
+	AreaHandler                  *handler.AreaHandler
+	UserService                  service.UserService
 }
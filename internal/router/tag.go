@@ -0,0 +1,12 @@
+package router
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+func InitTagRouter(deps RouterDeps, r *gin.RouterGroup) {
+	noAuthRouter := r.Group("/")
+	{
+		noAuthRouter.POST("/tags/list", deps.TagHandler.List)
+	}
+}
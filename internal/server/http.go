@@ -52,8 +52,17 @@ func NewHTTPServer(
 	router.InitCollectRouter(deps, root)
 	router.InitContactHistoryRouter(deps, root)
 	router.InitVoucherRouter(deps, root)
+	router.InitIntegralRouter(deps, root)
+	router.InitMembershipRouter(deps, root)
+	router.InitCouponRouter(deps, root)
+	router.InitInvoiceRouter(deps, root)
 	router.InitWechatRouter(deps, root)
 	router.InitUploadRouter(deps, root)
+	router.InitProductRouter(deps, root)
+	router.InitTagRouter(deps, root)
+	router.InitAreaRouter(deps, root)
+	router.InitOrderRouter(deps, root)
+	router.InitAdminRouter(deps, root)
 
 	s.Static("/uploads", "./storage/uploads")
 
@@ -2,11 +2,15 @@ package server
 
 import (
 	"context"
+	"crypto/sha1"
+	"encoding/hex"
 	"github.com/go-nunu/nunu-layout-advanced/internal/model"
 	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
 	"go.uber.org/zap"
 	"gorm.io/gorm"
 	"os"
+	"strings"
+	"time"
 )
 
 type MigrateServer struct {
@@ -23,14 +27,257 @@ func NewMigrateServer(db *gorm.DB, log *log.Logger) *MigrateServer {
 func (m *MigrateServer) Start(ctx context.Context) error {
 	if err := m.db.AutoMigrate(
 		&model.User{},
+		&model.Product{},
+		&model.Refund{},
+		&model.IdempotencyKey{},
+		&model.NotifyNonce{},
+		&model.ContactUnlock{},
+		&model.ContactVoucherBatch{},
+		&model.Invite{},
+		&model.IntegralHistory{},
+		&model.Membership{},
+		&model.JobRefresh{},
+		&model.CouponTemplate{},
+		&model.UserCoupon{},
+		&model.ReconcileDiscrepancy{},
+		&model.Invoice{},
+		&model.InvoiceOrder{},
+		&model.Tag{},
+		&model.JobTag{},
+		&model.Notification{},
 	); err != nil {
 		m.log.Error("user migrate error", zap.Error(err))
 		return err
 	}
+	// orders and order_item are managed by hand; only add the columns newer code relies on.
+	if err := m.addMissingColumns(&model.OrderItem{}, "MembershipDays"); err != nil {
+		m.log.Error("order_item migrate error", zap.Error(err))
+		return err
+	}
+	if err := m.addMissingColumns(&model.Order{}, "CouponID", "DiscountAmount", "InvoiceID"); err != nil {
+		m.log.Error("orders migrate error", zap.Error(err))
+		return err
+	}
+	// ClearInvoice looks orders up by invoice_id.
+	if !m.db.Migrator().HasIndex(&model.Order{}, "InvoiceID") {
+		if err := m.db.Migrator().CreateIndex(&model.Order{}, "InvoiceID"); err != nil {
+			m.log.Error("orders migrate error", zap.Error(err))
+			return err
+		}
+	}
+	// job is managed by hand too; the recommended list orders by is_top, nearby search
+	// pre-filters on a latitude/longitude box and the list filters on an area of any level.
+	if err := m.addMissingColumns(&model.Job{}, "IsTop"); err != nil {
+		m.log.Error("job migrate error", zap.Error(err))
+		return err
+	}
+	for _, index := range []string{"idx_status_top_refresh", "idx_job_lat_lng", "idx_job_first_area", "idx_job_second_area", "idx_job_third_area", "idx_job_four_area"} {
+		if m.db.Migrator().HasIndex(&model.Job{}, index) {
+			continue
+		}
+		if err := m.db.Migrator().CreateIndex(&model.Job{}, index); err != nil {
+			m.log.Error("job migrate error", zap.String("index", index), zap.Error(err))
+			return err
+		}
+	}
+	if err := m.backfillJobIsTop(); err != nil {
+		m.log.Error("job is_top backfill error", zap.Error(err))
+		return err
+	}
+	if err := m.backfillVoucherBatches(); err != nil {
+		m.log.Error("voucher batch backfill error", zap.Error(err))
+		return err
+	}
+	if err := m.seedTags(); err != nil {
+		m.log.Error("tag seed error", zap.Error(err))
+		return err
+	}
+	if err := m.backfillJobTags(); err != nil {
+		m.log.Error("job tag backfill error", zap.Error(err))
+		return err
+	}
 	m.log.Info("AutoMigrate success")
 	os.Exit(0)
 	return nil
 }
+
+func (m *MigrateServer) addMissingColumns(value interface{}, fields ...string) error {
+	for _, field := range fields {
+		if m.db.Migrator().HasColumn(value, field) {
+			continue
+		}
+		if err := m.db.Migrator().AddColumn(value, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillJobIsTop flags the placements that were running before is_top was kept up to
+// date; the task clears them as they end.
+func (m *MigrateServer) backfillJobIsTop() error {
+	now := time.Now()
+	return m.db.Model(&model.Job{}).
+		Where("is_top = ? AND top_start_time <= ? AND top_end_time > ?", false, now, now).
+		Update("is_top", true).Error
+}
+
+// backfillVoucherBatches turns balances from before the batch ledger into one batch per user
+// that never expires, so spending has a batch to take them from.
+func (m *MigrateServer) backfillVoucherBatches() error {
+	var users []*model.User
+	return m.db.Where("contact_voucher_num > 0").FindInBatches(&users, 200, func(tx *gorm.DB, batch int) error {
+		for _, user := range users {
+			var count int64
+			if err := m.db.Model(&model.ContactVoucherBatch{}).Where("user_id = ?", user.ID).Count(&count).Error; err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+			now := time.Now()
+			if err := m.db.Create(&model.ContactVoucherBatch{
+				UserID:    user.ID,
+				BizType:   model.ContactVoucherHistoryBuy,
+				TotalNum:  user.ContactVoucherNum,
+				RemainNum: user.ContactVoucherNum,
+				CreateAt:  now,
+				UpdateAt:  now,
+			}).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	}).Error
+}
+
+// defaultTags is the tag dictionary a fresh database starts with.
+var defaultTags = []model.Tag{
+	{Category: model.TagCategoryBasicProtection, Code: "five_insurances_fund", Label: "五险一金"},
+	{Category: model.TagCategoryBasicProtection, Code: "five_insurances", Label: "五险"},
+	{Category: model.TagCategoryBasicProtection, Code: "social_insurance", Label: "社保"},
+	{Category: model.TagCategoryBasicProtection, Code: "housing_fund", Label: "公积金"},
+	{Category: model.TagCategoryBasicProtection, Code: "meals", Label: "包吃"},
+	{Category: model.TagCategoryBasicProtection, Code: "housing", Label: "包住"},
+	{Category: model.TagCategorySalaryBenefits, Code: "meal_allowance", Label: "餐补"},
+	{Category: model.TagCategorySalaryBenefits, Code: "housing_allowance", Label: "住房补贴"},
+	{Category: model.TagCategorySalaryBenefits, Code: "holiday_benefits", Label: "节日福利"},
+	{Category: model.TagCategorySalaryBenefits, Code: "full_attendance_bonus", Label: "全勤奖"},
+	{Category: model.TagCategorySalaryBenefits, Code: "year_end_bonus", Label: "年终奖"},
+	{Category: model.TagCategorySalaryBenefits, Code: "commission", Label: "提成"},
+	{Category: model.TagCategoryAttendanceLeave, Code: "two_days_off", Label: "双休"},
+	{Category: model.TagCategoryAttendanceLeave, Code: "one_day_off", Label: "单休"},
+	{Category: model.TagCategoryAttendanceLeave, Code: "four_days_off_monthly", Label: "月休4天"},
+	{Category: model.TagCategoryAttendanceLeave, Code: "rotating_shifts", Label: "排班轮休"},
+	{Category: model.TagCategoryAttendanceLeave, Code: "paid_annual_leave", Label: "带薪年假"},
+	{Category: model.TagCategoryAttendanceLeave, Code: "statutory_holidays", Label: "法定节假日休息"},
+}
+
+// seedTags adds the default tags that are missing; tags edited since are left alone.
+func (m *MigrateServer) seedTags() error {
+	for i, tag := range defaultTags {
+		var count int64
+		if err := m.db.Model(&model.Tag{}).Where("category = ? AND code = ?", tag.Category, tag.Code).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			continue
+		}
+		now := time.Now()
+		tag.Sort = i + 1
+		tag.Status = model.TagStatusOnline
+		tag.CreateAt = now
+		tag.UpdateAt = now
+		if err := m.db.Create(&tag).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// backfillJobTags turns the comma-separated benefit columns of jobs from before job_tag into
+// tags. A value that matches no tag gets an offline one, so the job keeps it without it
+// being offered to new jobs. Jobs that already have tags are skipped.
+func (m *MigrateServer) backfillJobTags() error {
+	tags := make(map[model.TagCategory]map[string]*model.Tag)
+	findTag := func(category model.TagCategory, value string) (*model.Tag, error) {
+		if tags[category] == nil {
+			tags[category] = make(map[string]*model.Tag)
+		}
+		if tag, ok := tags[category][value]; ok {
+			return tag, nil
+		}
+		var found []*model.Tag
+		if err := m.db.Where("category = ? AND (code = ? OR label = ?)", category, value, value).
+			Order("id ASC").Find(&found).Error; err != nil {
+			return nil, err
+		}
+		var tag *model.Tag
+		if len(found) > 0 {
+			tag = found[0]
+		} else {
+			sum := sha1.Sum([]byte(value))
+			now := time.Now()
+			tag = &model.Tag{
+				Category: category,
+				Code:     "legacy_" + hex.EncodeToString(sum[:4]),
+				Label:    value,
+				Status:   model.TagStatusOffline,
+				CreateAt: now,
+				UpdateAt: now,
+			}
+			if err := m.db.Create(tag).Error; err != nil {
+				return nil, err
+			}
+		}
+		tags[category][value] = tag
+		return tag, nil
+	}
+
+	var jobs []*model.Job
+	return m.db.Where("basic_protection <> '' OR salary_benefits <> '' OR attendance_leave <> ''").
+		FindInBatches(&jobs, 200, func(tx *gorm.DB, batch int) error {
+			for _, job := range jobs {
+				var count int64
+				if err := m.db.Model(&model.JobTag{}).Where("job_id = ?", job.ID).Count(&count).Error; err != nil {
+					return err
+				}
+				if count > 0 {
+					continue
+				}
+				seen := make(map[int64]bool)
+				var rows []*model.JobTag
+				for category, csv := range map[model.TagCategory]string{
+					model.TagCategoryBasicProtection: job.BasicProtection,
+					model.TagCategorySalaryBenefits:  job.SalaryBenefits,
+					model.TagCategoryAttendanceLeave: job.AttendanceLeave,
+				} {
+					for _, value := range strings.FieldsFunc(csv, func(r rune) bool { return r == ',' || r == '，' }) {
+						if value = strings.TrimSpace(value); value == "" {
+							continue
+						}
+						tag, err := findTag(category, value)
+						if err != nil {
+							return err
+						}
+						if seen[tag.ID] {
+							continue
+						}
+						seen[tag.ID] = true
+						rows = append(rows, &model.JobTag{JobID: job.ID, TagID: tag.ID, Category: category, CreateAt: time.Now()})
+					}
+				}
+				if len(rows) == 0 {
+					continue
+				}
+				if err := m.db.Create(&rows).Error; err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Error
+}
+
 func (m *MigrateServer) Stop(ctx context.Context) error {
 	m.log.Info("AutoMigrate stop")
 	return nil
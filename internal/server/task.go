@@ -10,18 +10,30 @@ import (
 )
 
 type TaskServer struct {
-	log       *log.Logger
-	scheduler *gocron.Scheduler
-	userTask  task.UserTask
+	log            *log.Logger
+	scheduler      *gocron.Scheduler
+	orderTask      task.OrderTask
+	voucherTask    task.VoucherTask
+	membershipTask task.MembershipTask
+	reconcileTask  task.ReconcileTask
+	payTask        task.PayTask
 }
 
 func NewTaskServer(
 	log *log.Logger,
-	userTask task.UserTask,
+	orderTask task.OrderTask,
+	voucherTask task.VoucherTask,
+	membershipTask task.MembershipTask,
+	reconcileTask task.ReconcileTask,
+	payTask task.PayTask,
 ) *TaskServer {
 	return &TaskServer{
-		log:      log,
-		userTask: userTask,
+		log:            log,
+		orderTask:      orderTask,
+		voucherTask:    voucherTask,
+		membershipTask: membershipTask,
+		reconcileTask:  reconcileTask,
+		payTask:        payTask,
 	}
 }
 func (t *TaskServer) Start(ctx context.Context) error {
@@ -35,14 +47,75 @@ func (t *TaskServer) Start(ctx context.Context) error {
 	// t.scheduler = gocron.NewScheduler(time.FixedZone("PRC", 8*60*60))
 
 	//_, err := t.scheduler.Every("3s").Do(func()
-	_, err := t.scheduler.CronWithSeconds("0/3 * * * * *").Do(func() {
-		err := t.userTask.CheckUser(ctx)
+	_, err := t.scheduler.CronWithSeconds("0 * * * * *").SingletonMode().Do(func() {
+		err := t.orderTask.ExpirePendingOrders(ctx)
 		if err != nil {
-			t.log.Error("CheckUser error", zap.Error(err))
+			t.log.Error("ExpirePendingOrders error", zap.Error(err))
 		}
 	})
 	if err != nil {
-		t.log.Error("CheckUser error", zap.Error(err))
+		t.log.Error("ExpirePendingOrders error", zap.Error(err))
+	}
+
+	_, err = t.scheduler.CronWithSeconds("30 * * * * *").SingletonMode().Do(func() {
+		err := t.orderTask.ExpireTops(ctx)
+		if err != nil {
+			t.log.Error("ExpireTops error", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("ExpireTops error", zap.Error(err))
+	}
+
+	_, err = t.scheduler.CronWithSeconds("0 0 * * * *").SingletonMode().Do(func() {
+		err := t.orderTask.PurgeIdempotencyKeys(ctx)
+		if err != nil {
+			t.log.Error("PurgeIdempotencyKeys error", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("PurgeIdempotencyKeys error", zap.Error(err))
+	}
+
+	_, err = t.scheduler.CronWithSeconds("0 15 * * * *").SingletonMode().Do(func() {
+		err := t.payTask.PurgeNotifyNonces(ctx)
+		if err != nil {
+			t.log.Error("PurgeNotifyNonces error", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("PurgeNotifyNonces error", zap.Error(err))
+	}
+
+	_, err = t.scheduler.CronWithSeconds("0 */10 * * * *").SingletonMode().Do(func() {
+		err := t.voucherTask.ExpireVouchers(ctx)
+		if err != nil {
+			t.log.Error("ExpireVouchers error", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("ExpireVouchers error", zap.Error(err))
+	}
+
+	_, err = t.scheduler.CronWithSeconds("0 5,35 * * * *").SingletonMode().Do(func() {
+		err := t.membershipTask.GrantAllowances(ctx)
+		if err != nil {
+			t.log.Error("GrantAllowances error", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("GrantAllowances error", zap.Error(err))
+	}
+
+	// 03:30 UTC is 11:30 in Beijing, after WeChat Pay publishes the previous day's bill.
+	_, err = t.scheduler.CronWithSeconds("0 30 3 * * *").SingletonMode().Do(func() {
+		err := t.reconcileTask.ReconcileYesterday(ctx)
+		if err != nil {
+			t.log.Error("ReconcileYesterday error", zap.Error(err))
+		}
+	})
+	if err != nil {
+		t.log.Error("ReconcileYesterday error", zap.Error(err))
 	}
 
 	t.scheduler.StartBlocking()
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"context"
+	"os"
+	"strings"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/area"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+type AreaService interface {
+	// Children lists the areas under parentID, or the provinces for 0.
+	Children(ctx context.Context, parentID int) ([]*area.Area, error)
+	// ByLocation returns the areas around a point, province first; nil when none is near.
+	ByLocation(ctx context.Context, latitude, longitude float64) ([]*area.Area, error)
+	// NormalizeJob checks the job's four area levels against the dictionary, fills in an ID
+	// or description the client left out, and returns ErrInvalidArea on a mismatch. A level
+	// the dictionary doesn't list, such as a street, needs both its ID and its description.
+	NormalizeJob(ctx context.Context, job *model.Job) error
+}
+
+// NewAreaService uses the dictionary built into the binary, or the file at area.data_file
+// when one is configured and loads.
+func NewAreaService(service *Service, conf *viper.Viper) AreaService {
+	dict := area.Default()
+	if path := conf.GetString("area.data_file"); path != "" {
+		if loaded, err := loadAreaFile(path); err != nil {
+			service.logger.Error("area data file error, using the embedded one", zap.String("path", path), zap.Error(err))
+		} else {
+			dict = loaded
+		}
+	}
+	return &areaService{
+		Service: service,
+		dict:    dict,
+	}
+}
+
+func loadAreaFile(path string) (*area.Dictionary, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return area.Load(f)
+}
+
+type areaService struct {
+	*Service
+	dict *area.Dictionary
+}
+
+func (s *areaService) Children(ctx context.Context, parentID int) ([]*area.Area, error) {
+	if parentID != 0 {
+		if _, ok := s.dict.Get(parentID); !ok {
+			return nil, ErrInvalidArea
+		}
+	}
+	return s.dict.Children(parentID), nil
+}
+
+func (s *areaService) ByLocation(ctx context.Context, latitude, longitude float64) ([]*area.Area, error) {
+	return s.dict.Locate(latitude, longitude), nil
+}
+
+func (s *areaService) NormalizeJob(ctx context.Context, job *model.Job) error {
+	ids := []*int{&job.FirstAreaID, &job.SecondAreaID, &job.ThirdAreaID, &job.FourAreaID}
+	names := []*string{&job.FirstAreaDes, &job.SecondAreaDes, &job.ThirdAreaDes, &job.FourAreaDes}
+	parentID, ended := 0, false
+	for i := range ids {
+		id, name := *ids[i], strings.TrimSpace(*names[i])
+		if id == 0 && name == "" {
+			ended = true
+			continue
+		}
+		// Levels run top down without gaps.
+		if ended {
+			return ErrInvalidArea
+		}
+		level := i + 1
+		if id != 0 && (area.Level(id) != level || (parentID != 0 && area.ParentID(id) != parentID)) {
+			return ErrInvalidArea
+		}
+		// Where the dictionary lists this level, the area has to be one of the listed ones.
+		if level == area.LevelProvince || s.dict.Covers(parentID) {
+			var (
+				a  *area.Area
+				ok bool
+			)
+			if id != 0 {
+				a, ok = s.dict.Get(id)
+			} else {
+				a, ok = s.dict.ChildByName(parentID, name)
+			}
+			if !ok || (name != "" && name != a.Name) {
+				return ErrInvalidArea
+			}
+			id, name = a.ID, a.Name
+		} else if id == 0 || name == "" {
+			// Nothing to look the missing half up in.
+			return ErrInvalidArea
+		}
+		*ids[i], *names[i] = id, name
+		parentID = id
+	}
+	return nil
+}
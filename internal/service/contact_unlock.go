@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+)
+
+// ContactUnlockResult is the phone behind a purpose. Charged is set only on the call that
+// spent the voucher.
+type ContactUnlockResult struct {
+	Phone             string
+	Charged           bool
+	ContactVoucherNum int
+}
+
+type ContactUnlockService interface {
+	Unlock(ctx context.Context, userID int64, purposeType int, purposeID int64) (*ContactUnlockResult, error)
+	UnlockedJobIDs(ctx context.Context, userID int64, jobIDs []int64) (map[int64]bool, error)
+}
+
+func NewContactUnlockService(
+	service *Service,
+	contactUnlockRepository repository.ContactUnlockRepository,
+	jobRepository repository.JobRepository,
+	contactVoucherHistoryService ContactVoucherHistoryService,
+	contactHistoryService ContactHistoryService,
+) ContactUnlockService {
+	return &contactUnlockService{
+		Service:                      service,
+		contactUnlockRepository:      contactUnlockRepository,
+		jobRepository:                jobRepository,
+		contactVoucherHistoryService: contactVoucherHistoryService,
+		contactHistoryService:        contactHistoryService,
+	}
+}
+
+type contactUnlockService struct {
+	*Service
+	contactUnlockRepository      repository.ContactUnlockRepository
+	jobRepository                repository.JobRepository
+	contactVoucherHistoryService ContactVoucherHistoryService
+	contactHistoryService        ContactHistoryService
+}
+
+// Unlock reveals the contact phone of a job, spending one voucher the first time the user
+// asks for it. Later calls, and the job's owner, get the phone for free.
+func (s *contactUnlockService) Unlock(ctx context.Context, userID int64, purposeType int, purposeID int64) (*ContactUnlockResult, error) {
+	if purposeType != model.ContactPurposeJob {
+		return nil, ErrUnsupportedPurpose
+	}
+	job, err := s.jobRepository.GetByID(ctx, purposeID)
+	if err != nil {
+		return nil, err
+	}
+	result := &ContactUnlockResult{Phone: job.Contact}
+	if job.UserID == userID {
+		return result, s.fillVoucherNum(ctx, userID, result)
+	}
+
+	attempt := func(ctx context.Context) error {
+		result.Charged = false
+		unlock, err := s.contactUnlockRepository.Get(ctx, userID, purposeType, purposeID)
+		if err != nil {
+			return err
+		}
+		if unlock != nil {
+			return nil
+		}
+		if job.Status != model.JobStatusActive {
+			return ErrJobNotActive
+		}
+		// The unique (user_id, purpose_type, purpose_id) index makes a concurrent twin fail
+		// here, which rolls back its voucher along with it.
+		if err := s.contactUnlockRepository.Create(ctx, &model.ContactUnlock{
+			UserID:      userID,
+			PurposeType: purposeType,
+			PurposeID:   purposeID,
+			CreateAt:    time.Now(),
+		}); err != nil {
+			return err
+		}
+		num, err := s.contactVoucherHistoryService.AdjustVoucher(ctx, userID, model.ContactVoucherHistoryCost, -1, "拨打电话")
+		if err != nil {
+			return err
+		}
+		result.Charged = true
+		result.ContactVoucherNum = num
+		_, err = s.contactHistoryService.Create(ctx, ContactHistoryCreateInput{
+			UserID:           userID,
+			PurposeID:        purposeID,
+			PurposeType:      purposeType,
+			PurposeUserID:    job.UserID,
+			PurposeUserName:  job.ContactPersonName,
+			PurposeUserPhone: job.Contact,
+		})
+		return err
+	}
+	err = s.tm.Transaction(ctx, attempt)
+	if err != nil && err != ErrInsufficientVoucher && err != ErrJobNotActive {
+		// Lost the race to a concurrent unlock of the same job: it paid, we don't.
+		if unlock, getErr := s.contactUnlockRepository.Get(ctx, userID, purposeType, purposeID); getErr == nil && unlock != nil {
+			err = s.tm.Transaction(ctx, attempt)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !result.Charged {
+		return result, s.fillVoucherNum(ctx, userID, result)
+	}
+	return result, nil
+}
+
+func (s *contactUnlockService) fillVoucherNum(ctx context.Context, userID int64, result *ContactUnlockResult) error {
+	num, err := s.contactVoucherHistoryService.GetUserVoucherNum(ctx, userID)
+	if err != nil {
+		return err
+	}
+	result.ContactVoucherNum = num
+	return nil
+}
+
+func (s *contactUnlockService) UnlockedJobIDs(ctx context.Context, userID int64, jobIDs []int64) (map[int64]bool, error) {
+	unlocked := make(map[int64]bool, len(jobIDs))
+	if userID == 0 {
+		return unlocked, nil
+	}
+	ids, err := s.contactUnlockRepository.ListPurposeIDs(ctx, userID, model.ContactPurposeJob, jobIDs)
+	if err != nil {
+		return nil, err
+	}
+	for _, id := range ids {
+		unlocked[id] = true
+	}
+	return unlocked, nil
+}
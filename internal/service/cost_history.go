@@ -2,40 +2,76 @@ package service
 
 import (
 	"context"
+	"errors"
 	"time"
 
+	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
 	"github.com/go-nunu/nunu-layout-advanced/internal/model"
 	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
 )
 
 type ContactVoucherHistoryService interface {
 	ListByUser(ctx context.Context, userID int64, pageNum, pageSize int) ([]*model.ContactVoucherHistory, int64, error)
+	ListBatches(ctx context.Context, userID int64) ([]*model.ContactVoucherBatch, error)
 	AdjustVoucher(ctx context.Context, userID int64, bizType model.ContactVoucherHistoryBizType, changeNum int, remark string) (int, error)
+	GrantVoucher(ctx context.Context, input VoucherGrantInput) (int, error)
+	// RevokeOrderVouchers takes up to num vouchers back from the batches the order granted and
+	// returns how many it took; vouchers already spent or expired stay with the user.
+	RevokeOrderVouchers(ctx context.Context, userID, orderID int64, num int, remark string) (int, error)
+	// CapBatches brings the user's bizType batches that outlast expireAt down to it. What is
+	// left of the ones that have run out by now is revoked straight away; it returns how many.
+	CapBatches(ctx context.Context, userID int64, bizType model.ContactVoucherHistoryBizType, expireAt time.Time, remark string) (int, error)
+	ExpireVouchers(ctx context.Context, now time.Time) (int, error)
 	GetUserVoucherNum(ctx context.Context, userID int64) (int, error)
 }
 
 func NewContactVoucherHistoryService(
 	service *Service,
 	contactVoucherHistoryRepository repository.ContactVoucherHistoryRepository,
+	contactVoucherBatchRepository repository.ContactVoucherBatchRepository,
 	userRepository repository.UserRepository,
+	config *viper.Viper,
 ) ContactVoucherHistoryService {
 	return &contactVoucherHistoryService{
 		Service:                         service,
+		config:                          config,
 		contactVoucherHistoryRepository: contactVoucherHistoryRepository,
+		contactVoucherBatchRepository:   contactVoucherBatchRepository,
 		userRepository:                  userRepository,
 	}
 }
 
+var errBatchChanged = errors.New("voucher batch changed concurrently")
+
 type contactVoucherHistoryService struct {
 	*Service
+	config                          *viper.Viper
 	contactVoucherHistoryRepository repository.ContactVoucherHistoryRepository
+	contactVoucherBatchRepository   repository.ContactVoucherBatchRepository
 	userRepository                  repository.UserRepository
 }
 
+// VoucherGrantInput credits a new batch. A zero ExpireAt uses contact_voucher.valid_days.
+type VoucherGrantInput struct {
+	UserID   int64
+	BizType  model.ContactVoucherHistoryBizType
+	Num      int
+	Remark   string
+	OrderID  int64
+	ExpireAt time.Time
+}
+
 func (s *contactVoucherHistoryService) ListByUser(ctx context.Context, userID int64, pageNum, pageSize int) ([]*model.ContactVoucherHistory, int64, error) {
 	return s.contactVoucherHistoryRepository.ListByUser(ctx, userID, pageNum, pageSize)
 }
 
+// ListBatches returns the batches the user can still spend, in spending order.
+func (s *contactVoucherHistoryService) ListBatches(ctx context.Context, userID int64) ([]*model.ContactVoucherBatch, error) {
+	return s.contactVoucherBatchRepository.ListUsable(ctx, userID, time.Now())
+}
+
 func (s *contactVoucherHistoryService) GetUserVoucherNum(ctx context.Context, userID int64) (int, error) {
 	user, err := s.userRepository.GetByID(ctx, userID)
 	if err != nil {
@@ -44,33 +80,276 @@ func (s *contactVoucherHistoryService) GetUserVoucherNum(ctx context.Context, us
 	return user.ContactVoucherNum, nil
 }
 
+// AdjustVoucher grants a new batch for a positive change and spends the earliest-expiring
+// batches for a negative one.
 func (s *contactVoucherHistoryService) AdjustVoucher(ctx context.Context, userID int64, bizType model.ContactVoucherHistoryBizType, changeNum int, remark string) (int, error) {
+	if changeNum > 0 {
+		return s.GrantVoucher(ctx, VoucherGrantInput{
+			UserID:  userID,
+			BizType: bizType,
+			Num:     changeNum,
+			Remark:  remark,
+		})
+	}
 	var nextNum int
 	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
-		user, err := s.userRepository.GetByID(ctx, userID)
+		var err error
+		// The balance update locks the user row, so concurrent spends of the same user
+		// walk the batches one at a time.
+		nextNum, err = s.userRepository.IncrVoucher(ctx, userID, changeNum)
 		if err != nil {
+			if err == v1.ErrInsufficientVoucher {
+				return ErrInsufficientVoucher
+			}
 			return err
 		}
-		lastNum := user.ContactVoucherNum
-		nextNum = lastNum + changeNum
-		if nextNum < 0 {
-			return ErrInsufficientVoucher
+		if err := s.consumeBatches(ctx, userID, -changeNum); err != nil {
+			return err
 		}
-		user.ContactVoucherNum = nextNum
-		user.UpdateAt = time.Now()
-		if err := s.userRepository.Update(ctx, user); err != nil {
+		return s.writeHistory(ctx, userID, bizType, changeNum, nextNum, remark)
+	})
+	return nextNum, err
+}
+
+func (s *contactVoucherHistoryService) GrantVoucher(ctx context.Context, input VoucherGrantInput) (int, error) {
+	if input.Num <= 0 {
+		return 0, ErrInvalidVoucherNum
+	}
+	now := time.Now()
+	expireAt := input.ExpireAt
+	if expireAt.IsZero() {
+		expireAt = now.Add(voucherValidity(s.config))
+	}
+	var nextNum int
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		nextNum, err = s.userRepository.IncrVoucher(ctx, input.UserID, input.Num)
+		if err != nil {
 			return err
 		}
-		history := &model.ContactVoucherHistory{
-			UserID:    userID,
-			BizType:   bizType,
-			ChangeNum: changeNum,
-			LastNum:   lastNum,
-			NextNum:   nextNum,
-			Remark:    remark,
-			CreateAt:  time.Now(),
+		if err := s.contactVoucherBatchRepository.Create(ctx, &model.ContactVoucherBatch{
+			UserID:    input.UserID,
+			BizType:   input.BizType,
+			OrderID:   input.OrderID,
+			TotalNum:  input.Num,
+			RemainNum: input.Num,
+			ExpireAt:  &expireAt,
+			CreateAt:  now,
+			UpdateAt:  now,
+		}); err != nil {
+			return err
 		}
-		return s.contactVoucherHistoryRepository.Create(ctx, history)
+		return s.writeHistory(ctx, input.UserID, input.BizType, input.Num, nextNum, input.Remark)
 	})
 	return nextNum, err
 }
+
+func (s *contactVoucherHistoryService) RevokeOrderVouchers(ctx context.Context, userID, orderID int64, num int, remark string) (int, error) {
+	revoked := 0
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		revoked = 0
+		// Lock the balance before the batches, in the same order as spending and expiry.
+		user, err := s.userRepository.GetByIDForUpdate(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if num > user.ContactVoucherNum {
+			num = user.ContactVoucherNum
+		}
+		batches, err := s.contactVoucherBatchRepository.ListByOrderIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		for _, batch := range batches {
+			if revoked >= num {
+				break
+			}
+			if batch.UserID != userID {
+				continue
+			}
+			take := batch.RemainNum
+			if take > num-revoked {
+				take = num - revoked
+			}
+			ok, err := s.contactVoucherBatchRepository.Consume(ctx, batch.ID, take)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return errBatchChanged
+			}
+			revoked += take
+		}
+		if revoked == 0 {
+			return nil
+		}
+		nextNum, err := s.userRepository.IncrVoucher(ctx, userID, -revoked)
+		if err != nil {
+			return err
+		}
+		return s.writeHistory(ctx, userID, model.ContactVoucherHistoryRefund, -revoked, nextNum, remark)
+	})
+	return revoked, err
+}
+
+func (s *contactVoucherHistoryService) CapBatches(ctx context.Context, userID int64, bizType model.ContactVoucherHistoryBizType, expireAt time.Time, remark string) (int, error) {
+	revoked := 0
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		revoked = 0
+		// Lock the balance before the batches, in the same order as spending and expiry.
+		user, err := s.userRepository.GetByIDForUpdate(ctx, userID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		batches, err := s.contactVoucherBatchRepository.ListUsableForUpdate(ctx, userID, now)
+		if err != nil {
+			return err
+		}
+		for _, batch := range batches {
+			if batch.BizType != bizType || (batch.ExpireAt != nil && !batch.ExpireAt.After(expireAt)) {
+				continue
+			}
+			if expireAt.After(now) {
+				if err := s.contactVoucherBatchRepository.SetExpireAt(ctx, batch.ID, expireAt); err != nil {
+					return err
+				}
+				continue
+			}
+			take := batch.RemainNum
+			if take > user.ContactVoucherNum-revoked {
+				take = user.ContactVoucherNum - revoked
+			}
+			ok, err := s.contactVoucherBatchRepository.Expire(ctx, batch.ID, batch.RemainNum)
+			if err != nil {
+				return err
+			}
+			if !ok {
+				return errBatchChanged
+			}
+			revoked += take
+		}
+		if revoked == 0 {
+			return nil
+		}
+		nextNum, err := s.userRepository.IncrVoucher(ctx, userID, -revoked)
+		if err != nil {
+			return err
+		}
+		return s.writeHistory(ctx, userID, model.ContactVoucherHistoryRefund, -revoked, nextNum, remark)
+	})
+	return revoked, err
+}
+
+// ExpireVouchers zeroes the batches whose expiry has passed and takes their leftovers off
+// the balance, one batch per transaction.
+func (s *contactVoucherHistoryService) ExpireVouchers(ctx context.Context, now time.Time) (int, error) {
+	const batchSize = 100
+	expired := 0
+	for {
+		batches, err := s.contactVoucherBatchRepository.ListExpired(ctx, now, batchSize)
+		if err != nil {
+			return expired, err
+		}
+		progressed := false
+		for _, batch := range batches {
+			ok, err := s.expireBatch(ctx, batch)
+			if err != nil {
+				s.logger.WithContext(ctx).Error("expire voucher batch error", zap.Int64("batch_id", batch.ID), zap.Error(err))
+				continue
+			}
+			if ok {
+				expired += batch.RemainNum
+				progressed = true
+			}
+		}
+		if len(batches) < batchSize || !progressed {
+			return expired, nil
+		}
+	}
+}
+
+func (s *contactVoucherHistoryService) expireBatch(ctx context.Context, batch *model.ContactVoucherBatch) (bool, error) {
+	expired := false
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		// Balance first, like spending, so both lock the user row before the batch.
+		num := batch.RemainNum
+		nextNum, err := s.userRepository.IncrVoucher(ctx, batch.UserID, -num)
+		if err == v1.ErrInsufficientVoucher {
+			// The cached balance drifted below the batches; expire what is left of it.
+			num = nextNum
+			nextNum, err = s.userRepository.IncrVoucher(ctx, batch.UserID, -num)
+		}
+		if err != nil {
+			return err
+		}
+		ok, err := s.contactVoucherBatchRepository.Expire(ctx, batch.ID, batch.RemainNum)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			// Spent from concurrently; roll back and let the next run see the new count.
+			return errBatchChanged
+		}
+		expired = true
+		if num == 0 {
+			return nil
+		}
+		return s.writeHistory(ctx, batch.UserID, model.ContactVoucherHistoryExpired, -num, nextNum, "联系券过期")
+	})
+	if err == errBatchChanged {
+		return false, nil
+	}
+	return expired, err
+}
+
+// consumeBatches takes num vouchers from the user's batches, earliest expiry first. The
+// cached balance still counts batches that expired since the last sweep, so it fails with
+// ErrInsufficientVoucher when the unexpired batches can't cover num.
+func (s *contactVoucherHistoryService) consumeBatches(ctx context.Context, userID int64, num int) error {
+	batches, err := s.contactVoucherBatchRepository.ListUsableForUpdate(ctx, userID, time.Now())
+	if err != nil {
+		return err
+	}
+	for _, batch := range batches {
+		if num <= 0 {
+			break
+		}
+		take := batch.RemainNum
+		if take > num {
+			take = num
+		}
+		ok, err := s.contactVoucherBatchRepository.Consume(ctx, batch.ID, take)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return errBatchChanged
+		}
+		num -= take
+	}
+	if num > 0 {
+		return ErrInsufficientVoucher
+	}
+	return nil
+}
+
+func (s *contactVoucherHistoryService) writeHistory(ctx context.Context, userID int64, bizType model.ContactVoucherHistoryBizType, changeNum, nextNum int, remark string) error {
+	return s.contactVoucherHistoryRepository.Create(ctx, &model.ContactVoucherHistory{
+		UserID:    userID,
+		BizType:   bizType,
+		ChangeNum: changeNum,
+		LastNum:   nextNum - changeNum,
+		NextNum:   nextNum,
+		Remark:    remark,
+		CreateAt:  time.Now(),
+	})
+}
+
+// voucherValidity is how long a new voucher batch stays usable (contact_voucher.valid_days, default 365).
+func voucherValidity(conf *viper.Viper) time.Duration {
+	if days := conf.GetInt("contact_voucher.valid_days"); days > 0 {
+		return time.Duration(days) * 24 * time.Hour
+	}
+	return 365 * 24 * time.Hour
+}
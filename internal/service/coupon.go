@@ -0,0 +1,329 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"gorm.io/gorm"
+)
+
+type CouponService interface {
+	CreateTemplate(ctx context.Context, input CouponTemplateInput) (*model.CouponTemplate, error)
+	ListClaimable(ctx context.Context, userID int64) ([]*ClaimableCoupon, error)
+	// Claim hands the user a coupon from an online template inside its claim window.
+	Claim(ctx context.Context, userID, templateID int64) (*model.UserCoupon, error)
+	// Issue hands the user a coupon from any template, ignoring its status and claim window.
+	Issue(ctx context.Context, userID, templateID int64) (*model.UserCoupon, error)
+	ListByUser(ctx context.Context, userID int64, status model.UserCouponStatus, pageNum, pageSize int) ([]*model.UserCoupon, int64, error)
+	// Discount works out what the coupon takes off the order's items without reserving it.
+	Discount(ctx context.Context, input CouponDiscountInput) (*CouponDiscount, error)
+	// Lock reserves the coupon for a pending order; it fails with ErrCouponUnavailable
+	// when another order took it first.
+	Lock(ctx context.Context, couponID, orderID int64) error
+	// Release hands the order's coupon back after the order is canceled.
+	Release(ctx context.Context, order *model.Order) error
+	// Settle runs when an order is paid: it spends the order's coupon and records the
+	// user's first recharge. ErrCouponUnavailable means the coupon went to another order or
+	// a first-recharge coupon came after the first recharge; the caller rolls back.
+	Settle(ctx context.Context, order *model.Order) error
+}
+
+func NewCouponService(
+	service *Service,
+	couponTemplateRepository repository.CouponTemplateRepository,
+	userCouponRepository repository.UserCouponRepository,
+	userRepository repository.UserRepository,
+) CouponService {
+	return &couponService{
+		Service:                  service,
+		couponTemplateRepository: couponTemplateRepository,
+		userCouponRepository:     userCouponRepository,
+		userRepository:           userRepository,
+	}
+}
+
+type couponService struct {
+	*Service
+	couponTemplateRepository repository.CouponTemplateRepository
+	userCouponRepository     repository.UserCouponRepository
+	userRepository           repository.UserRepository
+}
+
+type CouponTemplateInput struct {
+	Title             string
+	ProductType       model.ProductType
+	DiscountAmount    model.Decimal
+	MinAmount         model.Decimal
+	FirstRechargeOnly bool
+	ValidDays         int
+	TotalNum          int
+	ClaimStartAt      *time.Time
+	ClaimEndAt        *time.Time
+}
+
+// ClaimableCoupon is a template open for claiming and whether the user already holds it.
+type ClaimableCoupon struct {
+	Template *model.CouponTemplate
+	Claimed  bool
+}
+
+// CouponDiscountInput carries the priced items of an order; the coupon applies to the
+// items of its product type only, or to all of them when it has none.
+type CouponDiscountInput struct {
+	UserID   int64
+	CouponID int64
+	Items    []*model.OrderItem
+}
+
+// CouponDiscount is what the coupon takes off. The discount never brings the items it
+// applies to below one cent, because the payment provider can't take a zero amount.
+type CouponDiscount struct {
+	Coupon   *model.UserCoupon
+	Discount model.Decimal
+}
+
+func (s *couponService) CreateTemplate(ctx context.Context, input CouponTemplateInput) (*model.CouponTemplate, error) {
+	if _, err := input.DiscountAmount.ToCents(); err != nil || input.DiscountAmount.Sign() <= 0 {
+		return nil, ErrInvalidCoupon
+	}
+	if _, err := input.MinAmount.ToCents(); err != nil || input.MinAmount.Sign() < 0 {
+		return nil, ErrInvalidCoupon
+	}
+	if input.Title == "" || input.ValidDays <= 0 || input.TotalNum < 0 ||
+		input.ProductType < 0 || input.ProductType > model.ProductTypeMembership {
+		return nil, ErrInvalidCoupon
+	}
+	if input.ClaimStartAt != nil && input.ClaimEndAt != nil && !input.ClaimEndAt.After(*input.ClaimStartAt) {
+		return nil, ErrInvalidCoupon
+	}
+	now := time.Now()
+	template := &model.CouponTemplate{
+		Title:             input.Title,
+		ProductType:       input.ProductType,
+		DiscountAmount:    input.DiscountAmount,
+		MinAmount:         input.MinAmount,
+		FirstRechargeOnly: input.FirstRechargeOnly,
+		ValidDays:         input.ValidDays,
+		TotalNum:          input.TotalNum,
+		ClaimStartAt:      input.ClaimStartAt,
+		ClaimEndAt:        input.ClaimEndAt,
+		Status:            model.CouponTemplateStatusOnline,
+		CreateAt:          now,
+		UpdateAt:          now,
+	}
+	if err := s.couponTemplateRepository.Create(ctx, template); err != nil {
+		return nil, err
+	}
+	return template, nil
+}
+
+// ListClaimable leaves out first-recharge campaigns once the user has paid an order.
+func (s *couponService) ListClaimable(ctx context.Context, userID int64) ([]*ClaimableCoupon, error) {
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	templates, err := s.couponTemplateRepository.ListClaimable(ctx, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	list := make([]*ClaimableCoupon, 0, len(templates))
+	for _, template := range templates {
+		if template.FirstRechargeOnly && user.FirstRecharge != "" {
+			continue
+		}
+		claimed, err := s.userCouponRepository.ExistsByTemplate(ctx, userID, template.ID)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, &ClaimableCoupon{Template: template, Claimed: claimed})
+	}
+	return list, nil
+}
+
+func (s *couponService) Claim(ctx context.Context, userID, templateID int64) (*model.UserCoupon, error) {
+	template, err := s.getTemplate(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if template.Status != model.CouponTemplateStatusOnline ||
+		(template.ClaimStartAt != nil && now.Before(*template.ClaimStartAt)) ||
+		(template.ClaimEndAt != nil && !now.Before(*template.ClaimEndAt)) {
+		return nil, ErrCouponUnavailable
+	}
+	if template.FirstRechargeOnly {
+		user, err := s.userRepository.GetByID(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if user.FirstRecharge != "" {
+			return nil, ErrCouponUnavailable
+		}
+	}
+	return s.issue(ctx, userID, template)
+}
+
+func (s *couponService) Issue(ctx context.Context, userID, templateID int64) (*model.UserCoupon, error) {
+	template, err := s.getTemplate(ctx, templateID)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.userRepository.GetByID(ctx, userID); err != nil {
+		return nil, err
+	}
+	return s.issue(ctx, userID, template)
+}
+
+func (s *couponService) getTemplate(ctx context.Context, templateID int64) (*model.CouponTemplate, error) {
+	template, err := s.couponTemplateRepository.GetByID(ctx, templateID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCouponUnavailable
+		}
+		return nil, err
+	}
+	return template, nil
+}
+
+// issue gives the user one coupon of the template; each user holds at most one per template.
+func (s *couponService) issue(ctx context.Context, userID int64, template *model.CouponTemplate) (*model.UserCoupon, error) {
+	now := time.Now()
+	coupon := &model.UserCoupon{
+		UserID:            userID,
+		TemplateID:        template.ID,
+		Title:             template.Title,
+		ProductType:       template.ProductType,
+		DiscountAmount:    template.DiscountAmount,
+		MinAmount:         template.MinAmount,
+		FirstRechargeOnly: template.FirstRechargeOnly,
+		StartAt:           now,
+		EndAt:             now.Add(time.Duration(template.ValidDays) * 24 * time.Hour),
+		Status:            model.UserCouponStatusUnused,
+		CreateAt:          now,
+		UpdateAt:          now,
+	}
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		claimed, err := s.userCouponRepository.ExistsByTemplate(ctx, userID, template.ID)
+		if err != nil {
+			return err
+		}
+		if claimed {
+			return ErrCouponClaimed
+		}
+		ok, err := s.couponTemplateRepository.IncrIssued(ctx, template.ID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrCouponUnavailable
+		}
+		// The unique (user_id, template_id) index stops a concurrent twin of this claim.
+		return s.userCouponRepository.Create(ctx, coupon)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return coupon, nil
+}
+
+func (s *couponService) ListByUser(ctx context.Context, userID int64, status model.UserCouponStatus, pageNum, pageSize int) ([]*model.UserCoupon, int64, error) {
+	return s.userCouponRepository.ListByUser(ctx, userID, status, pageNum, pageSize)
+}
+
+func (s *couponService) Discount(ctx context.Context, input CouponDiscountInput) (*CouponDiscount, error) {
+	coupon, err := s.userCouponRepository.GetByID(ctx, input.CouponID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrCouponUnavailable
+		}
+		return nil, err
+	}
+	if coupon.UserID != input.UserID || !coupon.Usable(time.Now()) {
+		return nil, ErrCouponUnavailable
+	}
+	amount := model.NewDecimalFromCents(0)
+	eligible := false
+	for _, item := range input.Items {
+		if coupon.AppliesTo(item.ProductType) {
+			amount = amount.Add(item.UnitPriceSnapshot)
+			eligible = true
+		}
+	}
+	if !eligible {
+		return nil, ErrCouponUnavailable
+	}
+	if coupon.FirstRechargeOnly {
+		user, err := s.userRepository.GetByID(ctx, input.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if user.FirstRecharge != "" {
+			return nil, ErrCouponUnavailable
+		}
+	}
+	if amount.Cmp(coupon.MinAmount) < 0 {
+		return nil, ErrCouponUnavailable
+	}
+	discount := coupon.DiscountAmount
+	if most := amount.Sub(model.NewDecimalFromCents(1)); discount.Cmp(most) > 0 {
+		discount = most
+	}
+	if discount.Sign() <= 0 {
+		return nil, ErrCouponUnavailable
+	}
+	return &CouponDiscount{
+		Coupon:   coupon,
+		Discount: discount,
+	}, nil
+}
+
+func (s *couponService) Lock(ctx context.Context, couponID, orderID int64) error {
+	ok, err := s.userCouponRepository.Lock(ctx, couponID, orderID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrCouponUnavailable
+	}
+	return nil
+}
+
+func (s *couponService) Release(ctx context.Context, order *model.Order) error {
+	if order.CouponID == 0 {
+		return nil
+	}
+	_, err := s.userCouponRepository.Release(ctx, order.CouponID, order.ID)
+	return err
+}
+
+func (s *couponService) Settle(ctx context.Context, order *model.Order) error {
+	first, err := s.userRepository.SetFirstRecharge(ctx, order.UserID, order.OrderNo)
+	if err != nil {
+		return err
+	}
+	if order.CouponID == 0 {
+		return nil
+	}
+	coupon, err := s.userCouponRepository.GetByID(ctx, order.CouponID)
+	if err != nil {
+		return err
+	}
+	// Discount checked this when the order was placed; another order may have been paid since.
+	if coupon.FirstRechargeOnly && !first {
+		return ErrCouponUnavailable
+	}
+	used, err := s.userCouponRepository.MarkUsed(ctx, order.CouponID, order.ID, time.Now())
+	if err != nil {
+		return err
+	}
+	if !used {
+		// The order was canceled, its coupon went to another order, and then a late
+		// payment arrived; the discount can't be honoured twice.
+		return ErrCouponUnavailable
+	}
+	return nil
+}
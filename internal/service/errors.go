@@ -3,11 +3,35 @@ package service
 import "errors"
 
 var (
-	ErrForbidden          = errors.New("forbidden")
-	ErrInsufficientVoucher = errors.New("insufficient contact voucher")
-	ErrAmountMismatch     = errors.New("amount mismatch")
-	ErrInvalidVoucherNum  = errors.New("invalid voucher number")
-	ErrUserExists         = errors.New("user already exists")
-	ErrUserNotFound       = errors.New("user not found")
-	ErrJobLimitExceeded   = errors.New("job limit exceeded")
+	ErrForbidden            = errors.New("forbidden")
+	ErrInsufficientVoucher  = errors.New("insufficient contact voucher")
+	ErrAmountMismatch       = errors.New("amount mismatch")
+	ErrInvalidVoucherNum    = errors.New("invalid voucher number")
+	ErrUserExists           = errors.New("user already exists")
+	ErrUserNotFound         = errors.New("user not found")
+	ErrJobLimitExceeded     = errors.New("job limit exceeded")
+	ErrProductNotFound      = errors.New("product not found")
+	ErrInvalidNotify        = errors.New("invalid payment notification")
+	ErrOrderNotPending      = errors.New("order is not pending")
+	ErrOrderStatusChanged   = errors.New("order status changed concurrently")
+	ErrOrderNotRefundable   = errors.New("order is not refundable")
+	ErrInvalidRefundAmount  = errors.New("invalid refund amount")
+	ErrRefundRejected       = errors.New("refund rejected by the payment provider")
+	ErrIdempotencyKeyReused = errors.New("idempotency key reused with a different request")
+	ErrUnsupportedPurpose   = errors.New("unsupported contact purpose")
+	ErrJobNotActive         = errors.New("job is not active")
+	ErrInviteCodeExhausted  = errors.New("no free invite code")
+	ErrInsufficientIntegral = errors.New("insufficient points")
+	ErrAlreadyCheckedIn     = errors.New("already checked in today")
+	ErrInvalidRedeem        = errors.New("invalid redemption")
+	ErrRefreshLimitExceeded = errors.New("free refresh limit exceeded")
+	ErrCouponUnavailable    = errors.New("coupon unavailable")
+	ErrCouponClaimed        = errors.New("coupon already claimed")
+	ErrInvalidCoupon        = errors.New("invalid coupon template")
+	ErrInvalidOrderLines    = errors.New("invalid order lines")
+	ErrInvalidInvoice       = errors.New("invalid invoice request")
+	ErrOrderNotInvoiceable  = errors.New("order is not invoiceable")
+	ErrInvoiceNotPending    = errors.New("invoice is not pending")
+	ErrInvalidTag           = errors.New("invalid tag")
+	ErrInvalidArea          = errors.New("invalid area")
 )
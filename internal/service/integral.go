@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/spf13/viper"
+)
+
+type IntegralService interface {
+	ListByUser(ctx context.Context, userID int64, pageNum, pageSize int) ([]*model.IntegralHistory, int64, error)
+	GetBalance(ctx context.Context, userID int64) (int64, error)
+	CheckIn(ctx context.Context, userID int64) (*IntegralChangeResult, error)
+	// Earn credits a one-off reward. Rewards already credited under the same BizKey, or
+	// configured as 0, earn nothing and report a zero Change.
+	Earn(ctx context.Context, input IntegralEarnInput) (*IntegralChangeResult, error)
+	Redeem(ctx context.Context, input IntegralRedeemInput) (*IntegralChangeResult, error)
+}
+
+func NewIntegralService(
+	service *Service,
+	integralHistoryRepository repository.IntegralHistoryRepository,
+	userRepository repository.UserRepository,
+	jobRepository repository.JobRepository,
+	contactVoucherHistoryService ContactVoucherHistoryService,
+	config *viper.Viper,
+) IntegralService {
+	return &integralService{
+		Service:                      service,
+		integralHistoryRepository:    integralHistoryRepository,
+		userRepository:               userRepository,
+		jobRepository:                jobRepository,
+		contactVoucherHistoryService: contactVoucherHistoryService,
+		config:                       config,
+	}
+}
+
+type integralService struct {
+	*Service
+	integralHistoryRepository    repository.IntegralHistoryRepository
+	userRepository               repository.UserRepository
+	jobRepository                repository.JobRepository
+	contactVoucherHistoryService ContactVoucherHistoryService
+	config                       *viper.Viper
+}
+
+type IntegralEarnInput struct {
+	UserID  int64
+	BizType model.IntegralHistoryBizType
+	BizKey  string
+	Remark  string
+}
+
+// IntegralRedeemInput spends points on Num contact vouchers (IntegralHistoryRedeemVoucher)
+// or on one refresh of JobID (IntegralHistoryRedeemRefresh).
+type IntegralRedeemInput struct {
+	UserID  int64
+	BizType model.IntegralHistoryBizType
+	Num     int
+	JobID   int64
+}
+
+type IntegralChangeResult struct {
+	Change   int64
+	Integral int64
+}
+
+// Points per earning rule and redemption price, keyed by biz type. Each can be overridden
+// under integral.*; setting a reward to 0 turns it off.
+var integralRules = map[model.IntegralHistoryBizType]struct {
+	key string
+	def int64
+}{
+	model.IntegralHistoryCheckIn:         {"integral.checkin", 5},
+	model.IntegralHistoryFirstJob:        {"integral.first_job", 20},
+	model.IntegralHistoryProfileComplete: {"integral.profile_complete", 10},
+	model.IntegralHistoryInvite:          {"integral.invite", 10},
+	model.IntegralHistoryRedeemVoucher:   {"integral.voucher_price", 100},
+	model.IntegralHistoryRedeemRefresh:   {"integral.refresh_price", 50},
+}
+
+func (s *integralService) ListByUser(ctx context.Context, userID int64, pageNum, pageSize int) ([]*model.IntegralHistory, int64, error) {
+	return s.integralHistoryRepository.ListByUser(ctx, userID, pageNum, pageSize)
+}
+
+func (s *integralService) GetBalance(ctx context.Context, userID int64) (int64, error) {
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	return int64(user.Integral), nil
+}
+
+func (s *integralService) CheckIn(ctx context.Context, userID int64) (*IntegralChangeResult, error) {
+	result, err := s.Earn(ctx, IntegralEarnInput{
+		UserID:  userID,
+		BizType: model.IntegralHistoryCheckIn,
+		BizKey:  "checkin:" + time.Now().Format("20060102"),
+		Remark:  "每日签到",
+	})
+	if err != nil {
+		return nil, err
+	}
+	if result.Change == 0 {
+		return nil, ErrAlreadyCheckedIn
+	}
+	return result, nil
+}
+
+func (s *integralService) Earn(ctx context.Context, input IntegralEarnInput) (*IntegralChangeResult, error) {
+	points := s.points(input.BizType)
+	result := &IntegralChangeResult{}
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		// Check before writing: joined to a caller's transaction there is no savepoint to
+		// roll back to. A concurrent duplicate still fails on uk_user_biz_key.
+		earned, err := s.integralHistoryRepository.ExistsBizKey(ctx, input.UserID, input.BizKey)
+		if err != nil {
+			return err
+		}
+		if earned || points <= 0 {
+			return nil
+		}
+		next, err := s.userRepository.IncrIntegral(ctx, input.UserID, points)
+		if err != nil {
+			return err
+		}
+		result.Change = points
+		result.Integral = next
+		return s.writeHistory(ctx, input.UserID, input.BizType, input.BizKey, points, next, input.Remark)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *integralService) Redeem(ctx context.Context, input IntegralRedeemInput) (*IntegralChangeResult, error) {
+	var remark string
+	switch input.BizType {
+	case model.IntegralHistoryRedeemVoucher:
+		if input.Num <= 0 {
+			return nil, ErrInvalidRedeem
+		}
+		remark = "兑换联系券 " + strconv.Itoa(input.Num) + " 张"
+	case model.IntegralHistoryRedeemRefresh:
+		if input.JobID <= 0 {
+			return nil, ErrInvalidRedeem
+		}
+		input.Num = 1
+		remark = "兑换刷新 " + strconv.FormatInt(input.JobID, 10)
+	default:
+		return nil, ErrInvalidRedeem
+	}
+	cost := s.points(input.BizType) * int64(input.Num)
+	result := &IntegralChangeResult{Change: -cost}
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		next, err := s.userRepository.IncrIntegral(ctx, input.UserID, -cost)
+		if err != nil {
+			if err == v1.ErrInsufficientIntegral {
+				return ErrInsufficientIntegral
+			}
+			return err
+		}
+		result.Integral = next
+		if err := s.writeHistory(ctx, input.UserID, input.BizType, "", -cost, next, remark); err != nil {
+			return err
+		}
+		if input.BizType == model.IntegralHistoryRedeemVoucher {
+			_, err := s.contactVoucherHistoryService.GrantVoucher(ctx, VoucherGrantInput{
+				UserID:  input.UserID,
+				BizType: model.ContactVoucherHistoryIntegral,
+				Num:     input.Num,
+				Remark:  "积分兑换",
+			})
+			return err
+		}
+		job, err := s.jobRepository.GetByIDForUpdate(ctx, input.JobID)
+		if err != nil {
+			return err
+		}
+		if job.UserID != input.UserID {
+			return ErrForbidden
+		}
+		if job.Status != model.JobStatusActive {
+			return ErrJobNotActive
+		}
+		now := time.Now()
+		job.RefreshTime = &now
+		job.UpdateAt = now
+		return s.jobRepository.Update(ctx, job)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *integralService) points(bizType model.IntegralHistoryBizType) int64 {
+	rule, ok := integralRules[bizType]
+	if !ok {
+		return 0
+	}
+	if s.config.IsSet(rule.key) {
+		return s.config.GetInt64(rule.key)
+	}
+	return rule.def
+}
+
+func (s *integralService) writeHistory(ctx context.Context, userID int64, bizType model.IntegralHistoryBizType, bizKey string, changeNum, nextNum int64, remark string) error {
+	return s.integralHistoryRepository.Create(ctx, &model.IntegralHistory{
+		UserID:    userID,
+		BizType:   bizType,
+		BizKey:    bizKey,
+		ChangeNum: changeNum,
+		LastNum:   nextNum - changeNum,
+		NextNum:   nextNum,
+		Remark:    remark,
+		CreateAt:  time.Now(),
+	})
+}
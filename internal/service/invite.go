@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+type InviteService interface {
+	// ResolveInviter finds the inviter of a new registration by invite code, falling back to
+	// the legacy inviter_id. Unknown inviters resolve to 0 rather than failing registration.
+	ResolveInviter(ctx context.Context, inviteCode string, inviterID int64) (int64, error)
+	Accept(ctx context.Context, invitee *model.User, deviceID string) error
+	GetInviteInfo(ctx context.Context, userID int64) (*InviteInfo, error)
+	ListInvites(ctx context.Context, inviterID int64, pageNum, pageSize int) ([]*InviteRecord, int64, error)
+}
+
+func NewInviteService(
+	service *Service,
+	inviteRepository repository.InviteRepository,
+	userRepository repository.UserRepository,
+	contactVoucherHistoryService ContactVoucherHistoryService,
+	integralService IntegralService,
+	config *viper.Viper,
+) InviteService {
+	return &inviteService{
+		Service:                      service,
+		inviteRepository:             inviteRepository,
+		userRepository:               userRepository,
+		contactVoucherHistoryService: contactVoucherHistoryService,
+		integralService:              integralService,
+		config:                       config,
+	}
+}
+
+type inviteService struct {
+	*Service
+	inviteRepository             repository.InviteRepository
+	userRepository               repository.UserRepository
+	contactVoucherHistoryService ContactVoucherHistoryService
+	integralService              IntegralService
+	config                       *viper.Viper
+}
+
+// InviteInfo is what a user shares to invite others. SharePath is the mini program page
+// (invite.share_path) with the code appended as invite_code.
+type InviteInfo struct {
+	InviteCode string
+	SharePath  string
+	InviteNum  uint64
+}
+
+// InviteRecord is an invite together with the invitee's profile.
+type InviteRecord struct {
+	Invite  *model.Invite
+	Invitee *model.User
+}
+
+// Unambiguous characters only, so codes survive being read out or typed by hand.
+const inviteCodeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+const inviteCodeLength = 8
+
+func (s *inviteService) ResolveInviter(ctx context.Context, inviteCode string, inviterID int64) (int64, error) {
+	if inviteCode != "" {
+		inviter, err := s.userRepository.GetByInviteCode(ctx, inviteCode)
+		if err != nil {
+			return 0, err
+		}
+		if inviter == nil {
+			s.logger.WithContext(ctx).Warn("unknown invite code", zap.String("invite_code", inviteCode))
+			return 0, nil
+		}
+		return inviter.ID, nil
+	}
+	if inviterID <= 0 {
+		return 0, nil
+	}
+	inviter, err := s.userRepository.GetByID(ctx, inviterID)
+	if err != nil {
+		if err == v1.ErrNotFound {
+			s.logger.WithContext(ctx).Warn("unknown inviter", zap.Int64("inviter_id", inviterID))
+			return 0, nil
+		}
+		return 0, err
+	}
+	return inviter.ID, nil
+}
+
+// Accept counts a new registration towards its inviter and hands out the configured
+// rewards. Rewards are withheld when the phone or device has been rewarded before, or
+// when the inviter has hit invite.daily_limit today; the invite is still recorded.
+func (s *inviteService) Accept(ctx context.Context, invitee *model.User, deviceID string) error {
+	if invitee.InviteID == 0 || invitee.InviteID == invitee.ID {
+		return nil
+	}
+	now := time.Now()
+	invite := &model.Invite{
+		InviterID:     invitee.InviteID,
+		InviteeID:     invitee.ID,
+		InviteePhone:  invitee.Phone,
+		DeviceID:      deviceID,
+		RewardStatus:  model.InviteRewardStatusGranted,
+		InviterReward: s.config.GetInt("invite.inviter_reward"),
+		InviteeReward: s.config.GetInt("invite.invitee_reward"),
+		CreateAt:      now,
+	}
+	if !s.config.IsSet("invite.inviter_reward") {
+		invite.InviterReward = 1
+	}
+	if !s.config.IsSet("invite.invitee_reward") {
+		invite.InviteeReward = 1
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.userRepository.IncrInviteNum(ctx, invite.InviterID); err != nil {
+			return err
+		}
+		remark, err := s.rejectReason(ctx, invite, now)
+		if err != nil {
+			return err
+		}
+		if remark != "" {
+			invite.RewardStatus = model.InviteRewardStatusRejected
+			invite.InviterReward = 0
+			invite.InviteeReward = 0
+			invite.Remark = remark
+		}
+		// The unique invitee index keeps a retried registration from being rewarded twice.
+		if err := s.inviteRepository.Create(ctx, invite); err != nil {
+			return err
+		}
+		if invite.InviterReward > 0 {
+			if _, err := s.contactVoucherHistoryService.GrantVoucher(ctx, VoucherGrantInput{
+				UserID:  invite.InviterID,
+				BizType: model.ContactVoucherHistoryInvite,
+				Num:     invite.InviterReward,
+				Remark:  "邀请好友 " + strconv.FormatInt(invite.InviteeID, 10),
+			}); err != nil {
+				return err
+			}
+		}
+		if invite.InviteeReward > 0 {
+			if _, err := s.contactVoucherHistoryService.GrantVoucher(ctx, VoucherGrantInput{
+				UserID:  invite.InviteeID,
+				BizType: model.ContactVoucherHistoryInvite,
+				Num:     invite.InviteeReward,
+				Remark:  "受邀注册",
+			}); err != nil {
+				return err
+			}
+		}
+		if invite.RewardStatus != model.InviteRewardStatusGranted {
+			return nil
+		}
+		_, err = s.integralService.Earn(ctx, IntegralEarnInput{
+			UserID:  invite.InviterID,
+			BizType: model.IntegralHistoryInvite,
+			BizKey:  "invite:" + strconv.FormatInt(invite.InviteeID, 10),
+			Remark:  "邀请好友 " + strconv.FormatInt(invite.InviteeID, 10),
+		})
+		return err
+	})
+}
+
+func (s *inviteService) rejectReason(ctx context.Context, invite *model.Invite, now time.Time) (string, error) {
+	rewarded, err := s.inviteRepository.CountGrantedByPhoneOrDevice(ctx, invite.InviteePhone, invite.DeviceID)
+	if err != nil {
+		return "", err
+	}
+	if rewarded > 0 {
+		return "手机号或设备已领取过邀请奖励", nil
+	}
+	limit := s.config.GetInt("invite.daily_limit")
+	if limit <= 0 {
+		limit = 20
+	}
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	today, err := s.inviteRepository.CountGrantedSince(ctx, invite.InviterID, startOfDay)
+	if err != nil {
+		return "", err
+	}
+	if today >= int64(limit) {
+		return "邀请人今日奖励已达上限", nil
+	}
+	return "", nil
+}
+
+func (s *inviteService) GetInviteInfo(ctx context.Context, userID int64) (*InviteInfo, error) {
+	user, err := s.userRepository.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	code, err := s.ensureInviteCode(ctx, user)
+	if err != nil {
+		return nil, err
+	}
+	sharePath := s.config.GetString("invite.share_path")
+	if sharePath == "" {
+		sharePath = "/pages/index/index"
+	}
+	separator := "?"
+	if strings.Contains(sharePath, "?") {
+		separator = "&"
+	}
+	return &InviteInfo{
+		InviteCode: code,
+		SharePath:  sharePath + separator + "invite_code=" + url.QueryEscape(code),
+		InviteNum:  user.InviteNum,
+	}, nil
+}
+
+// ensureInviteCode returns the user's invite code, generating one on first use.
+func (s *inviteService) ensureInviteCode(ctx context.Context, user *model.User) (string, error) {
+	if user.InviteCode != "" {
+		return user.InviteCode, nil
+	}
+	userID := user.ID
+	for i := 0; i < 5; i++ {
+		code, err := newInviteCode()
+		if err != nil {
+			return "", err
+		}
+		taken, err := s.userRepository.GetByInviteCode(ctx, code)
+		if err != nil {
+			return "", err
+		}
+		if taken != nil {
+			continue
+		}
+		ok, err := s.userRepository.SetInviteCode(ctx, userID, code)
+		if err != nil {
+			// Most likely taken between the lookup and the update; try another.
+			s.logger.WithContext(ctx).Warn("set invite code error", zap.Int64("user_id", userID), zap.Error(err))
+			continue
+		}
+		if ok {
+			return code, nil
+		}
+		// A concurrent request got there first.
+		user, err := s.userRepository.GetByID(ctx, userID)
+		if err != nil {
+			return "", err
+		}
+		return user.InviteCode, nil
+	}
+	return "", ErrInviteCodeExhausted
+}
+
+func (s *inviteService) ListInvites(ctx context.Context, inviterID int64, pageNum, pageSize int) ([]*InviteRecord, int64, error) {
+	invites, total, err := s.inviteRepository.ListByInviter(ctx, inviterID, pageNum, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	ids := make([]int64, 0, len(invites))
+	for _, invite := range invites {
+		ids = append(ids, invite.InviteeID)
+	}
+	users, err := s.userRepository.ListByIDs(ctx, ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	userMap := make(map[int64]*model.User, len(users))
+	for _, user := range users {
+		userMap[user.ID] = user
+	}
+	records := make([]*InviteRecord, 0, len(invites))
+	for _, invite := range invites {
+		invitee := userMap[invite.InviteeID]
+		if invitee == nil {
+			invitee = &model.User{ID: invite.InviteeID, Phone: invite.InviteePhone}
+		}
+		records = append(records, &InviteRecord{Invite: invite, Invitee: invitee})
+	}
+	return records, total, nil
+}
+
+func newInviteCode() (string, error) {
+	code := make([]byte, inviteCodeLength)
+	max := big.NewInt(int64(len(inviteCodeAlphabet)))
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = inviteCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
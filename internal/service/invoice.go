@@ -0,0 +1,256 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+)
+
+type InvoiceService interface {
+	// Request asks for one invoice over the user's paid orders; an order already held by
+	// a pending or issued invoice is refused.
+	Request(ctx context.Context, input InvoiceRequestInput) (*InvoiceDetail, error)
+	// List pages invoices; userID 0 lists every user's.
+	List(ctx context.Context, userID int64, status model.InvoiceStatus, pageNum, pageSize int) ([]*InvoiceDetail, int64, error)
+	Issue(ctx context.Context, operatorID, invoiceID int64, pdfURL string) (*InvoiceDetail, error)
+	// Reject closes the request and frees its orders for a new one.
+	Reject(ctx context.Context, operatorID, invoiceID int64, reason string) (*InvoiceDetail, error)
+}
+
+func NewInvoiceService(
+	service *Service,
+	invoiceRepository repository.InvoiceRepository,
+	orderRepository repository.OrderRepository,
+	refundRepository repository.RefundRepository,
+) InvoiceService {
+	return &invoiceService{
+		Service:           service,
+		invoiceRepository: invoiceRepository,
+		orderRepository:   orderRepository,
+		refundRepository:  refundRepository,
+	}
+}
+
+type invoiceService struct {
+	*Service
+	invoiceRepository repository.InvoiceRepository
+	orderRepository   repository.OrderRepository
+	refundRepository  repository.RefundRepository
+}
+
+type InvoiceRequestInput struct {
+	UserID    int64
+	OrderNos  []string
+	TitleType model.InvoiceTitleType
+	Title     string
+	TaxNo     string
+	Email     string
+}
+
+// InvoiceDetail is an invoice with the orders it covers.
+type InvoiceDetail struct {
+	Invoice *model.Invoice
+	Orders  []*model.InvoiceOrder
+}
+
+// maxInvoiceOrders bounds how many orders one invoice request may cover.
+const maxInvoiceOrders = 50
+
+// taxNoPattern matches a unified social credit code (18) or a legacy taxpayer number (15-20).
+var taxNoPattern = regexp.MustCompile(`^[0-9A-Z]{15,20}$`)
+
+func (s *invoiceService) Request(ctx context.Context, input InvoiceRequestInput) (*InvoiceDetail, error) {
+	input.Title = strings.TrimSpace(input.Title)
+	input.TaxNo = strings.ToUpper(strings.TrimSpace(input.TaxNo))
+	input.Email = strings.TrimSpace(input.Email)
+	if input.Title == "" || len([]rune(input.Title)) > 100 || !strings.Contains(input.Email, "@") {
+		return nil, ErrInvalidInvoice
+	}
+	switch input.TitleType {
+	case model.InvoiceTitlePersonal:
+		input.TaxNo = ""
+	case model.InvoiceTitleCompany:
+		if !taxNoPattern.MatchString(input.TaxNo) {
+			return nil, ErrInvalidInvoice
+		}
+	default:
+		return nil, ErrInvalidInvoice
+	}
+	orderNos := make([]string, 0, len(input.OrderNos))
+	seen := make(map[string]bool, len(input.OrderNos))
+	for _, orderNo := range input.OrderNos {
+		if !seen[orderNo] {
+			seen[orderNo] = true
+			orderNos = append(orderNos, orderNo)
+		}
+	}
+	if len(orderNos) == 0 || len(orderNos) > maxInvoiceOrders {
+		return nil, ErrInvalidInvoice
+	}
+
+	orders, err := s.orderRepository.ListByOrderNos(ctx, orderNos)
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) != len(orderNos) {
+		return nil, ErrOrderNotInvoiceable
+	}
+	now := time.Now()
+	invoice := &model.Invoice{
+		UserID:    input.UserID,
+		TitleType: input.TitleType,
+		Title:     input.Title,
+		TaxNo:     input.TaxNo,
+		Email:     input.Email,
+		Amount:    model.NewDecimalFromCents(0),
+		Status:    model.InvoiceStatusRequested,
+		CreateAt:  now,
+		UpdateAt:  now,
+	}
+	orderIDs := make([]int64, 0, len(orders))
+	links := make([]*model.InvoiceOrder, 0, len(orders))
+	for _, order := range orders {
+		if order.UserID != input.UserID || order.Status != model.OrderStatusPaid || order.InvoiceID != 0 {
+			return nil, ErrOrderNotInvoiceable
+		}
+		amount, err := s.invoiceableAmount(ctx, order)
+		if err != nil {
+			return nil, err
+		}
+		if amount.Sign() <= 0 {
+			return nil, ErrOrderNotInvoiceable
+		}
+		invoice.Amount = invoice.Amount.Add(amount)
+		orderIDs = append(orderIDs, order.ID)
+		links = append(links, &model.InvoiceOrder{
+			OrderID:  order.ID,
+			OrderNo:  order.OrderNo,
+			Amount:   amount,
+			CreateAt: now,
+		})
+	}
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.invoiceRepository.Create(ctx, invoice); err != nil {
+			return err
+		}
+		// A concurrent request for the same order loses here and rolls back.
+		ok, err := s.orderRepository.SetInvoice(ctx, orderIDs, invoice.ID)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrOrderNotInvoiceable
+		}
+		for _, link := range links {
+			link.InvoiceID = invoice.ID
+		}
+		return s.invoiceRepository.CreateOrders(ctx, links)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &InvoiceDetail{Invoice: invoice, Orders: links}, nil
+}
+
+// invoiceableAmount is what the user actually paid for the order, less what has been or
+// is being refunded.
+func (s *invoiceService) invoiceableAmount(ctx context.Context, order *model.Order) (model.Decimal, error) {
+	refunds, err := s.refundRepository.ListByOrderID(ctx, order.ID)
+	if err != nil {
+		return model.Decimal{}, err
+	}
+	amount := order.AmountPaid
+	for _, refund := range refunds {
+		if refund.Status != model.RefundStatusFailed {
+			amount = amount.Sub(refund.Amount)
+		}
+	}
+	return amount, nil
+}
+
+func (s *invoiceService) List(ctx context.Context, userID int64, status model.InvoiceStatus, pageNum, pageSize int) ([]*InvoiceDetail, int64, error) {
+	invoices, total, err := s.invoiceRepository.List(ctx, userID, status, pageNum, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	details, err := s.buildDetails(ctx, invoices)
+	if err != nil {
+		return nil, 0, err
+	}
+	return details, total, nil
+}
+
+func (s *invoiceService) Issue(ctx context.Context, operatorID, invoiceID int64, pdfURL string) (*InvoiceDetail, error) {
+	return s.finish(ctx, invoiceID, func(invoice *model.Invoice) {
+		invoice.Status = model.InvoiceStatusIssued
+		invoice.PdfURL = pdfURL
+		invoice.OperatorID = operatorID
+	})
+}
+
+func (s *invoiceService) Reject(ctx context.Context, operatorID, invoiceID int64, reason string) (*InvoiceDetail, error) {
+	return s.finish(ctx, invoiceID, func(invoice *model.Invoice) {
+		invoice.Status = model.InvoiceStatusRejected
+		invoice.RejectReason = reason
+		invoice.OperatorID = operatorID
+	})
+}
+
+func (s *invoiceService) finish(ctx context.Context, invoiceID int64, apply func(invoice *model.Invoice)) (*InvoiceDetail, error) {
+	invoice, err := s.invoiceRepository.GetByID(ctx, invoiceID)
+	if err != nil {
+		return nil, err
+	}
+	if invoice.Status != model.InvoiceStatusRequested {
+		return nil, ErrInvoiceNotPending
+	}
+	now := time.Now()
+	apply(invoice)
+	invoice.HandledAt = &now
+	invoice.UpdateAt = now
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		ok, err := s.invoiceRepository.Finish(ctx, invoice)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrInvoiceNotPending
+		}
+		if invoice.Status == model.InvoiceStatusRejected {
+			return s.orderRepository.ClearInvoice(ctx, invoice.ID)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	details, err := s.buildDetails(ctx, []*model.Invoice{invoice})
+	if err != nil {
+		return nil, err
+	}
+	return details[0], nil
+}
+
+func (s *invoiceService) buildDetails(ctx context.Context, invoices []*model.Invoice) ([]*InvoiceDetail, error) {
+	invoiceIDs := make([]int64, 0, len(invoices))
+	for _, invoice := range invoices {
+		invoiceIDs = append(invoiceIDs, invoice.ID)
+	}
+	links, err := s.invoiceRepository.ListOrders(ctx, invoiceIDs)
+	if err != nil {
+		return nil, err
+	}
+	byInvoice := make(map[int64][]*model.InvoiceOrder, len(invoices))
+	for _, link := range links {
+		byInvoice[link.InvoiceID] = append(byInvoice[link.InvoiceID], link)
+	}
+	details := make([]*InvoiceDetail, 0, len(invoices))
+	for _, invoice := range invoices {
+		details = append(details, &InvoiceDetail{Invoice: invoice, Orders: byInvoice[invoice.ID]})
+	}
+	return details, nil
+}
@@ -2,10 +2,13 @@ package service
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/go-nunu/nunu-layout-advanced/internal/model"
 	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"go.uber.org/zap"
 )
 
 type JobService interface {
@@ -21,18 +24,41 @@ type JobService interface {
 func NewJobService(
 	service *Service,
 	jobRepository repository.JobRepository,
+	jobRefreshRepository repository.JobRefreshRepository,
+	integralService IntegralService,
+	membershipService MembershipService,
+	tagService TagService,
+	areaService AreaService,
+	jobSearcher JobSearcher,
 ) JobService {
 	return &jobService{
-		Service:       service,
-		jobRepository: jobRepository,
+		Service:              service,
+		jobRepository:        jobRepository,
+		jobRefreshRepository: jobRefreshRepository,
+		integralService:      integralService,
+		membershipService:    membershipService,
+		tagService:           tagService,
+		areaService:          areaService,
+		jobSearcher:          jobSearcher,
 	}
 }
 
 type jobService struct {
 	*Service
-	jobRepository repository.JobRepository
+	jobRepository        repository.JobRepository
+	jobRefreshRepository repository.JobRefreshRepository
+	integralService      IntegralService
+	membershipService    MembershipService
+	tagService           TagService
+	areaService          AreaService
+	jobSearcher          JobSearcher
 }
 
+// maxJobSearchHits caps how many keyword hits are ranked; deeper pages of a search end there.
+const maxJobSearchHits = 500
+
+// JobCreateInput takes BasicProtection, SalaryBenefits and AttendanceLeave as tag codes,
+// or the labels older clients send.
 type JobCreateInput struct {
 	Positions          string
 	CompanyName        string
@@ -53,11 +79,13 @@ type JobCreateInput struct {
 	FourAreaDes        string
 	SalaryMin          int
 	SalaryMax          int
-	BasicProtection    string
-	SalaryBenefits     string
-	AttendanceLeave    string
+	BasicProtection    []string
+	SalaryBenefits     []string
+	AttendanceLeave    []string
 }
 
+// JobUpdateInput leaves nil fields as they are. For the tag lists an empty, non-nil list
+// clears the category.
 type JobUpdateInput struct {
 	ID              int64
 	Positions       *string
@@ -78,19 +106,27 @@ type JobUpdateInput struct {
 	FourAreaDes     *string
 	SalaryMin       *int
 	SalaryMax       *int
-	BasicProtection *string
-	SalaryBenefits  *string
-	AttendanceLeave *string
+	BasicProtection []string
+	SalaryBenefits  []string
+	AttendanceLeave []string
 }
 
 func (s *jobService) Create(ctx context.Context, userID int64, input JobCreateInput) (*model.Job, error) {
+	benefits, err := s.membershipService.GetBenefits(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
 	total, err := s.jobRepository.CountByUser(ctx, userID, model.JobStatusActive)
 	if err != nil {
 		return nil, err
 	}
-	if total >= 5 {
+	if total >= benefits.JobLimit {
 		return nil, ErrJobLimitExceeded
 	}
+	tags, err := s.resolveJobTags(ctx, input.BasicProtection, input.SalaryBenefits, input.AttendanceLeave)
+	if err != nil {
+		return nil, err
+	}
 	now := time.Now()
 	job := &model.Job{
 		UserID:            userID,
@@ -114,15 +150,30 @@ func (s *jobService) Create(ctx context.Context, userID int64, input JobCreateIn
 		FourAreaDes:       input.FourAreaDes,
 		SalaryMin:         input.SalaryMin,
 		SalaryMax:         input.SalaryMax,
-		BasicProtection:   input.BasicProtection,
-		SalaryBenefits:    input.SalaryBenefits,
-		AttendanceLeave:   input.AttendanceLeave,
 		CreateAt:          now,
 		UpdateAt:          now,
 	}
-	if err := s.jobRepository.Create(ctx, job); err != nil {
+	if err := s.areaService.NormalizeJob(ctx, job); err != nil {
 		return nil, err
 	}
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.jobRepository.Create(ctx, job); err != nil {
+			return err
+		}
+		return s.setJobTags(ctx, job.ID, tags)
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.syncSearch(ctx, job)
+	if _, err := s.integralService.Earn(ctx, IntegralEarnInput{
+		UserID:  userID,
+		BizType: model.IntegralHistoryFirstJob,
+		BizKey:  "first_job",
+		Remark:  "首次发布招聘",
+	}); err != nil {
+		s.logger.WithContext(ctx).Error("integralService.Earn error", zap.Int64("user_id", userID), zap.Error(err))
+	}
 	return job, nil
 }
 
@@ -188,18 +239,28 @@ func (s *jobService) Update(ctx context.Context, userID int64, input JobUpdateIn
 	if input.SalaryMax != nil {
 		job.SalaryMax = *input.SalaryMax
 	}
-	if input.BasicProtection != nil {
-		job.BasicProtection = *input.BasicProtection
+	if err := s.areaService.NormalizeJob(ctx, job); err != nil {
+		return err
 	}
-	if input.SalaryBenefits != nil {
-		job.SalaryBenefits = *input.SalaryBenefits
+	tags, err := s.resolveJobTags(ctx, input.BasicProtection, input.SalaryBenefits, input.AttendanceLeave)
+	if err != nil {
+		return err
 	}
-	if input.AttendanceLeave != nil {
-		job.AttendanceLeave = *input.AttendanceLeave
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		if err := s.jobRepository.Update(ctx, job); err != nil {
+			return err
+		}
+		return s.setJobTags(ctx, job.ID, tags)
+	})
+	if err != nil {
+		return err
 	}
-	return s.jobRepository.Update(ctx, job)
+	s.syncSearch(ctx, job)
+	return nil
 }
 
+// Refresh uses one of the user's free refreshes for today; beyond the allowance the
+// client has to go through /jobs/refresh/pay.
 func (s *jobService) Refresh(ctx context.Context, userID, jobID int64) error {
 	job, err := s.jobRepository.GetByID(ctx, jobID)
 	if err != nil {
@@ -208,9 +269,40 @@ func (s *jobService) Refresh(ctx context.Context, userID, jobID int64) error {
 	if job.UserID != userID {
 		return ErrForbidden
 	}
+	benefits, err := s.membershipService.GetBenefits(ctx, userID)
+	if err != nil {
+		return err
+	}
 	now := time.Now()
-	job.RefreshTime = &now
-	return s.jobRepository.Update(ctx, job)
+	day := now.Format("20060102")
+	used, err := s.jobRefreshRepository.CountByUserDay(ctx, userID, day)
+	if err != nil {
+		return err
+	}
+	if used >= int64(benefits.FreeRefreshDaily) {
+		return ErrRefreshLimitExceeded
+	}
+	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		// A concurrent refresh taking the same seq fails on uk_user_day_seq.
+		if err := s.jobRefreshRepository.Create(ctx, &model.JobRefresh{
+			UserID:     userID,
+			JobID:      jobID,
+			RefreshDay: day,
+			Seq:        int(used) + 1,
+			CreateAt:   now,
+		}); err != nil {
+			return err
+		}
+		job.RefreshTime = &now
+		return s.jobRepository.Update(ctx, job)
+	})
+	if err != nil {
+		if again, countErr := s.jobRefreshRepository.CountByUserDay(ctx, userID, day); countErr == nil && again > used {
+			return ErrRefreshLimitExceeded
+		}
+		return err
+	}
+	return nil
 }
 
 func (s *jobService) Close(ctx context.Context, userID, jobID int64) error {
@@ -222,7 +314,50 @@ func (s *jobService) Close(ctx context.Context, userID, jobID int64) error {
 		return ErrForbidden
 	}
 	job.Status = model.JobStatusUserClosed
-	return s.jobRepository.Update(ctx, job)
+	if err := s.jobRepository.Update(ctx, job); err != nil {
+		return err
+	}
+	s.syncSearch(ctx, job)
+	return nil
+}
+
+// resolveJobTags looks up the tags sent for each category. A nil list is left out, so the
+// job keeps what it has there.
+func (s *jobService) resolveJobTags(ctx context.Context, basicProtection, salaryBenefits, attendanceLeave []string) (map[model.TagCategory][]*model.Tag, error) {
+	lists := map[model.TagCategory][]string{
+		model.TagCategoryBasicProtection: basicProtection,
+		model.TagCategorySalaryBenefits:  salaryBenefits,
+		model.TagCategoryAttendanceLeave: attendanceLeave,
+	}
+	tags := make(map[model.TagCategory][]*model.Tag, len(lists))
+	for category, values := range lists {
+		if values == nil {
+			continue
+		}
+		resolved, err := s.tagService.Resolve(ctx, category, values)
+		if err != nil {
+			return nil, err
+		}
+		tags[category] = resolved
+	}
+	return tags, nil
+}
+
+func (s *jobService) setJobTags(ctx context.Context, jobID int64, tags map[model.TagCategory][]*model.Tag) error {
+	for category, list := range tags {
+		if err := s.tagService.SetJobTags(ctx, jobID, category, list); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// syncSearch hands a saved job to the searcher. The job is already stored, so a failure
+// is only logged; the job's search hits are stale until it is saved again.
+func (s *jobService) syncSearch(ctx context.Context, job *model.Job) {
+	if err := s.jobSearcher.Index(ctx, job); err != nil {
+		s.logger.WithContext(ctx).Error("jobSearcher.Index error", zap.Int64("job_id", job.ID), zap.Error(err))
+	}
 }
 
 func (s *jobService) GetByID(ctx context.Context, jobID int64) (*model.Job, error) {
@@ -230,7 +365,83 @@ func (s *jobService) GetByID(ctx context.Context, jobID int64) (*model.Job, erro
 }
 
 func (s *jobService) List(ctx context.Context, query repository.JobListQuery) ([]*model.Job, int64, error) {
-	return s.jobRepository.List(ctx, query)
+	tags, err := s.resolveJobTags(ctx, query.BasicProtection, query.SalaryBenefits, query.AttendanceLeave)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, list := range tags {
+		for _, tag := range list {
+			query.TagIDs = append(query.TagIDs, tag.ID)
+		}
+	}
+	if strings.TrimSpace(query.Keyword) == "" {
+		return s.jobRepository.List(ctx, query)
+	}
+	hits, err := s.jobSearcher.Search(ctx, query.Keyword, maxJobSearchHits)
+	if err != nil {
+		return nil, 0, err
+	}
+	if len(hits) == 0 {
+		return []*model.Job{}, 0, nil
+	}
+	relevance := make(map[int64]float64, len(hits))
+	query.IDs = make([]int64, 0, len(hits))
+	for _, hit := range hits {
+		relevance[hit.JobID] = hit.Score
+		query.IDs = append(query.IDs, hit.JobID)
+	}
+	pageNum, pageSize := query.PageNum, query.PageSize
+	if pageNum <= 0 {
+		pageNum = 1
+	}
+	if pageSize <= 0 {
+		pageSize = 10
+	}
+	// The other filters still apply, so load every hit that passes them and rank here.
+	query.PageNum, query.PageSize = 1, len(hits)
+	jobs, total, err := s.jobRepository.List(ctx, query)
+	if err != nil {
+		return nil, 0, err
+	}
+	now := time.Now()
+	scores := make(map[int64]float64, len(jobs))
+	for _, job := range jobs {
+		scores[job.ID] = relevance[job.ID] * searchBoost(job, now)
+	}
+	sort.SliceStable(jobs, func(i, j int) bool {
+		if scores[jobs[i].ID] != scores[jobs[j].ID] {
+			return scores[jobs[i].ID] > scores[jobs[j].ID]
+		}
+		return jobs[i].ID > jobs[j].ID
+	})
+	start := (pageNum - 1) * pageSize
+	if start >= len(jobs) {
+		return []*model.Job{}, total, nil
+	}
+	end := start + pageSize
+	if end > len(jobs) {
+		end = len(jobs)
+	}
+	return jobs[start:end], total, nil
+}
+
+// searchBoost scales a job's text relevance: a running top doubles it, and a fresh
+// refresh (or a new job) adds up to half again, fading over the following days. The top
+// is read from IsTop, the same flag the unfiltered list orders by.
+func searchBoost(job *model.Job, now time.Time) float64 {
+	boost := 1.0
+	if job.IsTop {
+		boost += 1
+	}
+	fresh := job.CreateAt
+	if job.RefreshTime != nil && job.RefreshTime.After(fresh) {
+		fresh = *job.RefreshTime
+	}
+	days := now.Sub(fresh).Hours() / 24
+	if days < 0 {
+		days = 0
+	}
+	return boost + 0.5/(1+days)
 }
 
 func (s *jobService) ListByUser(ctx context.Context, userID int64, bizType int, pageNum, pageSize int) ([]*model.Job, int64, error) {
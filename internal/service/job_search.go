@@ -0,0 +1,314 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"sync"
+	"time"
+	"unicode"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/spf13/viper"
+)
+
+// JobSearcher finds active jobs by keyword. Hits only carry text relevance; the caller
+// reloads the jobs to apply its other filters and the top/refresh boosts.
+type JobSearcher interface {
+	// Index adds or replaces a job; a job that is no longer active is dropped instead.
+	Index(ctx context.Context, job *model.Job) error
+	Remove(ctx context.Context, jobID int64) error
+	// Search returns at most limit jobs containing every term of keyword, best first.
+	Search(ctx context.Context, keyword string, limit int) ([]JobSearchHit, error)
+}
+
+type JobSearchHit struct {
+	JobID int64
+	Score float64
+}
+
+// NewJobSearcher returns the in-process searcher. It builds its index from the active jobs
+// on first use and is kept current by JobService. Every process holds its own copy, so
+// once the index is older than JobSearchSyncInterval the next call first reindexes the jobs
+// whose update_at moved since, which brings in what other instances and tasks wrote.
+func NewJobSearcher(jobRepository repository.JobRepository, conf *viper.Viper) JobSearcher {
+	return &memoryJobSearcher{
+		jobRepository: jobRepository,
+		syncInterval:  JobSearchSyncInterval(conf),
+		postings:      make(map[string]map[int64]float64),
+		terms:         make(map[int64][]string),
+		updated:       make(map[int64]time.Time),
+	}
+}
+
+// JobSearchSyncInterval is how stale the search index may get (job.search_sync_interval,
+// default 1m).
+func JobSearchSyncInterval(conf *viper.Viper) time.Duration {
+	if interval := conf.GetDuration("job.search_sync_interval"); interval > 0 {
+		return interval
+	}
+	return time.Minute
+}
+
+// jobSearchSyncOverlap reaches back before the last sync, so a job whose transaction
+// stamped update_at before the sync started but committed after it is still picked up.
+const jobSearchSyncOverlap = time.Minute
+
+// jobSearchPageSize is how many jobs a load or sync reads per query.
+const jobSearchPageSize = 500
+
+// Field weights: a hit in the positions counts three times one in the description.
+const (
+	jobSearchPositionsWeight   = 3
+	jobSearchCompanyNameWeight = 2
+	jobSearchAreaWeight        = 1.5
+	jobSearchDescriptionWeight = 1
+)
+
+// jobSearchSaturation is BM25's k1: repeating a term helps less each time.
+const jobSearchSaturation = 1.2
+
+type memoryJobSearcher struct {
+	jobRepository repository.JobRepository
+	syncInterval  time.Duration
+
+	// syncMu lets one caller at a time read the database for a load or a sync, while
+	// searches keep using the current index.
+	syncMu sync.Mutex
+	mu     sync.RWMutex
+	loaded bool
+	// syncedAt is when the last load or sync started reading.
+	syncedAt time.Time
+	// postings maps a term to the weighted frequency of that term in each job.
+	postings map[string]map[int64]float64
+	// terms keeps each job's terms so it can be taken out of postings again.
+	terms map[int64][]string
+	// updated is the update_at of the copy of each job last put, inactive ones included.
+	updated map[int64]time.Time
+}
+
+func (s *memoryJobSearcher) Index(ctx context.Context, job *model.Job) error {
+	if err := s.load(ctx); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.put(job)
+	return nil
+}
+
+func (s *memoryJobSearcher) Remove(ctx context.Context, jobID int64) error {
+	if err := s.load(ctx); err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.remove(jobID)
+	return nil
+}
+
+func (s *memoryJobSearcher) Search(ctx context.Context, keyword string, limit int) ([]JobSearchHit, error) {
+	if err := s.load(ctx); err != nil {
+		return nil, err
+	}
+	terms := dedupTerms(tokenize(keyword, false))
+	if len(terms) == 0 {
+		return nil, nil
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	// Walk the rarest term's postings and check the others against them.
+	sort.Slice(terms, func(i, j int) bool { return len(s.postings[terms[i]]) < len(s.postings[terms[j]]) })
+	docs := float64(len(s.terms))
+	idf := make([]float64, len(terms))
+	for i, term := range terms {
+		df := float64(len(s.postings[term]))
+		idf[i] = math.Log(1 + (docs-df+0.5)/(df+0.5))
+	}
+	var hits []JobSearchHit
+	for jobID := range s.postings[terms[0]] {
+		score := 0.0
+		for i, term := range terms {
+			freq, ok := s.postings[term][jobID]
+			if !ok {
+				score = -1
+				break
+			}
+			score += idf[i] * freq * (jobSearchSaturation + 1) / (freq + jobSearchSaturation)
+		}
+		if score >= 0 {
+			hits = append(hits, JobSearchHit{JobID: jobID, Score: score})
+		}
+	}
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		return hits[i].JobID > hits[j].JobID
+	})
+	if limit > 0 && len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// load builds the index from the database the first time it is needed, and afterwards
+// reindexes the jobs updated since the last sync once syncInterval has passed. A failed
+// load or sync is retried by the next call.
+func (s *memoryJobSearcher) load(ctx context.Context) error {
+	if s.fresh() {
+		return nil
+	}
+	s.syncMu.Lock()
+	defer s.syncMu.Unlock()
+	if s.fresh() {
+		return nil
+	}
+	s.mu.RLock()
+	loaded, since := s.loaded, s.syncedAt.Add(-jobSearchSyncOverlap)
+	s.mu.RUnlock()
+
+	started := time.Now()
+	var (
+		jobs    []*model.Job
+		afterID int64
+	)
+	for {
+		var (
+			page []*model.Job
+			err  error
+		)
+		if loaded {
+			page, err = s.jobRepository.ListUpdatedSince(ctx, since, afterID, jobSearchPageSize)
+		} else {
+			page, err = s.jobRepository.ListActiveAfterID(ctx, afterID, jobSearchPageSize)
+		}
+		if err != nil {
+			return err
+		}
+		if len(page) == 0 {
+			break
+		}
+		jobs = append(jobs, page...)
+		afterID = page[len(page)-1].ID
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, job := range jobs {
+		// Index may have put a newer copy in while the page was read.
+		if indexed, ok := s.updated[job.ID]; ok && indexed.After(job.UpdateAt) {
+			continue
+		}
+		s.put(job)
+	}
+	s.loaded, s.syncedAt = true, started
+	return nil
+}
+
+func (s *memoryJobSearcher) fresh() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.loaded && time.Since(s.syncedAt) < s.syncInterval
+}
+
+func (s *memoryJobSearcher) put(job *model.Job) {
+	s.remove(job.ID)
+	s.updated[job.ID] = job.UpdateAt
+	if job.Status != model.JobStatusActive {
+		return
+	}
+	freqs := make(map[string]float64)
+	add := func(text string, weight float64) {
+		for _, term := range tokenize(text, true) {
+			freqs[term] += weight
+		}
+	}
+	add(job.Positions, jobSearchPositionsWeight)
+	add(job.CompanyName, jobSearchCompanyNameWeight)
+	for _, area := range []string{job.FirstAreaDes, job.SecondAreaDes, job.ThirdAreaDes, job.FourAreaDes} {
+		add(area, jobSearchAreaWeight)
+	}
+	add(job.Description, jobSearchDescriptionWeight)
+
+	terms := make([]string, 0, len(freqs))
+	for term, freq := range freqs {
+		postings := s.postings[term]
+		if postings == nil {
+			postings = make(map[int64]float64)
+			s.postings[term] = postings
+		}
+		postings[job.ID] = freq
+		terms = append(terms, term)
+	}
+	s.terms[job.ID] = terms
+}
+
+func (s *memoryJobSearcher) remove(jobID int64) {
+	for _, term := range s.terms[jobID] {
+		delete(s.postings[term], jobID)
+		if len(s.postings[term]) == 0 {
+			delete(s.postings, term)
+		}
+	}
+	delete(s.terms, jobID)
+}
+
+// tokenize splits text into lower-cased words of letters and digits, and runs of Chinese
+// characters into overlapping pairs, so "火锅店" gives 火锅 and 锅店. Indexed text also
+// gets every single character so that one-character keywords match; a keyword only uses a
+// single character when it stands alone.
+func tokenize(text string, indexing bool) []string {
+	var (
+		terms []string
+		word  []rune
+		han   []rune
+	)
+	flushWord := func() {
+		if len(word) > 0 {
+			terms = append(terms, string(word))
+			word = word[:0]
+		}
+	}
+	flushHan := func() {
+		if len(han) == 1 || indexing {
+			for _, r := range han {
+				terms = append(terms, string(r))
+			}
+		}
+		for i := 0; i+1 < len(han); i++ {
+			terms = append(terms, string(han[i:i+2]))
+		}
+		han = han[:0]
+	}
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			flushWord()
+			han = append(han, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			flushHan()
+			word = append(word, unicode.ToLower(r))
+		default:
+			flushWord()
+			flushHan()
+		}
+	}
+	flushWord()
+	flushHan()
+	return terms
+}
+
+func dedupTerms(terms []string) []string {
+	seen := make(map[string]bool, len(terms))
+	out := terms[:0]
+	for _, term := range terms {
+		if !seen[term] {
+			seen[term] = true
+			out = append(out, term)
+		}
+	}
+	return out
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+type MembershipService interface {
+	// GetBenefits returns what the user is entitled to right now, member or not.
+	GetBenefits(ctx context.Context, userID int64) (*MembershipBenefits, error)
+	// Extend adds days to the user's membership, starting a new one if it has lapsed.
+	Extend(ctx context.Context, userID int64, days int) (*model.Membership, error)
+	// Shorten takes up to days off an active membership and returns the days removed. Allowance
+	// vouchers outlasting the new end expire with it, and go back at once if it ends now.
+	Shorten(ctx context.Context, userID int64, days int) (int, error)
+	GrantAllowances(ctx context.Context, now time.Time) (int, error)
+}
+
+func NewMembershipService(
+	service *Service,
+	membershipRepository repository.MembershipRepository,
+	contactVoucherHistoryService ContactVoucherHistoryService,
+	config *viper.Viper,
+) MembershipService {
+	return &membershipService{
+		Service:                      service,
+		membershipRepository:         membershipRepository,
+		contactVoucherHistoryService: contactVoucherHistoryService,
+		config:                       config,
+	}
+}
+
+type membershipService struct {
+	*Service
+	membershipRepository         repository.MembershipRepository
+	contactVoucherHistoryService ContactVoucherHistoryService
+	config                       *viper.Viper
+}
+
+// MembershipBenefits are the limits in force for a user. Non-members get the defaults
+// (job.active_limit, job.free_refresh_daily); members get the membership.* values.
+// TopPricePercent is the share of the list price paid for top hours.
+type MembershipBenefits struct {
+	Active           bool
+	EndAt            *time.Time
+	JobLimit         int64
+	FreeRefreshDaily int
+	MonthlyVouchers  int
+	TopPricePercent  int
+}
+
+// The allowance is granted every 30 days while the membership lasts.
+const membershipGrantPeriod = 30 * 24 * time.Hour
+
+func (s *membershipService) GetBenefits(ctx context.Context, userID int64) (*MembershipBenefits, error) {
+	membership, err := s.membershipRepository.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !membership.Active(time.Now()) {
+		return &MembershipBenefits{
+			JobLimit:         int64(configInt(s.config, "job.active_limit", 5)),
+			FreeRefreshDaily: configInt(s.config, "job.free_refresh_daily", 1),
+			TopPricePercent:  100,
+		}, nil
+	}
+	endAt := membership.EndAt
+	return &MembershipBenefits{
+		Active:           true,
+		EndAt:            &endAt,
+		JobLimit:         int64(configInt(s.config, "membership.job_limit", 20)),
+		FreeRefreshDaily: configInt(s.config, "membership.free_refresh_daily", 5),
+		MonthlyVouchers:  configInt(s.config, "membership.monthly_vouchers", 10),
+		TopPricePercent:  configInt(s.config, "membership.top_price_percent", 80),
+	}, nil
+}
+
+func (s *membershipService) Extend(ctx context.Context, userID int64, days int) (*model.Membership, error) {
+	if days <= 0 {
+		return nil, ErrProductNotFound
+	}
+	var membership *model.Membership
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		membership, err = s.membershipRepository.GetByUserIDForUpdate(ctx, userID)
+		if err != nil {
+			return err
+		}
+		// Whole seconds, so the conditional update in grantAllowance matches what the
+		// database stored whatever its datetime precision.
+		now := time.Now().Truncate(time.Second)
+		period := time.Duration(days) * 24 * time.Hour
+		switch {
+		case membership == nil:
+			membership = &model.Membership{
+				UserID:      userID,
+				StartAt:     now,
+				EndAt:       now.Add(period),
+				NextGrantAt: now,
+				CreateAt:    now,
+				UpdateAt:    now,
+			}
+			if err := s.membershipRepository.Create(ctx, membership); err != nil {
+				return err
+			}
+		case !membership.Active(now):
+			membership.StartAt = now
+			membership.EndAt = now.Add(period)
+			membership.NextGrantAt = now
+			membership.UpdateAt = now
+			if err := s.membershipRepository.Update(ctx, membership); err != nil {
+				return err
+			}
+		default:
+			membership.EndAt = membership.EndAt.Add(period)
+			membership.UpdateAt = now
+			if err := s.membershipRepository.Update(ctx, membership); err != nil {
+				return err
+			}
+		}
+		if membership.NextGrantAt.After(now) {
+			return nil
+		}
+		_, err = s.grantAllowance(ctx, membership, now)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return membership, nil
+}
+
+func (s *membershipService) Shorten(ctx context.Context, userID int64, days int) (int, error) {
+	removed := 0
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		membership, err := s.membershipRepository.GetByUserIDForUpdate(ctx, userID)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		if days <= 0 || !membership.Active(now) {
+			return nil
+		}
+		end := membership.EndAt.Add(-time.Duration(days) * 24 * time.Hour)
+		removed = days
+		if end.Before(now) {
+			removed = int(math.Ceil(membership.EndAt.Sub(now).Hours() / 24))
+			end = now
+		}
+		membership.EndAt = end
+		membership.UpdateAt = now
+		if err := s.membershipRepository.Update(ctx, membership); err != nil {
+			return err
+		}
+		_, err = s.contactVoucherHistoryService.CapBatches(ctx, userID, model.ContactVoucherHistoryMembership, end, "会员退款扣回")
+		return err
+	})
+	return removed, err
+}
+
+// GrantAllowances hands out the monthly vouchers that have come due, one membership per
+// transaction, and returns how many vouchers were granted.
+func (s *membershipService) GrantAllowances(ctx context.Context, now time.Time) (int, error) {
+	const batchSize = 100
+	granted := 0
+	for {
+		memberships, err := s.membershipRepository.ListGrantDue(ctx, now, batchSize)
+		if err != nil {
+			return granted, err
+		}
+		progressed := false
+		for _, membership := range memberships {
+			var num int
+			err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+				var err error
+				num, err = s.grantAllowance(ctx, membership, now)
+				return err
+			})
+			if err != nil {
+				s.logger.WithContext(ctx).Error("grant membership allowance error", zap.Int64("membership_id", membership.ID), zap.Error(err))
+				continue
+			}
+			granted += num
+			progressed = true
+		}
+		if len(memberships) < batchSize || !progressed {
+			return granted, nil
+		}
+	}
+}
+
+// grantAllowance credits one month of vouchers, expiring with the month or the membership
+// whichever comes first, and moves NextGrantAt on. It must run in a transaction.
+func (s *membershipService) grantAllowance(ctx context.Context, membership *model.Membership, now time.Time) (int, error) {
+	next := membership.NextGrantAt.Add(membershipGrantPeriod)
+	if !next.After(now) {
+		// Missed periods (the task was down) are not paid out retroactively.
+		next = now.Truncate(time.Second).Add(membershipGrantPeriod)
+	}
+	ok, err := s.membershipRepository.AdvanceNextGrant(ctx, membership.ID, membership.NextGrantAt, next)
+	if err != nil || !ok {
+		return 0, err
+	}
+	membership.NextGrantAt = next
+	num := configInt(s.config, "membership.monthly_vouchers", 10)
+	if num <= 0 {
+		return 0, nil
+	}
+	expireAt := next
+	if membership.EndAt.Before(expireAt) {
+		expireAt = membership.EndAt
+	}
+	if _, err := s.contactVoucherHistoryService.GrantVoucher(ctx, VoucherGrantInput{
+		UserID:   membership.UserID,
+		BizType:  model.ContactVoucherHistoryMembership,
+		Num:      num,
+		Remark:   "会员每月联系券",
+		ExpireAt: expireAt,
+	}); err != nil {
+		return 0, err
+	}
+	return num, nil
+}
+
+// configInt reads key, using def when it is not configured; an explicit 0 is kept.
+func configInt(conf *viper.Viper, key string, def int) int {
+	if conf.IsSet(key) {
+		return conf.GetInt(key)
+	}
+	return def
+}
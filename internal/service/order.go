@@ -2,19 +2,45 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/go-nunu/nunu-layout-advanced/internal/model"
 	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
 )
 
+// OrderDetail is an order with its items, the jobs they target and the latest invoice
+// request covering it, if any.
+type OrderDetail struct {
+	Order   *model.Order
+	Items   []*model.OrderItem
+	Jobs    map[int64]*model.Job
+	Invoice *model.Invoice
+}
+
 type OrderService interface {
-	CreateTopOrder(ctx context.Context, userID, jobID int64, topHour int, price float64) (*model.Order, *model.OrderItem, error)
-	CreateContactVoucherOrder(ctx context.Context, userID int64, price float64, voucherNum int) (*model.Order, *model.OrderItem, error)
-	CreateRefreshOrder(ctx context.Context, userID, jobID int64, price float64) (*model.Order, *model.OrderItem, error)
-	PayOrder(ctx context.Context, userID, orderID int64, orderNo string, amount float64, payChannel, payTradeNo string) (*model.Order, error)
-	PayOrderByNotify(ctx context.Context, orderNo string, amount float64, payChannel, payTradeNo string) (*model.Order, error)
+	CreateOrder(ctx context.Context, userID int64, lines []LineItem, couponID int64, idempotencyKey string) (*model.Order, []*model.OrderItem, error)
+	CreateTopOrder(ctx context.Context, userID, jobID, skuID, couponID int64, idempotencyKey string) (*model.Order, *model.OrderItem, error)
+	CreateContactVoucherOrder(ctx context.Context, userID, skuID, couponID int64, idempotencyKey string) (*model.Order, *model.OrderItem, error)
+	CreateRefreshOrder(ctx context.Context, userID, jobID, skuID, couponID int64, idempotencyKey string) (*model.Order, *model.OrderItem, error)
+	CreateMembershipOrder(ctx context.Context, userID, skuID, couponID int64, idempotencyKey string) (*model.Order, *model.OrderItem, error)
+	ConfirmOrder(ctx context.Context, userID int64, orderNo string) (*model.Order, error)
+	CancelOrder(ctx context.Context, userID int64, orderNo string) (*model.Order, error)
+	ExpirePendingOrders(ctx context.Context, createdBefore time.Time) (int, error)
+	// ExpireTops takes the jobs whose top placement has ended off the top and tells their owners.
+	ExpireTops(ctx context.Context, now time.Time) (int, error)
+	PurgeIdempotencyKeys(ctx context.Context) (int64, error)
+	ListByUser(ctx context.Context, userID int64, status model.OrderStatus, pageNum, pageSize int) ([]*OrderDetail, int64, error)
+	GetMyOrder(ctx context.Context, userID int64, orderNo string) (*OrderDetail, error)
+	GetOrder(ctx context.Context, orderNo string) (*OrderDetail, error)
+	PayOrderByNotify(ctx context.Context, orderNo string, amount int64, payChannel, payTradeNo string) (*model.Order, error)
 }
 
 func NewOrderService(
@@ -22,160 +48,582 @@ func NewOrderService(
 	orderRepository repository.OrderRepository,
 	orderItemRepository repository.OrderItemRepository,
 	jobRepository repository.JobRepository,
-	userRepository repository.UserRepository,
-	contactVoucherHistoryRepository repository.ContactVoucherHistoryRepository,
+	invoiceRepository repository.InvoiceRepository,
+	notificationRepository repository.NotificationRepository,
+	contactVoucherHistoryService ContactVoucherHistoryService,
+	membershipService MembershipService,
+	couponService CouponService,
+	idempotencyKeyRepository repository.IdempotencyKeyRepository,
+	productService ProductService,
+	paymentProvider PaymentProvider,
+	refundService RefundService,
+	config *viper.Viper,
 ) OrderService {
 	return &orderService{
-		Service:                         service,
-		orderRepository:                 orderRepository,
-		orderItemRepository:             orderItemRepository,
-		jobRepository:                   jobRepository,
-		userRepository:                  userRepository,
-		contactVoucherHistoryRepository: contactVoucherHistoryRepository,
+		Service:                      service,
+		config:                       config,
+		idempotencyKeyRepository:     idempotencyKeyRepository,
+		productService:               productService,
+		paymentProvider:              paymentProvider,
+		refundService:                refundService,
+		orderRepository:              orderRepository,
+		orderItemRepository:          orderItemRepository,
+		jobRepository:                jobRepository,
+		invoiceRepository:            invoiceRepository,
+		notificationRepository:       notificationRepository,
+		contactVoucherHistoryService: contactVoucherHistoryService,
+		membershipService:            membershipService,
+		couponService:                couponService,
 	}
 }
 
 type orderService struct {
 	*Service
-	orderRepository                 repository.OrderRepository
-	orderItemRepository             repository.OrderItemRepository
-	jobRepository                   repository.JobRepository
-	userRepository                  repository.UserRepository
-	contactVoucherHistoryRepository repository.ContactVoucherHistoryRepository
+	config                       *viper.Viper
+	idempotencyKeyRepository     repository.IdempotencyKeyRepository
+	orderRepository              repository.OrderRepository
+	orderItemRepository          repository.OrderItemRepository
+	jobRepository                repository.JobRepository
+	invoiceRepository            repository.InvoiceRepository
+	notificationRepository       repository.NotificationRepository
+	contactVoucherHistoryService ContactVoucherHistoryService
+	membershipService            MembershipService
+	couponService                CouponService
+	productService               ProductService
+	paymentProvider              PaymentProvider
+	refundService                RefundService
+}
+
+// LineItem is one line of an order: a SKU and, for top and refresh SKUs, the job it is
+// bought for. A zero ProductType accepts a SKU of any type.
+type LineItem struct {
+	ProductType model.ProductType
+	SkuID       int64
+	JobID       int64
+}
+
+// maxOrderLines bounds a cart so one order stays within a single payment description.
+const maxOrderLines = 10
+
+// CreateOrder prices every line server-side and places them as one order to be paid at once.
+func (s *orderService) CreateOrder(ctx context.Context, userID int64, lines []LineItem, couponID int64, idempotencyKey string) (*model.Order, []*model.OrderItem, error) {
+	payload := map[string]interface{}{"lines": lines, "coupon_id": couponID}
+	return s.createOnce(ctx, userID, idempotencyKey, "cart", payload, func(ctx context.Context) (*model.Order, []*model.OrderItem, error) {
+		return s.createOrder(ctx, userID, lines, couponID)
+	})
+}
+
+func (s *orderService) CreateTopOrder(ctx context.Context, userID, jobID, skuID, couponID int64, idempotencyKey string) (*model.Order, *model.OrderItem, error) {
+	payload := map[string]int64{"job_id": jobID, "sku_id": skuID, "coupon_id": couponID}
+	line := LineItem{ProductType: model.ProductTypeTop, SkuID: skuID, JobID: jobID}
+	return s.createSingle(ctx, userID, idempotencyKey, "top", payload, line, couponID)
+}
+
+func (s *orderService) CreateContactVoucherOrder(ctx context.Context, userID, skuID, couponID int64, idempotencyKey string) (*model.Order, *model.OrderItem, error) {
+	payload := map[string]int64{"sku_id": skuID, "coupon_id": couponID}
+	line := LineItem{ProductType: model.ProductTypeContactVoucher, SkuID: skuID}
+	return s.createSingle(ctx, userID, idempotencyKey, "contact_voucher", payload, line, couponID)
+}
+
+func (s *orderService) CreateRefreshOrder(ctx context.Context, userID, jobID, skuID, couponID int64, idempotencyKey string) (*model.Order, *model.OrderItem, error) {
+	payload := map[string]int64{"job_id": jobID, "sku_id": skuID, "coupon_id": couponID}
+	line := LineItem{ProductType: model.ProductTypeRefresh, SkuID: skuID, JobID: jobID}
+	return s.createSingle(ctx, userID, idempotencyKey, "refresh", payload, line, couponID)
+}
+
+func (s *orderService) CreateMembershipOrder(ctx context.Context, userID, skuID, couponID int64, idempotencyKey string) (*model.Order, *model.OrderItem, error) {
+	payload := map[string]int64{"sku_id": skuID, "coupon_id": couponID}
+	line := LineItem{ProductType: model.ProductTypeMembership, SkuID: skuID}
+	return s.createSingle(ctx, userID, idempotencyKey, "membership", payload, line, couponID)
 }
 
-func (s *orderService) CreateTopOrder(ctx context.Context, userID, jobID int64, topHour int, price float64) (*model.Order, *model.OrderItem, error) {
-	job, err := s.jobRepository.GetByID(ctx, jobID)
+// createSingle backs the one-product endpoints, which keep their own idempotency scopes.
+func (s *orderService) createSingle(ctx context.Context, userID int64, key, scope string, payload interface{}, line LineItem, couponID int64) (*model.Order, *model.OrderItem, error) {
+	order, items, err := s.createOnce(ctx, userID, key, scope, payload, func(ctx context.Context) (*model.Order, []*model.OrderItem, error) {
+		return s.createOrder(ctx, userID, []LineItem{line}, couponID)
+	})
 	if err != nil {
 		return nil, nil, err
 	}
-	if job.UserID != userID {
-		return nil, nil, ErrForbidden
-	}
-	order := &model.Order{
-		OrderNo:     s.generateOrderNo("TOP"),
-		UserID:      userID,
-		AmountTotal: model.NewDecimalFromFloat64(price),
-		AmountPaid:  model.NewDecimalFromFloat64(0),
-		Currency:    "CNY",
-		Status:      model.OrderStatusPending,
-		CreateAt:    time.Now(),
-		UpdateAt:    time.Now(),
-	}
-	item := &model.OrderItem{
-		ProductType:       model.ProductTypeTop,
-		TitleSnapshot:     fmt.Sprintf("置顶套餐-%d小时", topHour),
-		TopHour:           topHour,
-		UnitPriceSnapshot: price,
-		TargetType:        model.OrderTargetJob,
-		TargetID:          jobID,
-		CreateAt:          time.Now(),
-		UpdateAt:          time.Now(),
+	return order, items[0], nil
+}
+
+// createOnce runs create at most once per (user, idempotency key) while the key is alive.
+// A repeated request gets the order created the first time; the same key with another
+// endpoint or payload is rejected. An empty key disables the check.
+func (s *orderService) createOnce(ctx context.Context, userID int64, key, scope string, payload interface{}, create func(ctx context.Context) (*model.Order, []*model.OrderItem, error)) (*model.Order, []*model.OrderItem, error) {
+	if key == "" {
+		return create(ctx)
+	}
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, nil, err
 	}
+	sum := sha256.Sum256(append([]byte(scope+"\n"), raw...))
+	hash := hex.EncodeToString(sum[:])
 
-	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
-		if err := s.orderRepository.Create(ctx, order); err != nil {
+	var (
+		order *model.Order
+		items []*model.OrderItem
+	)
+	attempt := func(ctx context.Context) error {
+		now := time.Now()
+		record, err := s.idempotencyKeyRepository.Get(ctx, userID, key)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 			return err
 		}
-		item.OrderID = order.ID
-		return s.orderItemRepository.Create(ctx, item)
-	})
+		if err == nil {
+			if record.ExpireAt.After(now) {
+				if record.Scope != scope || record.RequestHash != hash {
+					return ErrIdempotencyKeyReused
+				}
+				order, items, err = s.loadOrderWithItems(ctx, record.OrderID)
+				return err
+			}
+			if err := s.idempotencyKeyRepository.Delete(ctx, record.ID); err != nil {
+				return err
+			}
+		}
+		record = &model.IdempotencyKey{
+			UserID:      userID,
+			IdemKey:     key,
+			Scope:       scope,
+			RequestHash: hash,
+			ExpireAt:    now.Add(idempotencyKeyTTL(s.config)),
+			CreateAt:    now,
+		}
+		// The unique (user_id, idem_key) index makes a concurrent twin wait here and then fail.
+		if err := s.idempotencyKeyRepository.Create(ctx, record); err != nil {
+			return err
+		}
+		order, items, err = create(ctx)
+		if err != nil {
+			return err
+		}
+		record.OrderID = order.ID
+		return s.idempotencyKeyRepository.Update(ctx, record)
+	}
+	err = s.tm.Transaction(ctx, attempt)
+	if err != nil && err != ErrIdempotencyKeyReused {
+		// Lost the race to a concurrent request with the same key: answer with its order.
+		if _, getErr := s.idempotencyKeyRepository.Get(ctx, userID, key); getErr == nil {
+			err = s.tm.Transaction(ctx, attempt)
+		}
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return order, items, nil
+}
+
+func (s *orderService) loadOrderWithItems(ctx context.Context, orderID int64) (*model.Order, []*model.OrderItem, error) {
+	order, err := s.orderRepository.GetByID(ctx, orderID)
 	if err != nil {
 		return nil, nil, err
 	}
-	return order, item, nil
+	items, err := s.orderItemRepository.ListByOrderID(ctx, orderID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(items) == 0 {
+		return nil, nil, fmt.Errorf("order %d has no items", orderID)
+	}
+	return order, items, nil
 }
 
-func (s *orderService) CreateContactVoucherOrder(ctx context.Context, userID int64, price float64, voucherNum int) (*model.Order, *model.OrderItem, error) {
+// PurgeIdempotencyKeys drops the keys past their expiry.
+func (s *orderService) PurgeIdempotencyKeys(ctx context.Context) (int64, error) {
+	return s.idempotencyKeyRepository.DeleteExpired(ctx, time.Now())
+}
+
+func (s *orderService) createOrder(ctx context.Context, userID int64, lines []LineItem, couponID int64) (*model.Order, []*model.OrderItem, error) {
+	if len(lines) == 0 || len(lines) > maxOrderLines {
+		return nil, nil, ErrInvalidOrderLines
+	}
+	now := time.Now()
 	order := &model.Order{
-		OrderNo:     s.generateOrderNo("CV"),
 		UserID:      userID,
-		AmountTotal: model.NewDecimalFromFloat64(price),
-		AmountPaid:  model.NewDecimalFromFloat64(0),
+		AmountTotal: model.NewDecimalFromCents(0),
+		AmountPaid:  model.NewDecimalFromCents(0),
 		Currency:    "CNY",
 		Status:      model.OrderStatusPending,
-		CreateAt:    time.Now(),
-		UpdateAt:    time.Now(),
+		CreateAt:    now,
+		UpdateAt:    now,
+	}
+	items := make([]*model.OrderItem, 0, len(lines))
+	for _, line := range lines {
+		item, remark, err := s.priceLine(ctx, userID, line)
+		if err != nil {
+			return nil, nil, err
+		}
+		item.CreateAt = now
+		item.UpdateAt = now
+		items = append(items, item)
+		order.AmountTotal = order.AmountTotal.Add(item.UnitPriceSnapshot)
+		if remark != "" {
+			order.Remark = remark
+		}
+	}
+	order.OrderNo = s.generateOrderNo(orderNoPrefix(items))
+	if err := s.placeOrder(ctx, order, items, couponID); err != nil {
+		return nil, nil, err
+	}
+	return order, items, nil
+}
+
+// priceLine checks a line against its SKU and job and prices it for the user. The unit
+// price snapshot is what the user is charged for the line, member discount included.
+func (s *orderService) priceLine(ctx context.Context, userID int64, line LineItem) (*model.OrderItem, string, error) {
+	product, err := s.productService.GetOnSale(ctx, line.SkuID, line.ProductType)
+	if err != nil {
+		return nil, "", err
 	}
 	item := &model.OrderItem{
-		ProductType:       model.ProductTypeContactVoucher,
-		TitleSnapshot:     fmt.Sprintf("联系券-%d张", voucherNum),
-		UnitPriceSnapshot: price,
-		ContactVoucherNum: voucherNum,
-		CreateAt:          time.Now(),
-		UpdateAt:          time.Now(),
+		ProductType:       product.ProductType,
+		TitleSnapshot:     product.Title,
+		UnitPriceSnapshot: product.Price,
+	}
+	var remark string
+	switch product.ProductType {
+	case model.ProductTypeTop, model.ProductTypeRefresh:
+		if line.JobID <= 0 {
+			return nil, "", ErrInvalidOrderLines
+		}
+		job, err := s.jobRepository.GetByID(ctx, line.JobID)
+		if err != nil {
+			return nil, "", err
+		}
+		if job.UserID != userID {
+			return nil, "", ErrForbidden
+		}
+		item.TargetType = model.OrderTargetJob
+		item.TargetID = job.ID
+		if product.ProductType == model.ProductTypeTop {
+			if product.TopHour <= 0 {
+				return nil, "", ErrProductNotFound
+			}
+			item.TopHour = product.TopHour
+			item.UnitPriceSnapshot, remark, err = s.topPrice(ctx, userID, product)
+			if err != nil {
+				return nil, "", err
+			}
+		}
+	case model.ProductTypeContactVoucher:
+		if product.ContactVoucherNum <= 0 {
+			return nil, "", ErrProductNotFound
+		}
+		item.ContactVoucherNum = product.ContactVoucherNum
+	case model.ProductTypeMembership:
+		if product.MembershipDays <= 0 {
+			return nil, "", ErrProductNotFound
+		}
+		item.MembershipDays = product.MembershipDays
+	default:
+		return nil, "", ErrProductNotFound
 	}
-	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+	return item, remark, nil
+}
+
+// orderNoPrefix keeps the per-product prefixes for single-type orders; mixed carts get ORD.
+func orderNoPrefix(items []*model.OrderItem) string {
+	prefix := ""
+	for _, item := range items {
+		p := "ORD"
+		switch item.ProductType {
+		case model.ProductTypeTop:
+			p = "TOP"
+		case model.ProductTypeContactVoucher:
+			p = "CV"
+		case model.ProductTypeRefresh:
+			p = "REF"
+		case model.ProductTypeMembership:
+			p = "MEM"
+		}
+		if prefix != "" && prefix != p {
+			return "ORD"
+		}
+		prefix = p
+	}
+	return prefix
+}
+
+// placeOrder takes the coupon, if any, off the order's price and saves the order with its
+// items, locking the coupon in the same transaction.
+func (s *orderService) placeOrder(ctx context.Context, order *model.Order, items []*model.OrderItem, couponID int64) error {
+	order.DiscountAmount = model.NewDecimalFromCents(0)
+	if couponID > 0 {
+		discount, err := s.couponService.Discount(ctx, CouponDiscountInput{
+			UserID:   order.UserID,
+			CouponID: couponID,
+			Items:    items,
+		})
+		if err != nil {
+			return err
+		}
+		order.CouponID = couponID
+		order.DiscountAmount = discount.Discount
+		order.AmountTotal = order.AmountTotal.Sub(discount.Discount)
+	}
+	return s.tm.Transaction(ctx, func(ctx context.Context) error {
 		if err := s.orderRepository.Create(ctx, order); err != nil {
 			return err
 		}
-		item.OrderID = order.ID
-		return s.orderItemRepository.Create(ctx, item)
+		for _, item := range items {
+			item.OrderID = order.ID
+			if err := s.orderItemRepository.Create(ctx, item); err != nil {
+				return err
+			}
+		}
+		if order.CouponID > 0 {
+			return s.couponService.Lock(ctx, order.CouponID, order.ID)
+		}
+		return nil
 	})
+}
+
+// topPrice applies the member discount to a top package, rounding half up to the cent
+// and never below one cent.
+func (s *orderService) topPrice(ctx context.Context, userID int64, product *model.Product) (model.Decimal, string, error) {
+	benefits, err := s.membershipService.GetBenefits(ctx, userID)
 	if err != nil {
-		return nil, nil, err
+		return model.Decimal{}, "", err
+	}
+	if !benefits.Active || benefits.TopPricePercent <= 0 || benefits.TopPricePercent >= 100 {
+		return product.Price, "", nil
 	}
-	return order, item, nil
+	discounted := product.Price.MulInt(int64(benefits.TopPricePercent)).DivInt(100, 2, model.RoundHalfUp)
+	if minPrice := model.NewDecimalFromCents(1); discounted.Cmp(minPrice) < 0 {
+		discounted = minPrice
+	}
+	return discounted, fmt.Sprintf("会员置顶价 %d%%", benefits.TopPricePercent), nil
 }
 
-func (s *orderService) CreateRefreshOrder(ctx context.Context, userID, jobID int64, price float64) (*model.Order, *model.OrderItem, error) {
-	job, err := s.jobRepository.GetByID(ctx, jobID)
+// ConfirmOrder lets the client settle its order right after wx.requestPayment
+// instead of waiting for the notify; only the provider's answer is trusted.
+func (s *orderService) ConfirmOrder(ctx context.Context, userID int64, orderNo string) (*model.Order, error) {
+	order, err := s.orderRepository.GetByOrderNo(ctx, orderNo)
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	if job.UserID != userID {
-		return nil, nil, ErrForbidden
+	if order.UserID != userID {
+		return nil, ErrForbidden
 	}
-	order := &model.Order{
-		OrderNo:     s.generateOrderNo("REF"),
-		UserID:      userID,
-		AmountTotal: model.NewDecimalFromFloat64(price),
-		AmountPaid:  model.NewDecimalFromFloat64(0),
-		Currency:    "CNY",
-		Status:      model.OrderStatusPending,
-		CreateAt:    time.Now(),
-		UpdateAt:    time.Now(),
+	if order.Status != model.OrderStatusPending && order.Status != model.OrderStatusCanceled {
+		return order, nil
 	}
-	item := &model.OrderItem{
-		ProductType:       model.ProductTypeRefresh,
-		TitleSnapshot:     "刷新招聘",
-		UnitPriceSnapshot: price,
-		TargetType:        model.OrderTargetJob,
-		TargetID:          jobID,
-		CreateAt:          time.Now(),
-		UpdateAt:          time.Now(),
+	result, err := s.paymentProvider.QueryOrder(ctx, order.OrderNo)
+	if err != nil {
+		return nil, err
 	}
-	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
-		if err := s.orderRepository.Create(ctx, order); err != nil {
+	if !result.Success {
+		return order, nil
+	}
+	return s.payOrderWithItems(ctx, order, result.Amount, result.Channel, result.TradeNo)
+}
+
+func (s *orderService) CancelOrder(ctx context.Context, userID int64, orderNo string) (*model.Order, error) {
+	order, err := s.orderRepository.GetByOrderNo(ctx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userID {
+		return nil, ErrForbidden
+	}
+	if order.Status != model.OrderStatusPending {
+		return nil, ErrOrderNotPending
+	}
+	if err := s.cancelOrder(ctx, order, "用户取消"); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// ExpirePendingOrders cancels the orders still unpaid after being created before createdBefore.
+func (s *orderService) ExpirePendingOrders(ctx context.Context, createdBefore time.Time) (int, error) {
+	const batchSize = 100
+	canceled := 0
+	for {
+		orders, err := s.orderRepository.ListPendingBefore(ctx, createdBefore, batchSize)
+		if err != nil {
+			return canceled, err
+		}
+		for _, order := range orders {
+			if err := s.cancelOrder(ctx, order, "超时未支付自动取消"); err != nil {
+				if err == ErrOrderNotPending {
+					continue
+				}
+				return canceled, err
+			}
+			canceled++
+		}
+		if len(orders) < batchSize {
+			return canceled, nil
+		}
+	}
+}
+
+func (s *orderService) ExpireTops(ctx context.Context, now time.Time) (int, error) {
+	const batchSize = 100
+	expired := 0
+	for {
+		jobs, err := s.jobRepository.ListTopExpired(ctx, now, batchSize)
+		if err != nil {
+			return expired, err
+		}
+		for _, job := range jobs {
+			ok, err := s.expireTop(ctx, job, now)
+			if err != nil {
+				return expired, err
+			}
+			if ok {
+				expired++
+			}
+		}
+		if len(jobs) < batchSize {
+			return expired, nil
+		}
+	}
+}
+
+// expireTop clears the job's flag and leaves its owner a notification in one go; a job
+// whose placement was extended in the meantime is left alone.
+func (s *orderService) expireTop(ctx context.Context, job *model.Job, now time.Time) (bool, error) {
+	expired := false
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		ok, err := s.jobRepository.ClearTop(ctx, job.ID, now)
+		if err != nil || !ok {
 			return err
 		}
-		item.OrderID = order.ID
-		return s.orderItemRepository.Create(ctx, item)
+		expired = true
+		content := fmt.Sprintf("您发布的招聘「%s」置顶已到期，可重新购买置顶以继续展示在列表前列。", job.Positions)
+		if job.TopEndTime != nil {
+			content = fmt.Sprintf("您发布的招聘「%s」置顶已于 %s 到期，可重新购买置顶以继续展示在列表前列。",
+				job.Positions, job.TopEndTime.Format("2006-01-02 15:04"))
+		}
+		return s.notificationRepository.Create(ctx, &model.Notification{
+			UserID:   job.UserID,
+			Type:     model.NotificationTopExpired,
+			TargetID: job.ID,
+			Title:    "置顶已到期",
+			Content:  content,
+			CreateAt: now,
+		})
 	})
 	if err != nil {
-		return nil, nil, err
+		return false, err
 	}
-	return order, item, nil
+	return expired, nil
 }
 
-func (s *orderService) PayOrder(ctx context.Context, userID, orderID int64, orderNo string, amount float64, payChannel, payTradeNo string) (*model.Order, error) {
-	var order *model.Order
-	var err error
-	if orderID > 0 {
-		order, err = s.orderRepository.GetByID(ctx, orderID)
-	} else {
-		order, err = s.orderRepository.GetByOrderNo(ctx, orderNo)
+// cancelOrder cancels the order locally first, handing its coupon back, then closes it at the
+// provider so it can't be paid any more. If the user pays in between, the late notify revives it in payOrderWithItems.
+func (s *orderService) cancelOrder(ctx context.Context, order *model.Order, remark string) error {
+	now := time.Now()
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		ok, err := s.orderRepository.Cancel(ctx, order.ID, now, remark)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrOrderNotPending
+		}
+		return s.couponService.Release(ctx, order)
+	})
+	if err != nil {
+		return err
+	}
+	order.Status = model.OrderStatusCanceled
+	order.CanceledAt = &now
+	order.Remark = remark
+	order.UpdateAt = now
+	if err := s.paymentProvider.CloseOrder(ctx, order.OrderNo); err != nil {
+		s.logger.WithContext(ctx).Warn("paymentProvider.CloseOrder error", zap.String("order_no", order.OrderNo), zap.Error(err))
+	}
+	return nil
+}
+
+func (s *orderService) ListByUser(ctx context.Context, userID int64, status model.OrderStatus, pageNum, pageSize int) ([]*OrderDetail, int64, error) {
+	orders, total, err := s.orderRepository.ListByUser(ctx, userID, status, pageNum, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	details, err := s.buildDetails(ctx, orders)
+	if err != nil {
+		return nil, 0, err
 	}
+	return details, total, nil
+}
+
+func (s *orderService) GetMyOrder(ctx context.Context, userID int64, orderNo string) (*OrderDetail, error) {
+	detail, err := s.GetOrder(ctx, orderNo)
 	if err != nil {
 		return nil, err
 	}
-	if order.UserID != userID {
+	if detail.Order.UserID != userID {
 		return nil, ErrForbidden
 	}
-	return s.payOrderWithItems(ctx, order, amount, payChannel, payTradeNo)
+	return detail, nil
 }
 
-func (s *orderService) PayOrderByNotify(ctx context.Context, orderNo string, amount float64, payChannel, payTradeNo string) (*model.Order, error) {
+// GetOrder loads any order without an owner check; it backs the admin view.
+func (s *orderService) GetOrder(ctx context.Context, orderNo string) (*OrderDetail, error) {
+	order, err := s.orderRepository.GetByOrderNo(ctx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	details, err := s.buildDetails(ctx, []*model.Order{order})
+	if err != nil {
+		return nil, err
+	}
+	return details[0], nil
+}
+
+func (s *orderService) buildDetails(ctx context.Context, orders []*model.Order) ([]*OrderDetail, error) {
+	orderIDs := make([]int64, 0, len(orders))
+	for _, order := range orders {
+		orderIDs = append(orderIDs, order.ID)
+	}
+	items, err := s.orderItemRepository.ListByOrderIDs(ctx, orderIDs)
+	if err != nil {
+		return nil, err
+	}
+	itemsByOrder := make(map[int64][]*model.OrderItem, len(orders))
+	var jobIDs []int64
+	for _, item := range items {
+		itemsByOrder[item.OrderID] = append(itemsByOrder[item.OrderID], item)
+		if item.TargetType == model.OrderTargetJob && item.TargetID > 0 {
+			jobIDs = append(jobIDs, item.TargetID)
+		}
+	}
+	jobs := make(map[int64]*model.Job, len(jobIDs))
+	if len(jobIDs) > 0 {
+		list, err := s.jobRepository.ListByIDs(ctx, jobIDs)
+		if err != nil {
+			return nil, err
+		}
+		for _, job := range list {
+			jobs[job.ID] = job
+		}
+	}
+	invoices, err := s.invoiceRepository.LatestByOrderIDs(ctx, orderIDs)
+	if err != nil {
+		return nil, err
+	}
+	details := make([]*OrderDetail, 0, len(orders))
+	for _, order := range orders {
+		details = append(details, &OrderDetail{
+			Order:   order,
+			Items:   itemsByOrder[order.ID],
+			Jobs:    jobs,
+			Invoice: invoices[order.ID],
+		})
+	}
+	return details, nil
+}
+
+// PayOrderByNotify applies a verified provider callback; amount is in cents. A payment of any
+// other amount than the order's is recorded, refunded and reported as ErrAmountMismatch.
+func (s *orderService) PayOrderByNotify(ctx context.Context, orderNo string, amount int64, payChannel, payTradeNo string) (*model.Order, error) {
 	order, err := s.orderRepository.GetByOrderNo(ctx, orderNo)
 	if err != nil {
 		return nil, err
@@ -183,34 +631,85 @@ func (s *orderService) PayOrderByNotify(ctx context.Context, orderNo string, amo
 	return s.payOrderWithItems(ctx, order, amount, payChannel, payTradeNo)
 }
 
-func (s *orderService) payOrderWithItems(ctx context.Context, order *model.Order, amount float64, payChannel, payTradeNo string) (*model.Order, error) {
-	if order.Status != model.OrderStatusPending {
+func (s *orderService) payOrderWithItems(ctx context.Context, order *model.Order, amount int64, payChannel, payTradeNo string) (*model.Order, error) {
+	if order.Status != model.OrderStatusPending && order.Status != model.OrderStatusCanceled {
 		return order, nil
 	}
-	if amount > 0 {
-		expected, err := order.AmountTotal.ToCents()
+	// Both callers carry the provider's amount, so a zero is a mismatch like any other.
+	if !order.AmountTotal.Equal(model.NewDecimalFromCents(amount)) {
+		if amount <= 0 {
+			return nil, ErrAmountMismatch
+		}
+		// The provider took money the order doesn't ask for. Nothing is delivered: the payment
+		// goes on record against the canceled order and is refunded in full.
+		s.logger.WithContext(ctx).Warn("payment amount mismatch, refunding",
+			zap.String("order_no", order.OrderNo), zap.Int64("amount", amount))
+		order, err := s.turnAwayPayment(ctx, order.ID, model.NewDecimalFromCents(amount), payChannel, payTradeNo, "支付金额与订单不一致，订单取消并原路退款")
 		if err != nil {
 			return nil, err
 		}
-		given := int64(amount*100 + 0.5)
-		if expected != given {
-			return nil, ErrAmountMismatch
+		if err := s.refundTurnedAway(ctx, order, "支付金额与订单不一致"); err != nil {
+			return nil, err
 		}
+		return nil, ErrAmountMismatch
 	}
 	items, err := s.orderItemRepository.ListByOrderID(ctx, order.ID)
 	if err != nil {
 		return nil, err
 	}
 
+	// The status read above may be stale: a concurrent notify, confirm or cancel can
+	// move the order at any time. Re-read it under a row lock and only apply the items
+	// when this call is the one that moves it to paid.
+	refundLate := false
 	err = s.tm.Transaction(ctx, func(ctx context.Context) error {
+		current, err := s.orderRepository.GetByIDForUpdate(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		order = current
+		from := order.Status
+		switch from {
+		case model.OrderStatusPending:
+		case model.OrderStatusCanceled:
+			if order.PaidAt != nil {
+				// A late payment already turned away; make sure its refund went out.
+				refundLate = true
+				return nil
+			}
+			// The money arrived after the order was canceled (a late notify, or a cancel racing
+			// the payment). The user has paid, so deliver it and leave a trace for support.
+			s.logger.WithContext(ctx).Warn("order paid after cancel, reviving", zap.String("order_no", order.OrderNo))
+			order.Remark = "取消后收到支付，订单已恢复"
+		default:
+			return nil
+		}
+		now := time.Now()
 		order.Status = model.OrderStatusPaid
 		order.AmountPaid = order.AmountTotal
 		order.PayChannel = payChannel
 		order.PayTradeNo = payTradeNo
-		paidAt := time.Now()
-		order.PaidAt = &paidAt
-		order.UpdateAt = time.Now()
-		if err := s.orderRepository.Update(ctx, order); err != nil {
+		order.PaidAt = &now
+		order.UpdateAt = now
+		// Databases without row locks (sqlite) still get here twice; the conditional
+		// update lets exactly one of them through.
+		paid, err := s.orderRepository.MarkPaid(ctx, order, from)
+		if err != nil {
+			return err
+		}
+		if !paid {
+			latest, err := s.orderRepository.GetByID(ctx, order.ID)
+			if err != nil {
+				return err
+			}
+			order = latest
+			if latest.Status == model.OrderStatusPaid {
+				return nil
+			}
+			return ErrOrderStatusChanged
+		}
+		// ErrCouponUnavailable rolls all of this back; the payment is turned away below.
+		if err := s.couponService.Settle(ctx, order); err != nil {
 			return err
 		}
 		for _, item := range items {
@@ -220,17 +719,77 @@ func (s *orderService) payOrderWithItems(ctx context.Context, order *model.Order
 					return err
 				}
 			case model.ProductTypeContactVoucher:
-				if err := s.applyContactVoucher(ctx, order.UserID, item); err != nil {
+				if err := s.applyContactVoucher(ctx, order, item); err != nil {
 					return err
 				}
 			case model.ProductTypeRefresh:
 				if err := s.applyRefresh(ctx, item); err != nil {
 					return err
 				}
+			case model.ProductTypeMembership:
+				if _, err := s.membershipService.Extend(ctx, order.UserID, item.MembershipDays); err != nil {
+					return err
+				}
 			}
 		}
 		return nil
 	})
+	if err == ErrCouponUnavailable {
+		refundLate = true
+		// The coupon went to another order after a cancel, or another order became the first
+		// recharge a first-recharge coupon asks for.
+		s.logger.WithContext(ctx).Warn("payment can't keep its coupon, refunding", zap.String("order_no", order.OrderNo))
+		order, err = s.turnAwayPayment(ctx, order.ID, order.AmountTotal, payChannel, payTradeNo, "优惠券已不可用，订单取消并原路退款")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if refundLate {
+		if err := s.refundTurnedAway(ctx, order, "订单已取消"); err != nil {
+			return nil, err
+		}
+	}
+	return order, nil
+}
+
+// refundTurnedAway refunds whatever is left of a turned-away payment; nothing left means an
+// earlier attempt already covered it.
+func (s *orderService) refundTurnedAway(ctx context.Context, order *model.Order, reason string) error {
+	if _, err := s.refundService.CreateRefund(ctx, 0, order.OrderNo, model.Decimal{}, reason); err != nil && err != ErrInvalidRefundAmount {
+		return err
+	}
+	return nil
+}
+
+// turnAwayPayment records a payment the order can't be delivered for. The order ends up
+// canceled with the payment and remark on record, ready to be refunded in full.
+func (s *orderService) turnAwayPayment(ctx context.Context, orderID int64, amountPaid model.Decimal, payChannel, payTradeNo, remark string) (*model.Order, error) {
+	var order *model.Order
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		order, err = s.orderRepository.GetByIDForUpdate(ctx, orderID)
+		if err != nil {
+			return err
+		}
+		if order.PaidAt != nil {
+			return nil
+		}
+		now := time.Now()
+		if order.Status == model.OrderStatusPending {
+			if err := s.couponService.Release(ctx, order); err != nil {
+				return err
+			}
+			order.CanceledAt = &now
+		}
+		order.Status = model.OrderStatusCanceled
+		order.AmountPaid = amountPaid
+		order.PayChannel = payChannel
+		order.PayTradeNo = payTradeNo
+		order.PaidAt = &now
+		order.Remark = remark
+		order.UpdateAt = now
+		return s.orderRepository.Update(ctx, order)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -238,7 +797,7 @@ func (s *orderService) payOrderWithItems(ctx context.Context, order *model.Order
 }
 
 func (s *orderService) applyTop(ctx context.Context, item *model.OrderItem) error {
-	job, err := s.jobRepository.GetByID(ctx, item.TargetID)
+	job, err := s.jobRepository.GetByIDForUpdate(ctx, item.TargetID)
 	if err != nil {
 		return err
 	}
@@ -252,11 +811,12 @@ func (s *orderService) applyTop(ctx context.Context, item *model.OrderItem) erro
 	}
 	end := baseTime.Add(time.Duration(item.TopHour) * time.Hour)
 	job.TopEndTime = &end
+	job.IsTop = true
 	job.UpdateAt = now
 	return s.jobRepository.Update(ctx, job)
 }
 
-func (s *orderService) applyContactVoucher(ctx context.Context, userID int64, item *model.OrderItem) error {
+func (s *orderService) applyContactVoucher(ctx context.Context, order *model.Order, item *model.OrderItem) error {
 	voucherNum := item.ContactVoucherNum
 	if voucherNum <= 0 {
 		voucherNum = parseVoucherNum(item.TitleSnapshot)
@@ -264,31 +824,18 @@ func (s *orderService) applyContactVoucher(ctx context.Context, userID int64, it
 	if voucherNum <= 0 {
 		return ErrInvalidVoucherNum
 	}
-	user, err := s.userRepository.GetByID(ctx, userID)
-	if err != nil {
-		return err
-	}
-	lastNum := user.ContactVoucherNum
-	nextNum := lastNum + voucherNum
-	user.ContactVoucherNum = nextNum
-	user.UpdateAt = time.Now()
-	if err := s.userRepository.Update(ctx, user); err != nil {
-		return err
-	}
-	history := &model.ContactVoucherHistory{
-		UserID:    userID,
-		BizType:   model.ContactVoucherHistoryBuy,
-		ChangeNum: voucherNum,
-		LastNum:   lastNum,
-		NextNum:   nextNum,
-		Remark:    "购买联系券",
-		CreateAt:  time.Now(),
-	}
-	return s.contactVoucherHistoryRepository.Create(ctx, history)
+	_, err := s.contactVoucherHistoryService.GrantVoucher(ctx, VoucherGrantInput{
+		UserID:  order.UserID,
+		BizType: model.ContactVoucherHistoryBuy,
+		Num:     voucherNum,
+		Remark:  "购买联系券",
+		OrderID: order.ID,
+	})
+	return err
 }
 
 func (s *orderService) applyRefresh(ctx context.Context, item *model.OrderItem) error {
-	job, err := s.jobRepository.GetByID(ctx, item.TargetID)
+	job, err := s.jobRepository.GetByIDForUpdate(ctx, item.TargetID)
 	if err != nil {
 		return err
 	}
@@ -297,6 +844,22 @@ func (s *orderService) applyRefresh(ctx context.Context, item *model.OrderItem)
 	return s.jobRepository.Update(ctx, job)
 }
 
+// PendingOrderTTL is how long an unpaid order stays payable (order.pending_ttl, default 30m).
+func PendingOrderTTL(conf *viper.Viper) time.Duration {
+	if ttl := conf.GetDuration("order.pending_ttl"); ttl > 0 {
+		return ttl
+	}
+	return 30 * time.Minute
+}
+
+// idempotencyKeyTTL is how long an Idempotency-Key is remembered (order.idempotency_ttl, default 24h).
+func idempotencyKeyTTL(conf *viper.Viper) time.Duration {
+	if ttl := conf.GetDuration("order.idempotency_ttl"); ttl > 0 {
+		return ttl
+	}
+	return 24 * time.Hour
+}
+
 func (s *orderService) generateOrderNo(prefix string) string {
 	id, err := s.sid.GenUint64()
 	if err != nil {
@@ -2,51 +2,73 @@ package service
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"errors"
-	"fmt"
-	"strconv"
-	"strings"
+	"net/http"
 	"time"
 
 	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
 	"github.com/spf13/viper"
 )
 
 type PayService interface {
-	BuildJSAPIPayParams(ctx context.Context, orderNo string, amount float64) (v1.PayParams, error)
+	BuildJSAPIPayParams(ctx context.Context, order *model.Order, description string) (v1.PayParams, error)
+	ParsePayNotify(ctx context.Context, header http.Header, body []byte) (*PayResult, error)
+	ParseRefundNotify(ctx context.Context, header http.Header, body []byte) (*RefundResult, error)
+	PurgeNotifyNonces(ctx context.Context) (int64, error)
 }
 
 type payService struct {
-	config *viper.Viper
+	config                *viper.Viper
+	provider              PaymentProvider
+	userRepository        repository.UserRepository
+	notifyNonceRepository repository.NotifyNonceRepository
 }
 
-func NewPayService(config *viper.Viper) PayService {
-	return &payService{config: config}
+func NewPayService(config *viper.Viper, provider PaymentProvider, userRepository repository.UserRepository, notifyNonceRepository repository.NotifyNonceRepository) PayService {
+	return &payService{
+		config:                config,
+		provider:              provider,
+		userRepository:        userRepository,
+		notifyNonceRepository: notifyNonceRepository,
+	}
 }
 
-func (s *payService) BuildJSAPIPayParams(ctx context.Context, orderNo string, amount float64) (v1.PayParams, error) {
-	appID := s.config.GetString("wxpay.app_id")
-	if appID == "" {
-		return v1.PayParams{}, errors.New("wxpay.app_id is empty")
+// BuildJSAPIPayParams places the order with the payment provider on behalf of its owner
+// and returns the signed parameters for wx.requestPayment.
+func (s *payService) BuildJSAPIPayParams(ctx context.Context, order *model.Order, description string) (v1.PayParams, error) {
+	user, err := s.userRepository.GetByID(ctx, order.UserID)
+	if err != nil {
+		return v1.PayParams{}, err
+	}
+	if user.WechatOpenID == "" {
+		return v1.PayParams{}, errors.New("user has no wechat openid")
 	}
-	nonce := strconv.FormatInt(time.Now().UnixNano(), 36)
-	timeStamp := strconv.FormatInt(time.Now().Unix(), 10)
-	pkg := fmt.Sprintf("prepay_id=%s", orderNo)
-	signType := "RSA"
-	paySign := signPayParams(appID, timeStamp, nonce, pkg)
-	return v1.PayParams{
-		TimeStamp: timeStamp,
-		NonceStr:  nonce,
-		Package:   pkg,
-		SignType:  signType,
-		PaySign:   paySign,
-	}, nil
+	amount, err := order.AmountTotal.ToCents()
+	if err != nil {
+		return v1.PayParams{}, err
+	}
+	// Let the provider refuse payment once the expiry task may have canceled the order.
+	expireAt := order.CreateAt.Add(PendingOrderTTL(s.config))
+	return s.provider.JSAPIPay(ctx, PrepayRequest{
+		OrderNo:     order.OrderNo,
+		Description: description,
+		Amount:      amount,
+		OpenID:      user.WechatOpenID,
+		ExpireAt:    &expireAt,
+	})
+}
+
+func (s *payService) ParsePayNotify(ctx context.Context, header http.Header, body []byte) (*PayResult, error) {
+	return s.provider.ParsePayNotify(ctx, header, body)
+}
+
+func (s *payService) ParseRefundNotify(ctx context.Context, header http.Header, body []byte) (*RefundResult, error) {
+	return s.provider.ParseRefundNotify(ctx, header, body)
 }
 
-func signPayParams(appID, timeStamp, nonceStr, pkg string) string {
-	payload := strings.Join([]string{appID, timeStamp, nonceStr, pkg}, "\n")
-	sum := sha256.Sum256([]byte(payload))
-	return hex.EncodeToString(sum[:])
+// PurgeNotifyNonces drops the notification nonces past their expiry.
+func (s *payService) PurgeNotifyNonces(ctx context.Context) (int64, error) {
+	return s.notifyNonceRepository.DeleteExpired(ctx, time.Now())
 }
@@ -0,0 +1,322 @@
+package service
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/wxpay"
+	"github.com/spf13/viper"
+)
+
+// PrepayRequest describes an order to be paid through the provider. Amount is in cents.
+type PrepayRequest struct {
+	OrderNo     string
+	Description string
+	Amount      int64
+	OpenID      string
+	ExpireAt    *time.Time
+}
+
+// PayResult is a verified payment state reported by the provider. Amount is in cents.
+type PayResult struct {
+	Channel string
+	OrderNo string
+	TradeNo string
+	Amount  int64
+	Success bool
+}
+
+// RefundRequest returns part or all of a paid order. Amounts are in cents.
+type RefundRequest struct {
+	OrderNo  string
+	RefundNo string
+	Amount   int64
+	Total    int64
+	Reason   string
+}
+
+// RefundResult is the provider's view of a refund.
+type RefundResult struct {
+	RefundNo      string
+	TradeRefundNo string
+	Status        model.RefundStatus
+}
+
+// BillRecord is a successful payment listed in the provider's trade bill. Amount is in cents.
+type BillRecord struct {
+	OrderNo   string
+	TradeNo   string
+	Amount    int64
+	TradeTime time.Time
+}
+
+// PaymentProvider hides the payment channel from the order flow.
+type PaymentProvider interface {
+	Channel() string
+	JSAPIPay(ctx context.Context, req PrepayRequest) (v1.PayParams, error)
+	// ParsePayNotify authenticates a payment callback; failures wrap ErrInvalidNotify.
+	ParsePayNotify(ctx context.Context, header http.Header, body []byte) (*PayResult, error)
+	QueryOrder(ctx context.Context, orderNo string) (*PayResult, error)
+	CloseOrder(ctx context.Context, orderNo string) error
+	// Refund asks for a refund; one the provider turned down wraps ErrRefundRejected.
+	Refund(ctx context.Context, req RefundRequest) (*RefundResult, error)
+	// ParseRefundNotify authenticates a refund callback; failures wrap ErrInvalidNotify.
+	ParseRefundNotify(ctx context.Context, header http.Header, body []byte) (*RefundResult, error)
+	// TradeBill lists the payments the provider settled on billDate's calendar day.
+	TradeBill(ctx context.Context, billDate time.Time) ([]BillRecord, error)
+}
+
+func NewPaymentProvider(config *viper.Viper, notifyNonceRepository repository.NotifyNonceRepository) PaymentProvider {
+	return &wechatPayProvider{
+		config:                config,
+		notifyNonceRepository: notifyNonceRepository,
+	}
+}
+
+type wechatPayProvider struct {
+	config                *viper.Viper
+	notifyNonceRepository repository.NotifyNonceRepository
+	client                *wxpay.Client
+	mu                    sync.Mutex
+}
+
+// notifyNonceStore keeps the wxpay notification nonces in the database, shared by every
+// server instance behind the notify URL.
+type notifyNonceStore struct {
+	channel               string
+	notifyNonceRepository repository.NotifyNonceRepository
+}
+
+func (s notifyNonceStore) Remember(ctx context.Context, nonce string, expireAt time.Time) (bool, error) {
+	return s.notifyNonceRepository.Add(ctx, &model.NotifyNonce{
+		Channel:  s.channel,
+		Nonce:    nonce,
+		ExpireAt: expireAt,
+		CreateAt: time.Now(),
+	})
+}
+
+func (p *wechatPayProvider) Channel() string {
+	return "wxpay"
+}
+
+func (p *wechatPayProvider) JSAPIPay(ctx context.Context, req PrepayRequest) (v1.PayParams, error) {
+	client, err := p.ensureClient()
+	if err != nil {
+		return v1.PayParams{}, err
+	}
+	prepayID, err := client.JSAPIPrepay(ctx, wxpay.PrepayRequest{
+		Description: req.Description,
+		OutTradeNo:  req.OrderNo,
+		TimeExpire:  req.ExpireAt,
+		Amount:      wxpay.Amount{Total: req.Amount},
+		Payer:       wxpay.Payer{OpenID: req.OpenID},
+	})
+	if err != nil {
+		return v1.PayParams{}, err
+	}
+	params, err := client.JSAPIPayParams(prepayID)
+	if err != nil {
+		return v1.PayParams{}, err
+	}
+	return v1.PayParams{
+		TimeStamp: params.TimeStamp,
+		NonceStr:  params.NonceStr,
+		Package:   params.Package,
+		SignType:  params.SignType,
+		PaySign:   params.PaySign,
+	}, nil
+}
+
+func (p *wechatPayProvider) ParsePayNotify(ctx context.Context, header http.Header, body []byte) (*PayResult, error) {
+	client, err := p.ensureClient()
+	if err != nil {
+		return nil, err
+	}
+	var transaction wxpay.Transaction
+	notification, err := client.ParseNotify(ctx, header, body, &transaction)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotify, err)
+	}
+	// Only a successful payment may be posted here; anything else is not ours to apply.
+	if notification.EventType != wxpay.EventTransactionSuccess {
+		return nil, fmt.Errorf("%w: event_type %s", ErrInvalidNotify, notification.EventType)
+	}
+	if transaction.MchID != client.MchID() || transaction.AppID != client.AppID() {
+		return nil, fmt.Errorf("%w: mchid %s appid %s", ErrInvalidNotify, transaction.MchID, transaction.AppID)
+	}
+	return p.payResult(&transaction), nil
+}
+
+func (p *wechatPayProvider) QueryOrder(ctx context.Context, orderNo string) (*PayResult, error) {
+	client, err := p.ensureClient()
+	if err != nil {
+		return nil, err
+	}
+	transaction, err := client.QueryOrderByOutTradeNo(ctx, orderNo)
+	if err != nil {
+		return nil, err
+	}
+	return p.payResult(transaction), nil
+}
+
+func (p *wechatPayProvider) CloseOrder(ctx context.Context, orderNo string) error {
+	client, err := p.ensureClient()
+	if err != nil {
+		return err
+	}
+	return client.CloseOrder(ctx, orderNo)
+}
+
+func (p *wechatPayProvider) Refund(ctx context.Context, req RefundRequest) (*RefundResult, error) {
+	client, err := p.ensureClient()
+	if err != nil {
+		return nil, err
+	}
+	refund, err := client.Refund(ctx, wxpay.RefundRequest{
+		OutTradeNo:  req.OrderNo,
+		OutRefundNo: req.RefundNo,
+		Reason:      req.Reason,
+		Amount:      wxpay.RefundAmount{Refund: req.Amount, Total: req.Total},
+	})
+	if err != nil {
+		// A 4xx answer means WeChat Pay turned the request down; anything else may have been applied.
+		var apiErr *wxpay.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode >= 400 && apiErr.StatusCode < 500 {
+			return nil, fmt.Errorf("%w: %v", ErrRefundRejected, err)
+		}
+		return nil, err
+	}
+	return refundResult(refund), nil
+}
+
+func (p *wechatPayProvider) ParseRefundNotify(ctx context.Context, header http.Header, body []byte) (*RefundResult, error) {
+	client, err := p.ensureClient()
+	if err != nil {
+		return nil, err
+	}
+	var refund wxpay.Refund
+	if _, err := client.ParseNotify(ctx, header, body, &refund); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidNotify, err)
+	}
+	return refundResult(&refund), nil
+}
+
+func (p *wechatPayProvider) TradeBill(ctx context.Context, billDate time.Time) ([]BillRecord, error) {
+	client, err := p.ensureClient()
+	if err != nil {
+		return nil, err
+	}
+	raw, err := client.DownloadTradeBill(ctx, billDate.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	rows, err := wxpay.ParseTradeBill(raw)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]BillRecord, 0, len(rows))
+	for _, row := range rows {
+		// Refund rows repeat the order they belong to; the payment has its own row.
+		if row.TradeState != wxpay.TradeBillStateSuccess {
+			continue
+		}
+		records = append(records, BillRecord{
+			OrderNo:   row.OutTradeNo,
+			TradeNo:   row.TransactionID,
+			Amount:    row.Amount,
+			TradeTime: row.TradeTime,
+		})
+	}
+	return records, nil
+}
+
+func refundResult(refund *wxpay.Refund) *RefundResult {
+	status := model.RefundStatusProcessing
+	switch refund.State() {
+	case wxpay.RefundStatusSuccess:
+		status = model.RefundStatusSuccess
+	case wxpay.RefundStatusClosed, wxpay.RefundStatusAbnormal:
+		status = model.RefundStatusFailed
+	}
+	return &RefundResult{
+		RefundNo:      refund.OutRefundNo,
+		TradeRefundNo: refund.RefundID,
+		Status:        status,
+	}
+}
+
+func (p *wechatPayProvider) payResult(transaction *wxpay.Transaction) *PayResult {
+	return &PayResult{
+		Channel: p.Channel(),
+		OrderNo: transaction.OutTradeNo,
+		TradeNo: transaction.TransactionID,
+		Amount:  transaction.Amount.Total,
+		Success: transaction.TradeState == wxpay.TradeStateSuccess,
+	}
+}
+
+func (p *wechatPayProvider) ensureClient() (*wxpay.Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.client != nil {
+		return p.client, nil
+	}
+	appID := p.config.GetString("wxpay.app_id")
+	mchID := p.config.GetString("wxpay.mch_id")
+	serialNo := p.config.GetString("wxpay.mch_serial_no")
+	keyPath := p.config.GetString("wxpay.private_key_path")
+	notifyURL := p.config.GetString("wxpay.notify_url")
+	if appID == "" || mchID == "" || serialNo == "" || keyPath == "" || notifyURL == "" {
+		return nil, errors.New("wxpay config is incomplete")
+	}
+	keyPEM, err := os.ReadFile(keyPath)
+	if err != nil {
+		return nil, err
+	}
+	privateKey, err := wxpay.LoadPrivateKey(keyPEM)
+	if err != nil {
+		return nil, err
+	}
+	// Pinned platform certificates are optional; without them the client downloads
+	// them with the APIv3 key on first use.
+	var certs []*x509.Certificate
+	for _, certPath := range p.config.GetStringSlice("wxpay.platform_cert_paths") {
+		certPEM, err := os.ReadFile(certPath)
+		if err != nil {
+			return nil, err
+		}
+		cert, err := wxpay.LoadCertificate(certPEM)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	client, err := wxpay.NewClient(wxpay.Config{
+		Endpoint:        p.config.GetString("wxpay.endpoint"),
+		AppID:           appID,
+		MchID:           mchID,
+		MchSerialNo:     serialNo,
+		PrivateKey:      privateKey,
+		APIv3Key:        p.config.GetString("wxpay.api_v3_key"),
+		NotifyURL:       notifyURL,
+		RefundNotifyURL: p.config.GetString("wxpay.refund_notify_url"),
+		PlatformCerts:   certs,
+		NonceStore:      notifyNonceStore{channel: p.Channel(), notifyNonceRepository: p.notifyNonceRepository},
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.client = client
+	return p.client, nil
+}
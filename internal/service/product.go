@@ -0,0 +1,54 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"gorm.io/gorm"
+)
+
+type ProductService interface {
+	List(ctx context.Context, productType model.ProductType) ([]*model.Product, error)
+	GetOnSale(ctx context.Context, skuID int64, productType model.ProductType) (*model.Product, error)
+}
+
+func NewProductService(
+	service *Service,
+	productRepository repository.ProductRepository,
+) ProductService {
+	return &productService{
+		Service:           service,
+		productRepository: productRepository,
+	}
+}
+
+type productService struct {
+	*Service
+	productRepository repository.ProductRepository
+}
+
+func (s *productService) List(ctx context.Context, productType model.ProductType) ([]*model.Product, error) {
+	return s.productRepository.ListOnline(ctx, productType)
+}
+
+// GetOnSale returns the SKU only when it is online and of the expected type,
+// so a client can't buy e.g. a voucher bundle through the top endpoint. A zero
+// productType accepts any type, as cart lines do.
+func (s *productService) GetOnSale(ctx context.Context, skuID int64, productType model.ProductType) (*model.Product, error) {
+	product, err := s.productRepository.GetByID(ctx, skuID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrProductNotFound
+		}
+		return nil, err
+	}
+	if product.Status != model.ProductStatusOnline || (productType != 0 && product.ProductType != productType) {
+		return nil, ErrProductNotFound
+	}
+	if _, err := product.Price.ToCents(); err != nil || product.Price.Sign() <= 0 {
+		return nil, ErrProductNotFound
+	}
+	return product, nil
+}
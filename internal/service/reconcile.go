@@ -0,0 +1,184 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type ReconcileService interface {
+	// Reconcile checks billDate's trade bill against the orders and records what differs.
+	// Payments we missed are applied the same way a notify would apply them.
+	Reconcile(ctx context.Context, billDate time.Time) (*ReconcileResult, error)
+	ListDiscrepancies(ctx context.Context, billDate string, status model.ReconcileDiscrepancyStatus, pageNum, pageSize int) ([]*model.ReconcileDiscrepancy, int64, error)
+}
+
+func NewReconcileService(
+	service *Service,
+	orderRepository repository.OrderRepository,
+	reconcileDiscrepancyRepository repository.ReconcileDiscrepancyRepository,
+	orderService OrderService,
+	paymentProvider PaymentProvider,
+) ReconcileService {
+	return &reconcileService{
+		Service:                        service,
+		orderRepository:                orderRepository,
+		reconcileDiscrepancyRepository: reconcileDiscrepancyRepository,
+		orderService:                   orderService,
+		paymentProvider:                paymentProvider,
+	}
+}
+
+type reconcileService struct {
+	*Service
+	orderRepository                repository.OrderRepository
+	reconcileDiscrepancyRepository repository.ReconcileDiscrepancyRepository
+	orderService                   OrderService
+	paymentProvider                PaymentProvider
+}
+
+// ReconcileResult sums up one run over a bill date.
+type ReconcileResult struct {
+	BillDate      string
+	Records       int
+	Discrepancies int
+	Fixed         int
+}
+
+func (s *reconcileService) Reconcile(ctx context.Context, billDate time.Time) (*ReconcileResult, error) {
+	start := time.Date(billDate.Year(), billDate.Month(), billDate.Day(), 0, 0, 0, 0, billDate.Location())
+	end := start.AddDate(0, 0, 1)
+	channel := s.paymentProvider.Channel()
+	result := &ReconcileResult{BillDate: start.Format("2006-01-02")}
+
+	records, err := s.paymentProvider.TradeBill(ctx, start)
+	if err != nil {
+		return nil, err
+	}
+	result.Records = len(records)
+	billed := make(map[string]bool, len(records))
+	for _, record := range records {
+		billed[record.OrderNo] = true
+		discrepancy, err := s.checkRecord(ctx, channel, record)
+		if err != nil {
+			return nil, err
+		}
+		if discrepancy == nil {
+			continue
+		}
+		if err := s.save(ctx, result, discrepancy); err != nil {
+			return nil, err
+		}
+	}
+
+	orders, err := s.orderRepository.ListPaidBetween(ctx, channel, start, end)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if billed[order.OrderNo] {
+			continue
+		}
+		// A payment made just before midnight can be notified after it and land in the
+		// neighbouring day's bill; only report it when the provider doesn't know it at all.
+		payResult, err := s.paymentProvider.QueryOrder(ctx, order.OrderNo)
+		if err == nil && payResult.Success && payResult.TradeNo == order.PayTradeNo {
+			continue
+		}
+		remark := "账单中无此支付"
+		if err != nil {
+			remark = err.Error()
+		}
+		if err := s.save(ctx, result, &model.ReconcileDiscrepancy{
+			Channel:     channel,
+			Type:        model.ReconcileMissingInBill,
+			OrderNo:     order.OrderNo,
+			OrderID:     order.ID,
+			TradeNo:     order.PayTradeNo,
+			BillAmount:  model.NewDecimalFromCents(0),
+			OrderAmount: order.AmountPaid,
+			Status:      model.ReconcileDiscrepancyOpen,
+			Remark:      remark,
+		}); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// checkRecord compares one billed payment with its order. A payment the order never saw
+// is applied on the spot; the discrepancy is then recorded as fixed.
+func (s *reconcileService) checkRecord(ctx context.Context, channel string, record BillRecord) (*model.ReconcileDiscrepancy, error) {
+	discrepancy := &model.ReconcileDiscrepancy{
+		Channel:     channel,
+		OrderNo:     record.OrderNo,
+		TradeNo:     record.TradeNo,
+		BillAmount:  model.NewDecimalFromCents(record.Amount),
+		OrderAmount: model.NewDecimalFromCents(0),
+		Status:      model.ReconcileDiscrepancyOpen,
+	}
+	order, err := s.orderRepository.GetByOrderNo(ctx, record.OrderNo)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			discrepancy.Type = model.ReconcileUnknownOrder
+			return discrepancy, nil
+		}
+		return nil, err
+	}
+	discrepancy.OrderID = order.ID
+	discrepancy.OrderAmount = order.AmountTotal
+
+	switch order.Status {
+	case model.OrderStatusPending, model.OrderStatusCanceled:
+		discrepancy.Type = model.ReconcileMissedPayment
+		_, err := s.orderService.PayOrderByNotify(ctx, order.OrderNo, record.Amount, channel, record.TradeNo)
+		switch {
+		case err == nil:
+			discrepancy.Status = model.ReconcileDiscrepancyFixed
+			discrepancy.Remark = "已按账单补单"
+		case err == ErrAmountMismatch:
+			discrepancy.Type = model.ReconcileAmountMismatch
+		default:
+			s.logger.WithContext(ctx).Error("reconcile apply payment error",
+				zap.String("order_no", order.OrderNo), zap.Error(err))
+			discrepancy.Remark = err.Error()
+		}
+		return discrepancy, nil
+	}
+	discrepancy.OrderAmount = order.AmountPaid
+	if !order.AmountPaid.Equal(discrepancy.BillAmount) {
+		discrepancy.Type = model.ReconcileAmountMismatch
+		return discrepancy, nil
+	}
+	if order.PayTradeNo != record.TradeNo {
+		// Most likely the user paid twice; the second trade needs a refund by hand.
+		discrepancy.Type = model.ReconcileTradeNoMismatch
+		discrepancy.Remark = "本地支付单号 " + order.PayTradeNo
+		return discrepancy, nil
+	}
+	return nil, nil
+}
+
+func (s *reconcileService) save(ctx context.Context, result *ReconcileResult, discrepancy *model.ReconcileDiscrepancy) error {
+	now := time.Now()
+	discrepancy.BillDate = result.BillDate
+	discrepancy.CreateAt = now
+	discrepancy.UpdateAt = now
+	if err := s.reconcileDiscrepancyRepository.Save(ctx, discrepancy); err != nil {
+		return err
+	}
+	result.Discrepancies++
+	if discrepancy.Status == model.ReconcileDiscrepancyFixed {
+		result.Fixed++
+	}
+	return nil
+}
+
+func (s *reconcileService) ListDiscrepancies(ctx context.Context, billDate string, status model.ReconcileDiscrepancyStatus, pageNum, pageSize int) ([]*model.ReconcileDiscrepancy, int64, error) {
+	return s.reconcileDiscrepancyRepository.List(ctx, billDate, status, pageNum, pageSize)
+}
@@ -0,0 +1,300 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"go.uber.org/zap"
+)
+
+type RefundService interface {
+	CreateRefund(ctx context.Context, operatorID int64, orderNo string, amount model.Decimal, reason string) (*model.Refund, error)
+	ApplyRefundResult(ctx context.Context, result *RefundResult) (*model.Refund, error)
+}
+
+func NewRefundService(
+	service *Service,
+	orderRepository repository.OrderRepository,
+	orderItemRepository repository.OrderItemRepository,
+	refundRepository repository.RefundRepository,
+	jobRepository repository.JobRepository,
+	contactVoucherHistoryService ContactVoucherHistoryService,
+	membershipService MembershipService,
+	paymentProvider PaymentProvider,
+) RefundService {
+	return &refundService{
+		Service:                      service,
+		orderRepository:              orderRepository,
+		orderItemRepository:          orderItemRepository,
+		refundRepository:             refundRepository,
+		jobRepository:                jobRepository,
+		contactVoucherHistoryService: contactVoucherHistoryService,
+		membershipService:            membershipService,
+		paymentProvider:              paymentProvider,
+	}
+}
+
+type refundService struct {
+	*Service
+	orderRepository              repository.OrderRepository
+	orderItemRepository          repository.OrderItemRepository
+	refundRepository             repository.RefundRepository
+	jobRepository                repository.JobRepository
+	contactVoucherHistoryService ContactVoucherHistoryService
+	membershipService            MembershipService
+	paymentProvider              PaymentProvider
+}
+
+// CreateRefund refunds amount of a paid order; a zero amount refunds whatever is left.
+// The refund is saved as processing before the provider is asked, so a refund the provider
+// took is never lost to a rollback: if recording the answer fails, the refund notify
+// settles it later.
+func (s *refundService) CreateRefund(ctx context.Context, operatorID int64, orderNo string, amount model.Decimal, reason string) (*model.Refund, error) {
+	var (
+		refund *model.Refund
+		cents  int64
+		paid   int64
+	)
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		found, err := s.orderRepository.GetByOrderNo(ctx, orderNo)
+		if err != nil {
+			return err
+		}
+		// The row lock keeps concurrent refunds of one order from adding up to more than was paid.
+		order, err := s.orderRepository.GetByIDForUpdate(ctx, found.ID)
+		if err != nil {
+			return err
+		}
+		// A canceled order with a payment on record is one whose late payment was turned away.
+		if order.Status != model.OrderStatusPaid && (order.Status != model.OrderStatusCanceled || order.PaidAt == nil) {
+			return ErrOrderNotRefundable
+		}
+		refunds, err := s.refundRepository.ListByOrderID(ctx, order.ID)
+		if err != nil {
+			return err
+		}
+		remaining := order.AmountPaid
+		for _, refund := range refunds {
+			if refund.Status == model.RefundStatusFailed {
+				continue
+			}
+			remaining = remaining.Sub(refund.Amount)
+		}
+		if amount.IsZero() {
+			amount = remaining
+		}
+		// The provider takes both amounts in cents.
+		cents, err = amount.ToCents()
+		if err != nil || amount.Sign() <= 0 || amount.Cmp(remaining) > 0 {
+			return ErrInvalidRefundAmount
+		}
+		if paid, err = order.AmountPaid.ToCents(); err != nil {
+			return err
+		}
+		now := time.Now()
+		refund = &model.Refund{
+			RefundNo:   s.generateRefundNo(),
+			OrderID:    order.ID,
+			OrderNo:    order.OrderNo,
+			UserID:     order.UserID,
+			Amount:     amount,
+			Reason:     reason,
+			Status:     model.RefundStatusProcessing,
+			OperatorID: operatorID,
+			CreateAt:   now,
+			UpdateAt:   now,
+		}
+		return s.refundRepository.Create(ctx, refund)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.paymentProvider.Refund(ctx, RefundRequest{
+		OrderNo:  refund.OrderNo,
+		RefundNo: refund.RefundNo,
+		Amount:   cents,
+		Total:    paid,
+		Reason:   reason,
+	})
+	if err != nil {
+		if errors.Is(err, ErrRefundRejected) {
+			// Nothing went out; fail the refund so its amount can be refunded again.
+			if _, ferr := s.ApplyRefundResult(ctx, &RefundResult{RefundNo: refund.RefundNo, Status: model.RefundStatusFailed}); ferr != nil {
+				s.logger.WithContext(ctx).Error("record rejected refund error", zap.String("refund_no", refund.RefundNo), zap.Error(ferr))
+			}
+		}
+		// Otherwise the refund may have gone through; it stays processing for the notify.
+		return nil, err
+	}
+	result.RefundNo = refund.RefundNo
+	recorded, err := s.ApplyRefundResult(ctx, result)
+	if err != nil {
+		s.logger.WithContext(ctx).Error("record refund result error", zap.String("refund_no", refund.RefundNo), zap.Error(err))
+		return refund, nil
+	}
+	return recorded, nil
+}
+
+// ApplyRefundResult records the outcome pushed by the refund notify.
+func (s *refundService) ApplyRefundResult(ctx context.Context, result *RefundResult) (*model.Refund, error) {
+	var refund *model.Refund
+	err := s.tm.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		refund, err = s.refundRepository.GetByRefundNo(ctx, result.RefundNo)
+		if err != nil {
+			return err
+		}
+		// Locked like CreateRefund, so refunds of one order finish one at a time.
+		order, err := s.orderRepository.GetByIDForUpdate(ctx, refund.OrderID)
+		if err != nil {
+			return err
+		}
+		return s.finish(ctx, order, refund, result)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return refund, nil
+}
+
+// finish moves a processing refund to its final state once. Benefits are only taken back
+// when the money has actually gone out, limited to what the user hasn't used by then.
+func (s *refundService) finish(ctx context.Context, order *model.Order, refund *model.Refund, result *RefundResult) error {
+	now := time.Now()
+	if result.TradeRefundNo != "" {
+		refund.PayRefundNo = result.TradeRefundNo
+	}
+	if result.Status == model.RefundStatusProcessing {
+		refund.UpdateAt = now
+		return s.refundRepository.Update(ctx, refund)
+	}
+	refund.Status = result.Status
+	refund.UpdateAt = now
+	if result.Status == model.RefundStatusSuccess {
+		refund.SuccessAt = &now
+	}
+	ok, err := s.refundRepository.Finish(ctx, refund)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+	if refund.Status == model.RefundStatusFailed {
+		s.logger.WithContext(ctx).Warn("refund failed", zap.String("refund_no", refund.RefundNo), zap.String("order_no", order.OrderNo))
+		return nil
+	}
+
+	paid, err := order.AmountPaid.ToCents()
+	if err != nil {
+		return err
+	}
+	cents, err := refund.Amount.ToCents()
+	if err != nil {
+		return err
+	}
+	// A canceled order never delivered anything to take back.
+	if order.Status != model.OrderStatusCanceled {
+		if err := s.rollbackBenefits(ctx, order, refund, cents, paid); err != nil {
+			return err
+		}
+	}
+	if err := s.refundRepository.Update(ctx, refund); err != nil {
+		return err
+	}
+
+	refunds, err := s.refundRepository.ListByOrderID(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+	var refunded model.Decimal
+	for _, r := range refunds {
+		if r.Status != model.RefundStatusSuccess {
+			continue
+		}
+		refunded = refunded.Add(r.Amount)
+	}
+	if refunded.Cmp(order.AmountPaid) < 0 {
+		return nil
+	}
+	order.Status = model.OrderStatusRefunded
+	order.RefundedAt = &now
+	order.UpdateAt = now
+	return s.orderRepository.Update(ctx, order)
+}
+
+// rollbackBenefits takes back the refunded share (refund/paid, rounded up) of every item.
+// Vouchers are capped by what is left of the order's own batches, top hours by the time the job has left on top and
+// membership days by the time the membership has left; Shorten also takes back the allowance
+// vouchers the shorter membership no longer covers.
+func (s *refundService) rollbackBenefits(ctx context.Context, order *model.Order, refund *model.Refund, refundCents, paidCents int64) error {
+	items, err := s.orderItemRepository.ListByOrderID(ctx, order.ID)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		switch item.ProductType {
+		case model.ProductTypeContactVoucher:
+			num := refundShare(item.ContactVoucherNum, refundCents, paidCents)
+			if num <= 0 {
+				continue
+			}
+			revoked, err := s.contactVoucherHistoryService.RevokeOrderVouchers(ctx, order.UserID, order.ID, num, "订单退款扣回")
+			if err != nil {
+				return err
+			}
+			refund.RollbackVoucherNum += revoked
+		case model.ProductTypeTop:
+			hours := refundShare(item.TopHour, refundCents, paidCents)
+			job, err := s.jobRepository.GetByID(ctx, item.TargetID)
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			if hours <= 0 || job.TopEndTime == nil || !job.TopEndTime.After(now) {
+				continue
+			}
+			end := job.TopEndTime.Add(-time.Duration(hours) * time.Hour)
+			if end.Before(now) {
+				hours = int(math.Ceil(job.TopEndTime.Sub(now).Hours()))
+				end = now
+			}
+			job.TopEndTime = &end
+			// Taken back whole, the placement ends now rather than expiring.
+			job.IsTop = end.After(now)
+			job.UpdateAt = now
+			if err := s.jobRepository.Update(ctx, job); err != nil {
+				return err
+			}
+			refund.RollbackTopHour += hours
+		case model.ProductTypeMembership:
+			days, err := s.membershipService.Shorten(ctx, order.UserID, refundShare(item.MembershipDays, refundCents, paidCents))
+			if err != nil {
+				return err
+			}
+			refund.RollbackMemberDays += days
+		}
+	}
+	return nil
+}
+
+func refundShare(num int, refundCents, paidCents int64) int {
+	if num <= 0 || paidCents <= 0 || refundCents >= paidCents {
+		return num
+	}
+	return int((int64(num)*refundCents + paidCents - 1) / paidCents)
+}
+
+func (s *refundService) generateRefundNo() string {
+	id, err := s.sid.GenUint64()
+	if err != nil {
+		return fmt.Sprintf("RF%s", time.Now().Format("20060102150405"))
+	}
+	return fmt.Sprintf("RF%s%06d", time.Now().Format("20060102150405"), id%1000000)
+}
@@ -4,12 +4,16 @@ import (
 	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
 	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
 	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
-	"github.com/go-nunu/nunu-layout-advanced/pkg/sid"
 )
 
+// IDGenerator hands out the unique ids behind order and refund numbers; *sid.Sid in the apps.
+type IDGenerator interface {
+	GenUint64() (uint64, error)
+}
+
 type Service struct {
 	logger *log.Logger
-	sid    *sid.Sid
+	sid    IDGenerator
 	jwt    *jwt.JWT
 	tm     repository.Transaction
 }
@@ -17,7 +21,7 @@ type Service struct {
 func NewService(
 	tm repository.Transaction,
 	logger *log.Logger,
-	sid *sid.Sid,
+	sid IDGenerator,
 	jwt *jwt.JWT,
 ) *Service {
 	return &Service{
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+)
+
+type TagService interface {
+	// List returns the online tags of a category, or of every category when it is 0.
+	List(ctx context.Context, category model.TagCategory) ([]*model.Tag, error)
+	// Resolve maps codes, or the labels older clients send, to the category's tags. An
+	// unknown value is ErrInvalidTag.
+	Resolve(ctx context.Context, category model.TagCategory, values []string) ([]*model.Tag, error)
+	// SetJobTags replaces the job's tags of one category.
+	SetJobTags(ctx context.Context, jobID int64, category model.TagCategory, tags []*model.Tag) error
+	// JobTags returns each job's tags, ordered by category and then as the dictionary sorts them.
+	JobTags(ctx context.Context, jobIDs []int64) (map[int64][]*model.Tag, error)
+}
+
+func NewTagService(
+	service *Service,
+	tagRepository repository.TagRepository,
+	jobTagRepository repository.JobTagRepository,
+) TagService {
+	return &tagService{
+		Service:          service,
+		tagRepository:    tagRepository,
+		jobTagRepository: jobTagRepository,
+	}
+}
+
+type tagService struct {
+	*Service
+	tagRepository    repository.TagRepository
+	jobTagRepository repository.JobTagRepository
+}
+
+func (s *tagService) List(ctx context.Context, category model.TagCategory) ([]*model.Tag, error) {
+	return s.tagRepository.ListOnline(ctx, category)
+}
+
+func (s *tagService) Resolve(ctx context.Context, category model.TagCategory, values []string) ([]*model.Tag, error) {
+	wanted := make([]string, 0, len(values))
+	for _, value := range values {
+		if value = strings.TrimSpace(value); value != "" {
+			wanted = append(wanted, value)
+		}
+	}
+	if len(wanted) == 0 {
+		return []*model.Tag{}, nil
+	}
+	found, err := s.tagRepository.FindByValues(ctx, category, wanted)
+	if err != nil {
+		return nil, err
+	}
+	byValue := make(map[string]*model.Tag, len(found)*2)
+	for _, tag := range found {
+		byValue[tag.Label] = tag
+	}
+	// A code wins over another tag's identical label.
+	for _, tag := range found {
+		byValue[tag.Code] = tag
+	}
+	tags := make([]*model.Tag, 0, len(wanted))
+	seen := make(map[int64]bool, len(wanted))
+	for _, value := range wanted {
+		tag, ok := byValue[value]
+		if !ok {
+			return nil, ErrInvalidTag
+		}
+		if !seen[tag.ID] {
+			seen[tag.ID] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags, nil
+}
+
+func (s *tagService) SetJobTags(ctx context.Context, jobID int64, category model.TagCategory, tags []*model.Tag) error {
+	tagIDs := make([]int64, 0, len(tags))
+	for _, tag := range tags {
+		tagIDs = append(tagIDs, tag.ID)
+	}
+	return s.jobTagRepository.Replace(ctx, jobID, category, tagIDs)
+}
+
+func (s *tagService) JobTags(ctx context.Context, jobIDs []int64) (map[int64][]*model.Tag, error) {
+	jobTags, err := s.jobTagRepository.ListByJobIDs(ctx, jobIDs)
+	if err != nil {
+		return nil, err
+	}
+	tagIDs := make([]int64, 0, len(jobTags))
+	seen := make(map[int64]bool, len(jobTags))
+	for _, jobTag := range jobTags {
+		if !seen[jobTag.TagID] {
+			seen[jobTag.TagID] = true
+			tagIDs = append(tagIDs, jobTag.TagID)
+		}
+	}
+	tags, err := s.tagRepository.ListByIDs(ctx, tagIDs)
+	if err != nil {
+		return nil, err
+	}
+	tagJobs := make(map[int64][]int64, len(tagIDs))
+	for _, jobTag := range jobTags {
+		tagJobs[jobTag.TagID] = append(tagJobs[jobTag.TagID], jobTag.JobID)
+	}
+	result := make(map[int64][]*model.Tag, len(jobIDs))
+	for _, tag := range tags {
+		for _, jobID := range tagJobs[tag.ID] {
+			result[jobID] = append(result[jobID], tag)
+		}
+	}
+	return result, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/go-nunu/nunu-layout-advanced/internal/model"
 	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"go.uber.org/zap"
 	"time"
 )
 
@@ -16,15 +17,18 @@ type UserService interface {
 func NewUserService(
 	service *Service,
 	userRepo repository.UserRepository,
+	integralService IntegralService,
 ) UserService {
 	return &userService{
-		userRepo: userRepo,
-		Service:  service,
+		userRepo:        userRepo,
+		integralService: integralService,
+		Service:         service,
 	}
 }
 
 type userService struct {
-	userRepo repository.UserRepository
+	userRepo        repository.UserRepository
+	integralService IntegralService
 	*Service
 }
 
@@ -66,7 +70,20 @@ func (s *userService) UpdateInfo(ctx context.Context, userID int64, input Update
 		user.Phone = *input.Phone
 	}
 	user.UpdateAt = time.Now()
-	return s.userRepo.Update(ctx, user)
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		return err
+	}
+	if user.Avatar != "" && user.Name != "" && user.Sex != 0 && user.Phone != "" {
+		if _, err := s.integralService.Earn(ctx, IntegralEarnInput{
+			UserID:  userID,
+			BizType: model.IntegralHistoryProfileComplete,
+			BizKey:  "profile_complete",
+			Remark:  "完善个人资料",
+		}); err != nil {
+			s.logger.WithContext(ctx).Error("integralService.Earn error", zap.Int64("user_id", userID), zap.Error(err))
+		}
+	}
+	return nil
 }
 
 func (s *userService) UpdateGeo(ctx context.Context, userID int64, input UpdateUserGeoInput) error {
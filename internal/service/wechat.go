@@ -16,10 +16,11 @@ import (
 	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
 	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
 	"github.com/spf13/viper"
+	"go.uber.org/zap"
 )
 
 type WechatService interface {
-	Register(ctx context.Context, code, loginCode string, inviterID int64) (string, *model.User, error)
+	Register(ctx context.Context, input WechatRegisterInput) (string, *model.User, error)
 	Login(ctx context.Context, code string) (string, *model.User, error)
 }
 
@@ -28,20 +29,31 @@ func NewWechatService(
 	config *viper.Viper,
 	jwtClient *jwt.JWT,
 	userRepo repository.UserRepository,
+	inviteService InviteService,
 ) WechatService {
 	return &wechatService{
-		logger:   logger,
-		config:   config,
-		jwt:      jwtClient,
-		userRepo: userRepo,
+		logger:        logger,
+		config:        config,
+		jwt:           jwtClient,
+		userRepo:      userRepo,
+		inviteService: inviteService,
 	}
 }
 
 type wechatService struct {
-	logger   *log.Logger
-	config   *viper.Viper
-	jwt      *jwt.JWT
-	userRepo repository.UserRepository
+	logger        *log.Logger
+	config        *viper.Viper
+	jwt           *jwt.JWT
+	userRepo      repository.UserRepository
+	inviteService InviteService
+}
+
+type WechatRegisterInput struct {
+	PhoneCode  string
+	LoginCode  string
+	InviterID  int64
+	InviteCode string
+	DeviceID   string
 }
 
 type wechatSessionResponse struct {
@@ -66,15 +78,15 @@ type wechatPhoneResponse struct {
 	} `json:"phone_info"`
 }
 
-func (s *wechatService) Register(ctx context.Context, code, loginCode string, inviterID int64) (string, *model.User, error) {
-	if code == "" || loginCode == "" {
+func (s *wechatService) Register(ctx context.Context, input WechatRegisterInput) (string, *model.User, error) {
+	if input.PhoneCode == "" || input.LoginCode == "" {
 		return "", nil, errors.New("code or loginCode is empty")
 	}
-	session, err := s.code2session(ctx, loginCode)
+	session, err := s.code2session(ctx, input.LoginCode)
 	if err != nil {
 		return "", nil, err
 	}
-	phone, err := s.getPhone(ctx, code)
+	phone, err := s.getPhone(ctx, input.PhoneCode)
 	if err != nil {
 		return "", nil, err
 	}
@@ -86,6 +98,10 @@ func (s *wechatService) Register(ctx context.Context, code, loginCode string, in
 	if user != nil {
 		return "", nil, ErrUserExists
 	}
+	inviterID, err := s.inviteService.ResolveInviter(ctx, input.InviteCode, input.InviterID)
+	if err != nil {
+		return "", nil, err
+	}
 	user = &model.User{
 		WechatOpenID: session.OpenID,
 		Phone:        phone,
@@ -105,6 +121,10 @@ func (s *wechatService) Register(ctx context.Context, code, loginCode string, in
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		return "", nil, err
 	}
+	// The account exists at this point; a failed reward is logged rather than failing sign-up.
+	if err := s.inviteService.Accept(ctx, user, input.DeviceID); err != nil {
+		s.logger.WithContext(ctx).Error("inviteService.Accept error", zap.Int64("user_id", user.ID), zap.Error(err))
+	}
 	return token, user, nil
 }
 
@@ -0,0 +1,37 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"go.uber.org/zap"
+)
+
+type MembershipTask interface {
+	GrantAllowances(ctx context.Context) error
+}
+
+func NewMembershipTask(
+	task *Task,
+	membershipService service.MembershipService,
+) MembershipTask {
+	return &membershipTask{
+		Task:              task,
+		membershipService: membershipService,
+	}
+}
+
+type membershipTask struct {
+	*Task
+	membershipService service.MembershipService
+}
+
+// GrantAllowances hands active members the monthly vouchers that have come due.
+func (t *membershipTask) GrantAllowances(ctx context.Context) error {
+	granted, err := t.membershipService.GrantAllowances(ctx, time.Now())
+	if granted > 0 {
+		t.logger.Info("GrantAllowances", zap.Int("granted", granted))
+	}
+	return err
+}
@@ -0,0 +1,61 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+)
+
+type OrderTask interface {
+	ExpirePendingOrders(ctx context.Context) error
+	PurgeIdempotencyKeys(ctx context.Context) error
+	ExpireTops(ctx context.Context) error
+}
+
+func NewOrderTask(
+	task *Task,
+	config *viper.Viper,
+	orderService service.OrderService,
+) OrderTask {
+	return &orderTask{
+		Task:         task,
+		config:       config,
+		orderService: orderService,
+	}
+}
+
+type orderTask struct {
+	*Task
+	config       *viper.Viper
+	orderService service.OrderService
+}
+
+// ExpirePendingOrders cancels the orders left unpaid longer than order.pending_ttl.
+func (t *orderTask) ExpirePendingOrders(ctx context.Context) error {
+	before := time.Now().Add(-service.PendingOrderTTL(t.config))
+	canceled, err := t.orderService.ExpirePendingOrders(ctx, before)
+	if canceled > 0 {
+		t.logger.Info("ExpirePendingOrders", zap.Int("canceled", canceled))
+	}
+	return err
+}
+
+func (t *orderTask) PurgeIdempotencyKeys(ctx context.Context) error {
+	deleted, err := t.orderService.PurgeIdempotencyKeys(ctx)
+	if deleted > 0 {
+		t.logger.Info("PurgeIdempotencyKeys", zap.Int64("deleted", deleted))
+	}
+	return err
+}
+
+// ExpireTops takes ended top placements off the list's top.
+func (t *orderTask) ExpireTops(ctx context.Context) error {
+	expired, err := t.orderService.ExpireTops(ctx, time.Now())
+	if expired > 0 {
+		t.logger.Info("ExpireTops", zap.Int("expired", expired))
+	}
+	return err
+}
@@ -0,0 +1,36 @@
+package task
+
+import (
+	"context"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"go.uber.org/zap"
+)
+
+type PayTask interface {
+	PurgeNotifyNonces(ctx context.Context) error
+}
+
+func NewPayTask(
+	task *Task,
+	payService service.PayService,
+) PayTask {
+	return &payTask{
+		Task:       task,
+		payService: payService,
+	}
+}
+
+type payTask struct {
+	*Task
+	payService service.PayService
+}
+
+// PurgeNotifyNonces drops the payment notification nonces that can no longer be replayed.
+func (t *payTask) PurgeNotifyNonces(ctx context.Context) error {
+	deleted, err := t.payService.PurgeNotifyNonces(ctx)
+	if deleted > 0 {
+		t.logger.Info("PurgeNotifyNonces", zap.Int64("deleted", deleted))
+	}
+	return err
+}
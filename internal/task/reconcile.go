@@ -0,0 +1,53 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"go.uber.org/zap"
+)
+
+type ReconcileTask interface {
+	ReconcileYesterday(ctx context.Context) error
+}
+
+func NewReconcileTask(
+	task *Task,
+	reconcileService service.ReconcileService,
+) ReconcileTask {
+	return &reconcileTask{
+		Task:             task,
+		reconcileService: reconcileService,
+	}
+}
+
+type reconcileTask struct {
+	*Task
+	reconcileService service.ReconcileService
+}
+
+// billLocation is the zone the provider cuts its bill days in. Asia/Shanghai has no DST, so a
+// fixed UTC+8 stands in on hosts without a tz database.
+var billLocation = func() *time.Location {
+	if loc, err := time.LoadLocation("Asia/Shanghai"); err == nil {
+		return loc
+	}
+	return time.FixedZone("CST", 8*60*60)
+}()
+
+// ReconcileYesterday checks yesterday's trade bill against the orders. Reconcile takes the
+// day's bounds from the date's location, so yesterday is taken in the bill's zone whatever
+// the host's is.
+func (t *reconcileTask) ReconcileYesterday(ctx context.Context) error {
+	result, err := t.reconcileService.Reconcile(ctx, time.Now().In(billLocation).AddDate(0, 0, -1))
+	if err != nil {
+		return err
+	}
+	t.logger.Info("ReconcileYesterday",
+		zap.String("bill_date", result.BillDate),
+		zap.Int("records", result.Records),
+		zap.Int("discrepancies", result.Discrepancies),
+		zap.Int("fixed", result.Fixed))
+	return nil
+}
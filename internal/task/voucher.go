@@ -0,0 +1,37 @@
+package task
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"go.uber.org/zap"
+)
+
+type VoucherTask interface {
+	ExpireVouchers(ctx context.Context) error
+}
+
+func NewVoucherTask(
+	task *Task,
+	contactVoucherHistoryService service.ContactVoucherHistoryService,
+) VoucherTask {
+	return &voucherTask{
+		Task:                         task,
+		contactVoucherHistoryService: contactVoucherHistoryService,
+	}
+}
+
+type voucherTask struct {
+	*Task
+	contactVoucherHistoryService service.ContactVoucherHistoryService
+}
+
+// ExpireVouchers takes the leftovers of expired voucher batches off the balances.
+func (t *voucherTask) ExpireVouchers(ctx context.Context) error {
+	expired, err := t.contactVoucherHistoryService.ExpireVouchers(ctx, time.Now())
+	if expired > 0 {
+		t.logger.Info("ExpireVouchers", zap.Int("expired", expired))
+	}
+	return err
+}
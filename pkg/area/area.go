@@ -0,0 +1,213 @@
+package area
+
+import (
+	"bufio"
+	"bytes"
+	_ "embed"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-nunu/nunu-layout-advanced/pkg/geo"
+)
+
+// Levels of the dictionary, matching Job's First..FourAreaID.
+const (
+	LevelProvince = 1
+	LevelCity     = 2
+	LevelDistrict = 3
+	LevelStreet   = 4
+)
+
+//go:embed areas.csv
+var embedded []byte
+
+type Area struct {
+	ID        int
+	ParentID  int
+	Level     int
+	Name      string
+	Longitude float64
+	Latitude  float64
+}
+
+// HasLocation reports whether the area's seat is known.
+func (a *Area) HasLocation() bool {
+	return a.Longitude != 0 || a.Latitude != 0
+}
+
+// Level tells an area's level from the length of its code; 0 is not a valid code.
+func Level(id int) int {
+	switch {
+	case id >= 10 && id <= 99:
+		return LevelProvince
+	case id >= 1000 && id <= 9999:
+		return LevelCity
+	case id >= 100000 && id <= 999999:
+		return LevelDistrict
+	case id >= 100000000 && id <= 999999999:
+		return LevelStreet
+	}
+	return 0
+}
+
+// ParentID is the code id extends, 0 for a province or an invalid code.
+func ParentID(id int) int {
+	switch Level(id) {
+	case LevelCity, LevelDistrict:
+		return id / 100
+	case LevelStreet:
+		return id / 1000
+	}
+	return 0
+}
+
+// Dictionary is an immutable set of areas.
+type Dictionary struct {
+	byID     map[int]*Area
+	children map[int][]*Area
+}
+
+var (
+	defaultOnce sync.Once
+	defaultDict *Dictionary
+)
+
+// Default returns the dictionary built into the binary.
+func Default() *Dictionary {
+	defaultOnce.Do(func() {
+		dict, err := Load(bytes.NewReader(embedded))
+		if err != nil {
+			panic(fmt.Sprintf("area: embedded dataset: %v", err))
+		}
+		defaultDict = dict
+	})
+	return defaultDict
+}
+
+// Load reads lines of "id,name,longitude,latitude"; the coordinates may be empty, and
+// blank lines and lines starting with # are skipped. Every area's parent must be listed.
+func Load(r io.Reader) (*Dictionary, error) {
+	dict := &Dictionary{
+		byID:     make(map[int]*Area),
+		children: make(map[int][]*Area),
+	}
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		fields := strings.Split(text, ",")
+		if len(fields) != 2 && len(fields) != 4 {
+			return nil, fmt.Errorf("line %d: want 2 or 4 fields", line)
+		}
+		id, err := strconv.Atoi(strings.TrimSpace(fields[0]))
+		if err != nil || Level(id) == 0 {
+			return nil, fmt.Errorf("line %d: invalid id %q", line, fields[0])
+		}
+		a := &Area{ID: id, ParentID: ParentID(id), Level: Level(id), Name: strings.TrimSpace(fields[1])}
+		if a.Name == "" {
+			return nil, fmt.Errorf("line %d: empty name", line)
+		}
+		if len(fields) == 4 && strings.TrimSpace(fields[2]) != "" {
+			if a.Longitude, err = strconv.ParseFloat(strings.TrimSpace(fields[2]), 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid longitude", line)
+			}
+			if a.Latitude, err = strconv.ParseFloat(strings.TrimSpace(fields[3]), 64); err != nil {
+				return nil, fmt.Errorf("line %d: invalid latitude", line)
+			}
+		}
+		if _, ok := dict.byID[id]; ok {
+			return nil, fmt.Errorf("line %d: duplicate id %d", line, id)
+		}
+		dict.byID[id] = a
+		dict.children[a.ParentID] = append(dict.children[a.ParentID], a)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	for _, a := range dict.byID {
+		if a.ParentID != 0 {
+			if _, ok := dict.byID[a.ParentID]; !ok {
+				return nil, fmt.Errorf("area %d: parent %d is not listed", a.ID, a.ParentID)
+			}
+		}
+	}
+	for _, list := range dict.children {
+		sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	}
+	return dict, nil
+}
+
+func (d *Dictionary) Get(id int) (*Area, bool) {
+	a, ok := d.byID[id]
+	return a, ok
+}
+
+// Children lists the areas under parentID, or the provinces for 0.
+func (d *Dictionary) Children(parentID int) []*Area {
+	return d.children[parentID]
+}
+
+// Covers reports whether the areas under parentID are listed. Below an area that isn't
+// covered, codes can only be checked against their parent's.
+func (d *Dictionary) Covers(parentID int) bool {
+	return len(d.children[parentID]) > 0
+}
+
+func (d *Dictionary) ChildByName(parentID int, name string) (*Area, bool) {
+	for _, a := range d.children[parentID] {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return nil, false
+}
+
+// Path returns the area and its ancestors, province first.
+func (d *Dictionary) Path(id int) []*Area {
+	var path []*Area
+	for a, ok := d.byID[id]; ok; a, ok = d.byID[a.ParentID] {
+		path = append([]*Area{a}, path...)
+	}
+	return path
+}
+
+// nearbyRadius is how far from its seat a point is still taken to be in an area, by level.
+var nearbyRadius = map[int]float64{
+	LevelStreet:   5000,
+	LevelDistrict: 30000,
+	LevelCity:     150000,
+	LevelProvince: 1000000,
+}
+
+// Locate guesses the areas a point lies in from the nearest seat, trying the finest level
+// first, and returns its path. Without boundaries this is only an approximation near the
+// edge of an area. It returns nil when no seat is near enough.
+func (d *Dictionary) Locate(latitude, longitude float64) []*Area {
+	for level := LevelStreet; level >= LevelProvince; level-- {
+		var (
+			nearest  *Area
+			distance float64
+		)
+		for _, a := range d.byID {
+			if a.Level != level || !a.HasLocation() {
+				continue
+			}
+			dist := geo.Distance(latitude, longitude, a.Latitude, a.Longitude)
+			if nearest == nil || dist < distance || (dist == distance && a.ID < nearest.ID) {
+				nearest, distance = a, dist
+			}
+		}
+		if nearest != nil && distance <= nearbyRadius[level] {
+			return d.Path(nearest.ID)
+		}
+	}
+	return nil
+}
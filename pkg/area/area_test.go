@@ -0,0 +1,96 @@
+package area
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func names(areas []*Area) []string {
+	out := make([]string, 0, len(areas))
+	for _, a := range areas {
+		out = append(out, a.Name)
+	}
+	return out
+}
+
+func TestLevelAndParent(t *testing.T) {
+	for id, want := range map[int][2]int{
+		11:        {LevelProvince, 0},
+		1101:      {LevelCity, 11},
+		110105:    {LevelDistrict, 1101},
+		110105001: {LevelStreet, 110105},
+		0:         {0, 0},
+		123:       {0, 0},
+	} {
+		assert.Equal(t, want[0], Level(id), "%d", id)
+		assert.Equal(t, want[1], ParentID(id), "%d", id)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dict, err := Load(strings.NewReader("# comment\n11,北京市,116.4074,39.9042\n1101,北京市\n\n110101,东城区,116.4160,39.9288\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"北京市", "北京市", "东城区"}, names(dict.Path(110101)))
+	assert.True(t, dict.Covers(1101))
+	assert.False(t, dict.Covers(110101))
+
+	for _, data := range []string{
+		"1101,北京市\n",       // parent not listed
+		"11,北京市\n11,北京市\n", // duplicate
+		"11\n",             // too few fields
+		"123,x\n",          // invalid code
+		"11,北京市,east,39\n", // invalid coordinate
+	} {
+		_, err := Load(strings.NewReader(data))
+		assert.Error(t, err, data)
+	}
+}
+
+func TestDefault(t *testing.T) {
+	dict := Default()
+	assert.Len(t, dict.Children(0), 34)
+	assert.Equal(t, []string{"北京市"}, names(dict.Children(11)))
+	assert.Contains(t, names(dict.Children(1101)), "朝阳区")
+	a, ok := dict.ChildByName(1101, "朝阳区")
+	if assert.True(t, ok) {
+		assert.Equal(t, 110105, a.ID)
+	}
+	a, ok = dict.ChildByName(4401, "天河区")
+	if assert.True(t, ok) {
+		assert.Equal(t, 440106, a.ID)
+	}
+}
+
+func TestDefault_ListsEveryDistrict(t *testing.T) {
+	dict := Default()
+	// Prefecture-level cities that GB/T 2260 gives no county-level divisions.
+	noDistricts := map[int]bool{4419: true, 4420: true, 4604: true, 6202: true}
+	cities, districts := 0, 0
+	for _, province := range dict.Children(0) {
+		switch province.ID {
+		case 71, 81, 82:
+			assert.False(t, dict.Covers(province.ID), province.Name)
+			continue
+		}
+		assert.True(t, dict.Covers(province.ID), province.Name)
+		for _, city := range dict.Children(province.ID) {
+			cities++
+			districts += len(dict.Children(city.ID))
+			assert.Equal(t, !noDistricts[city.ID], dict.Covers(city.ID), city.Name)
+		}
+	}
+	assert.Equal(t, 342, cities)
+	assert.Equal(t, 2844, districts)
+}
+
+func TestLocate(t *testing.T) {
+	dict := Default()
+	// A point in 王府井 is nearest to 东城区's seat.
+	located := dict.Locate(39.9140, 116.4100)
+	if assert.Len(t, located, 3) {
+		assert.Equal(t, []int{11, 1101, 110101}, []int{located[0].ID, located[1].ID, located[2].ID})
+	}
+	assert.Empty(t, dict.Locate(-33.86, 151.21))
+}
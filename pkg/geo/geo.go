@@ -0,0 +1,84 @@
+package geo
+
+import "math"
+
+// EarthRadius is the mean earth radius in meters.
+const EarthRadius = 6371008.8
+
+// metersPerDegree is the length of one degree of latitude, and of longitude at the equator.
+const metersPerDegree = EarthRadius * math.Pi / 180
+
+// Distance returns the great-circle distance in meters between two points, using the
+// haversine formula.
+func Distance(lat1, lng1, lat2, lng2 float64) float64 {
+	rLat1 := radians(lat1)
+	rLat2 := radians(lat2)
+	sinLat := math.Sin(radians(lat2-lat1) / 2)
+	sinLng := math.Sin(radians(lng2-lng1) / 2)
+	a := sinLat*sinLat + math.Cos(rLat1)*math.Cos(rLat2)*sinLng*sinLng
+	return 2 * EarthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+// Box is a latitude/longitude range.
+type Box struct {
+	MinLat, MaxLat float64
+	MinLng, MaxLng float64
+}
+
+// BoundingBox returns the smallest box containing every point within radius meters of
+// (lat, lng). Near a pole it covers every longitude; it is clipped rather than wrapped at
+// the antimeridian, like Plane.
+func BoundingBox(lat, lng, radius float64) Box {
+	dLat := radius / metersPerDegree
+	box := Box{MinLat: lat - dLat, MaxLat: lat + dLat, MinLng: -180, MaxLng: 180}
+	if box.MinLat <= -90 || box.MaxLat >= 90 {
+		box.MinLat = math.Max(box.MinLat, -90)
+		box.MaxLat = math.Min(box.MaxLat, 90)
+		return box
+	}
+	// The circle is widest nearer the pole than its center, at asin(sin r / cos lat).
+	ratio := math.Sin(radius/EarthRadius) / math.Cos(radians(lat))
+	if ratio >= 1 {
+		return box
+	}
+	dLng := math.Asin(ratio) * 180 / math.Pi
+	box.MinLng = math.Max(lng-dLng, -180)
+	box.MaxLng = math.Min(lng+dLng, 180)
+	return box
+}
+
+// PlaneMaxRadius is how far from its center, in meters, a Plane stays within 1% of Distance
+// anywhere below 70° of latitude. Radius filters that go by a Plane are capped at it.
+const PlaneMaxRadius = 100000
+
+// Plane is an equirectangular projection about a point: it maps a nearby latitude and
+// longitude to meters north and east of it with nothing but subtraction and
+// multiplication, so the same formula runs inside any SQL database. Within PlaneMaxRadius it
+// stays within about 1% of Distance.
+type Plane struct {
+	Lat, Lng float64
+	// LatScale and LngScale are meters per degree in each direction at the point.
+	LatScale, LngScale float64
+}
+
+func NewPlane(lat, lng float64) Plane {
+	return Plane{
+		Lat:      lat,
+		Lng:      lng,
+		LatScale: metersPerDegree,
+		LngScale: metersPerDegree * math.Cos(radians(lat)),
+	}
+}
+
+// Distance returns the planar distance in meters from the plane's center to a point. It
+// is the square root of the expression the job list filters and orders by, so a distance
+// shown next to a result agrees with the radius that let it through.
+func (p Plane) Distance(lat, lng float64) float64 {
+	dy := (lat - p.Lat) * p.LatScale
+	dx := (lng - p.Lng) * p.LngScale
+	return math.Sqrt(dx*dx + dy*dy)
+}
+
+func radians(deg float64) float64 {
+	return deg * math.Pi / 180
+}
@@ -0,0 +1,54 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDistance(t *testing.T) {
+	const lat, lng = 39.9087, 116.3975
+	assert.InDelta(t, 1067000, Distance(lat, lng, 31.2304, 121.4737), 5000)
+	assert.InDelta(t, 10930, Distance(lat, lng, lat+0.07, lng+0.09), 50)
+	assert.Zero(t, Distance(lat, lng, lat, lng))
+}
+
+func TestBoundingBox(t *testing.T) {
+	const lat, lng = 39.9087, 116.3975
+	box := BoundingBox(lat, lng, 10000)
+	// Every point on the circle lies inside the box.
+	for _, p := range [][2]float64{{box.MinLat, lng}, {box.MaxLat, lng}, {lat, box.MinLng}, {lat, box.MaxLng}} {
+		assert.InDelta(t, 10000, Distance(lat, lng, p[0], p[1]), 50)
+	}
+	polar := BoundingBox(89.99, 0, 10000)
+	assert.Equal(t, 90.0, polar.MaxLat)
+	assert.Equal(t, -180.0, polar.MinLng)
+	assert.Equal(t, 180.0, polar.MaxLng)
+}
+
+func TestPlane(t *testing.T) {
+	const lat, lng = 39.9087, 116.3975
+	p := NewPlane(lat, lng)
+	dy := (lat + 0.05 - p.Lat) * p.LatScale
+	dx := (lng + 0.05 - p.Lng) * p.LngScale
+	planar := dx*dx + dy*dy
+	exact := Distance(lat, lng, lat+0.05, lng+0.05)
+	assert.InEpsilon(t, exact*exact, planar, 0.02)
+	assert.InDelta(t, math.Sqrt(planar), p.Distance(lat+0.05, lng+0.05), 1e-6)
+	assert.Zero(t, p.Distance(lat, lng))
+}
+
+func TestPlane_MaxRadius(t *testing.T) {
+	const radius = PlaneMaxRadius / EarthRadius
+	for _, lat := range []float64{0, 23.5, 39.9, 53.5, 69.9} {
+		p := NewPlane(lat, 0)
+		// Walk the great circle of radius PlaneMaxRadius around the center.
+		for bearing := 0.0; bearing < 360; bearing += 15 {
+			rLat, rBearing := radians(lat), radians(bearing)
+			lat2 := math.Asin(math.Sin(rLat)*math.Cos(radius) + math.Cos(rLat)*math.Sin(radius)*math.Cos(rBearing))
+			lng2 := math.Atan2(math.Sin(rBearing)*math.Sin(radius)*math.Cos(rLat), math.Cos(radius)-math.Sin(rLat)*math.Sin(lat2))
+			assert.InEpsilon(t, PlaneMaxRadius, p.Distance(lat2*180/math.Pi, lng2*180/math.Pi), 0.01, "lat %v bearing %v", lat, bearing)
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package wxpay
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/csv"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	TradeBillStateSuccess = "SUCCESS"
+	TradeBillStateRefund  = "REFUND"
+	TradeBillStateRevoked = "REVOKED"
+)
+
+// billLocation is the zone the bill's trade times are written in.
+var billLocation = time.FixedZone("CST", 8*60*60)
+
+type tradeBillResponse struct {
+	HashType    string `json:"hash_type"`
+	HashValue   string `json:"hash_value"`
+	DownloadURL string `json:"download_url"`
+}
+
+// TradeBillRow is one line of a trade bill. Amounts are in cents; a REFUND row carries
+// the refund fields and repeats the order it belongs to.
+type TradeBillRow struct {
+	TradeTime     time.Time
+	TransactionID string
+	OutTradeNo    string
+	TradeState    string
+	Amount        int64
+	RefundID      string
+	OutRefundNo   string
+	RefundAmount  int64
+}
+
+// DownloadTradeBill fetches the CSV trade bill of every transaction on billDate
+// (2006-01-02). A day without transactions yields an empty bill rather than an error.
+func (c *Client) DownloadTradeBill(ctx context.Context, billDate string) ([]byte, error) {
+	path := "/v3/bill/tradebill?bill_date=" + url.QueryEscape(billDate) + "&bill_type=ALL"
+	var resp tradeBillResponse
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && apiErr.Code == "NO_STATEMENT_EXIST" {
+			return nil, nil
+		}
+		return nil, err
+	}
+	raw, err := c.download(ctx, resp.DownloadURL)
+	if err != nil {
+		return nil, err
+	}
+	// The file itself is unsigned; the hash in the signed answer above vouches for it.
+	if !strings.EqualFold(resp.HashType, "SHA1") {
+		return nil, fmt.Errorf("wxpay: unsupported bill hash type %s", resp.HashType)
+	}
+	sum := sha1.Sum(raw)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), resp.HashValue) {
+		return nil, errors.New("wxpay: bill hash mismatch")
+	}
+	return raw, nil
+}
+
+// download GETs an absolute download_url, signing its path and query like any other call.
+func (c *Client) download(ctx context.Context, rawURL string) ([]byte, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	auth, err := c.authorization(http.MethodGet, u.RequestURI(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", auth)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, &APIError{StatusCode: resp.StatusCode, Message: string(raw)}
+	}
+	return raw, nil
+}
+
+// ParseTradeBill reads the rows of a trade bill, stopping at the summary block that
+// follows them. Columns are found by their header, so the ALL, SUCCESS and REFUND
+// layouts all parse.
+func ParseTradeBill(data []byte) ([]TradeBillRow, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))))
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	for _, name := range []string{"交易时间", "微信订单号", "商户订单号", "交易状态", "订单金额"} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("wxpay: bill has no %s column", name)
+		}
+	}
+	field := func(record []string, name string) string {
+		i, ok := columns[name]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		// Every value is prefixed with a backtick so spreadsheets keep it as text.
+		return strings.TrimPrefix(strings.TrimSpace(record[i]), "`")
+	}
+
+	var rows []TradeBillRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if len(record) > 0 && strings.HasPrefix(strings.TrimSpace(record[0]), "总") {
+			break
+		}
+		tradeTime, err := time.ParseInLocation("2006-01-02 15:04:05", field(record, "交易时间"), billLocation)
+		if err != nil {
+			return nil, fmt.Errorf("wxpay: bill line %d: %w", len(rows)+2, err)
+		}
+		row := TradeBillRow{
+			TradeTime:     tradeTime,
+			TransactionID: field(record, "微信订单号"),
+			OutTradeNo:    field(record, "商户订单号"),
+			TradeState:    field(record, "交易状态"),
+			RefundID:      field(record, "微信退款单号"),
+			OutRefundNo:   field(record, "商户退款单号"),
+		}
+		if row.Amount, err = parseYuan(field(record, "订单金额")); err != nil {
+			return nil, fmt.Errorf("wxpay: bill line %d: %w", len(rows)+2, err)
+		}
+		if row.RefundAmount, err = parseYuan(field(record, "退款金额")); err != nil {
+			return nil, fmt.Errorf("wxpay: bill line %d: %w", len(rows)+2, err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// parseYuan turns a bill amount such as "12.5" or "0.01" into cents; empty reads as 0.
+func parseYuan(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	whole, frac, _ := strings.Cut(s, ".")
+	if len(frac) > 2 {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	frac += strings.Repeat("0", 2-len(frac))
+	yuan, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil || yuan < 0 {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	cents, err := strconv.ParseInt(frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	return yuan*100 + cents, nil
+}
@@ -0,0 +1,303 @@
+package wxpay
+
+import (
+	"bytes"
+	"context"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultEndpoint = "https://api.mch.weixin.qq.com"
+
+// CertRefreshInterval is the least time between two platform certificate downloads. A
+// serial still unknown after one is refused without asking again until the next is due,
+// so forged Wechatpay-Serial headers can't make every notify hit /v3/certificates.
+const CertRefreshInterval = time.Minute
+
+// Config holds the merchant credentials of a WeChat Pay v3 account.
+// Endpoint can point at a local mock server in tests.
+type Config struct {
+	Endpoint        string
+	AppID           string
+	MchID           string
+	MchSerialNo     string
+	PrivateKey      *rsa.PrivateKey
+	APIv3Key        string
+	NotifyURL       string
+	RefundNotifyURL string
+	PlatformCerts   []*x509.Certificate
+	HTTPClient      *http.Client
+	// NonceStore defaults to one in memory, which only covers a single process.
+	NonceStore NonceStore
+}
+
+type Client struct {
+	conf       Config
+	httpClient *http.Client
+
+	mu     sync.RWMutex
+	certs  map[string]*x509.Certificate
+	nonces NonceStore
+
+	// refreshMu lets one certificate download run at a time; refreshedAt is when the
+	// last one started.
+	refreshMu   sync.Mutex
+	refreshedAt time.Time
+}
+
+// APIError is returned for non-2xx answers from WeChat Pay.
+type APIError struct {
+	StatusCode int    `json:"-"`
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("wxpay: http %d %s %s", e.StatusCode, e.Code, e.Message)
+}
+
+func NewClient(conf Config) (*Client, error) {
+	if conf.MchID == "" || conf.MchSerialNo == "" || conf.PrivateKey == nil {
+		return nil, errors.New("wxpay: mch_id, mch_serial_no and private key are required")
+	}
+	if conf.Endpoint == "" {
+		conf.Endpoint = DefaultEndpoint
+	}
+	conf.Endpoint = strings.TrimRight(conf.Endpoint, "/")
+	httpClient := conf.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	nonces := conf.NonceStore
+	if nonces == nil {
+		nonces = &memoryNonceStore{}
+	}
+	c := &Client{
+		conf:       conf,
+		httpClient: httpClient,
+		certs:      make(map[string]*x509.Certificate),
+		nonces:     nonces,
+	}
+	for _, cert := range conf.PlatformCerts {
+		c.certs[SerialNumber(cert)] = cert
+	}
+	return c, nil
+}
+
+func (c *Client) AppID() string {
+	return c.conf.AppID
+}
+
+func (c *Client) MchID() string {
+	return c.conf.MchID
+}
+
+func (c *Client) NotifyURL() string {
+	return c.conf.NotifyURL
+}
+
+// authorization builds the WECHATPAY2-SHA256-RSA2048 Authorization header.
+func (c *Client) authorization(method, canonicalURL string, body []byte) (string, error) {
+	nonce, err := nonceStr()
+	if err != nil {
+		return "", err
+	}
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	message := method + "\n" + canonicalURL + "\n" + timestamp + "\n" + nonce + "\n" + string(body) + "\n"
+	signature, err := signSHA256WithRSA(c.conf.PrivateKey, message)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`WECHATPAY2-SHA256-RSA2048 mchid="%s",nonce_str="%s",signature="%s",timestamp="%s",serial_no="%s"`,
+		c.conf.MchID, nonce, signature, timestamp, c.conf.MchSerialNo), nil
+}
+
+// do sends a signed request and verifies the response signature before decoding it into out.
+func (c *Client) do(ctx context.Context, method, path string, in, out interface{}) error {
+	raw, header, err := c.send(ctx, method, path, in)
+	if err != nil {
+		return err
+	}
+	// 204 answers (e.g. close order) carry nothing worth verifying.
+	if len(raw) == 0 {
+		return nil
+	}
+	if err := c.VerifySignature(ctx, header, raw); err != nil {
+		return err
+	}
+	if out == nil {
+		return nil
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func (c *Client) send(ctx context.Context, method, path string, in interface{}) ([]byte, http.Header, error) {
+	var body []byte
+	if in != nil {
+		var err error
+		body, err = json.Marshal(in)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	auth, err := c.authorization(method, path, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.conf.Endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", auth)
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		apiErr := &APIError{StatusCode: resp.StatusCode}
+		_ = json.Unmarshal(raw, apiErr)
+		return nil, nil, apiErr
+	}
+	return raw, resp.Header, nil
+}
+
+// VerifySignature checks the Wechatpay-* headers of a response or notification against
+// the platform certificate identified by Wechatpay-Serial.
+func (c *Client) VerifySignature(ctx context.Context, header http.Header, body []byte) error {
+	serial := header.Get("Wechatpay-Serial")
+	signature := header.Get("Wechatpay-Signature")
+	timestamp := header.Get("Wechatpay-Timestamp")
+	nonce := header.Get("Wechatpay-Nonce")
+	if serial == "" || signature == "" || timestamp == "" || nonce == "" {
+		return errors.New("wxpay: missing signature headers")
+	}
+	cert, err := c.certificate(ctx, serial)
+	if err != nil {
+		return err
+	}
+	return verifyWithCert(cert, timestamp, nonce, body, signature)
+}
+
+func verifyWithCert(cert *x509.Certificate, timestamp, nonce string, body []byte, signature string) error {
+	pub, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return errors.New("wxpay: platform certificate is not RSA")
+	}
+	message := timestamp + "\n" + nonce + "\n" + string(body) + "\n"
+	if err := verifySHA256WithRSA(pub, message, signature); err != nil {
+		return fmt.Errorf("wxpay: invalid signature: %w", err)
+	}
+	return nil
+}
+
+func (c *Client) certificate(ctx context.Context, serial string) (*x509.Certificate, error) {
+	if cert, ok := c.cachedCertificate(serial); ok {
+		return cert, nil
+	}
+	c.refreshMu.Lock()
+	defer c.refreshMu.Unlock()
+	// The download we waited behind may have brought the serial in.
+	if cert, ok := c.cachedCertificate(serial); ok {
+		return cert, nil
+	}
+	// A failed download counts too, so an unreachable endpoint isn't retried per request.
+	if now := time.Now(); now.Sub(c.refreshedAt) >= CertRefreshInterval {
+		c.refreshedAt = now
+		if err := c.refreshCertificates(ctx); err != nil {
+			return nil, err
+		}
+		if cert, ok := c.cachedCertificate(serial); ok {
+			return cert, nil
+		}
+	}
+	return nil, fmt.Errorf("wxpay: unknown platform certificate %s", serial)
+}
+
+func (c *Client) cachedCertificate(serial string) (*x509.Certificate, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	cert, ok := c.certs[serial]
+	return cert, ok
+}
+
+type certificatesResponse struct {
+	Data []struct {
+		SerialNo           string          `json:"serial_no"`
+		EncryptCertificate EncryptResource `json:"encrypt_certificate"`
+	} `json:"data"`
+}
+
+// EncryptResource is the AEAD_AES_256_GCM envelope shared by certificates and notifications.
+type EncryptResource struct {
+	Algorithm      string `json:"algorithm"`
+	Ciphertext     string `json:"ciphertext"`
+	AssociatedData string `json:"associated_data"`
+	Nonce          string `json:"nonce"`
+	OriginalType   string `json:"original_type"`
+}
+
+// refreshCertificates downloads the platform certificates and decrypts them with the APIv3 key.
+// The first download can't be checked against a certificate we don't have yet, so the
+// response is verified with the certificate it carries; TLS and the AEAD tag cover the rest.
+func (c *Client) refreshCertificates(ctx context.Context) error {
+	if c.conf.APIv3Key == "" {
+		return errors.New("wxpay: api v3 key is required to download platform certificates")
+	}
+	raw, header, err := c.send(ctx, http.MethodGet, "/v3/certificates", nil)
+	if err != nil {
+		return err
+	}
+	var resp certificatesResponse
+	if err := json.Unmarshal(raw, &resp); err != nil {
+		return err
+	}
+	certs := make(map[string]*x509.Certificate, len(resp.Data))
+	for _, item := range resp.Data {
+		plain, err := DecryptAES256GCM(c.conf.APIv3Key, item.EncryptCertificate.Nonce,
+			item.EncryptCertificate.AssociatedData, item.EncryptCertificate.Ciphertext)
+		if err != nil {
+			return err
+		}
+		cert, err := LoadCertificate(plain)
+		if err != nil {
+			return err
+		}
+		certs[item.SerialNo] = cert
+	}
+	serial := header.Get("Wechatpay-Serial")
+	c.mu.RLock()
+	signer, ok := c.certs[serial]
+	c.mu.RUnlock()
+	if !ok {
+		signer, ok = certs[serial]
+	}
+	if !ok {
+		return fmt.Errorf("wxpay: certificates signed by unknown serial %s", serial)
+	}
+	if err := verifyWithCert(signer, header.Get("Wechatpay-Timestamp"), header.Get("Wechatpay-Nonce"),
+		raw, header.Get("Wechatpay-Signature")); err != nil {
+		return err
+	}
+	c.mu.Lock()
+	for serial, cert := range certs {
+		c.certs[serial] = cert
+	}
+	c.mu.Unlock()
+	return nil
+}
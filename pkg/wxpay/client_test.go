@@ -0,0 +1,236 @@
+package wxpay
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAPIv3Key = "0123456789abcdef0123456789abcdef"
+
+// mockPlatform stands in for WeChat Pay: it checks the merchant signature of every
+// request, signs its answers with the platform key and serves /v3/certificates.
+type mockPlatform struct {
+	t           *testing.T
+	server      *httptest.Server
+	merchantKey *rsa.PrivateKey
+	key         *rsa.PrivateKey
+	cert        *x509.Certificate
+	certPEM     []byte
+	certFetches int32
+	// handle answers the API paths other than /v3/certificates.
+	handle func(w http.ResponseWriter, r *http.Request, body []byte) interface{}
+}
+
+func newMockPlatform(t *testing.T) *mockPlatform {
+	merchantKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(0x5157F09EFDC096DE),
+		Subject:      pkix.Name{CommonName: "Tenpay.com Root CA"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	m := &mockPlatform{
+		t:           t,
+		merchantKey: merchantKey,
+		key:         key,
+		cert:        cert,
+		certPEM:     pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+	}
+	m.server = httptest.NewServer(http.HandlerFunc(m.serve))
+	t.Cleanup(m.server.Close)
+	return m
+}
+
+func (m *mockPlatform) client(t *testing.T, certs ...*x509.Certificate) *Client {
+	c, err := NewClient(Config{
+		Endpoint:      m.server.URL,
+		AppID:         "wx-app",
+		MchID:         "1900000001",
+		MchSerialNo:   "MCH-SERIAL",
+		PrivateKey:    m.merchantKey,
+		APIv3Key:      testAPIv3Key,
+		NotifyURL:     "https://example.com/notify",
+		PlatformCerts: certs,
+	})
+	require.NoError(t, err)
+	return c
+}
+
+var authPattern = regexp.MustCompile(`^WECHATPAY2-SHA256-RSA2048 mchid="([^"]+)",nonce_str="([^"]+)",signature="([^"]+)",timestamp="([^"]+)",serial_no="([^"]+)"$`)
+
+func (m *mockPlatform) serve(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	require.NoError(m.t, err)
+	parts := authPattern.FindStringSubmatch(r.Header.Get("Authorization"))
+	if parts == nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	message := r.Method + "\n" + r.URL.RequestURI() + "\n" + parts[4] + "\n" + parts[2] + "\n" + string(body) + "\n"
+	if verifySHA256WithRSA(&m.merchantKey.PublicKey, message, parts[3]) != nil {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"code":"SIGN_ERROR","message":"bad signature"}`))
+		return
+	}
+
+	var out interface{}
+	if r.URL.Path == "/v3/certificates" {
+		atomic.AddInt32(&m.certFetches, 1)
+		nonce, ciphertext := encryptForTest(m.t, "certificate", m.certPEM)
+		out = map[string]interface{}{"data": []map[string]interface{}{{
+			"serial_no": SerialNumber(m.cert),
+			"encrypt_certificate": EncryptResource{
+				Algorithm: "AEAD_AES_256_GCM", Nonce: nonce, AssociatedData: "certificate", Ciphertext: ciphertext,
+			},
+		}}}
+	} else if m.handle != nil {
+		out = m.handle(w, r, body)
+	}
+	if out == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	raw, err := json.Marshal(out)
+	require.NoError(m.t, err)
+	for k, v := range m.sign(raw) {
+		w.Header()[k] = v
+	}
+	_, _ = w.Write(raw)
+}
+
+// sign returns the Wechatpay-* headers the platform puts on body.
+func (m *mockPlatform) sign(body []byte) http.Header {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	nonce, err := nonceStr()
+	require.NoError(m.t, err)
+	signature, err := signSHA256WithRSA(m.key, timestamp+"\n"+nonce+"\n"+string(body)+"\n")
+	require.NoError(m.t, err)
+	header := http.Header{}
+	header.Set("Wechatpay-Serial", SerialNumber(m.cert))
+	header.Set("Wechatpay-Timestamp", timestamp)
+	header.Set("Wechatpay-Nonce", nonce)
+	header.Set("Wechatpay-Signature", signature)
+	return header
+}
+
+func encryptForTest(t *testing.T, associatedData string, plain []byte) (string, string) {
+	block, err := aes.NewCipher([]byte(testAPIv3Key))
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	nonce := "a1b2c3d4e5f6"
+	sealed := gcm.Seal(nil, []byte(nonce), plain, []byte(associatedData))
+	return nonce, base64.StdEncoding.EncodeToString(sealed)
+}
+
+func TestClient_SignsRequestsAndVerifiesAnswers(t *testing.T) {
+	m := newMockPlatform(t)
+	m.handle = func(w http.ResponseWriter, r *http.Request, body []byte) interface{} {
+		var req PrepayRequest
+		assert.NoError(t, json.Unmarshal(body, &req))
+		assert.Equal(t, "wx-app", req.AppID)
+		assert.Equal(t, int64(990), req.Amount.Total)
+		return map[string]string{"prepay_id": "wx-prepay-1"}
+	}
+	c := m.client(t)
+
+	// The first answer comes from a serial the client hasn't seen: it downloads and
+	// decrypts the certificates, then checks the answer against them.
+	prepayID, err := c.JSAPIPrepay(context.Background(), PrepayRequest{Description: "top", OutTradeNo: "O1", Amount: Amount{Total: 990}})
+	assert.NoError(t, err)
+	assert.Equal(t, "wx-prepay-1", prepayID)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&m.certFetches))
+
+	_, err = c.JSAPIPrepay(context.Background(), PrepayRequest{Description: "top", OutTradeNo: "O2", Amount: Amount{Total: 990}})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&m.certFetches))
+}
+
+func TestClient_RejectsTamperedAnswers(t *testing.T) {
+	m := newMockPlatform(t)
+	c := m.client(t, m.cert)
+	body := []byte(`{"out_trade_no":"O1","trade_state":"SUCCESS"}`)
+	header := m.sign(body)
+	assert.NoError(t, c.VerifySignature(context.Background(), header, body))
+	assert.Error(t, c.VerifySignature(context.Background(), header, []byte(`{"out_trade_no":"O1","trade_state":"CLOSED"}`)))
+
+	header.Del("Wechatpay-Nonce")
+	assert.Error(t, c.VerifySignature(context.Background(), header, body))
+}
+
+func TestClient_APIError(t *testing.T) {
+	m := newMockPlatform(t)
+	m.handle = func(w http.ResponseWriter, r *http.Request, body []byte) interface{} {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"code":"NOT_ENOUGH","message":"balance"}`))
+		return nil
+	}
+	_, err := m.client(t, m.cert).Refund(context.Background(), RefundRequest{
+		OutTradeNo: "O1", OutRefundNo: "R1", Amount: RefundAmount{Refund: 100, Total: 100},
+	})
+	var apiErr *APIError
+	if assert.ErrorAs(t, err, &apiErr) {
+		assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+		assert.Equal(t, "NOT_ENOUGH", apiErr.Code)
+	}
+}
+
+func TestClient_RateLimitsCertificateRefresh(t *testing.T) {
+	m := newMockPlatform(t)
+	c := m.client(t)
+	body := []byte(`{}`)
+	header := m.sign(body)
+	header.Set("Wechatpay-Serial", "FORGED")
+
+	for i := 0; i < 5; i++ {
+		assert.Error(t, c.VerifySignature(context.Background(), header, body))
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&m.certFetches))
+
+	// Once the interval has passed an unknown serial may trigger the next download.
+	c.refreshMu.Lock()
+	c.refreshedAt = time.Now().Add(-CertRefreshInterval)
+	c.refreshMu.Unlock()
+	assert.Error(t, c.VerifySignature(context.Background(), header, body))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&m.certFetches))
+}
+
+func TestDecryptAES256GCM(t *testing.T) {
+	nonce, ciphertext := encryptForTest(t, "transaction", []byte(`{"out_trade_no":"O1"}`))
+	plain, err := DecryptAES256GCM(testAPIv3Key, nonce, "transaction", ciphertext)
+	assert.NoError(t, err)
+	assert.Equal(t, `{"out_trade_no":"O1"}`, string(plain))
+
+	_, err = DecryptAES256GCM(testAPIv3Key, nonce, "certificate", ciphertext)
+	assert.Error(t, err)
+	_, err = DecryptAES256GCM("short", nonce, "transaction", ciphertext)
+	assert.Error(t, err)
+}
@@ -0,0 +1,97 @@
+package wxpay
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// LoadPrivateKey parses the merchant apiclient_key.pem (PKCS#8 or PKCS#1).
+func LoadPrivateKey(pemBytes []byte) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("wxpay: invalid private key pem")
+	}
+	if key, err := x509.ParsePKCS8PrivateKey(block.Bytes); err == nil {
+		rsaKey, ok := key.(*rsa.PrivateKey)
+		if !ok {
+			return nil, errors.New("wxpay: private key is not RSA")
+		}
+		return rsaKey, nil
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+// LoadCertificate parses a PEM encoded platform certificate.
+func LoadCertificate(pemBytes []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(pemBytes)
+	if block == nil {
+		return nil, errors.New("wxpay: invalid certificate pem")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// SerialNumber formats a certificate serial the way WeChat Pay sends it in Wechatpay-Serial.
+func SerialNumber(cert *x509.Certificate) string {
+	return fmt.Sprintf("%X", cert.SerialNumber)
+}
+
+func signSHA256WithRSA(key *rsa.PrivateKey, message string) (string, error) {
+	sum := sha256.Sum256([]byte(message))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, sum[:])
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(sig), nil
+}
+
+func verifySHA256WithRSA(pub *rsa.PublicKey, message, signature string) error {
+	sig, err := base64.StdEncoding.DecodeString(signature)
+	if err != nil {
+		return fmt.Errorf("wxpay: decode signature: %w", err)
+	}
+	sum := sha256.Sum256([]byte(message))
+	return rsa.VerifyPKCS1v15(pub, crypto.SHA256, sum[:], sig)
+}
+
+// DecryptAES256GCM decrypts the AEAD_AES_256_GCM payload used by certificates and notifications.
+func DecryptAES256GCM(apiV3Key, nonce, associatedData, ciphertext string) ([]byte, error) {
+	if len(apiV3Key) != 32 {
+		return nil, errors.New("wxpay: api v3 key must be 32 bytes")
+	}
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return nil, fmt.Errorf("wxpay: decode ciphertext: %w", err)
+	}
+	block, err := aes.NewCipher([]byte(apiV3Key))
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(nonce))
+	if err != nil {
+		return nil, err
+	}
+	return gcm.Open(nil, []byte(nonce), data, []byte(associatedData))
+}
+
+func nonceStr() (string, error) {
+	const letters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	buf := make([]byte, 32)
+	for i := range buf {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(letters))))
+		if err != nil {
+			return "", err
+		}
+		buf[i] = letters[n.Int64()]
+	}
+	return string(buf), nil
+}
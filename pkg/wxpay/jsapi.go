@@ -0,0 +1,119 @@
+package wxpay
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+type Amount struct {
+	Total    int64  `json:"total"`
+	Currency string `json:"currency,omitempty"`
+}
+
+type Payer struct {
+	OpenID string `json:"openid"`
+}
+
+// PrepayRequest is the body of POST /v3/pay/transactions/jsapi. Amount.Total is in cents.
+type PrepayRequest struct {
+	AppID       string     `json:"appid"`
+	MchID       string     `json:"mchid"`
+	Description string     `json:"description"`
+	OutTradeNo  string     `json:"out_trade_no"`
+	TimeExpire  *time.Time `json:"time_expire,omitempty"`
+	Attach      string     `json:"attach,omitempty"`
+	NotifyURL   string     `json:"notify_url"`
+	Amount      Amount     `json:"amount"`
+	Payer       Payer      `json:"payer"`
+}
+
+type prepayResponse struct {
+	PrepayID string `json:"prepay_id"`
+}
+
+// JSAPIPrepay places a JSAPI order and returns its prepay_id. AppID, MchID and NotifyURL
+// default to the client config when left empty.
+func (c *Client) JSAPIPrepay(ctx context.Context, req PrepayRequest) (string, error) {
+	if req.AppID == "" {
+		req.AppID = c.conf.AppID
+	}
+	if req.MchID == "" {
+		req.MchID = c.conf.MchID
+	}
+	if req.NotifyURL == "" {
+		req.NotifyURL = c.conf.NotifyURL
+	}
+	if req.TimeExpire != nil {
+		expire := req.TimeExpire.Truncate(time.Second)
+		req.TimeExpire = &expire
+	}
+	if req.Amount.Currency == "" {
+		req.Amount.Currency = "CNY"
+	}
+	if req.AppID == "" || req.NotifyURL == "" {
+		return "", errors.New("wxpay: app_id and notify_url are required")
+	}
+	var resp prepayResponse
+	if err := c.do(ctx, http.MethodPost, "/v3/pay/transactions/jsapi", req, &resp); err != nil {
+		return "", err
+	}
+	if resp.PrepayID == "" {
+		return "", errors.New("wxpay: empty prepay_id")
+	}
+	return resp.PrepayID, nil
+}
+
+// JSAPIPayParams are the arguments of wx.requestPayment in the mini program.
+type JSAPIPayParams struct {
+	AppID     string
+	TimeStamp string
+	NonceStr  string
+	Package   string
+	SignType  string
+	PaySign   string
+}
+
+// JSAPIPayParams signs "appId\ntimeStamp\nnonceStr\npackage\n" with the merchant private key.
+func (c *Client) JSAPIPayParams(prepayID string) (JSAPIPayParams, error) {
+	nonce, err := nonceStr()
+	if err != nil {
+		return JSAPIPayParams{}, err
+	}
+	params := JSAPIPayParams{
+		AppID:     c.conf.AppID,
+		TimeStamp: strconv.FormatInt(time.Now().Unix(), 10),
+		NonceStr:  nonce,
+		Package:   "prepay_id=" + prepayID,
+		SignType:  "RSA",
+	}
+	message := params.AppID + "\n" + params.TimeStamp + "\n" + params.NonceStr + "\n" + params.Package + "\n"
+	params.PaySign, err = signSHA256WithRSA(c.conf.PrivateKey, message)
+	if err != nil {
+		return JSAPIPayParams{}, err
+	}
+	return params, nil
+}
+
+// QueryOrderByOutTradeNo asks WeChat Pay for the current state of a merchant order.
+func (c *Client) QueryOrderByOutTradeNo(ctx context.Context, outTradeNo string) (*Transaction, error) {
+	path := "/v3/pay/transactions/out-trade-no/" + url.PathEscape(outTradeNo) + "?mchid=" + url.QueryEscape(c.conf.MchID)
+	var transaction Transaction
+	if err := c.do(ctx, http.MethodGet, path, nil, &transaction); err != nil {
+		return nil, err
+	}
+	return &transaction, nil
+}
+
+type closeOrderRequest struct {
+	MchID string `json:"mchid"`
+}
+
+// CloseOrder closes an unpaid merchant order so it can no longer be paid.
+func (c *Client) CloseOrder(ctx context.Context, outTradeNo string) error {
+	path := "/v3/pay/transactions/out-trade-no/" + url.PathEscape(outTradeNo) + "/close"
+	return c.do(ctx, http.MethodPost, path, closeOrderRequest{MchID: c.conf.MchID}, nil)
+}
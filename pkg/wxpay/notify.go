@@ -0,0 +1,130 @@
+package wxpay
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// NotifyWindow is how far a notification timestamp may drift from the local clock.
+const NotifyWindow = 5 * time.Minute
+
+var (
+	ErrNotifyExpired  = errors.New("wxpay: notification timestamp out of window")
+	ErrNotifyReplayed = errors.New("wxpay: notification replayed")
+)
+
+// Notification is the envelope WeChat Pay posts to notify_url.
+type Notification struct {
+	ID           string          `json:"id"`
+	CreateTime   string          `json:"create_time"`
+	EventType    string          `json:"event_type"`
+	ResourceType string          `json:"resource_type"`
+	Resource     EncryptResource `json:"resource"`
+	Summary      string          `json:"summary"`
+}
+
+// Transaction is the decrypted resource of a TRANSACTION.* notification or an order query.
+type Transaction struct {
+	AppID          string `json:"appid"`
+	MchID          string `json:"mchid"`
+	OutTradeNo     string `json:"out_trade_no"`
+	TransactionID  string `json:"transaction_id"`
+	TradeType      string `json:"trade_type"`
+	TradeState     string `json:"trade_state"`
+	TradeStateDesc string `json:"trade_state_desc"`
+	SuccessTime    string `json:"success_time"`
+	Payer          Payer  `json:"payer"`
+	Amount         struct {
+		Total         int64  `json:"total"`
+		PayerTotal    int64  `json:"payer_total"`
+		Currency      string `json:"currency"`
+		PayerCurrency string `json:"payer_currency"`
+	} `json:"amount"`
+}
+
+const TradeStateSuccess = "SUCCESS"
+
+// EventTransactionSuccess is the event_type of a payment notification.
+const EventTransactionSuccess = "TRANSACTION.SUCCESS"
+
+// NonceStore remembers notification nonces until expireAt so a captured request can't be
+// posted again. Remember reports false for a nonce it still holds. Servers that share a
+// notify_url have to share the store; WeChat signs every retry afresh.
+type NonceStore interface {
+	Remember(ctx context.Context, nonce string, expireAt time.Time) (bool, error)
+}
+
+// memoryNonceStore is the NonceStore of a client configured without one; it only sees the
+// notifications of its own process.
+type memoryNonceStore struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+func (n *memoryNonceStore) Remember(ctx context.Context, nonce string, expireAt time.Time) (bool, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	now := time.Now()
+	if n.seen == nil {
+		n.seen = make(map[string]time.Time)
+	}
+	for k, at := range n.seen {
+		if now.After(at) {
+			delete(n.seen, k)
+		}
+	}
+	if _, ok := n.seen[nonce]; ok {
+		return false, nil
+	}
+	n.seen[nonce] = expireAt
+	return true, nil
+}
+
+// ParseNotify verifies the signature, timestamp window and nonce of a notification,
+// then decrypts its resource into out.
+func (c *Client) ParseNotify(ctx context.Context, header http.Header, body []byte, out interface{}) (*Notification, error) {
+	timestamp, err := strconv.ParseInt(header.Get("Wechatpay-Timestamp"), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("wxpay: invalid timestamp: %w", err)
+	}
+	now := time.Now()
+	sentAt := time.Unix(timestamp, 0)
+	if sentAt.Before(now.Add(-NotifyWindow)) || sentAt.After(now.Add(NotifyWindow)) {
+		return nil, ErrNotifyExpired
+	}
+	if err := c.VerifySignature(ctx, header, body); err != nil {
+		return nil, err
+	}
+	// Kept for twice the window, so it outlives every timestamp that could still pass.
+	fresh, err := c.nonces.Remember(ctx, header.Get("Wechatpay-Nonce"), now.Add(2*NotifyWindow))
+	if err != nil {
+		return nil, err
+	}
+	if !fresh {
+		return nil, ErrNotifyReplayed
+	}
+	var notification Notification
+	if err := json.Unmarshal(body, &notification); err != nil {
+		return nil, err
+	}
+	if notification.Resource.Algorithm != "AEAD_AES_256_GCM" {
+		return nil, fmt.Errorf("wxpay: unsupported algorithm %s", notification.Resource.Algorithm)
+	}
+	plain, err := DecryptAES256GCM(c.conf.APIv3Key, notification.Resource.Nonce,
+		notification.Resource.AssociatedData, notification.Resource.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if out != nil {
+		if err := json.Unmarshal(plain, out); err != nil {
+			return nil, err
+		}
+	}
+	return &notification, nil
+}
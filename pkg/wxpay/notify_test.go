@@ -0,0 +1,93 @@
+package wxpay
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// notification builds a signed TRANSACTION.SUCCESS notification for transaction.
+func (m *mockPlatform) notification(t *testing.T, transaction Transaction) (http.Header, []byte) {
+	plain, err := json.Marshal(transaction)
+	require.NoError(t, err)
+	nonce, ciphertext := encryptForTest(t, "transaction", plain)
+	body, err := json.Marshal(Notification{
+		ID:           "EV-1",
+		EventType:    EventTransactionSuccess,
+		ResourceType: "encrypt-resource",
+		Resource: EncryptResource{
+			Algorithm: "AEAD_AES_256_GCM", Nonce: nonce, AssociatedData: "transaction", Ciphertext: ciphertext, OriginalType: "transaction",
+		},
+	})
+	require.NoError(t, err)
+	return m.sign(body), body
+}
+
+func TestParseNotify(t *testing.T) {
+	m := newMockPlatform(t)
+	c := m.client(t, m.cert)
+	header, body := m.notification(t, Transaction{OutTradeNo: "O1", TradeState: TradeStateSuccess})
+
+	var transaction Transaction
+	notification, err := c.ParseNotify(context.Background(), header, body, &transaction)
+	assert.NoError(t, err)
+	assert.Equal(t, EventTransactionSuccess, notification.EventType)
+	assert.Equal(t, "O1", transaction.OutTradeNo)
+
+	_, err = c.ParseNotify(context.Background(), header, body, &transaction)
+	assert.ErrorIs(t, err, ErrNotifyReplayed)
+}
+
+func TestParseNotify_RejectsStaleAndForged(t *testing.T) {
+	m := newMockPlatform(t)
+	c := m.client(t, m.cert)
+
+	header, body := m.notification(t, Transaction{OutTradeNo: "O1"})
+	header.Set("Wechatpay-Timestamp", strconv.FormatInt(time.Now().Add(-2*NotifyWindow).Unix(), 10))
+	_, err := c.ParseNotify(context.Background(), header, body, nil)
+	assert.ErrorIs(t, err, ErrNotifyExpired)
+
+	header, body = m.notification(t, Transaction{OutTradeNo: "O1"})
+	body[len(body)-2] = ' '
+	_, err = c.ParseNotify(context.Background(), header, body, nil)
+	assert.Error(t, err)
+}
+
+// sharedNonces is what a database-backed store looks like to two clients.
+type sharedNonces struct {
+	seen map[string]bool
+}
+
+func (s *sharedNonces) Remember(ctx context.Context, nonce string, expireAt time.Time) (bool, error) {
+	if s.seen[nonce] {
+		return false, nil
+	}
+	s.seen[nonce] = true
+	return true, nil
+}
+
+func TestParseNotify_SharedNonceStore(t *testing.T) {
+	m := newMockPlatform(t)
+	store := &sharedNonces{seen: map[string]bool{}}
+	newClient := func() *Client {
+		c, err := NewClient(Config{
+			Endpoint: m.server.URL, MchID: "1900000001", MchSerialNo: "MCH-SERIAL", PrivateKey: m.merchantKey,
+			APIv3Key: testAPIv3Key, PlatformCerts: []*x509.Certificate{m.cert}, NonceStore: store,
+		})
+		require.NoError(t, err)
+		return c
+	}
+	header, body := m.notification(t, Transaction{OutTradeNo: "O1"})
+	_, err := newClient().ParseNotify(context.Background(), header, body, nil)
+	assert.NoError(t, err)
+	// Another server instance sees the same notification as a replay.
+	_, err = newClient().ParseNotify(context.Background(), header, body, nil)
+	assert.ErrorIs(t, err, ErrNotifyReplayed)
+}
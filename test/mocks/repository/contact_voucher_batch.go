@@ -0,0 +1,155 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/contact_voucher_batch.go
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/go-nunu/nunu-layout-advanced/internal/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockContactVoucherBatchRepository is a mock of ContactVoucherBatchRepository interface.
+type MockContactVoucherBatchRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockContactVoucherBatchRepositoryMockRecorder
+}
+
+// MockContactVoucherBatchRepositoryMockRecorder is the mock recorder for MockContactVoucherBatchRepository.
+type MockContactVoucherBatchRepositoryMockRecorder struct {
+	mock *MockContactVoucherBatchRepository
+}
+
+// NewMockContactVoucherBatchRepository creates a new mock instance.
+func NewMockContactVoucherBatchRepository(ctrl *gomock.Controller) *MockContactVoucherBatchRepository {
+	mock := &MockContactVoucherBatchRepository{ctrl: ctrl}
+	mock.recorder = &MockContactVoucherBatchRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockContactVoucherBatchRepository) EXPECT() *MockContactVoucherBatchRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Consume mocks base method.
+func (m *MockContactVoucherBatchRepository) Consume(ctx context.Context, id int64, num int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Consume", ctx, id, num)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Consume indicates an expected call of Consume.
+func (mr *MockContactVoucherBatchRepositoryMockRecorder) Consume(ctx, id, num interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Consume", reflect.TypeOf((*MockContactVoucherBatchRepository)(nil).Consume), ctx, id, num)
+}
+
+// Create mocks base method.
+func (m *MockContactVoucherBatchRepository) Create(ctx context.Context, batch *model.ContactVoucherBatch) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, batch)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockContactVoucherBatchRepositoryMockRecorder) Create(ctx, batch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockContactVoucherBatchRepository)(nil).Create), ctx, batch)
+}
+
+// Expire mocks base method.
+func (m *MockContactVoucherBatchRepository) Expire(ctx context.Context, id int64, remainNum int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Expire", ctx, id, remainNum)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Expire indicates an expected call of Expire.
+func (mr *MockContactVoucherBatchRepositoryMockRecorder) Expire(ctx, id, remainNum interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Expire", reflect.TypeOf((*MockContactVoucherBatchRepository)(nil).Expire), ctx, id, remainNum)
+}
+
+// ListByOrderIDForUpdate mocks base method.
+func (m *MockContactVoucherBatchRepository) ListByOrderIDForUpdate(ctx context.Context, orderID int64) ([]*model.ContactVoucherBatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByOrderIDForUpdate", ctx, orderID)
+	ret0, _ := ret[0].([]*model.ContactVoucherBatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByOrderIDForUpdate indicates an expected call of ListByOrderIDForUpdate.
+func (mr *MockContactVoucherBatchRepositoryMockRecorder) ListByOrderIDForUpdate(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByOrderIDForUpdate", reflect.TypeOf((*MockContactVoucherBatchRepository)(nil).ListByOrderIDForUpdate), ctx, orderID)
+}
+
+// ListExpired mocks base method.
+func (m *MockContactVoucherBatchRepository) ListExpired(ctx context.Context, now time.Time, limit int) ([]*model.ContactVoucherBatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListExpired", ctx, now, limit)
+	ret0, _ := ret[0].([]*model.ContactVoucherBatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListExpired indicates an expected call of ListExpired.
+func (mr *MockContactVoucherBatchRepositoryMockRecorder) ListExpired(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListExpired", reflect.TypeOf((*MockContactVoucherBatchRepository)(nil).ListExpired), ctx, now, limit)
+}
+
+// ListUsable mocks base method.
+func (m *MockContactVoucherBatchRepository) ListUsable(ctx context.Context, userID int64, now time.Time) ([]*model.ContactVoucherBatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsable", ctx, userID, now)
+	ret0, _ := ret[0].([]*model.ContactVoucherBatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsable indicates an expected call of ListUsable.
+func (mr *MockContactVoucherBatchRepositoryMockRecorder) ListUsable(ctx, userID, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsable", reflect.TypeOf((*MockContactVoucherBatchRepository)(nil).ListUsable), ctx, userID, now)
+}
+
+// ListUsableForUpdate mocks base method.
+func (m *MockContactVoucherBatchRepository) ListUsableForUpdate(ctx context.Context, userID int64, now time.Time) ([]*model.ContactVoucherBatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListUsableForUpdate", ctx, userID, now)
+	ret0, _ := ret[0].([]*model.ContactVoucherBatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListUsableForUpdate indicates an expected call of ListUsableForUpdate.
+func (mr *MockContactVoucherBatchRepositoryMockRecorder) ListUsableForUpdate(ctx, userID, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListUsableForUpdate", reflect.TypeOf((*MockContactVoucherBatchRepository)(nil).ListUsableForUpdate), ctx, userID, now)
+}
+
+// SetExpireAt mocks base method.
+func (m *MockContactVoucherBatchRepository) SetExpireAt(ctx context.Context, id int64, expireAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetExpireAt", ctx, id, expireAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetExpireAt indicates an expected call of SetExpireAt.
+func (mr *MockContactVoucherBatchRepositoryMockRecorder) SetExpireAt(ctx, id, expireAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetExpireAt", reflect.TypeOf((*MockContactVoucherBatchRepository)(nil).SetExpireAt), ctx, id, expireAt)
+}
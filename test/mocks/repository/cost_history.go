@@ -0,0 +1,66 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/cost_history.go
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/go-nunu/nunu-layout-advanced/internal/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockContactVoucherHistoryRepository is a mock of ContactVoucherHistoryRepository interface.
+type MockContactVoucherHistoryRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockContactVoucherHistoryRepositoryMockRecorder
+}
+
+// MockContactVoucherHistoryRepositoryMockRecorder is the mock recorder for MockContactVoucherHistoryRepository.
+type MockContactVoucherHistoryRepositoryMockRecorder struct {
+	mock *MockContactVoucherHistoryRepository
+}
+
+// NewMockContactVoucherHistoryRepository creates a new mock instance.
+func NewMockContactVoucherHistoryRepository(ctrl *gomock.Controller) *MockContactVoucherHistoryRepository {
+	mock := &MockContactVoucherHistoryRepository{ctrl: ctrl}
+	mock.recorder = &MockContactVoucherHistoryRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockContactVoucherHistoryRepository) EXPECT() *MockContactVoucherHistoryRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockContactVoucherHistoryRepository) Create(ctx context.Context, history *model.ContactVoucherHistory) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, history)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockContactVoucherHistoryRepositoryMockRecorder) Create(ctx, history interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockContactVoucherHistoryRepository)(nil).Create), ctx, history)
+}
+
+// ListByUser mocks base method.
+func (m *MockContactVoucherHistoryRepository) ListByUser(ctx context.Context, userID int64, pageNum, pageSize int) ([]*model.ContactVoucherHistory, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUser", ctx, userID, pageNum, pageSize)
+	ret0, _ := ret[0].([]*model.ContactVoucherHistory)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListByUser indicates an expected call of ListByUser.
+func (mr *MockContactVoucherHistoryRepositoryMockRecorder) ListByUser(ctx, userID, pageNum, pageSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockContactVoucherHistoryRepository)(nil).ListByUser), ctx, userID, pageNum, pageSize)
+}
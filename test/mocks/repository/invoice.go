@@ -0,0 +1,140 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/invoice.go
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/go-nunu/nunu-layout-advanced/internal/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockInvoiceRepository is a mock of InvoiceRepository interface.
+type MockInvoiceRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockInvoiceRepositoryMockRecorder
+}
+
+// MockInvoiceRepositoryMockRecorder is the mock recorder for MockInvoiceRepository.
+type MockInvoiceRepositoryMockRecorder struct {
+	mock *MockInvoiceRepository
+}
+
+// NewMockInvoiceRepository creates a new mock instance.
+func NewMockInvoiceRepository(ctrl *gomock.Controller) *MockInvoiceRepository {
+	mock := &MockInvoiceRepository{ctrl: ctrl}
+	mock.recorder = &MockInvoiceRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockInvoiceRepository) EXPECT() *MockInvoiceRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockInvoiceRepository) Create(ctx context.Context, invoice *model.Invoice) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, invoice)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockInvoiceRepositoryMockRecorder) Create(ctx, invoice interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockInvoiceRepository)(nil).Create), ctx, invoice)
+}
+
+// CreateOrders mocks base method.
+func (m *MockInvoiceRepository) CreateOrders(ctx context.Context, orders []*model.InvoiceOrder) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateOrders", ctx, orders)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CreateOrders indicates an expected call of CreateOrders.
+func (mr *MockInvoiceRepositoryMockRecorder) CreateOrders(ctx, orders interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateOrders", reflect.TypeOf((*MockInvoiceRepository)(nil).CreateOrders), ctx, orders)
+}
+
+// Finish mocks base method.
+func (m *MockInvoiceRepository) Finish(ctx context.Context, invoice *model.Invoice) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Finish", ctx, invoice)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Finish indicates an expected call of Finish.
+func (mr *MockInvoiceRepositoryMockRecorder) Finish(ctx, invoice interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finish", reflect.TypeOf((*MockInvoiceRepository)(nil).Finish), ctx, invoice)
+}
+
+// GetByID mocks base method.
+func (m *MockInvoiceRepository) GetByID(ctx context.Context, id int64) (*model.Invoice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*model.Invoice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockInvoiceRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockInvoiceRepository)(nil).GetByID), ctx, id)
+}
+
+// LatestByOrderIDs mocks base method.
+func (m *MockInvoiceRepository) LatestByOrderIDs(ctx context.Context, orderIDs []int64) (map[int64]*model.Invoice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LatestByOrderIDs", ctx, orderIDs)
+	ret0, _ := ret[0].(map[int64]*model.Invoice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// LatestByOrderIDs indicates an expected call of LatestByOrderIDs.
+func (mr *MockInvoiceRepositoryMockRecorder) LatestByOrderIDs(ctx, orderIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LatestByOrderIDs", reflect.TypeOf((*MockInvoiceRepository)(nil).LatestByOrderIDs), ctx, orderIDs)
+}
+
+// List mocks base method.
+func (m *MockInvoiceRepository) List(ctx context.Context, userID int64, status model.InvoiceStatus, pageNum, pageSize int) ([]*model.Invoice, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "List", ctx, userID, status, pageNum, pageSize)
+	ret0, _ := ret[0].([]*model.Invoice)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// List indicates an expected call of List.
+func (mr *MockInvoiceRepositoryMockRecorder) List(ctx, userID, status, pageNum, pageSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "List", reflect.TypeOf((*MockInvoiceRepository)(nil).List), ctx, userID, status, pageNum, pageSize)
+}
+
+// ListOrders mocks base method.
+func (m *MockInvoiceRepository) ListOrders(ctx context.Context, invoiceIDs []int64) ([]*model.InvoiceOrder, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListOrders", ctx, invoiceIDs)
+	ret0, _ := ret[0].([]*model.InvoiceOrder)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListOrders indicates an expected call of ListOrders.
+func (mr *MockInvoiceRepositoryMockRecorder) ListOrders(ctx, invoiceIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListOrders", reflect.TypeOf((*MockInvoiceRepository)(nil).ListOrders), ctx, invoiceIDs)
+}
@@ -0,0 +1,125 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/membership.go
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/go-nunu/nunu-layout-advanced/internal/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockMembershipRepository is a mock of MembershipRepository interface.
+type MockMembershipRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockMembershipRepositoryMockRecorder
+}
+
+// MockMembershipRepositoryMockRecorder is the mock recorder for MockMembershipRepository.
+type MockMembershipRepositoryMockRecorder struct {
+	mock *MockMembershipRepository
+}
+
+// NewMockMembershipRepository creates a new mock instance.
+func NewMockMembershipRepository(ctrl *gomock.Controller) *MockMembershipRepository {
+	mock := &MockMembershipRepository{ctrl: ctrl}
+	mock.recorder = &MockMembershipRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMembershipRepository) EXPECT() *MockMembershipRepositoryMockRecorder {
+	return m.recorder
+}
+
+// AdvanceNextGrant mocks base method.
+func (m *MockMembershipRepository) AdvanceNextGrant(ctx context.Context, id int64, from, to time.Time) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdvanceNextGrant", ctx, id, from, to)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdvanceNextGrant indicates an expected call of AdvanceNextGrant.
+func (mr *MockMembershipRepositoryMockRecorder) AdvanceNextGrant(ctx, id, from, to interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdvanceNextGrant", reflect.TypeOf((*MockMembershipRepository)(nil).AdvanceNextGrant), ctx, id, from, to)
+}
+
+// Create mocks base method.
+func (m *MockMembershipRepository) Create(ctx context.Context, membership *model.Membership) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, membership)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockMembershipRepositoryMockRecorder) Create(ctx, membership interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockMembershipRepository)(nil).Create), ctx, membership)
+}
+
+// GetByUserID mocks base method.
+func (m *MockMembershipRepository) GetByUserID(ctx context.Context, userID int64) (*model.Membership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUserID", ctx, userID)
+	ret0, _ := ret[0].(*model.Membership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUserID indicates an expected call of GetByUserID.
+func (mr *MockMembershipRepositoryMockRecorder) GetByUserID(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserID", reflect.TypeOf((*MockMembershipRepository)(nil).GetByUserID), ctx, userID)
+}
+
+// GetByUserIDForUpdate mocks base method.
+func (m *MockMembershipRepository) GetByUserIDForUpdate(ctx context.Context, userID int64) (*model.Membership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByUserIDForUpdate", ctx, userID)
+	ret0, _ := ret[0].(*model.Membership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByUserIDForUpdate indicates an expected call of GetByUserIDForUpdate.
+func (mr *MockMembershipRepositoryMockRecorder) GetByUserIDForUpdate(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserIDForUpdate", reflect.TypeOf((*MockMembershipRepository)(nil).GetByUserIDForUpdate), ctx, userID)
+}
+
+// ListGrantDue mocks base method.
+func (m *MockMembershipRepository) ListGrantDue(ctx context.Context, now time.Time, limit int) ([]*model.Membership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListGrantDue", ctx, now, limit)
+	ret0, _ := ret[0].([]*model.Membership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListGrantDue indicates an expected call of ListGrantDue.
+func (mr *MockMembershipRepositoryMockRecorder) ListGrantDue(ctx, now, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListGrantDue", reflect.TypeOf((*MockMembershipRepository)(nil).ListGrantDue), ctx, now, limit)
+}
+
+// Update mocks base method.
+func (m *MockMembershipRepository) Update(ctx context.Context, membership *model.Membership) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, membership)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockMembershipRepositoryMockRecorder) Update(ctx, membership interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockMembershipRepository)(nil).Update), ctx, membership)
+}
@@ -0,0 +1,80 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/repository/order_item.go
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/go-nunu/nunu-layout-advanced/internal/model"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockOrderItemRepository is a mock of OrderItemRepository interface.
+type MockOrderItemRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockOrderItemRepositoryMockRecorder
+}
+
+// MockOrderItemRepositoryMockRecorder is the mock recorder for MockOrderItemRepository.
+type MockOrderItemRepositoryMockRecorder struct {
+	mock *MockOrderItemRepository
+}
+
+// NewMockOrderItemRepository creates a new mock instance.
+func NewMockOrderItemRepository(ctrl *gomock.Controller) *MockOrderItemRepository {
+	mock := &MockOrderItemRepository{ctrl: ctrl}
+	mock.recorder = &MockOrderItemRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockOrderItemRepository) EXPECT() *MockOrderItemRepositoryMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockOrderItemRepository) Create(ctx context.Context, item *model.OrderItem) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, item)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockOrderItemRepositoryMockRecorder) Create(ctx, item interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockOrderItemRepository)(nil).Create), ctx, item)
+}
+
+// ListByOrderID mocks base method.
+func (m *MockOrderItemRepository) ListByOrderID(ctx context.Context, orderID int64) ([]*model.OrderItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByOrderID", ctx, orderID)
+	ret0, _ := ret[0].([]*model.OrderItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByOrderID indicates an expected call of ListByOrderID.
+func (mr *MockOrderItemRepositoryMockRecorder) ListByOrderID(ctx, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByOrderID", reflect.TypeOf((*MockOrderItemRepository)(nil).ListByOrderID), ctx, orderID)
+}
+
+// ListByOrderIDs mocks base method.
+func (m *MockOrderItemRepository) ListByOrderIDs(ctx context.Context, orderIDs []int64) ([]*model.OrderItem, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByOrderIDs", ctx, orderIDs)
+	ret0, _ := ret[0].([]*model.OrderItem)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByOrderIDs indicates an expected call of ListByOrderIDs.
+func (mr *MockOrderItemRepositoryMockRecorder) ListByOrderIDs(ctx, orderIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByOrderIDs", reflect.TypeOf((*MockOrderItemRepository)(nil).ListByOrderIDs), ctx, orderIDs)
+}
@@ -49,34 +49,168 @@ func (mr *MockUserRepositoryMockRecorder) Create(ctx, user interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockUserRepository)(nil).Create), ctx, user)
 }
 
-// GetByEmail mocks base method.
-func (m *MockUserRepository) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+// GetByID mocks base method.
+func (m *MockUserRepository) GetByID(ctx context.Context, id int64) (*model.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByEmail", ctx, email)
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
 	ret0, _ := ret[0].(*model.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetByEmail indicates an expected call of GetByEmail.
-func (mr *MockUserRepositoryMockRecorder) GetByEmail(ctx, email interface{}) *gomock.Call {
+// GetByID indicates an expected call of GetByID.
+func (mr *MockUserRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmail", reflect.TypeOf((*MockUserRepository)(nil).GetByEmail), ctx, email)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserRepository)(nil).GetByID), ctx, id)
 }
 
-// GetByID mocks base method.
-func (m *MockUserRepository) GetByID(ctx context.Context, id string) (*model.User, error) {
+// GetByIDForUpdate mocks base method.
+func (m *MockUserRepository) GetByIDForUpdate(ctx context.Context, id int64) (*model.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret := m.ctrl.Call(m, "GetByIDForUpdate", ctx, id)
 	ret0, _ := ret[0].(*model.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetByID indicates an expected call of GetByID.
-func (mr *MockUserRepositoryMockRecorder) GetByID(ctx, id interface{}) *gomock.Call {
+// GetByIDForUpdate indicates an expected call of GetByIDForUpdate.
+func (mr *MockUserRepositoryMockRecorder) GetByIDForUpdate(ctx, id interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockUserRepository)(nil).GetByID), ctx, id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDForUpdate", reflect.TypeOf((*MockUserRepository)(nil).GetByIDForUpdate), ctx, id)
+}
+
+// GetByInviteCode mocks base method.
+func (m *MockUserRepository) GetByInviteCode(ctx context.Context, code string) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByInviteCode", ctx, code)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByInviteCode indicates an expected call of GetByInviteCode.
+func (mr *MockUserRepositoryMockRecorder) GetByInviteCode(ctx, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByInviteCode", reflect.TypeOf((*MockUserRepository)(nil).GetByInviteCode), ctx, code)
+}
+
+// GetByOpenID mocks base method.
+func (m *MockUserRepository) GetByOpenID(ctx context.Context, openID string) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByOpenID", ctx, openID)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByOpenID indicates an expected call of GetByOpenID.
+func (mr *MockUserRepositoryMockRecorder) GetByOpenID(ctx, openID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByOpenID", reflect.TypeOf((*MockUserRepository)(nil).GetByOpenID), ctx, openID)
+}
+
+// GetByPhone mocks base method.
+func (m *MockUserRepository) GetByPhone(ctx context.Context, phone string) (*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByPhone", ctx, phone)
+	ret0, _ := ret[0].(*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByPhone indicates an expected call of GetByPhone.
+func (mr *MockUserRepositoryMockRecorder) GetByPhone(ctx, phone interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByPhone", reflect.TypeOf((*MockUserRepository)(nil).GetByPhone), ctx, phone)
+}
+
+// IncrIntegral mocks base method.
+func (m *MockUserRepository) IncrIntegral(ctx context.Context, userID, delta int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrIntegral", ctx, userID, delta)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrIntegral indicates an expected call of IncrIntegral.
+func (mr *MockUserRepositoryMockRecorder) IncrIntegral(ctx, userID, delta interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrIntegral", reflect.TypeOf((*MockUserRepository)(nil).IncrIntegral), ctx, userID, delta)
+}
+
+// IncrInviteNum mocks base method.
+func (m *MockUserRepository) IncrInviteNum(ctx context.Context, userID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrInviteNum", ctx, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// IncrInviteNum indicates an expected call of IncrInviteNum.
+func (mr *MockUserRepositoryMockRecorder) IncrInviteNum(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrInviteNum", reflect.TypeOf((*MockUserRepository)(nil).IncrInviteNum), ctx, userID)
+}
+
+// IncrVoucher mocks base method.
+func (m *MockUserRepository) IncrVoucher(ctx context.Context, userID int64, delta int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrVoucher", ctx, userID, delta)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrVoucher indicates an expected call of IncrVoucher.
+func (mr *MockUserRepositoryMockRecorder) IncrVoucher(ctx, userID, delta interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrVoucher", reflect.TypeOf((*MockUserRepository)(nil).IncrVoucher), ctx, userID, delta)
+}
+
+// ListByIDs mocks base method.
+func (m *MockUserRepository) ListByIDs(ctx context.Context, ids []int64) ([]*model.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByIDs", ctx, ids)
+	ret0, _ := ret[0].([]*model.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListByIDs indicates an expected call of ListByIDs.
+func (mr *MockUserRepositoryMockRecorder) ListByIDs(ctx, ids interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByIDs", reflect.TypeOf((*MockUserRepository)(nil).ListByIDs), ctx, ids)
+}
+
+// SetFirstRecharge mocks base method.
+func (m *MockUserRepository) SetFirstRecharge(ctx context.Context, userID int64, value string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFirstRecharge", ctx, userID, value)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetFirstRecharge indicates an expected call of SetFirstRecharge.
+func (mr *MockUserRepositoryMockRecorder) SetFirstRecharge(ctx, userID, value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFirstRecharge", reflect.TypeOf((*MockUserRepository)(nil).SetFirstRecharge), ctx, userID, value)
+}
+
+// SetInviteCode mocks base method.
+func (m *MockUserRepository) SetInviteCode(ctx context.Context, userID int64, code string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetInviteCode", ctx, userID, code)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetInviteCode indicates an expected call of SetInviteCode.
+func (mr *MockUserRepositoryMockRecorder) SetInviteCode(ctx, userID, code interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetInviteCode", reflect.TypeOf((*MockUserRepository)(nil).SetInviteCode), ctx, userID, code)
 }
 
 // Update mocks base method.
@@ -0,0 +1,159 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/cost_history.go
+
+// Package mock_service is a generated GoMock package.
+package mock_service
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/go-nunu/nunu-layout-advanced/internal/model"
+	service "github.com/go-nunu/nunu-layout-advanced/internal/service"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockContactVoucherHistoryService is a mock of ContactVoucherHistoryService interface.
+type MockContactVoucherHistoryService struct {
+	ctrl     *gomock.Controller
+	recorder *MockContactVoucherHistoryServiceMockRecorder
+}
+
+// MockContactVoucherHistoryServiceMockRecorder is the mock recorder for MockContactVoucherHistoryService.
+type MockContactVoucherHistoryServiceMockRecorder struct {
+	mock *MockContactVoucherHistoryService
+}
+
+// NewMockContactVoucherHistoryService creates a new mock instance.
+func NewMockContactVoucherHistoryService(ctrl *gomock.Controller) *MockContactVoucherHistoryService {
+	mock := &MockContactVoucherHistoryService{ctrl: ctrl}
+	mock.recorder = &MockContactVoucherHistoryServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockContactVoucherHistoryService) EXPECT() *MockContactVoucherHistoryServiceMockRecorder {
+	return m.recorder
+}
+
+// AdjustVoucher mocks base method.
+func (m *MockContactVoucherHistoryService) AdjustVoucher(ctx context.Context, userID int64, bizType model.ContactVoucherHistoryBizType, changeNum int, remark string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AdjustVoucher", ctx, userID, bizType, changeNum, remark)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AdjustVoucher indicates an expected call of AdjustVoucher.
+func (mr *MockContactVoucherHistoryServiceMockRecorder) AdjustVoucher(ctx, userID, bizType, changeNum, remark interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AdjustVoucher", reflect.TypeOf((*MockContactVoucherHistoryService)(nil).AdjustVoucher), ctx, userID, bizType, changeNum, remark)
+}
+
+// CapBatches mocks base method.
+func (m *MockContactVoucherHistoryService) CapBatches(ctx context.Context, userID int64, bizType model.ContactVoucherHistoryBizType, expireAt time.Time, remark string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CapBatches", ctx, userID, bizType, expireAt, remark)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CapBatches indicates an expected call of CapBatches.
+func (mr *MockContactVoucherHistoryServiceMockRecorder) CapBatches(ctx, userID, bizType, expireAt, remark interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CapBatches", reflect.TypeOf((*MockContactVoucherHistoryService)(nil).CapBatches), ctx, userID, bizType, expireAt, remark)
+}
+
+// ExpireVouchers mocks base method.
+func (m *MockContactVoucherHistoryService) ExpireVouchers(ctx context.Context, now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpireVouchers", ctx, now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ExpireVouchers indicates an expected call of ExpireVouchers.
+func (mr *MockContactVoucherHistoryServiceMockRecorder) ExpireVouchers(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpireVouchers", reflect.TypeOf((*MockContactVoucherHistoryService)(nil).ExpireVouchers), ctx, now)
+}
+
+// GetUserVoucherNum mocks base method.
+func (m *MockContactVoucherHistoryService) GetUserVoucherNum(ctx context.Context, userID int64) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetUserVoucherNum", ctx, userID)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetUserVoucherNum indicates an expected call of GetUserVoucherNum.
+func (mr *MockContactVoucherHistoryServiceMockRecorder) GetUserVoucherNum(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUserVoucherNum", reflect.TypeOf((*MockContactVoucherHistoryService)(nil).GetUserVoucherNum), ctx, userID)
+}
+
+// GrantVoucher mocks base method.
+func (m *MockContactVoucherHistoryService) GrantVoucher(ctx context.Context, input service.VoucherGrantInput) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantVoucher", ctx, input)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GrantVoucher indicates an expected call of GrantVoucher.
+func (mr *MockContactVoucherHistoryServiceMockRecorder) GrantVoucher(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantVoucher", reflect.TypeOf((*MockContactVoucherHistoryService)(nil).GrantVoucher), ctx, input)
+}
+
+// ListBatches mocks base method.
+func (m *MockContactVoucherHistoryService) ListBatches(ctx context.Context, userID int64) ([]*model.ContactVoucherBatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListBatches", ctx, userID)
+	ret0, _ := ret[0].([]*model.ContactVoucherBatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListBatches indicates an expected call of ListBatches.
+func (mr *MockContactVoucherHistoryServiceMockRecorder) ListBatches(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListBatches", reflect.TypeOf((*MockContactVoucherHistoryService)(nil).ListBatches), ctx, userID)
+}
+
+// ListByUser mocks base method.
+func (m *MockContactVoucherHistoryService) ListByUser(ctx context.Context, userID int64, pageNum, pageSize int) ([]*model.ContactVoucherHistory, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUser", ctx, userID, pageNum, pageSize)
+	ret0, _ := ret[0].([]*model.ContactVoucherHistory)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListByUser indicates an expected call of ListByUser.
+func (mr *MockContactVoucherHistoryServiceMockRecorder) ListByUser(ctx, userID, pageNum, pageSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockContactVoucherHistoryService)(nil).ListByUser), ctx, userID, pageNum, pageSize)
+}
+
+// RevokeOrderVouchers mocks base method.
+func (m *MockContactVoucherHistoryService) RevokeOrderVouchers(ctx context.Context, userID, orderID int64, num int, remark string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevokeOrderVouchers", ctx, userID, orderID, num, remark)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevokeOrderVouchers indicates an expected call of RevokeOrderVouchers.
+func (mr *MockContactVoucherHistoryServiceMockRecorder) RevokeOrderVouchers(ctx, userID, orderID, num, remark interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevokeOrderVouchers", reflect.TypeOf((*MockContactVoucherHistoryService)(nil).RevokeOrderVouchers), ctx, userID, orderID, num, remark)
+}
@@ -0,0 +1,170 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/coupon.go
+
+// Package mock_service is a generated GoMock package.
+package mock_service
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/go-nunu/nunu-layout-advanced/internal/model"
+	service "github.com/go-nunu/nunu-layout-advanced/internal/service"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockCouponService is a mock of CouponService interface.
+type MockCouponService struct {
+	ctrl     *gomock.Controller
+	recorder *MockCouponServiceMockRecorder
+}
+
+// MockCouponServiceMockRecorder is the mock recorder for MockCouponService.
+type MockCouponServiceMockRecorder struct {
+	mock *MockCouponService
+}
+
+// NewMockCouponService creates a new mock instance.
+func NewMockCouponService(ctrl *gomock.Controller) *MockCouponService {
+	mock := &MockCouponService{ctrl: ctrl}
+	mock.recorder = &MockCouponServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockCouponService) EXPECT() *MockCouponServiceMockRecorder {
+	return m.recorder
+}
+
+// Claim mocks base method.
+func (m *MockCouponService) Claim(ctx context.Context, userID, templateID int64) (*model.UserCoupon, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Claim", ctx, userID, templateID)
+	ret0, _ := ret[0].(*model.UserCoupon)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Claim indicates an expected call of Claim.
+func (mr *MockCouponServiceMockRecorder) Claim(ctx, userID, templateID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Claim", reflect.TypeOf((*MockCouponService)(nil).Claim), ctx, userID, templateID)
+}
+
+// CreateTemplate mocks base method.
+func (m *MockCouponService) CreateTemplate(ctx context.Context, input service.CouponTemplateInput) (*model.CouponTemplate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateTemplate", ctx, input)
+	ret0, _ := ret[0].(*model.CouponTemplate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateTemplate indicates an expected call of CreateTemplate.
+func (mr *MockCouponServiceMockRecorder) CreateTemplate(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateTemplate", reflect.TypeOf((*MockCouponService)(nil).CreateTemplate), ctx, input)
+}
+
+// Discount mocks base method.
+func (m *MockCouponService) Discount(ctx context.Context, input service.CouponDiscountInput) (*service.CouponDiscount, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Discount", ctx, input)
+	ret0, _ := ret[0].(*service.CouponDiscount)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Discount indicates an expected call of Discount.
+func (mr *MockCouponServiceMockRecorder) Discount(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Discount", reflect.TypeOf((*MockCouponService)(nil).Discount), ctx, input)
+}
+
+// Issue mocks base method.
+func (m *MockCouponService) Issue(ctx context.Context, userID, templateID int64) (*model.UserCoupon, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Issue", ctx, userID, templateID)
+	ret0, _ := ret[0].(*model.UserCoupon)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Issue indicates an expected call of Issue.
+func (mr *MockCouponServiceMockRecorder) Issue(ctx, userID, templateID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Issue", reflect.TypeOf((*MockCouponService)(nil).Issue), ctx, userID, templateID)
+}
+
+// ListByUser mocks base method.
+func (m *MockCouponService) ListByUser(ctx context.Context, userID int64, status model.UserCouponStatus, pageNum, pageSize int) ([]*model.UserCoupon, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUser", ctx, userID, status, pageNum, pageSize)
+	ret0, _ := ret[0].([]*model.UserCoupon)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListByUser indicates an expected call of ListByUser.
+func (mr *MockCouponServiceMockRecorder) ListByUser(ctx, userID, status, pageNum, pageSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockCouponService)(nil).ListByUser), ctx, userID, status, pageNum, pageSize)
+}
+
+// ListClaimable mocks base method.
+func (m *MockCouponService) ListClaimable(ctx context.Context, userID int64) ([]*service.ClaimableCoupon, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListClaimable", ctx, userID)
+	ret0, _ := ret[0].([]*service.ClaimableCoupon)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListClaimable indicates an expected call of ListClaimable.
+func (mr *MockCouponServiceMockRecorder) ListClaimable(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListClaimable", reflect.TypeOf((*MockCouponService)(nil).ListClaimable), ctx, userID)
+}
+
+// Lock mocks base method.
+func (m *MockCouponService) Lock(ctx context.Context, couponID, orderID int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lock", ctx, couponID, orderID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Lock indicates an expected call of Lock.
+func (mr *MockCouponServiceMockRecorder) Lock(ctx, couponID, orderID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lock", reflect.TypeOf((*MockCouponService)(nil).Lock), ctx, couponID, orderID)
+}
+
+// Release mocks base method.
+func (m *MockCouponService) Release(ctx context.Context, order *model.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx, order)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockCouponServiceMockRecorder) Release(ctx, order interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockCouponService)(nil).Release), ctx, order)
+}
+
+// Settle mocks base method.
+func (m *MockCouponService) Settle(ctx context.Context, order *model.Order) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Settle", ctx, order)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Settle indicates an expected call of Settle.
+func (mr *MockCouponServiceMockRecorder) Settle(ctx, order interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Settle", reflect.TypeOf((*MockCouponService)(nil).Settle), ctx, order)
+}
@@ -0,0 +1,113 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/integral.go
+
+// Package mock_service is a generated GoMock package.
+package mock_service
+
+import (
+	context "context"
+	reflect "reflect"
+
+	model "github.com/go-nunu/nunu-layout-advanced/internal/model"
+	service "github.com/go-nunu/nunu-layout-advanced/internal/service"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIntegralService is a mock of IntegralService interface.
+type MockIntegralService struct {
+	ctrl     *gomock.Controller
+	recorder *MockIntegralServiceMockRecorder
+}
+
+// MockIntegralServiceMockRecorder is the mock recorder for MockIntegralService.
+type MockIntegralServiceMockRecorder struct {
+	mock *MockIntegralService
+}
+
+// NewMockIntegralService creates a new mock instance.
+func NewMockIntegralService(ctrl *gomock.Controller) *MockIntegralService {
+	mock := &MockIntegralService{ctrl: ctrl}
+	mock.recorder = &MockIntegralServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIntegralService) EXPECT() *MockIntegralServiceMockRecorder {
+	return m.recorder
+}
+
+// CheckIn mocks base method.
+func (m *MockIntegralService) CheckIn(ctx context.Context, userID int64) (*service.IntegralChangeResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckIn", ctx, userID)
+	ret0, _ := ret[0].(*service.IntegralChangeResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CheckIn indicates an expected call of CheckIn.
+func (mr *MockIntegralServiceMockRecorder) CheckIn(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckIn", reflect.TypeOf((*MockIntegralService)(nil).CheckIn), ctx, userID)
+}
+
+// Earn mocks base method.
+func (m *MockIntegralService) Earn(ctx context.Context, input service.IntegralEarnInput) (*service.IntegralChangeResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Earn", ctx, input)
+	ret0, _ := ret[0].(*service.IntegralChangeResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Earn indicates an expected call of Earn.
+func (mr *MockIntegralServiceMockRecorder) Earn(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Earn", reflect.TypeOf((*MockIntegralService)(nil).Earn), ctx, input)
+}
+
+// GetBalance mocks base method.
+func (m *MockIntegralService) GetBalance(ctx context.Context, userID int64) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBalance", ctx, userID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBalance indicates an expected call of GetBalance.
+func (mr *MockIntegralServiceMockRecorder) GetBalance(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBalance", reflect.TypeOf((*MockIntegralService)(nil).GetBalance), ctx, userID)
+}
+
+// ListByUser mocks base method.
+func (m *MockIntegralService) ListByUser(ctx context.Context, userID int64, pageNum, pageSize int) ([]*model.IntegralHistory, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListByUser", ctx, userID, pageNum, pageSize)
+	ret0, _ := ret[0].([]*model.IntegralHistory)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// ListByUser indicates an expected call of ListByUser.
+func (mr *MockIntegralServiceMockRecorder) ListByUser(ctx, userID, pageNum, pageSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListByUser", reflect.TypeOf((*MockIntegralService)(nil).ListByUser), ctx, userID, pageNum, pageSize)
+}
+
+// Redeem mocks base method.
+func (m *MockIntegralService) Redeem(ctx context.Context, input service.IntegralRedeemInput) (*service.IntegralChangeResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Redeem", ctx, input)
+	ret0, _ := ret[0].(*service.IntegralChangeResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Redeem indicates an expected call of Redeem.
+func (mr *MockIntegralServiceMockRecorder) Redeem(ctx, input interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Redeem", reflect.TypeOf((*MockIntegralService)(nil).Redeem), ctx, input)
+}
@@ -0,0 +1,98 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/membership.go
+
+// Package mock_service is a generated GoMock package.
+package mock_service
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	model "github.com/go-nunu/nunu-layout-advanced/internal/model"
+	service "github.com/go-nunu/nunu-layout-advanced/internal/service"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockMembershipService is a mock of MembershipService interface.
+type MockMembershipService struct {
+	ctrl     *gomock.Controller
+	recorder *MockMembershipServiceMockRecorder
+}
+
+// MockMembershipServiceMockRecorder is the mock recorder for MockMembershipService.
+type MockMembershipServiceMockRecorder struct {
+	mock *MockMembershipService
+}
+
+// NewMockMembershipService creates a new mock instance.
+func NewMockMembershipService(ctrl *gomock.Controller) *MockMembershipService {
+	mock := &MockMembershipService{ctrl: ctrl}
+	mock.recorder = &MockMembershipServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMembershipService) EXPECT() *MockMembershipServiceMockRecorder {
+	return m.recorder
+}
+
+// Extend mocks base method.
+func (m *MockMembershipService) Extend(ctx context.Context, userID int64, days int) (*model.Membership, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Extend", ctx, userID, days)
+	ret0, _ := ret[0].(*model.Membership)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Extend indicates an expected call of Extend.
+func (mr *MockMembershipServiceMockRecorder) Extend(ctx, userID, days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Extend", reflect.TypeOf((*MockMembershipService)(nil).Extend), ctx, userID, days)
+}
+
+// GetBenefits mocks base method.
+func (m *MockMembershipService) GetBenefits(ctx context.Context, userID int64) (*service.MembershipBenefits, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBenefits", ctx, userID)
+	ret0, _ := ret[0].(*service.MembershipBenefits)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBenefits indicates an expected call of GetBenefits.
+func (mr *MockMembershipServiceMockRecorder) GetBenefits(ctx, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBenefits", reflect.TypeOf((*MockMembershipService)(nil).GetBenefits), ctx, userID)
+}
+
+// GrantAllowances mocks base method.
+func (m *MockMembershipService) GrantAllowances(ctx context.Context, now time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GrantAllowances", ctx, now)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GrantAllowances indicates an expected call of GrantAllowances.
+func (mr *MockMembershipServiceMockRecorder) GrantAllowances(ctx, now interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GrantAllowances", reflect.TypeOf((*MockMembershipService)(nil).GrantAllowances), ctx, now)
+}
+
+// Shorten mocks base method.
+func (m *MockMembershipService) Shorten(ctx context.Context, userID int64, days int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Shorten", ctx, userID, days)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Shorten indicates an expected call of Shorten.
+func (mr *MockMembershipServiceMockRecorder) Shorten(ctx, userID, days interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shorten", reflect.TypeOf((*MockMembershipService)(nil).Shorten), ctx, userID, days)
+}
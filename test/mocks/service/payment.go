@@ -0,0 +1,157 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: internal/service/payment.go
+
+// Package mock_service is a generated GoMock package.
+package mock_service
+
+import (
+	context "context"
+	http "net/http"
+	reflect "reflect"
+	time "time"
+
+	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
+	service "github.com/go-nunu/nunu-layout-advanced/internal/service"
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockPaymentProvider is a mock of PaymentProvider interface.
+type MockPaymentProvider struct {
+	ctrl     *gomock.Controller
+	recorder *MockPaymentProviderMockRecorder
+}
+
+// MockPaymentProviderMockRecorder is the mock recorder for MockPaymentProvider.
+type MockPaymentProviderMockRecorder struct {
+	mock *MockPaymentProvider
+}
+
+// NewMockPaymentProvider creates a new mock instance.
+func NewMockPaymentProvider(ctrl *gomock.Controller) *MockPaymentProvider {
+	mock := &MockPaymentProvider{ctrl: ctrl}
+	mock.recorder = &MockPaymentProviderMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockPaymentProvider) EXPECT() *MockPaymentProviderMockRecorder {
+	return m.recorder
+}
+
+// Channel mocks base method.
+func (m *MockPaymentProvider) Channel() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Channel")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Channel indicates an expected call of Channel.
+func (mr *MockPaymentProviderMockRecorder) Channel() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Channel", reflect.TypeOf((*MockPaymentProvider)(nil).Channel))
+}
+
+// CloseOrder mocks base method.
+func (m *MockPaymentProvider) CloseOrder(ctx context.Context, orderNo string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CloseOrder", ctx, orderNo)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CloseOrder indicates an expected call of CloseOrder.
+func (mr *MockPaymentProviderMockRecorder) CloseOrder(ctx, orderNo interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseOrder", reflect.TypeOf((*MockPaymentProvider)(nil).CloseOrder), ctx, orderNo)
+}
+
+// JSAPIPay mocks base method.
+func (m *MockPaymentProvider) JSAPIPay(ctx context.Context, req service.PrepayRequest) (v1.PayParams, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "JSAPIPay", ctx, req)
+	ret0, _ := ret[0].(v1.PayParams)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// JSAPIPay indicates an expected call of JSAPIPay.
+func (mr *MockPaymentProviderMockRecorder) JSAPIPay(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "JSAPIPay", reflect.TypeOf((*MockPaymentProvider)(nil).JSAPIPay), ctx, req)
+}
+
+// ParsePayNotify mocks base method.
+func (m *MockPaymentProvider) ParsePayNotify(ctx context.Context, header http.Header, body []byte) (*service.PayResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParsePayNotify", ctx, header, body)
+	ret0, _ := ret[0].(*service.PayResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParsePayNotify indicates an expected call of ParsePayNotify.
+func (mr *MockPaymentProviderMockRecorder) ParsePayNotify(ctx, header, body interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParsePayNotify", reflect.TypeOf((*MockPaymentProvider)(nil).ParsePayNotify), ctx, header, body)
+}
+
+// ParseRefundNotify mocks base method.
+func (m *MockPaymentProvider) ParseRefundNotify(ctx context.Context, header http.Header, body []byte) (*service.RefundResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ParseRefundNotify", ctx, header, body)
+	ret0, _ := ret[0].(*service.RefundResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ParseRefundNotify indicates an expected call of ParseRefundNotify.
+func (mr *MockPaymentProviderMockRecorder) ParseRefundNotify(ctx, header, body interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ParseRefundNotify", reflect.TypeOf((*MockPaymentProvider)(nil).ParseRefundNotify), ctx, header, body)
+}
+
+// QueryOrder mocks base method.
+func (m *MockPaymentProvider) QueryOrder(ctx context.Context, orderNo string) (*service.PayResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryOrder", ctx, orderNo)
+	ret0, _ := ret[0].(*service.PayResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// QueryOrder indicates an expected call of QueryOrder.
+func (mr *MockPaymentProviderMockRecorder) QueryOrder(ctx, orderNo interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryOrder", reflect.TypeOf((*MockPaymentProvider)(nil).QueryOrder), ctx, orderNo)
+}
+
+// Refund mocks base method.
+func (m *MockPaymentProvider) Refund(ctx context.Context, req service.RefundRequest) (*service.RefundResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Refund", ctx, req)
+	ret0, _ := ret[0].(*service.RefundResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Refund indicates an expected call of Refund.
+func (mr *MockPaymentProviderMockRecorder) Refund(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Refund", reflect.TypeOf((*MockPaymentProvider)(nil).Refund), ctx, req)
+}
+
+// TradeBill mocks base method.
+func (m *MockPaymentProvider) TradeBill(ctx context.Context, billDate time.Time) ([]service.BillRecord, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TradeBill", ctx, billDate)
+	ret0, _ := ret[0].([]service.BillRecord)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TradeBill indicates an expected call of TradeBill.
+func (mr *MockPaymentProviderMockRecorder) TradeBill(ctx, billDate interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TradeBill", reflect.TypeOf((*MockPaymentProvider)(nil).TradeBill), ctx, billDate)
+}
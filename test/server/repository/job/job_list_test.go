@@ -0,0 +1,89 @@
+package job_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/geo"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestJobList_NearbyWithinRadius(t *testing.T) {
+	db := newDB(t)
+	repo := repository.NewRepository(&log.Logger{Logger: zap.NewNop()}, db)
+	jobRepository := repository.NewJobRepository(repo)
+	ctx := context.Background()
+	now := time.Now()
+
+	const lat, lng = 39.9087, 116.3975
+	newJob := func(positions string, latitude, longitude float64) {
+		assert.NoError(t, db.Create(&model.Job{
+			Positions: positions,
+			Latitude:  latitude,
+			Longitude: longitude,
+			Status:    model.JobStatusActive,
+			CreateAt:  now,
+			UpdateAt:  now,
+		}).Error)
+	}
+	newJob("far", lat+0.07, lng)          // about 7.8 km north
+	newJob("near", lat, lng+0.01)         // about 0.85 km east
+	newJob("corner", lat+0.07, lng+0.09)  // inside the 10 km box, 10.9 km away
+	newJob("tianjin", 39.0842, 117.2009)  // about 113 km
+	newJob("shanghai", 31.2304, 121.4737) // about 1070 km
+
+	jobs, total, err := jobRepository.List(ctx, repository.JobListQuery{
+		QueryType: 2, Latitude: lat, Longitude: lng, RadiusKm: 10,
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 2, total)
+	if assert.Len(t, jobs, 2) {
+		assert.Equal(t, "near", jobs[0].Positions)
+		assert.Equal(t, "far", jobs[1].Positions)
+	}
+
+	// Without a radius every job comes back, nearest first; degrees of longitude count for
+	// less than degrees of latitude this far north.
+	jobs, total, err = jobRepository.List(ctx, repository.JobListQuery{
+		QueryType: 2, Latitude: lat, Longitude: lng,
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 5, total)
+	var positions []string
+	for _, job := range jobs {
+		positions = append(positions, job.Positions)
+	}
+	assert.Equal(t, []string{"near", "far", "corner", "tianjin", "shanghai"}, positions)
+
+	// At the edge of a 100 km radius the plane and the great circle disagree by a couple of
+	// hundred meters; the list and the distance it reports both go by the plane.
+	newJob("edge", 39.3, 117.258) // 99.84 km on the plane, 100.08 km on the great circle
+	plane := geo.NewPlane(lat, lng)
+	jobs, _, err = jobRepository.List(ctx, repository.JobListQuery{
+		QueryType: 2, Latitude: lat, Longitude: lng, RadiusKm: 100,
+	})
+	assert.NoError(t, err)
+	positions = positions[:0]
+	previous := 0.0
+	for _, job := range jobs {
+		positions = append(positions, job.Positions)
+		distance := plane.Distance(job.Latitude, job.Longitude)
+		assert.LessOrEqual(t, distance, 100000.0, job.Positions)
+		assert.GreaterOrEqual(t, distance, previous, job.Positions)
+		previous = distance
+	}
+	assert.Equal(t, []string{"near", "far", "corner", "edge"}, positions)
+	assert.Greater(t, geo.Distance(lat, lng, 39.3, 117.258), 100000.0)
+
+	// A wider radius is held to the range the plane is accurate in.
+	_, total, err = jobRepository.List(ctx, repository.JobListQuery{
+		QueryType: 2, Latitude: lat, Longitude: lng, RadiusKm: 2000,
+	})
+	assert.NoError(t, err)
+	assert.EqualValues(t, 4, total)
+}
@@ -0,0 +1,87 @@
+package contact_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestContactUnlock_ChargesOnce(t *testing.T) {
+	db := newDB(t)
+	logger := &log.Logger{Logger: zap.NewNop()}
+	repo := repository.NewRepository(logger, db)
+	srv := service.NewService(repository.NewTransaction(repo), logger, nil, jwt.NewJwt(viper.New()))
+	userRepository := repository.NewUserRepository(repo)
+	jobRepository := repository.NewJobRepository(repo)
+	unlockService := service.NewContactUnlockService(srv,
+		repository.NewContactUnlockRepository(repo),
+		jobRepository,
+		newVoucherService(srv, repo),
+		service.NewContactHistoryService(srv, repository.NewContactHistoryRepository(repo), jobRepository, userRepository),
+	)
+	ctx := context.Background()
+	now := time.Now()
+
+	owner := &model.User{CreateAt: now, UpdateAt: now}
+	caller := &model.User{ContactVoucherNum: 3, CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(owner).Error)
+	assert.NoError(t, db.Create(caller).Error)
+	expireAt := now.Add(time.Hour)
+	assert.NoError(t, db.Create(&model.ContactVoucherBatch{
+		UserID: caller.ID, BizType: model.ContactVoucherHistoryBuy, TotalNum: 3, RemainNum: 3, ExpireAt: &expireAt, CreateAt: now, UpdateAt: now,
+	}).Error)
+	job := &model.Job{UserID: owner.ID, Contact: "13800138000", Status: model.JobStatusActive, CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(job).Error)
+
+	const workers = 6
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		charged int
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := unlockService.Unlock(ctx, caller.ID, model.ContactPurposeJob, job.ID)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, "13800138000", result.Phone)
+			assert.Equal(t, 2, result.ContactVoucherNum)
+			mu.Lock()
+			defer mu.Unlock()
+			if result.Charged {
+				charged++
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, charged)
+
+	var got model.User
+	assert.NoError(t, db.First(&got, caller.ID).Error)
+	assert.Equal(t, 2, got.ContactVoucherNum)
+	var contacts int64
+	assert.NoError(t, db.Model(&model.ContactHistory{}).Where("user_id = ?", caller.ID).Count(&contacts).Error)
+	assert.Equal(t, int64(1), contacts)
+
+	unlocked, err := unlockService.UnlockedJobIDs(ctx, caller.ID, []int64{job.ID, job.ID + 1})
+	assert.NoError(t, err)
+	assert.Equal(t, map[int64]bool{job.ID: true}, unlocked)
+
+	// The owner sees their own phone without spending anything.
+	result, err := unlockService.Unlock(ctx, owner.ID, model.ContactPurposeJob, job.ID)
+	assert.NoError(t, err)
+	assert.False(t, result.Charged)
+}
@@ -0,0 +1,75 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/test/mocks/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContactVoucherHistoryService_AdjustVoucher_Spend(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockHistoryRepo := mock_repository.NewMockContactVoucherHistoryRepository(ctrl)
+	mockBatchRepo := mock_repository.NewMockContactVoucherBatchRepository(ctrl)
+	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
+	mockTm := mock_repository.NewMockTransaction(ctrl)
+	runTransaction(mockTm)
+	srv := service.NewService(mockTm, logger, sf, j)
+	voucherService := service.NewContactVoucherHistoryService(srv, mockHistoryRepo, mockBatchRepo, mockUserRepo, viper.New())
+
+	ctx := context.Background()
+	var userID int64 = 1
+
+	mockUserRepo.EXPECT().IncrVoucher(ctx, userID, -3).Return(4, nil)
+	mockBatchRepo.EXPECT().ListUsableForUpdate(ctx, userID, gomock.Any()).Return([]*model.ContactVoucherBatch{
+		{ID: 1, RemainNum: 2},
+		{ID: 2, RemainNum: 5},
+	}, nil)
+	mockBatchRepo.EXPECT().Consume(ctx, int64(1), 2).Return(true, nil)
+	mockBatchRepo.EXPECT().Consume(ctx, int64(2), 1).Return(true, nil)
+	mockHistoryRepo.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, history *model.ContactVoucherHistory) error {
+		assert.Equal(t, -3, history.ChangeNum)
+		assert.Equal(t, 7, history.LastNum)
+		assert.Equal(t, 4, history.NextNum)
+		return nil
+	})
+
+	next, err := voucherService.AdjustVoucher(ctx, userID, model.ContactVoucherHistoryCost, -3, "拨打电话")
+
+	assert.NoError(t, err)
+	assert.Equal(t, 4, next)
+}
+
+func TestContactVoucherHistoryService_AdjustVoucher_BatchesShort(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockHistoryRepo := mock_repository.NewMockContactVoucherHistoryRepository(ctrl)
+	mockBatchRepo := mock_repository.NewMockContactVoucherBatchRepository(ctrl)
+	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
+	mockTm := mock_repository.NewMockTransaction(ctrl)
+	runTransaction(mockTm)
+	srv := service.NewService(mockTm, logger, sf, j)
+	voucherService := service.NewContactVoucherHistoryService(srv, mockHistoryRepo, mockBatchRepo, mockUserRepo, viper.New())
+
+	ctx := context.Background()
+	var userID int64 = 1
+
+	// The cached balance covers the spend, but part of it expired without being swept yet.
+	mockUserRepo.EXPECT().IncrVoucher(ctx, userID, -3).Return(2, nil)
+	mockBatchRepo.EXPECT().ListUsableForUpdate(ctx, userID, gomock.Any()).Return([]*model.ContactVoucherBatch{
+		{ID: 1, RemainNum: 2},
+	}, nil)
+	mockBatchRepo.EXPECT().Consume(ctx, int64(1), 2).Return(true, nil)
+
+	_, err := voucherService.AdjustVoucher(ctx, userID, model.ContactVoucherHistoryCost, -3, "拨打电话")
+
+	assert.Equal(t, service.ErrInsufficientVoucher, err)
+}
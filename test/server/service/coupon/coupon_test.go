@@ -0,0 +1,201 @@
+package coupon_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestCoupon_LockReleaseAndSettle(t *testing.T) {
+	db := newDB(t)
+	logger := &log.Logger{Logger: zap.NewNop()}
+	repo := repository.NewRepository(logger, db)
+	srv := service.NewService(repository.NewTransaction(repo), logger, nil, jwt.NewJwt(viper.New()))
+	couponService := newCouponService(srv, repo)
+	orderService := newOrderService(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	user := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+	template, err := couponService.CreateTemplate(ctx, service.CouponTemplateInput{
+		Title:             "首充立减10元",
+		ProductType:       model.ProductTypeTop,
+		DiscountAmount:    model.NewDecimalFromCents(1000),
+		MinAmount:         model.NewDecimalFromCents(1000),
+		FirstRechargeOnly: true,
+		ValidDays:         7,
+		TotalNum:          1,
+	})
+	assert.NoError(t, err)
+	coupon, err := couponService.Claim(ctx, user.ID, template.ID)
+	assert.NoError(t, err)
+	_, err = couponService.Claim(ctx, user.ID, template.ID)
+	assert.Equal(t, service.ErrCouponClaimed, err)
+
+	line := func(productType model.ProductType, cents int64) *model.OrderItem {
+		return &model.OrderItem{ProductType: productType, UnitPriceSnapshot: model.NewDecimalFromCents(cents)}
+	}
+	// Wrong product type, and a price below the threshold, don't take the coupon.
+	_, err = couponService.Discount(ctx, service.CouponDiscountInput{
+		UserID: user.ID, CouponID: coupon.ID, Items: []*model.OrderItem{line(model.ProductTypeRefresh, 1990)},
+	})
+	assert.Equal(t, service.ErrCouponUnavailable, err)
+	_, err = couponService.Discount(ctx, service.CouponDiscountInput{
+		UserID: user.ID, CouponID: coupon.ID, Items: []*model.OrderItem{line(model.ProductTypeTop, 990)},
+	})
+	assert.Equal(t, service.ErrCouponUnavailable, err)
+	// In a mixed cart only the top line counts towards the threshold and the cap: the
+	// discount never takes those lines below one cent.
+	discount, err := couponService.Discount(ctx, service.CouponDiscountInput{
+		UserID: user.ID, CouponID: coupon.ID, Items: []*model.OrderItem{
+			line(model.ProductTypeRefresh, 1990),
+			line(model.ProductTypeTop, 1000),
+		},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "9.99", discount.Discount.String())
+
+	newOrder := func(orderNo string) *model.Order {
+		order := &model.Order{
+			OrderNo:        orderNo,
+			UserID:         user.ID,
+			AmountTotal:    model.NewDecimalFromCents(990),
+			CouponID:       coupon.ID,
+			DiscountAmount: model.NewDecimalFromCents(1000),
+			Currency:       "CNY",
+			Status:         model.OrderStatusPending,
+			CreateAt:       now,
+			UpdateAt:       now,
+		}
+		assert.NoError(t, db.Create(order).Error)
+		assert.NoError(t, db.Create(&model.OrderItem{
+			OrderID:           order.ID,
+			ProductType:       model.ProductTypeContactVoucher,
+			TitleSnapshot:     "联系券-5张",
+			ContactVoucherNum: 5,
+			CreateAt:          now,
+			UpdateAt:          now,
+		}).Error)
+		return order
+	}
+
+	first := newOrder("CV-1")
+	assert.NoError(t, couponService.Lock(ctx, coupon.ID, first.ID))
+	second := newOrder("CV-2")
+	assert.Equal(t, service.ErrCouponUnavailable, couponService.Lock(ctx, coupon.ID, second.ID))
+
+	// Canceling the first order frees the coupon for the second.
+	_, err = orderService.CancelOrder(ctx, user.ID, first.OrderNo)
+	assert.NoError(t, err)
+	assert.NoError(t, couponService.Lock(ctx, coupon.ID, second.ID))
+	_, err = orderService.PayOrderByNotify(ctx, second.OrderNo, 990, "fake", "T"+second.OrderNo)
+	assert.NoError(t, err)
+
+	var used model.UserCoupon
+	assert.NoError(t, db.First(&used, coupon.ID).Error)
+	assert.Equal(t, model.UserCouponStatusUsed, used.Status)
+	assert.Equal(t, second.ID, used.OrderID)
+	var got model.User
+	assert.NoError(t, db.First(&got, user.ID).Error)
+	assert.Equal(t, second.OrderNo, got.FirstRecharge)
+
+	// A late payment of the canceled order can't have the coupon any more: it is refunded
+	// instead of delivered, and a retried notify doesn't refund it twice.
+	for i := 0; i < 2; i++ {
+		late, err := orderService.PayOrderByNotify(ctx, first.OrderNo, 990, "fake", "T"+first.OrderNo)
+		assert.NoError(t, err)
+		assert.Equal(t, model.OrderStatusCanceled, late.Status)
+	}
+	var after model.UserCoupon
+	assert.NoError(t, db.First(&after, coupon.ID).Error)
+	assert.Equal(t, second.ID, after.OrderID)
+	var refunds []*model.Refund
+	assert.NoError(t, db.Where("order_id = ?", first.ID).Find(&refunds).Error)
+	if assert.Len(t, refunds, 1) {
+		assert.Equal(t, "9.90", refunds[0].Amount.String())
+	}
+	assert.NoError(t, db.First(&got, user.ID).Error)
+	assert.Equal(t, 5, got.ContactVoucherNum)
+
+	// First-recharge campaigns are no longer offered once the user has paid.
+	list, err := couponService.ListClaimable(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Len(t, list, 0)
+}
+
+func TestCoupon_FirstRechargeRecheckedOnPayment(t *testing.T) {
+	db := newDB(t)
+	logger := &log.Logger{Logger: zap.NewNop()}
+	repo := repository.NewRepository(logger, db)
+	srv := service.NewService(repository.NewTransaction(repo), logger, nil, jwt.NewJwt(viper.New()))
+	couponService := newCouponService(srv, repo)
+	orderService := newOrderService(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	user := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+	// Two first-recharge campaigns, each coupon on its own pending order.
+	orders := make([]*model.Order, 0, 2)
+	coupons := make([]*model.UserCoupon, 0, 2)
+	for _, title := range []string{"首充立减1元", "首充立减2元"} {
+		template, err := couponService.CreateTemplate(ctx, service.CouponTemplateInput{
+			Title:             title,
+			ProductType:       model.ProductTypeContactVoucher,
+			DiscountAmount:    model.NewDecimalFromCents(100),
+			FirstRechargeOnly: true,
+			ValidDays:         7,
+			TotalNum:          1,
+		})
+		assert.NoError(t, err)
+		coupon, err := couponService.Claim(ctx, user.ID, template.ID)
+		assert.NoError(t, err)
+		order := &model.Order{
+			OrderNo:     "FR-" + title,
+			UserID:      user.ID,
+			AmountTotal: model.NewDecimalFromCents(890),
+			CouponID:    coupon.ID,
+			Currency:    "CNY",
+			Status:      model.OrderStatusPending,
+			CreateAt:    now,
+			UpdateAt:    now,
+		}
+		assert.NoError(t, db.Create(order).Error)
+		assert.NoError(t, db.Create(&model.OrderItem{
+			OrderID: order.ID, ProductType: model.ProductTypeContactVoucher, ContactVoucherNum: 5, CreateAt: now, UpdateAt: now,
+		}).Error)
+		assert.NoError(t, couponService.Lock(ctx, coupon.ID, order.ID))
+		orders = append(orders, order)
+		coupons = append(coupons, coupon)
+	}
+
+	paid, err := orderService.PayOrderByNotify(ctx, orders[0].OrderNo, 890, "fake", "T1")
+	assert.NoError(t, err)
+	assert.Equal(t, model.OrderStatusPaid, paid.Status)
+
+	// The second is no longer a first recharge: it is refunded, not delivered, and its
+	// coupon goes back to the user.
+	late, err := orderService.PayOrderByNotify(ctx, orders[1].OrderNo, 890, "fake", "T2")
+	assert.NoError(t, err)
+	assert.Equal(t, model.OrderStatusCanceled, late.Status)
+	var refunds int64
+	assert.NoError(t, db.Model(&model.Refund{}).Where("order_id = ?", orders[1].ID).Count(&refunds).Error)
+	assert.Equal(t, int64(1), refunds)
+	var released model.UserCoupon
+	assert.NoError(t, db.First(&released, coupons[1].ID).Error)
+	assert.Equal(t, model.UserCouponStatusUnused, released.Status)
+	var got model.User
+	assert.NoError(t, db.First(&got, user.ID).Error)
+	assert.Equal(t, orders[0].OrderNo, got.FirstRecharge)
+	assert.Equal(t, 5, got.ContactVoucherNum)
+}
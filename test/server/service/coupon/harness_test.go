@@ -0,0 +1,161 @@
+package coupon_test
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
+	"github.com/sony/sonyflake"
+	"github.com/spf13/viper"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+// newDB opens a throwaway sqlite database with the tables these tests touch; writers take
+// the lock at BEGIN and wait for each other instead of failing with SQLITE_BUSY.
+func newDB(t *testing.T) *gorm.DB {
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_txlock=immediate&_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(
+		&model.User{},
+		&model.Order{},
+		&model.OrderItem{},
+		&model.ContactVoucherHistory{},
+		&model.ContactVoucherBatch{},
+		&model.CouponTemplate{},
+		&model.UserCoupon{},
+		&model.Refund{},
+	); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+// fakeProvider accepts every payment call and authenticates no notify.
+type fakeProvider struct{}
+
+func (fakeProvider) Channel() string { return "fake" }
+
+func (fakeProvider) JSAPIPay(ctx context.Context, req service.PrepayRequest) (v1.PayParams, error) {
+	return v1.PayParams{}, nil
+}
+
+func (fakeProvider) ParsePayNotify(ctx context.Context, header http.Header, body []byte) (*service.PayResult, error) {
+	return nil, service.ErrInvalidNotify
+}
+
+func (fakeProvider) QueryOrder(ctx context.Context, orderNo string) (*service.PayResult, error) {
+	return &service.PayResult{Channel: "fake", OrderNo: orderNo}, nil
+}
+
+func (fakeProvider) CloseOrder(ctx context.Context, orderNo string) error {
+	return nil
+}
+
+func (fakeProvider) Refund(ctx context.Context, req service.RefundRequest) (*service.RefundResult, error) {
+	return &service.RefundResult{RefundNo: req.RefundNo, Status: model.RefundStatusProcessing}, nil
+}
+
+func (fakeProvider) ParseRefundNotify(ctx context.Context, header http.Header, body []byte) (*service.RefundResult, error) {
+	return nil, service.ErrInvalidNotify
+}
+
+func (fakeProvider) TradeBill(ctx context.Context, billDate time.Time) ([]service.BillRecord, error) {
+	return nil, nil
+}
+
+// fixedSid is a sonyflake with a fixed machine ID, as the sandbox may have no private IP
+// to derive one from.
+type fixedSid struct {
+	*sonyflake.Sonyflake
+}
+
+func (s fixedSid) GenUint64() (uint64, error) {
+	return s.NextID()
+}
+
+// testSid is shared by every service built here so two of them in one test never hand out
+// the same order or refund number.
+var testSid = fixedSid{sonyflake.NewSonyflake(sonyflake.Settings{
+	MachineID: func() (uint16, error) { return 1, nil },
+})}
+
+func newOrderService(db *gorm.DB) service.OrderService {
+	return newOrderServiceWithProvider(db, fakeProvider{})
+}
+
+func newOrderServiceWithProvider(db *gorm.DB, provider service.PaymentProvider) service.OrderService {
+	logger := &log.Logger{Logger: zap.NewNop()}
+	conf := viper.New()
+	repo := repository.NewRepository(logger, db)
+	srv := service.NewService(repository.NewTransaction(repo), logger, testSid, jwt.NewJwt(conf))
+	return service.NewOrderService(
+		srv,
+		repository.NewOrderRepository(repo),
+		repository.NewOrderItemRepository(repo),
+		repository.NewJobRepository(repo),
+		repository.NewInvoiceRepository(repo),
+		repository.NewNotificationRepository(repo),
+		newVoucherService(srv, repo),
+		newMembershipService(srv, repo, conf),
+		newCouponService(srv, repo),
+		repository.NewIdempotencyKeyRepository(repo),
+		service.NewProductService(srv, repository.NewProductRepository(repo)),
+		provider,
+		newRefundService(db, provider),
+		conf,
+	)
+}
+
+func newVoucherService(srv *service.Service, repo *repository.Repository) service.ContactVoucherHistoryService {
+	return service.NewContactVoucherHistoryService(srv,
+		repository.NewContactVoucherHistoryRepository(repo),
+		repository.NewContactVoucherBatchRepository(repo),
+		repository.NewUserRepository(repo),
+		viper.New(),
+	)
+}
+
+func newMembershipService(srv *service.Service, repo *repository.Repository, conf *viper.Viper) service.MembershipService {
+	return service.NewMembershipService(srv,
+		repository.NewMembershipRepository(repo),
+		newVoucherService(srv, repo),
+		conf,
+	)
+}
+
+func newCouponService(srv *service.Service, repo *repository.Repository) service.CouponService {
+	return service.NewCouponService(srv,
+		repository.NewCouponTemplateRepository(repo),
+		repository.NewUserCouponRepository(repo),
+		repository.NewUserRepository(repo),
+	)
+}
+
+func newRefundService(db *gorm.DB, provider service.PaymentProvider) service.RefundService {
+	logger := &log.Logger{Logger: zap.NewNop()}
+	conf := viper.New()
+	repo := repository.NewRepository(logger, db)
+	srv := service.NewService(repository.NewTransaction(repo), logger, testSid, jwt.NewJwt(conf))
+	return service.NewRefundService(srv,
+		repository.NewOrderRepository(repo),
+		repository.NewOrderItemRepository(repo),
+		repository.NewRefundRepository(repo),
+		repository.NewJobRepository(repo),
+		newVoucherService(srv, repo),
+		newMembershipService(srv, repo, conf),
+		provider,
+	)
+}
@@ -0,0 +1,81 @@
+package integral_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestIntegral_CheckInAndRedeem(t *testing.T) {
+	db := newDB(t)
+	logger := &log.Logger{Logger: zap.NewNop()}
+	repo := repository.NewRepository(logger, db)
+	srv := service.NewService(repository.NewTransaction(repo), logger, nil, jwt.NewJwt(viper.New()))
+	conf := viper.New()
+	conf.Set("integral.checkin", 60)
+	conf.Set("integral.voucher_price", 50)
+	integralService := newIntegralService(srv, repo, conf)
+	ctx := context.Background()
+	now := time.Now()
+
+	user := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+
+	const workers = 6
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		checked int
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := integralService.CheckIn(ctx, user.ID); err == nil {
+				mu.Lock()
+				checked++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 1, checked)
+	_, err := integralService.CheckIn(ctx, user.ID)
+	assert.Equal(t, service.ErrAlreadyCheckedIn, err)
+
+	// 60 points buy one 50-point voucher but not two.
+	_, err = integralService.Redeem(ctx, service.IntegralRedeemInput{
+		UserID: user.ID, BizType: model.IntegralHistoryRedeemVoucher, Num: 2,
+	})
+	assert.Equal(t, service.ErrInsufficientIntegral, err)
+	result, err := integralService.Redeem(ctx, service.IntegralRedeemInput{
+		UserID: user.ID, BizType: model.IntegralHistoryRedeemVoucher, Num: 1,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(-50), result.Change)
+	assert.Equal(t, int64(10), result.Integral)
+
+	var got model.User
+	assert.NoError(t, db.First(&got, user.ID).Error)
+	assert.Equal(t, uint64(10), got.Integral)
+	assert.Equal(t, 1, got.ContactVoucherNum)
+
+	histories, total, err := integralService.ListByUser(ctx, user.ID, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	if assert.Len(t, histories, 2) {
+		assert.Equal(t, model.IntegralHistoryRedeemVoucher, histories[0].BizType)
+		assert.Equal(t, int64(60), histories[0].LastNum)
+		assert.Equal(t, int64(10), histories[0].NextNum)
+	}
+}
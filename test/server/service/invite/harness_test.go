@@ -0,0 +1,52 @@
+package invite_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/spf13/viper"
+	"gorm.io/gorm"
+)
+
+// newDB opens a throwaway sqlite database with the tables these tests touch; writers take
+// the lock at BEGIN and wait for each other instead of failing with SQLITE_BUSY.
+func newDB(t *testing.T) *gorm.DB {
+	dsn := filepath.Join(t.TempDir(), "test.db") + "?_txlock=immediate&_pragma=busy_timeout(10000)&_pragma=journal_mode(WAL)"
+	db, err := gorm.Open(sqlite.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(
+		&model.User{},
+		&model.ContactVoucherHistory{},
+		&model.ContactVoucherBatch{},
+		&model.Invite{},
+		&model.IntegralHistory{},
+	); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func newVoucherService(srv *service.Service, repo *repository.Repository) service.ContactVoucherHistoryService {
+	return service.NewContactVoucherHistoryService(srv,
+		repository.NewContactVoucherHistoryRepository(repo),
+		repository.NewContactVoucherBatchRepository(repo),
+		repository.NewUserRepository(repo),
+		viper.New(),
+	)
+}
+
+func newIntegralService(srv *service.Service, repo *repository.Repository, conf *viper.Viper) service.IntegralService {
+	return service.NewIntegralService(srv,
+		repository.NewIntegralHistoryRepository(repo),
+		repository.NewUserRepository(repo),
+		repository.NewJobRepository(repo),
+		newVoucherService(srv, repo),
+		conf,
+	)
+}
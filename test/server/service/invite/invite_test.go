@@ -0,0 +1,83 @@
+package invite_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestInviteAccept_OneRewardPerPhoneOrDevice(t *testing.T) {
+	db := newDB(t)
+	logger := &log.Logger{Logger: zap.NewNop()}
+	repo := repository.NewRepository(logger, db)
+	srv := service.NewService(repository.NewTransaction(repo), logger, nil, jwt.NewJwt(viper.New()))
+	conf := viper.New()
+	conf.Set("invite.inviter_reward", 2)
+	conf.Set("invite.invitee_reward", 1)
+	userRepo := repository.NewUserRepository(repo)
+	inviteService := service.NewInviteService(srv,
+		repository.NewInviteRepository(repo),
+		userRepo,
+		newVoucherService(srv, repo),
+		newIntegralService(srv, repo, conf),
+		conf,
+	)
+	ctx := context.Background()
+	now := time.Now()
+
+	inviter := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(inviter).Error)
+	info, err := inviteService.GetInviteInfo(ctx, inviter.ID)
+	assert.NoError(t, err)
+	assert.Len(t, info.InviteCode, 8)
+	again, err := inviteService.GetInviteInfo(ctx, inviter.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, info.InviteCode, again.InviteCode)
+
+	inviterID, err := inviteService.ResolveInviter(ctx, info.InviteCode, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, inviter.ID, inviterID)
+	unknown, err := inviteService.ResolveInviter(ctx, "", inviter.ID+100)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), unknown)
+
+	register := func(phone, deviceID string) *model.User {
+		invitee := &model.User{Phone: phone, InviteID: inviterID, CreateAt: now, UpdateAt: now}
+		assert.NoError(t, db.Create(invitee).Error)
+		assert.NoError(t, inviteService.Accept(ctx, invitee, deviceID))
+		return invitee
+	}
+	first := register("13800000001", "device-a")
+	// Same device, new phone: counted but not rewarded.
+	second := register("13800000002", "device-a")
+	assert.Error(t, inviteService.Accept(ctx, first, "device-a"))
+
+	var gotInviter, gotFirst, gotSecond model.User
+	assert.NoError(t, db.First(&gotInviter, inviter.ID).Error)
+	assert.Equal(t, uint64(2), gotInviter.InviteNum)
+	assert.Equal(t, 2, gotInviter.ContactVoucherNum)
+	// Points only for the rewarded invite.
+	assert.Equal(t, uint64(10), gotInviter.Integral)
+	assert.NoError(t, db.First(&gotFirst, first.ID).Error)
+	assert.Equal(t, 1, gotFirst.ContactVoucherNum)
+	assert.NoError(t, db.First(&gotSecond, second.ID).Error)
+	assert.Equal(t, 0, gotSecond.ContactVoucherNum)
+
+	records, total, err := inviteService.ListInvites(ctx, inviter.ID, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	if assert.Len(t, records, 2) {
+		assert.Equal(t, second.ID, records[0].Invitee.ID)
+		assert.Equal(t, model.InviteRewardStatusRejected, records[0].Invite.RewardStatus)
+		assert.Equal(t, model.InviteRewardStatusGranted, records[1].Invite.RewardStatus)
+	}
+}
@@ -0,0 +1,112 @@
+package invoice_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestInvoice_OrdersInvoicedOnce(t *testing.T) {
+	db := newDB(t)
+	logger := &log.Logger{Logger: zap.NewNop()}
+	repo := repository.NewRepository(logger, db)
+	srv := service.NewService(repository.NewTransaction(repo), logger, nil, jwt.NewJwt(viper.New()))
+	orderRepository := repository.NewOrderRepository(repo)
+	invoiceService := service.NewInvoiceService(srv,
+		repository.NewInvoiceRepository(repo),
+		orderRepository,
+		repository.NewRefundRepository(repo),
+	)
+	ctx := context.Background()
+	now := time.Now()
+
+	user := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+	newOrder := func(orderNo string, cents int64, status model.OrderStatus) *model.Order {
+		order := &model.Order{
+			OrderNo:     orderNo,
+			UserID:      user.ID,
+			AmountTotal: model.NewDecimalFromCents(cents),
+			AmountPaid:  model.NewDecimalFromCents(cents),
+			Currency:    "CNY",
+			Status:      status,
+			CreateAt:    now,
+			UpdateAt:    now,
+		}
+		assert.NoError(t, db.Create(order).Error)
+		return order
+	}
+	top := newOrder("TOP-1", 990, model.OrderStatusPaid)
+	voucher := newOrder("CV-1", 500, model.OrderStatusPaid)
+	newOrder("CV-PENDING", 300, model.OrderStatusPending)
+	// A partial refund comes off the invoiced amount.
+	assert.NoError(t, db.Create(&model.Refund{
+		RefundNo: "R-1", OrderID: voucher.ID, OrderNo: voucher.OrderNo, UserID: user.ID,
+		Amount: model.NewDecimalFromCents(200), Status: model.RefundStatusSuccess, CreateAt: now, UpdateAt: now,
+	}).Error)
+
+	input := service.InvoiceRequestInput{
+		UserID:    user.ID,
+		OrderNos:  []string{"TOP-1", "CV-1"},
+		TitleType: model.InvoiceTitleCompany,
+		Title:     "某某科技有限公司",
+		TaxNo:     "91110108MA01ABCD2X",
+		Email:     "finance@example.com",
+	}
+	first, err := invoiceService.Request(ctx, input)
+	assert.NoError(t, err)
+	assert.Equal(t, "12.90", first.Invoice.Amount.String())
+	assert.Len(t, first.Orders, 2)
+
+	_, err = invoiceService.Request(ctx, service.InvoiceRequestInput{
+		UserID: user.ID, OrderNos: []string{"TOP-1"}, TitleType: model.InvoiceTitlePersonal, Title: "张三", Email: "a@example.com",
+	})
+	assert.Equal(t, service.ErrOrderNotInvoiceable, err)
+	_, err = invoiceService.Request(ctx, service.InvoiceRequestInput{
+		UserID: user.ID, OrderNos: []string{"CV-PENDING"}, TitleType: model.InvoiceTitlePersonal, Title: "张三", Email: "a@example.com",
+	})
+	assert.Equal(t, service.ErrOrderNotInvoiceable, err)
+	input.TaxNo = ""
+	_, err = invoiceService.Request(ctx, input)
+	assert.Equal(t, service.ErrInvalidInvoice, err)
+
+	// A stale order save must not free the order.
+	top.Remark = "stale"
+	assert.NoError(t, orderRepository.Update(ctx, top))
+	var got model.Order
+	assert.NoError(t, db.First(&got, top.ID).Error)
+	assert.Equal(t, first.Invoice.ID, got.InvoiceID)
+
+	// Rejecting frees both orders for a new request, which can then be issued once.
+	rejected, err := invoiceService.Reject(ctx, 1, first.Invoice.ID, "抬头有误")
+	assert.NoError(t, err)
+	assert.Equal(t, model.InvoiceStatusRejected, rejected.Invoice.Status)
+	_, err = invoiceService.Reject(ctx, 1, first.Invoice.ID, "抬头有误")
+	assert.Equal(t, service.ErrInvoiceNotPending, err)
+
+	input.TaxNo = "91110108MA01ABCD2X"
+	second, err := invoiceService.Request(ctx, input)
+	assert.NoError(t, err)
+	issued, err := invoiceService.Issue(ctx, 1, second.Invoice.ID, "https://cdn.example.com/invoice.pdf")
+	assert.NoError(t, err)
+	assert.Equal(t, model.InvoiceStatusIssued, issued.Invoice.Status)
+	assert.Equal(t, "https://cdn.example.com/invoice.pdf", issued.Invoice.PdfURL)
+
+	details, _, err := newOrderService(db).ListByUser(ctx, user.ID, model.OrderStatusPaid, 1, 10)
+	assert.NoError(t, err)
+	for _, detail := range details {
+		if assert.NotNil(t, detail.Invoice) {
+			assert.Equal(t, second.Invoice.ID, detail.Invoice.ID)
+			assert.Equal(t, model.InvoiceStatusIssued, detail.Invoice.Status)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package job_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestJobArea_ValidateAndFilter(t *testing.T) {
+	db := newDB(t)
+	logger := &log.Logger{Logger: zap.NewNop()}
+	conf := viper.New()
+	repo := repository.NewRepository(logger, db)
+	srv := service.NewService(repository.NewTransaction(repo), logger, nil, jwt.NewJwt(conf))
+	jobService := newJobService(srv, repo, conf)
+	areaService := service.NewAreaService(srv, conf)
+	ctx := context.Background()
+	now := time.Now()
+
+	_, err := areaService.Children(ctx, 12345)
+	assert.Equal(t, service.ErrInvalidArea, err)
+
+	user := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+
+	// Descriptions are filled in from the IDs, and a street below a listed district only
+	// has to extend its code.
+	chaoyang, err := jobService.Create(ctx, user.ID, service.JobCreateInput{
+		Positions:    "厨师",
+		FirstAreaID:  11,
+		SecondAreaID: 1101,
+		ThirdAreaID:  110105,
+		FourAreaID:   110105001,
+		FourAreaDes:  "三里屯街道",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"北京市", "北京市", "朝阳区", "三里屯街道"},
+		[]string{chaoyang.FirstAreaDes, chaoyang.SecondAreaDes, chaoyang.ThirdAreaDes, chaoyang.FourAreaDes})
+	// An ID is looked up from a listed name.
+	huangpu, err := jobService.Create(ctx, user.ID, service.JobCreateInput{
+		Positions:     "服务员",
+		FirstAreaID:   31,
+		SecondAreaID:  3101,
+		ThirdAreaDes:  "黄浦区",
+		SecondAreaDes: "上海市",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 310101, huangpu.ThirdAreaID)
+
+	for _, input := range []service.JobCreateInput{
+		{Positions: "收银", FirstAreaID: 11, FirstAreaDes: "上海市"},
+		{Positions: "收银", FirstAreaID: 11, SecondAreaID: 3101},
+		{Positions: "收银", FirstAreaID: 11, SecondAreaID: 1101, ThirdAreaID: 110199},
+		{Positions: "收银", FirstAreaID: 11, ThirdAreaID: 110105},
+		{Positions: "收银", FirstAreaID: 31, SecondAreaID: 3101, ThirdAreaDes: "上海市辖区"},
+		{Positions: "收银", FirstAreaID: 44, SecondAreaID: 4401, ThirdAreaID: 440106, ThirdAreaDes: "越秀区"},
+		{Positions: "收银", FirstAreaID: 13, SecondAreaDes: "石家庄市", ThirdAreaDes: "朝阳区"},
+		{Positions: "收银", FirstAreaID: 11, SecondAreaID: 1101, ThirdAreaID: 110105, FourAreaID: 110105001},
+		{Positions: "收银", FirstAreaID: 11, SecondAreaID: 1101, ThirdAreaID: 110105, FourAreaDes: "三里屯街道"},
+	} {
+		_, err := jobService.Create(ctx, user.ID, input)
+		assert.Equal(t, service.ErrInvalidArea, err, "%+v", input)
+	}
+	district := 110108
+	assert.Equal(t, service.ErrInvalidArea, jobService.Update(ctx, user.ID, service.JobUpdateInput{ID: huangpu.ID, ThirdAreaID: &district}))
+
+	list := func(areaID int) []int64 {
+		jobs, _, err := jobService.List(ctx, repository.JobListQuery{AreaID: areaID})
+		assert.NoError(t, err)
+		ids := make([]int64, 0, len(jobs))
+		for _, job := range jobs {
+			ids = append(ids, job.ID)
+		}
+		return ids
+	}
+	assert.Equal(t, []int64{chaoyang.ID}, list(11))
+	assert.Equal(t, []int64{huangpu.ID}, list(3101))
+	assert.Equal(t, []int64{chaoyang.ID}, list(110105))
+	assert.Equal(t, []int64{chaoyang.ID}, list(110105001))
+	assert.Empty(t, list(110108))
+	assert.ElementsMatch(t, []int64{chaoyang.ID, huangpu.ID}, list(0))
+}
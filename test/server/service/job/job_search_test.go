@@ -0,0 +1,119 @@
+package job_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestJobSearch_KeywordRankingAndSync(t *testing.T) {
+	db := newDB(t)
+	logger := &log.Logger{Logger: zap.NewNop()}
+	conf := viper.New()
+	repo := repository.NewRepository(logger, db)
+	srv := service.NewService(repository.NewTransaction(repo), logger, nil, jwt.NewJwt(conf))
+	jobService := newJobService(srv, repo, conf)
+	ctx := context.Background()
+	now := time.Now()
+	old := now.Add(-72 * time.Hour)
+
+	user := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+	// Jobs stored before the searcher starts are picked up when it first loads.
+	hotpot := &model.Job{
+		UserID: user.ID, Positions: "火锅店厨师", CompanyName: "老码头火锅",
+		Description: "负责后厨炒料", Status: model.JobStatusActive, CreateAt: old, UpdateAt: old,
+	}
+	waiter := &model.Job{
+		UserID: user.ID, Positions: "服务员", CompanyName: "老码头火锅",
+		Description: "火锅店前厅服务", Status: model.JobStatusActive, CreateAt: old, UpdateAt: old,
+	}
+	assert.NoError(t, db.Create(hotpot).Error)
+	assert.NoError(t, db.Create(waiter).Error)
+
+	search := func(keyword string) []string {
+		jobs, total, err := jobService.List(ctx, repository.JobListQuery{Keyword: keyword})
+		assert.NoError(t, err)
+		assert.EqualValues(t, len(jobs), total)
+		positions := make([]string, 0, len(jobs))
+		for _, job := range jobs {
+			positions = append(positions, job.Positions)
+		}
+		return positions
+	}
+	// Every term must match; a match in positions outranks one in the description.
+	assert.Equal(t, []string{"火锅店厨师"}, search("厨师 火锅"))
+	assert.Equal(t, []string{"火锅店厨师", "服务员"}, search("火锅店"))
+	assert.ElementsMatch(t, []string{"火锅店厨师", "服务员"}, search("老码头"))
+	assert.Empty(t, search("川菜"))
+
+	created, err := jobService.Create(ctx, user.ID, service.JobCreateInput{
+		Positions: "川菜厨师", CompanyName: "巴蜀小馆", Description: "会做火锅底料优先",
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"川菜厨师"}, search("川菜"))
+
+	// A running top lifts a weaker text match above a stronger one. The boost follows
+	// is_top, so a placement the task has already cleared no longer counts.
+	topStart, topEnd := now.Add(-time.Hour), now.Add(time.Hour)
+	assert.NoError(t, db.Model(&model.Job{}).Where("id = ?", waiter.ID).
+		Updates(map[string]interface{}{"top_start_time": topStart, "top_end_time": topEnd}).Error)
+	assert.Equal(t, []string{"火锅店厨师", "服务员"}, search("火锅店"))
+	assert.NoError(t, db.Model(&model.Job{}).Where("id = ?", waiter.ID).Update("is_top", true).Error)
+	assert.Equal(t, []string{"服务员", "火锅店厨师"}, search("火锅店"))
+
+	positions := "湘菜厨师"
+	assert.NoError(t, jobService.Update(ctx, user.ID, service.JobUpdateInput{ID: created.ID, Positions: &positions}))
+	assert.Empty(t, search("川菜"))
+	assert.Equal(t, []string{"湘菜厨师"}, search("湘菜"))
+
+	assert.NoError(t, jobService.Close(ctx, user.ID, hotpot.ID))
+	assert.Equal(t, []string{"湘菜厨师"}, search("厨师"))
+}
+
+func TestJobSearch_SyncsWritesFromOtherInstances(t *testing.T) {
+	db := newDB(t)
+	logger := &log.Logger{Logger: zap.NewNop()}
+	conf := viper.New()
+	conf.Set("job.search_sync_interval", "300ms")
+	repo := repository.NewRepository(logger, db)
+	srv := service.NewService(repository.NewTransaction(repo), logger, nil, jwt.NewJwt(conf))
+	jobService := newJobService(srv, repo, conf)
+	other := newJobService(srv, repo, conf)
+	ctx := context.Background()
+	now := time.Now()
+
+	user := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+	search := func(keyword string) []int64 {
+		jobs, _, err := jobService.List(ctx, repository.JobListQuery{Keyword: keyword})
+		assert.NoError(t, err)
+		ids := make([]int64, 0, len(jobs))
+		for _, job := range jobs {
+			ids = append(ids, job.ID)
+		}
+		return ids
+	}
+	assert.Empty(t, search("面点"))
+
+	// Jobs created and closed through another instance reach this one's index once it is
+	// older than the sync interval.
+	created, err := other.Create(ctx, user.ID, service.JobCreateInput{Positions: "面点师"})
+	assert.NoError(t, err)
+	assert.Empty(t, search("面点"))
+	time.Sleep(350 * time.Millisecond)
+	assert.Equal(t, []int64{created.ID}, search("面点"))
+
+	assert.NoError(t, other.Close(ctx, user.ID, created.ID))
+	time.Sleep(350 * time.Millisecond)
+	assert.Empty(t, search("面点"))
+}
@@ -0,0 +1,90 @@
+package job_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestJobTags_ExactMatchFilters(t *testing.T) {
+	db := newDB(t)
+	logger := &log.Logger{Logger: zap.NewNop()}
+	conf := viper.New()
+	repo := repository.NewRepository(logger, db)
+	srv := service.NewService(repository.NewTransaction(repo), logger, nil, jwt.NewJwt(conf))
+	jobService := newJobService(srv, repo, conf)
+	tagService := service.NewTagService(srv, repository.NewTagRepository(repo), repository.NewJobTagRepository(repo))
+	ctx := context.Background()
+	now := time.Now()
+
+	for i, tag := range []model.Tag{
+		{Category: model.TagCategoryBasicProtection, Code: "five_insurances_fund", Label: "五险一金"},
+		{Category: model.TagCategoryBasicProtection, Code: "five_insurances", Label: "五险"},
+		{Category: model.TagCategoryAttendanceLeave, Code: "two_days_off", Label: "双休"},
+	} {
+		tag.Sort, tag.Status, tag.CreateAt, tag.UpdateAt = i, model.TagStatusOnline, now, now
+		assert.NoError(t, db.Create(&tag).Error)
+	}
+	user := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+
+	full, err := jobService.Create(ctx, user.ID, service.JobCreateInput{
+		Positions:       "厨师",
+		BasicProtection: []string{"five_insurances_fund"},
+		AttendanceLeave: []string{"two_days_off"},
+	})
+	assert.NoError(t, err)
+	// Older clients send labels.
+	basic, err := jobService.Create(ctx, user.ID, service.JobCreateInput{
+		Positions:       "服务员",
+		BasicProtection: []string{"五险"},
+	})
+	assert.NoError(t, err)
+	_, err = jobService.Create(ctx, user.ID, service.JobCreateInput{Positions: "收银", BasicProtection: []string{"包吃"}})
+	assert.Equal(t, service.ErrInvalidTag, err)
+
+	list := func(query repository.JobListQuery) []int64 {
+		jobs, _, err := jobService.List(ctx, query)
+		assert.NoError(t, err)
+		ids := make([]int64, 0, len(jobs))
+		for _, job := range jobs {
+			ids = append(ids, job.ID)
+		}
+		return ids
+	}
+	// 五险 no longer matches 五险一金.
+	assert.Equal(t, []int64{basic.ID}, list(repository.JobListQuery{BasicProtection: []string{"five_insurances"}}))
+	assert.Equal(t, []int64{full.ID}, list(repository.JobListQuery{
+		BasicProtection: []string{"five_insurances_fund"},
+		AttendanceLeave: []string{"two_days_off"},
+	}))
+	assert.Empty(t, list(repository.JobListQuery{
+		BasicProtection: []string{"five_insurances"},
+		AttendanceLeave: []string{"two_days_off"},
+	}))
+	assert.ElementsMatch(t, []int64{full.ID, basic.ID}, list(repository.JobListQuery{
+		BasicProtection: []string{"five_insurances"},
+		AttendanceLeave: []string{"two_days_off"},
+		TagMatchAny:     true,
+	}))
+
+	// An update only replaces the categories it sends.
+	assert.NoError(t, jobService.Update(ctx, user.ID, service.JobUpdateInput{ID: full.ID, BasicProtection: []string{}}))
+	tags, err := tagService.JobTags(ctx, []int64{full.ID, basic.ID})
+	assert.NoError(t, err)
+	if assert.Len(t, tags[full.ID], 1) {
+		assert.Equal(t, "two_days_off", tags[full.ID][0].Code)
+	}
+	if assert.Len(t, tags[basic.ID], 1) {
+		assert.Equal(t, "五险", tags[basic.ID][0].Label)
+	}
+}
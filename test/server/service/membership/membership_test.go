@@ -0,0 +1,79 @@
+package membership_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestMembership_PayExtendsAndGrantsAllowance(t *testing.T) {
+	db := newDB(t)
+	orderService := newOrderService(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	user := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+
+	pay := func(orderNo string) {
+		order := &model.Order{
+			OrderNo:     orderNo,
+			UserID:      user.ID,
+			AmountTotal: model.NewDecimalFromCents(2900),
+			Currency:    "CNY",
+			Status:      model.OrderStatusPending,
+			CreateAt:    now,
+			UpdateAt:    now,
+		}
+		assert.NoError(t, db.Create(order).Error)
+		assert.NoError(t, db.Create(&model.OrderItem{
+			OrderID:        order.ID,
+			ProductType:    model.ProductTypeMembership,
+			TitleSnapshot:  "月度会员",
+			MembershipDays: 30,
+			CreateAt:       now,
+			UpdateAt:       now,
+		}).Error)
+		_, err := orderService.PayOrderByNotify(ctx, orderNo, 2900, "fake", "T"+orderNo)
+		assert.NoError(t, err)
+	}
+	pay("MEM-1")
+	pay("MEM-2")
+
+	var membership model.Membership
+	assert.NoError(t, db.Where("user_id = ?", user.ID).First(&membership).Error)
+	assert.InDelta(t, 60*24, membership.EndAt.Sub(membership.StartAt).Hours(), 0.01)
+
+	// The repurchase extends the period without paying the month's allowance again.
+	var got model.User
+	assert.NoError(t, db.First(&got, user.ID).Error)
+	assert.Equal(t, 10, got.ContactVoucherNum)
+
+	logger := &log.Logger{Logger: zap.NewNop()}
+	repo := repository.NewRepository(logger, db)
+	srv := service.NewService(repository.NewTransaction(repo), logger, nil, jwt.NewJwt(viper.New()))
+	membershipService := newMembershipService(srv, repo, viper.New())
+	granted, err := membershipService.GrantAllowances(ctx, now.Add(24*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, granted)
+	granted, err = membershipService.GrantAllowances(ctx, now.Add(31*24*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 10, granted)
+	granted, err = membershipService.GrantAllowances(ctx, now.Add(31*24*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, granted)
+
+	benefits, err := membershipService.GetBenefits(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.True(t, benefits.Active)
+	assert.Equal(t, int64(20), benefits.JobLimit)
+}
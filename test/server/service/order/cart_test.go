@@ -0,0 +1,52 @@
+package order_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayOrderByNotify_DeliversEveryItem(t *testing.T) {
+	db := newDB(t)
+	orderService := newOrderService(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	user := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+	order := &model.Order{
+		OrderNo:     "ORD-" + now.Format("150405.000000"),
+		UserID:      user.ID,
+		AmountTotal: model.NewDecimalFromCents(1388),
+		Currency:    "CNY",
+		Status:      model.OrderStatusPending,
+		CreateAt:    now,
+		UpdateAt:    now,
+	}
+	assert.NoError(t, db.Create(order).Error)
+	for _, num := range []int{5, 3} {
+		assert.NoError(t, db.Create(&model.OrderItem{
+			OrderID:           order.ID,
+			ProductType:       model.ProductTypeContactVoucher,
+			TitleSnapshot:     "联系券",
+			UnitPriceSnapshot: model.NewDecimalFromCents(694),
+			ContactVoucherNum: num,
+			CreateAt:          now,
+			UpdateAt:          now,
+		}).Error)
+	}
+
+	paid, err := orderService.PayOrderByNotify(ctx, order.OrderNo, 1388, "fake", "T"+order.OrderNo)
+	assert.NoError(t, err)
+	assert.Equal(t, model.OrderStatusPaid, paid.Status)
+
+	var histories int64
+	assert.NoError(t, db.Model(&model.ContactVoucherHistory{}).Where("user_id = ?", user.ID).Count(&histories).Error)
+	assert.Equal(t, int64(2), histories)
+	var got model.User
+	assert.NoError(t, db.First(&got, user.ID).Error)
+	assert.Equal(t, 8, got.ContactVoucherNum)
+}
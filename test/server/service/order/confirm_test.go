@@ -0,0 +1,96 @@
+package order_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+// paidProvider reports every order as paid in full.
+type paidProvider struct {
+	fakeProvider
+	cents int64
+}
+
+func (p paidProvider) QueryOrder(ctx context.Context, orderNo string) (*service.PayResult, error) {
+	return &service.PayResult{Channel: "fake", OrderNo: orderNo, TradeNo: "T" + orderNo, Amount: p.cents, Success: true}, nil
+}
+
+func TestConfirmOrder_TrustsOnlyTheProvider(t *testing.T) {
+	db := newDB(t)
+	ctx := context.Background()
+	products := newCatalog(t, db)
+	user, _ := newUserWithJob(t, db)
+
+	order, _, err := newOrderService(db).CreateContactVoucherOrder(ctx, user.ID, products.voucher.ID, 0, "")
+	assert.NoError(t, err)
+
+	// Not paid at the provider yet: the order stays pending.
+	unpaid, err := newOrderService(db).ConfirmOrder(ctx, user.ID, order.OrderNo)
+	assert.NoError(t, err)
+	assert.Equal(t, model.OrderStatusPending, unpaid.Status)
+
+	orderService := newOrderServiceWithProvider(db, paidProvider{cents: 1990})
+	other, _ := newUserWithJob(t, db)
+	_, err = orderService.ConfirmOrder(ctx, other.ID, order.OrderNo)
+	assert.Equal(t, service.ErrForbidden, err)
+
+	for i := 0; i < 2; i++ {
+		paid, err := orderService.ConfirmOrder(ctx, user.ID, order.OrderNo)
+		assert.NoError(t, err)
+		assert.Equal(t, model.OrderStatusPaid, paid.Status)
+		assert.Equal(t, "19.90", paid.AmountPaid.String())
+	}
+	var got model.User
+	assert.NoError(t, db.First(&got, user.ID).Error)
+	assert.Equal(t, 5, got.ContactVoucherNum)
+}
+
+func TestCancelOrder_AndExpiry(t *testing.T) {
+	db := newDB(t)
+	orderService := newOrderService(db)
+	ctx := context.Background()
+	products := newCatalog(t, db)
+	user, _ := newUserWithJob(t, db)
+
+	order, _, err := orderService.CreateContactVoucherOrder(ctx, user.ID, products.voucher.ID, 0, "")
+	assert.NoError(t, err)
+	other, _ := newUserWithJob(t, db)
+	_, err = orderService.CancelOrder(ctx, other.ID, order.OrderNo)
+	assert.Equal(t, service.ErrForbidden, err)
+	canceled, err := orderService.CancelOrder(ctx, user.ID, order.OrderNo)
+	assert.NoError(t, err)
+	assert.Equal(t, model.OrderStatusCanceled, canceled.Status)
+	_, err = orderService.CancelOrder(ctx, user.ID, order.OrderNo)
+	assert.Equal(t, service.ErrOrderNotPending, err)
+
+	// Only orders left unpaid past the cutoff expire.
+	stale, _, err := orderService.CreateContactVoucherOrder(ctx, user.ID, products.voucher.ID, 0, "")
+	assert.NoError(t, err)
+	assert.NoError(t, db.Model(&model.Order{}).Where("id = ?", stale.ID).Update("create_at", time.Now().Add(-time.Hour)).Error)
+	recent, _, err := orderService.CreateContactVoucherOrder(ctx, user.ID, products.voucher.ID, 0, "")
+	assert.NoError(t, err)
+
+	expired, err := orderService.ExpirePendingOrders(ctx, time.Now().Add(-service.PendingOrderTTL(viper.New())))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	for id, status := range map[int64]model.OrderStatus{
+		order.ID:  model.OrderStatusCanceled,
+		stale.ID:  model.OrderStatusCanceled,
+		recent.ID: model.OrderStatusPending,
+	} {
+		var got model.Order
+		assert.NoError(t, db.First(&got, id).Error)
+		assert.Equal(t, status, got.Status, got.OrderNo)
+	}
+
+	// The user pays the expired order anyway: the confirm revives it.
+	revived, err := newOrderServiceWithProvider(db, paidProvider{cents: 1990}).ConfirmOrder(ctx, user.ID, stale.OrderNo)
+	assert.NoError(t, err)
+	assert.Equal(t, model.OrderStatusPaid, revived.Status)
+}
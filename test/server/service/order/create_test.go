@@ -0,0 +1,122 @@
+package order_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// catalog is one SKU of each kind, plus an offline one.
+type catalog struct {
+	top, voucher, membership, offline *model.Product
+}
+
+func newCatalog(t *testing.T, db *gorm.DB) catalog {
+	now := time.Now()
+	newProduct := func(product *model.Product) *model.Product {
+		product.CreateAt = now
+		product.UpdateAt = now
+		if product.Status == 0 {
+			product.Status = model.ProductStatusOnline
+		}
+		assert.NoError(t, db.Create(product).Error)
+		return product
+	}
+	return catalog{
+		top:        newProduct(&model.Product{ProductType: model.ProductTypeTop, Title: "置顶2小时", Price: model.NewDecimalFromCents(990), TopHour: 2}),
+		voucher:    newProduct(&model.Product{ProductType: model.ProductTypeContactVoucher, Title: "联系券-5张", Price: model.NewDecimalFromCents(1990), ContactVoucherNum: 5}),
+		membership: newProduct(&model.Product{ProductType: model.ProductTypeMembership, Title: "月度会员", Price: model.NewDecimalFromCents(2990), MembershipDays: 30}),
+		offline:    newProduct(&model.Product{ProductType: model.ProductTypeContactVoucher, Title: "联系券-50张", Price: model.NewDecimalFromCents(9900), ContactVoucherNum: 50, Status: model.ProductStatusOffline}),
+	}
+}
+
+func newUserWithJob(t *testing.T, db *gorm.DB) (*model.User, *model.Job) {
+	now := time.Now()
+	user := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+	job := &model.Job{UserID: user.ID, Positions: "厨师", Status: model.JobStatusActive, CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(job).Error)
+	return user, job
+}
+
+func TestCreateOrder_IdempotencyKey(t *testing.T) {
+	db := newDB(t)
+	orderService := newOrderService(db)
+	ctx := context.Background()
+	products := newCatalog(t, db)
+	user, job := newUserWithJob(t, db)
+
+	first, _, err := orderService.CreateTopOrder(ctx, user.ID, job.ID, products.top.ID, 0, "key-1")
+	assert.NoError(t, err)
+	again, item, err := orderService.CreateTopOrder(ctx, user.ID, job.ID, products.top.ID, 0, "key-1")
+	assert.NoError(t, err)
+	assert.Equal(t, first.ID, again.ID)
+	assert.Equal(t, first.ID, item.OrderID)
+
+	// The same key with another payload or on another endpoint is a client bug.
+	_, _, err = orderService.CreateRefreshOrder(ctx, user.ID, job.ID, products.top.ID, 0, "key-1")
+	assert.Equal(t, service.ErrIdempotencyKeyReused, err)
+	_, _, err = orderService.CreateContactVoucherOrder(ctx, user.ID, products.voucher.ID, 0, "key-1")
+	assert.Equal(t, service.ErrIdempotencyKeyReused, err)
+
+	// Keys are per user, and a new key places a new order.
+	other, otherJob := newUserWithJob(t, db)
+	theirs, _, err := orderService.CreateTopOrder(ctx, other.ID, otherJob.ID, products.top.ID, 0, "key-1")
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.ID, theirs.ID)
+	next, _, err := orderService.CreateTopOrder(ctx, user.ID, job.ID, products.top.ID, 0, "key-2")
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.ID, next.ID)
+
+	// An expired key is forgotten.
+	assert.NoError(t, db.Model(&model.IdempotencyKey{}).Where("user_id = ? AND idem_key = ?", user.ID, "key-1").
+		Update("expire_at", time.Now().Add(-time.Minute)).Error)
+	purged, err := orderService.PurgeIdempotencyKeys(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), purged)
+	renewed, _, err := orderService.CreateContactVoucherOrder(ctx, user.ID, products.voucher.ID, 0, "key-1")
+	assert.NoError(t, err)
+	assert.NotEqual(t, first.ID, renewed.ID)
+}
+
+func TestCreateOrder_ConcurrentDuplicateKey(t *testing.T) {
+	db := newDB(t)
+	orderService := newOrderService(db)
+	products := newCatalog(t, db)
+	user, _ := newUserWithJob(t, db)
+
+	const workers = 8
+	var wg sync.WaitGroup
+	ids := make(chan int64, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			order, _, err := orderService.CreateContactVoucherOrder(context.Background(), user.ID, products.voucher.ID, 0, "double-tap")
+			if assert.NoError(t, err) {
+				ids <- order.ID
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
+	seen := map[int64]bool{}
+	for id := range ids {
+		seen[id] = true
+	}
+	assert.Len(t, seen, 1)
+
+	var orders, items, keys int64
+	assert.NoError(t, db.Model(&model.Order{}).Where("user_id = ?", user.ID).Count(&orders).Error)
+	assert.NoError(t, db.Model(&model.OrderItem{}).Count(&items).Error)
+	assert.NoError(t, db.Model(&model.IdempotencyKey{}).Where("user_id = ?", user.ID).Count(&keys).Error)
+	assert.Equal(t, int64(1), orders)
+	assert.Equal(t, int64(1), items)
+	assert.Equal(t, int64(1), keys)
+}
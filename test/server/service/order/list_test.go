@@ -0,0 +1,105 @@
+package order_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListByUser_FiltersAndPages(t *testing.T) {
+	db := newDB(t)
+	orderService := newOrderService(db)
+	ctx := context.Background()
+	user, job := newUserWithJob(t, db)
+	other, _ := newUserWithJob(t, db)
+
+	// Five paid and two pending orders, a minute apart, and one order of someone else's.
+	start := time.Now().Add(-time.Hour)
+	newOrder := func(userID int64, i int, status model.OrderStatus) *model.Order {
+		at := start.Add(time.Duration(i) * time.Minute)
+		order := &model.Order{
+			OrderNo:     fmt.Sprintf("TOP-%d-%d", userID, i),
+			UserID:      userID,
+			AmountTotal: model.NewDecimalFromCents(990),
+			Currency:    "CNY",
+			Status:      status,
+			CreateAt:    at,
+			UpdateAt:    at,
+		}
+		assert.NoError(t, db.Create(order).Error)
+		assert.NoError(t, db.Create(&model.OrderItem{
+			OrderID: order.ID, ProductType: model.ProductTypeTop, TargetType: model.OrderTargetJob, TargetID: job.ID, TopHour: 2, CreateAt: at, UpdateAt: at,
+		}).Error)
+		return order
+	}
+	var paid []*model.Order
+	for i := 0; i < 7; i++ {
+		status := model.OrderStatusPaid
+		if i%3 == 1 {
+			status = model.OrderStatusPending
+		}
+		order := newOrder(user.ID, i, status)
+		if status == model.OrderStatusPaid {
+			paid = append(paid, order)
+		}
+	}
+	newOrder(other.ID, 7, model.OrderStatusPaid)
+
+	// Newest first, two to a page.
+	details, total, err := orderService.ListByUser(ctx, user.ID, model.OrderStatusPaid, 1, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), total)
+	if assert.Len(t, details, 2) {
+		assert.Equal(t, paid[4].OrderNo, details[0].Order.OrderNo)
+		assert.Equal(t, paid[3].OrderNo, details[1].Order.OrderNo)
+		assert.Len(t, details[0].Items, 1)
+		assert.Equal(t, job.ID, details[0].Jobs[job.ID].ID)
+	}
+	details, _, err = orderService.ListByUser(ctx, user.ID, model.OrderStatusPaid, 3, 2)
+	assert.NoError(t, err)
+	if assert.Len(t, details, 1) {
+		assert.Equal(t, paid[0].OrderNo, details[0].Order.OrderNo)
+	}
+	details, _, err = orderService.ListByUser(ctx, user.ID, model.OrderStatusPaid, 4, 2)
+	assert.NoError(t, err)
+	assert.Empty(t, details)
+
+	details, total, err = orderService.ListByUser(ctx, user.ID, model.OrderStatusPending, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), total)
+	for _, detail := range details {
+		assert.Equal(t, model.OrderStatusPending, detail.Order.Status)
+	}
+
+	// Status 0 lists them all, still only the user's own.
+	details, total, err = orderService.ListByUser(ctx, user.ID, 0, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(7), total)
+	assert.Len(t, details, 7)
+	for _, detail := range details {
+		assert.Equal(t, user.ID, detail.Order.UserID)
+	}
+}
+
+func TestGetMyOrder_OtherUsersOrder(t *testing.T) {
+	db := newDB(t)
+	orderService := newOrderService(db)
+	ctx := context.Background()
+	products := newCatalog(t, db)
+	user, job := newUserWithJob(t, db)
+	other, _ := newUserWithJob(t, db)
+
+	order, _, err := orderService.CreateTopOrder(ctx, user.ID, job.ID, products.top.ID, 0, "")
+	assert.NoError(t, err)
+
+	detail, err := orderService.GetMyOrder(ctx, user.ID, order.OrderNo)
+	assert.NoError(t, err)
+	assert.Equal(t, order.ID, detail.Order.ID)
+	_, err = orderService.GetMyOrder(ctx, other.ID, order.OrderNo)
+	assert.Equal(t, service.ErrForbidden, err)
+}
@@ -0,0 +1,148 @@
+package order_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPayOrderByNotify_Concurrent(t *testing.T) {
+	db := newDB(t)
+	orderService := newOrderService(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	user := &model.User{ContactVoucherNum: 2, CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+
+	for _, status := range []model.OrderStatus{model.OrderStatusPending, model.OrderStatusCanceled} {
+		order := &model.Order{
+			OrderNo:     "CV-" + time.Now().Format("150405.000000"),
+			UserID:      user.ID,
+			AmountTotal: model.NewDecimalFromCents(990),
+			Currency:    "CNY",
+			Status:      status,
+			CreateAt:    now,
+			UpdateAt:    now,
+		}
+		assert.NoError(t, db.Create(order).Error)
+		assert.NoError(t, db.Create(&model.OrderItem{
+			OrderID:           order.ID,
+			ProductType:       model.ProductTypeContactVoucher,
+			TitleSnapshot:     "联系券-5张",
+			ContactVoucherNum: 5,
+			CreateAt:          now,
+			UpdateAt:          now,
+		}).Error)
+
+		const workers = 8
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				paid, err := orderService.PayOrderByNotify(ctx, order.OrderNo, 990, "fake", "T"+order.OrderNo)
+				if err == nil && paid.Status != model.OrderStatusPaid {
+					err = service.ErrOrderStatusChanged
+				}
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			assert.NoError(t, err)
+		}
+
+		var histories int64
+		assert.NoError(t, db.Model(&model.ContactVoucherHistory{}).Where("user_id = ?", user.ID).Count(&histories).Error)
+		var got model.User
+		assert.NoError(t, db.First(&got, user.ID).Error)
+		if status == model.OrderStatusPending {
+			assert.Equal(t, int64(1), histories)
+			assert.Equal(t, 7, got.ContactVoucherNum)
+		} else {
+			assert.Equal(t, int64(2), histories)
+			assert.Equal(t, 12, got.ContactVoucherNum)
+		}
+	}
+}
+
+func TestPayOrderByNotify_ZeroAmountIsAMismatch(t *testing.T) {
+	db := newDB(t)
+	orderService := newOrderService(db)
+	now := time.Now()
+	user := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+	order := &model.Order{
+		OrderNo:     "CV-ZERO",
+		UserID:      user.ID,
+		AmountTotal: model.NewDecimalFromCents(990),
+		Currency:    "CNY",
+		Status:      model.OrderStatusPending,
+		CreateAt:    now,
+		UpdateAt:    now,
+	}
+	assert.NoError(t, db.Create(order).Error)
+
+	_, err := orderService.PayOrderByNotify(context.Background(), order.OrderNo, 0, "fake", "T"+order.OrderNo)
+	assert.Equal(t, service.ErrAmountMismatch, err)
+	var got model.Order
+	assert.NoError(t, db.First(&got, order.ID).Error)
+	assert.Equal(t, model.OrderStatusPending, got.Status)
+}
+
+func TestPayOrderByNotify_AmountMismatchIsRefunded(t *testing.T) {
+	db := newDB(t)
+	orderService := newOrderService(db)
+	ctx := context.Background()
+	now := time.Now()
+	user := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+	order := &model.Order{
+		OrderNo:     "CV-SHORT",
+		UserID:      user.ID,
+		AmountTotal: model.NewDecimalFromCents(990),
+		Currency:    "CNY",
+		Status:      model.OrderStatusPending,
+		CreateAt:    now,
+		UpdateAt:    now,
+	}
+	assert.NoError(t, db.Create(order).Error)
+	assert.NoError(t, db.Create(&model.OrderItem{
+		OrderID:           order.ID,
+		ProductType:       model.ProductTypeContactVoucher,
+		TitleSnapshot:     "联系券-5张",
+		ContactVoucherNum: 5,
+		CreateAt:          now,
+		UpdateAt:          now,
+	}).Error)
+
+	// Retried notifies neither deliver nor refund twice.
+	for i := 0; i < 2; i++ {
+		_, err := orderService.PayOrderByNotify(ctx, order.OrderNo, 1, "fake", "T"+order.OrderNo)
+		assert.Equal(t, service.ErrAmountMismatch, err)
+	}
+
+	var got model.Order
+	assert.NoError(t, db.First(&got, order.ID).Error)
+	assert.Equal(t, model.OrderStatusCanceled, got.Status)
+	assert.Equal(t, "0.01", got.AmountPaid.String())
+	assert.Equal(t, "T"+order.OrderNo, got.PayTradeNo)
+	assert.NotNil(t, got.PaidAt)
+	assert.NotEmpty(t, got.Remark)
+	var refunds []model.Refund
+	assert.NoError(t, db.Where("order_id = ?", order.ID).Find(&refunds).Error)
+	if assert.Len(t, refunds, 1) {
+		assert.Equal(t, "0.01", refunds[0].Amount.String())
+	}
+	var owner model.User
+	assert.NoError(t, db.First(&owner, user.ID).Error)
+	assert.Equal(t, 0, owner.ContactVoucherNum)
+}
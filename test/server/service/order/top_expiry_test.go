@@ -0,0 +1,99 @@
+package order_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestExpireTops_ClearsFlagAndNotifies(t *testing.T) {
+	db := newDB(t)
+	logger := &log.Logger{Logger: zap.NewNop()}
+	conf := viper.New()
+	repo := repository.NewRepository(logger, db)
+	srv := service.NewService(repository.NewTransaction(repo), logger, nil, jwt.NewJwt(conf))
+	jobService := newJobService(srv, repo, conf)
+	orderService := newOrderService(db)
+	ctx := context.Background()
+	now := time.Now()
+
+	user := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+	newJob := func(positions string, refreshed time.Duration) *model.Job {
+		refreshTime := now.Add(-refreshed)
+		job := &model.Job{UserID: user.ID, Positions: positions, Status: model.JobStatusActive, RefreshTime: &refreshTime, CreateAt: now, UpdateAt: now}
+		assert.NoError(t, db.Create(job).Error)
+		return job
+	}
+	bought := newJob("厨师", 3*time.Hour)
+	fresh := newJob("服务员", time.Minute)
+	// Flagged by an earlier purchase whose placement has just ended.
+	ended := newJob("收银", 2*time.Hour)
+	start, end := now.Add(-25*time.Hour), now.Add(-time.Minute)
+	assert.NoError(t, db.Model(ended).Updates(map[string]interface{}{"top_start_time": start, "top_end_time": end, "is_top": true}).Error)
+
+	order := &model.Order{
+		OrderNo:     "ORD-" + now.Format("150405.000000"),
+		UserID:      user.ID,
+		AmountTotal: model.NewDecimalFromCents(990),
+		Currency:    "CNY",
+		Status:      model.OrderStatusPending,
+		CreateAt:    now,
+		UpdateAt:    now,
+	}
+	assert.NoError(t, db.Create(order).Error)
+	assert.NoError(t, db.Create(&model.OrderItem{
+		OrderID:           order.ID,
+		ProductType:       model.ProductTypeTop,
+		TargetID:          bought.ID,
+		TitleSnapshot:     "置顶2小时",
+		UnitPriceSnapshot: model.NewDecimalFromCents(990),
+		TopHour:           2,
+		CreateAt:          now,
+		UpdateAt:          now,
+	}).Error)
+	_, err := orderService.PayOrderByNotify(ctx, order.OrderNo, 990, "fake", "T"+order.OrderNo)
+	assert.NoError(t, err)
+
+	recommended := func() []int64 {
+		jobs, _, err := jobService.List(ctx, repository.JobListQuery{QueryType: 1})
+		assert.NoError(t, err)
+		ids := make([]int64, 0, len(jobs))
+		for _, job := range jobs {
+			ids = append(ids, job.ID)
+		}
+		return ids
+	}
+	// Until the task runs, the ended placement still counts.
+	assert.Equal(t, []int64{ended.ID, bought.ID, fresh.ID}, recommended())
+
+	expired, err := orderService.ExpireTops(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	assert.Equal(t, []int64{bought.ID, fresh.ID, ended.ID}, recommended())
+
+	expired, err = orderService.ExpireTops(ctx, now.Add(3*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, expired)
+	assert.Equal(t, []int64{fresh.ID, ended.ID, bought.ID}, recommended())
+	expired, err = orderService.ExpireTops(ctx, now.Add(3*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, expired)
+
+	var notifications []*model.Notification
+	assert.NoError(t, db.Where("user_id = ?", user.ID).Order("id ASC").Find(&notifications).Error)
+	if assert.Len(t, notifications, 2) {
+		assert.Equal(t, []int64{ended.ID, bought.ID}, []int64{notifications[0].TargetID, notifications[1].TargetID})
+		assert.Equal(t, model.NotificationTopExpired, notifications[0].Type)
+		assert.Contains(t, notifications[0].Content, "收银")
+	}
+}
@@ -0,0 +1,236 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/test/mocks/repository"
+	"github.com/go-nunu/nunu-layout-advanced/test/mocks/service"
+	"github.com/golang/mock/gomock"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+)
+
+type orderMocks struct {
+	tm                *mock_repository.MockTransaction
+	orderRepo         *mock_repository.MockOrderRepository
+	orderItemRepo     *mock_repository.MockOrderItemRepository
+	jobRepo           *mock_repository.MockJobRepository
+	invoiceRepo       *mock_repository.MockInvoiceRepository
+	productService    *mock_service.MockProductService
+	membershipService *mock_service.MockMembershipService
+	couponService     *mock_service.MockCouponService
+	paymentProvider   *mock_service.MockPaymentProvider
+}
+
+func newOrderService(ctrl *gomock.Controller) (service.OrderService, *orderMocks) {
+	m := &orderMocks{
+		tm:                mock_repository.NewMockTransaction(ctrl),
+		orderRepo:         mock_repository.NewMockOrderRepository(ctrl),
+		orderItemRepo:     mock_repository.NewMockOrderItemRepository(ctrl),
+		jobRepo:           mock_repository.NewMockJobRepository(ctrl),
+		invoiceRepo:       mock_repository.NewMockInvoiceRepository(ctrl),
+		productService:    mock_service.NewMockProductService(ctrl),
+		membershipService: mock_service.NewMockMembershipService(ctrl),
+		couponService:     mock_service.NewMockCouponService(ctrl),
+		paymentProvider:   mock_service.NewMockPaymentProvider(ctrl),
+	}
+	srv := service.NewService(m.tm, logger, sf, j)
+	orderService := service.NewOrderService(srv,
+		m.orderRepo,
+		m.orderItemRepo,
+		m.jobRepo,
+		m.invoiceRepo,
+		mock_repository.NewMockNotificationRepository(ctrl),
+		mock_service.NewMockContactVoucherHistoryService(ctrl),
+		m.membershipService,
+		m.couponService,
+		mock_repository.NewMockIdempotencyKeyRepository(ctrl),
+		m.productService,
+		m.paymentProvider,
+		mock_service.NewMockRefundService(ctrl),
+		viper.New(),
+	)
+	return orderService, m
+}
+
+// runTransaction makes the mocked transaction manager run the callback in place.
+func runTransaction(tm *mock_repository.MockTransaction) {
+	tm.EXPECT().Transaction(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, fn func(context.Context) error) error {
+		return fn(ctx)
+	}).AnyTimes()
+}
+
+func TestOrderService_CreateOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orderService, m := newOrderService(ctrl)
+	runTransaction(m.tm)
+
+	ctx := context.Background()
+	var userID int64 = 1
+	top := &model.Product{ID: 10, ProductType: model.ProductTypeTop, Title: "置顶2小时", Price: model.NewDecimalFromCents(990), TopHour: 2}
+	voucher := &model.Product{ID: 11, ProductType: model.ProductTypeContactVoucher, Title: "联系券-5张", Price: model.NewDecimalFromCents(1990), ContactVoucherNum: 5}
+
+	m.productService.EXPECT().GetOnSale(ctx, top.ID, model.ProductType(0)).Return(top, nil)
+	m.productService.EXPECT().GetOnSale(ctx, voucher.ID, model.ProductType(0)).Return(voucher, nil)
+	m.jobRepo.EXPECT().GetByID(ctx, int64(100)).Return(&model.Job{ID: 100, UserID: userID}, nil)
+	m.membershipService.EXPECT().GetBenefits(ctx, userID).Return(&service.MembershipBenefits{TopPricePercent: 100}, nil)
+	m.orderRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+	m.orderItemRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil).Times(2)
+
+	order, items, err := orderService.CreateOrder(ctx, userID, []service.LineItem{
+		{SkuID: top.ID, JobID: 100},
+		{SkuID: voucher.ID},
+	}, 0, "")
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.OrderStatusPending, order.Status)
+	assert.Equal(t, "29.80", order.AmountTotal.String())
+	assert.Regexp(t, `^ORD\d+$`, order.OrderNo)
+	if assert.Len(t, items, 2) {
+		assert.Equal(t, "9.90", items[0].UnitPriceSnapshot.String())
+		assert.Equal(t, 2, items[0].TopHour)
+		assert.Equal(t, int64(100), items[0].TargetID)
+		assert.Equal(t, "19.90", items[1].UnitPriceSnapshot.String())
+		assert.Equal(t, 5, items[1].ContactVoucherNum)
+	}
+}
+
+func TestOrderService_CreateTopOrder_MemberPrice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orderService, m := newOrderService(ctrl)
+	runTransaction(m.tm)
+
+	ctx := context.Background()
+	var userID int64 = 1
+	top := &model.Product{ID: 10, ProductType: model.ProductTypeTop, Title: "置顶2小时", Price: model.NewDecimalFromCents(990), TopHour: 2}
+
+	m.productService.EXPECT().GetOnSale(ctx, top.ID, model.ProductTypeTop).Return(top, nil)
+	m.jobRepo.EXPECT().GetByID(ctx, int64(100)).Return(&model.Job{ID: 100, UserID: userID}, nil)
+	m.membershipService.EXPECT().GetBenefits(ctx, userID).Return(&service.MembershipBenefits{Active: true, TopPricePercent: 80}, nil)
+	m.orderRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+	m.orderItemRepo.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+
+	order, item, err := orderService.CreateTopOrder(ctx, userID, 100, top.ID, 0, "")
+
+	assert.NoError(t, err)
+	// 9.90 * 80% = 7.92, rounded half up to the cent.
+	assert.Equal(t, "7.92", order.AmountTotal.String())
+	assert.Equal(t, "7.92", item.UnitPriceSnapshot.String())
+	assert.Regexp(t, `^TOP\d+$`, order.OrderNo)
+}
+
+func TestOrderService_CreateTopOrder_OtherUsersJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orderService, m := newOrderService(ctrl)
+
+	ctx := context.Background()
+	top := &model.Product{ID: 10, ProductType: model.ProductTypeTop, Price: model.NewDecimalFromCents(990), TopHour: 2}
+
+	m.productService.EXPECT().GetOnSale(ctx, top.ID, model.ProductTypeTop).Return(top, nil)
+	m.jobRepo.EXPECT().GetByID(ctx, int64(100)).Return(&model.Job{ID: 100, UserID: 2}, nil)
+
+	_, _, err := orderService.CreateTopOrder(ctx, 1, 100, top.ID, 0, "")
+
+	assert.Equal(t, service.ErrForbidden, err)
+}
+
+func TestOrderService_CreateOrder_InvalidLines(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orderService, m := newOrderService(ctrl)
+
+	ctx := context.Background()
+	top := &model.Product{ID: 10, ProductType: model.ProductTypeTop, Price: model.NewDecimalFromCents(990), TopHour: 2}
+
+	_, _, err := orderService.CreateOrder(ctx, 1, nil, 0, "")
+	assert.Equal(t, service.ErrInvalidOrderLines, err)
+
+	// A top line needs the job to put on top.
+	m.productService.EXPECT().GetOnSale(ctx, top.ID, model.ProductType(0)).Return(top, nil)
+	_, _, err = orderService.CreateOrder(ctx, 1, []service.LineItem{{SkuID: top.ID}}, 0, "")
+	assert.Equal(t, service.ErrInvalidOrderLines, err)
+}
+
+func TestOrderService_CreateOrder_ProductUnavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orderService, m := newOrderService(ctrl)
+
+	ctx := context.Background()
+
+	m.productService.EXPECT().GetOnSale(ctx, int64(10), model.ProductTypeMembership).Return(nil, service.ErrProductNotFound)
+
+	_, _, err := orderService.CreateMembershipOrder(ctx, 1, 10, 0, "")
+
+	assert.Equal(t, service.ErrProductNotFound, err)
+}
+
+func TestOrderService_ConfirmOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orderService, m := newOrderService(ctrl)
+
+	ctx := context.Background()
+	order := &model.Order{ID: 1, OrderNo: "CV1", UserID: 1, AmountTotal: model.NewDecimalFromCents(1990), Status: model.OrderStatusPending}
+
+	m.orderRepo.EXPECT().GetByOrderNo(ctx, order.OrderNo).Return(order, nil).Times(2)
+	m.paymentProvider.EXPECT().QueryOrder(ctx, order.OrderNo).Return(&service.PayResult{OrderNo: order.OrderNo}, nil)
+
+	// Not paid at the provider yet: the order comes back as it is.
+	got, err := orderService.ConfirmOrder(ctx, 1, order.OrderNo)
+	assert.NoError(t, err)
+	assert.Equal(t, model.OrderStatusPending, got.Status)
+
+	_, err = orderService.ConfirmOrder(ctx, 2, order.OrderNo)
+	assert.Equal(t, service.ErrForbidden, err)
+}
+
+func TestOrderService_CancelOrder(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orderService, m := newOrderService(ctrl)
+	runTransaction(m.tm)
+
+	ctx := context.Background()
+	order := &model.Order{ID: 1, OrderNo: "CV1", UserID: 1, Status: model.OrderStatusPending}
+
+	m.orderRepo.EXPECT().GetByOrderNo(ctx, order.OrderNo).Return(order, nil)
+	m.orderRepo.EXPECT().Cancel(ctx, order.ID, gomock.Any(), "用户取消").Return(true, nil)
+	m.couponService.EXPECT().Release(ctx, order).Return(nil)
+	m.paymentProvider.EXPECT().CloseOrder(ctx, order.OrderNo).Return(nil)
+
+	got, err := orderService.CancelOrder(ctx, 1, order.OrderNo)
+
+	assert.NoError(t, err)
+	assert.Equal(t, model.OrderStatusCanceled, got.Status)
+}
+
+func TestOrderService_CancelOrder_NotPending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	orderService, m := newOrderService(ctrl)
+
+	ctx := context.Background()
+	paid := &model.Order{ID: 1, OrderNo: "CV1", UserID: 1, Status: model.OrderStatusPaid}
+
+	m.orderRepo.EXPECT().GetByOrderNo(ctx, paid.OrderNo).Return(paid, nil).Times(2)
+
+	_, err := orderService.CancelOrder(ctx, 2, paid.OrderNo)
+	assert.Equal(t, service.ErrForbidden, err)
+	_, err = orderService.CancelOrder(ctx, 1, paid.OrderNo)
+	assert.Equal(t, service.ErrOrderNotPending, err)
+}
@@ -0,0 +1,140 @@
+package payment_test
+
+import (
+	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/wxpay"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+const apiV3Key = "0123456789abcdef0123456789abcdef"
+
+// platform signs notifications the way WeChat Pay does, with a certificate the providers
+// under test have pinned.
+type platform struct {
+	key    *rsa.PrivateKey
+	serial string
+	conf   *viper.Viper
+}
+
+func newPlatform(t *testing.T) *platform {
+	dir := t.TempDir()
+	merchantKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(merchantKey)
+	require.NoError(t, err)
+	keyPath := filepath.Join(dir, "apiclient_key.pem")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(0x5157F09EFDC096DE),
+		Subject:      pkix.Name{CommonName: "Tenpay.com Root CA"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(24 * time.Hour),
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	certPath := filepath.Join(dir, "wechatpay.pem")
+	require.NoError(t, os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER}), 0o600))
+
+	conf := viper.New()
+	conf.Set("wxpay.app_id", "wx-app")
+	conf.Set("wxpay.mch_id", "1900000001")
+	conf.Set("wxpay.mch_serial_no", "MCH-SERIAL")
+	conf.Set("wxpay.private_key_path", keyPath)
+	conf.Set("wxpay.notify_url", "https://example.com/notify")
+	conf.Set("wxpay.api_v3_key", apiV3Key)
+	conf.Set("wxpay.platform_cert_paths", []string{certPath})
+	return &platform{key: key, serial: "5157F09EFDC096DE", conf: conf}
+}
+
+func (p *platform) provider(db *gorm.DB) service.PaymentProvider {
+	repo := repository.NewRepository(&log.Logger{Logger: zap.NewNop()}, db)
+	return service.NewPaymentProvider(p.conf, repository.NewNotifyNonceRepository(repo))
+}
+
+func (p *platform) notify(t *testing.T, eventType string, nonce string) (http.Header, []byte) {
+	plain, err := json.Marshal(map[string]interface{}{
+		"appid": "wx-app", "mchid": "1900000001", "out_trade_no": "O1", "transaction_id": "T1",
+		"trade_state": wxpay.TradeStateSuccess, "amount": map[string]interface{}{"total": 990},
+	})
+	require.NoError(t, err)
+	block, err := aes.NewCipher([]byte(apiV3Key))
+	require.NoError(t, err)
+	gcm, err := cipher.NewGCM(block)
+	require.NoError(t, err)
+	const resourceNonce = "a1b2c3d4e5f6"
+	body, err := json.Marshal(wxpay.Notification{
+		ID:        "EV-" + nonce,
+		EventType: eventType,
+		Resource: wxpay.EncryptResource{
+			Algorithm:      "AEAD_AES_256_GCM",
+			Nonce:          resourceNonce,
+			AssociatedData: "transaction",
+			Ciphertext:     base64.StdEncoding.EncodeToString(gcm.Seal(nil, []byte(resourceNonce), plain, []byte("transaction"))),
+		},
+	})
+	require.NoError(t, err)
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	sum := sha256.Sum256([]byte(timestamp + "\n" + nonce + "\n" + string(body) + "\n"))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, sum[:])
+	require.NoError(t, err)
+	header := http.Header{}
+	header.Set("Wechatpay-Serial", p.serial)
+	header.Set("Wechatpay-Timestamp", timestamp)
+	header.Set("Wechatpay-Nonce", nonce)
+	header.Set("Wechatpay-Signature", base64.StdEncoding.EncodeToString(signature))
+	return header, body
+}
+
+func TestParsePayNotify_ReplayAcrossInstances(t *testing.T) {
+	db := newDB(t)
+	p := newPlatform(t)
+	header, body := p.notify(t, wxpay.EventTransactionSuccess, "NONCE-1")
+
+	result, err := p.provider(db).ParsePayNotify(context.Background(), header, body)
+	require.NoError(t, err)
+	assert.Equal(t, "O1", result.OrderNo)
+	assert.Equal(t, int64(990), result.Amount)
+
+	// A second server instance shares the nonces through the database.
+	_, err = p.provider(db).ParsePayNotify(context.Background(), header, body)
+	assert.ErrorIs(t, err, service.ErrInvalidNotify)
+}
+
+func TestParsePayNotify_RejectsOtherEvents(t *testing.T) {
+	db := newDB(t)
+	p := newPlatform(t)
+	header, body := p.notify(t, "REFUND.SUCCESS", "NONCE-2")
+
+	_, err := p.provider(db).ParsePayNotify(context.Background(), header, body)
+	assert.ErrorIs(t, err, service.ErrInvalidNotify)
+}
@@ -0,0 +1,75 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/test/mocks/repository"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestProductService_GetOnSale(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductRepo := mock_repository.NewMockProductRepository(ctrl)
+	mockTm := mock_repository.NewMockTransaction(ctrl)
+	srv := service.NewService(mockTm, logger, sf, j)
+	productService := service.NewProductService(srv, mockProductRepo)
+
+	ctx := context.Background()
+	product := &model.Product{
+		ID:          1,
+		ProductType: model.ProductTypeTop,
+		Price:       model.NewDecimalFromCents(990),
+		Status:      model.ProductStatusOnline,
+	}
+
+	mockProductRepo.EXPECT().GetByID(ctx, product.ID).Return(product, nil).Times(2)
+
+	got, err := productService.GetOnSale(ctx, product.ID, model.ProductTypeTop)
+	assert.NoError(t, err)
+	assert.Equal(t, product, got)
+
+	// Cart lines don't name a type.
+	got, err = productService.GetOnSale(ctx, product.ID, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, product, got)
+}
+
+func TestProductService_GetOnSale_Unavailable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductRepo := mock_repository.NewMockProductRepository(ctrl)
+	mockTm := mock_repository.NewMockTransaction(ctrl)
+	srv := service.NewService(mockTm, logger, sf, j)
+	productService := service.NewProductService(srv, mockProductRepo)
+
+	ctx := context.Background()
+	online := func(product model.Product) *model.Product {
+		product.Status = model.ProductStatusOnline
+		return &product
+	}
+	for name, product := range map[string]*model.Product{
+		"offline":      {ID: 1, ProductType: model.ProductTypeTop, Price: model.NewDecimalFromCents(990), Status: model.ProductStatusOffline},
+		"another type": online(model.Product{ID: 1, ProductType: model.ProductTypeContactVoucher, Price: model.NewDecimalFromCents(990)}),
+		"free":         online(model.Product{ID: 1, ProductType: model.ProductTypeTop, Price: model.NewDecimalFromCents(0)}),
+	} {
+		mockProductRepo.EXPECT().GetByID(ctx, int64(1)).Return(product, nil)
+
+		_, err := productService.GetOnSale(ctx, 1, model.ProductTypeTop)
+
+		assert.Equal(t, service.ErrProductNotFound, err, name)
+	}
+
+	mockProductRepo.EXPECT().GetByID(ctx, int64(2)).Return(nil, gorm.ErrRecordNotFound)
+
+	_, err := productService.GetOnSale(ctx, 2, model.ProductTypeTop)
+
+	assert.Equal(t, service.ErrProductNotFound, err)
+}
@@ -0,0 +1,143 @@
+package reconcile_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/wxpay"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+// billProvider serves a fixed CSV trade bill the way the WeChat Pay provider reads one.
+type billProvider struct {
+	fakeProvider
+	csv string
+}
+
+func (p billProvider) TradeBill(ctx context.Context, billDate time.Time) ([]service.BillRecord, error) {
+	rows, err := wxpay.ParseTradeBill([]byte(p.csv))
+	if err != nil {
+		return nil, err
+	}
+	var records []service.BillRecord
+	for _, row := range rows {
+		if row.TradeState == wxpay.TradeBillStateSuccess {
+			records = append(records, service.BillRecord{
+				OrderNo: row.OutTradeNo, TradeNo: row.TransactionID, Amount: row.Amount, TradeTime: row.TradeTime,
+			})
+		}
+	}
+	return records, nil
+}
+
+func billRow(tradeTime, tradeNo, orderNo, state, amount, refund string) string {
+	fields := []string{tradeTime, "wx1", "1900000001", "0", "", tradeNo, orderNo, "oUser", "JSAPI", state,
+		"OTHERS", "CNY", amount, "0.00", "", "", refund, "0.00", "", "", "联系券", "", "0.00000", "0.60%", amount, refund, ""}
+	return "`" + strings.Join(fields, ",`") + "\n"
+}
+
+func TestReconcile_FixesMissedPaymentsAndRecordsTheRest(t *testing.T) {
+	db := newDB(t)
+	ctx := context.Background()
+	now := time.Now()
+	billDate := now.AddDate(0, 0, -1)
+	noon := time.Date(billDate.Year(), billDate.Month(), billDate.Day(), 12, 0, 0, 0, time.Local)
+	day := noon.Format("2006-01-02 ")
+
+	user := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+	newOrder := func(orderNo string, cents int64, status model.OrderStatus, tradeNo string) {
+		order := &model.Order{
+			OrderNo:     orderNo,
+			UserID:      user.ID,
+			AmountTotal: model.NewDecimalFromCents(cents),
+			AmountPaid:  model.NewDecimalFromCents(0),
+			Currency:    "CNY",
+			Status:      status,
+			CreateAt:    noon,
+			UpdateAt:    noon,
+		}
+		if status == model.OrderStatusPaid {
+			order.AmountPaid = order.AmountTotal
+			order.PayChannel = "fake"
+			order.PayTradeNo = tradeNo
+			order.PaidAt = &noon
+		}
+		assert.NoError(t, db.Create(order).Error)
+		assert.NoError(t, db.Create(&model.OrderItem{
+			OrderID:           order.ID,
+			ProductType:       model.ProductTypeContactVoucher,
+			TitleSnapshot:     "联系券",
+			UnitPriceSnapshot: order.AmountTotal,
+			ContactVoucherNum: 5,
+			CreateAt:          noon,
+			UpdateAt:          noon,
+		}).Error)
+	}
+	newOrder("CV-MISSED", 990, model.OrderStatusCanceled, "")
+	newOrder("CV-OK", 500, model.OrderStatusPaid, "T-OK")
+	newOrder("CV-UNBILLED", 300, model.OrderStatusPaid, "T-UNBILLED")
+
+	bill := "\xef\xbb\xbf交易时间,公众账号ID,商户号,特约商户号,设备号,微信订单号,商户订单号,用户标识,交易类型,交易状态,付款银行,货币种类,应结订单金额,代金券金额,微信退款单号,商户退款单号,退款金额,充值券退款金额,退款类型,退款状态,商品名称,商户数据包,手续费,费率,订单金额,申请退款金额,费率备注\n" +
+		billRow(day+"12:00:01", "T-MISSED", "CV-MISSED", "SUCCESS", "9.90", "0.00") +
+		billRow(day+"12:00:02", "T-OK", "CV-OK", "SUCCESS", "5.00", "0.00") +
+		billRow(day+"13:00:00", "T-OK", "CV-OK", "REFUND", "5.00", "5.00") +
+		billRow(day+"12:00:03", "T-NOPE", "CV-NOPE", "SUCCESS", "1.00", "0.00") +
+		"总交易单数,应结订单总金额,退款总金额,充值券退款总金额,手续费总金额,订单总金额,申请退款总金额\n" +
+		"`4,`15.90,`5.00,`0.00,`0.00,`15.90,`5.00\n"
+
+	logger := &log.Logger{Logger: zap.NewNop()}
+	repo := repository.NewRepository(logger, db)
+	srv := service.NewService(repository.NewTransaction(repo), logger, nil, jwt.NewJwt(viper.New()))
+	reconcileService := service.NewReconcileService(srv,
+		repository.NewOrderRepository(repo),
+		repository.NewReconcileDiscrepancyRepository(repo),
+		newOrderService(db),
+		billProvider{csv: bill},
+	)
+
+	result, err := reconcileService.Reconcile(ctx, billDate)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, result.Records)
+	assert.Equal(t, 3, result.Discrepancies)
+	assert.Equal(t, 1, result.Fixed)
+
+	var missed model.Order
+	assert.NoError(t, db.Where("order_no = ?", "CV-MISSED").First(&missed).Error)
+	assert.Equal(t, model.OrderStatusPaid, missed.Status)
+	assert.Equal(t, "T-MISSED", missed.PayTradeNo)
+	var got model.User
+	assert.NoError(t, db.First(&got, user.ID).Error)
+	assert.Equal(t, 5, got.ContactVoucherNum)
+
+	list, total, err := reconcileService.ListDiscrepancies(ctx, noon.Format("2006-01-02"), 0, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	types := map[string]model.ReconcileDiscrepancyType{}
+	for _, discrepancy := range list {
+		types[discrepancy.OrderNo] = discrepancy.Type
+	}
+	assert.Equal(t, model.ReconcileMissedPayment, types["CV-MISSED"])
+	assert.Equal(t, model.ReconcileUnknownOrder, types["CV-NOPE"])
+	assert.Equal(t, model.ReconcileMissingInBill, types["CV-UNBILLED"])
+
+	// A second run finds the payment already applied and updates the rest in place.
+	result, err = reconcileService.Reconcile(ctx, billDate)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, result.Discrepancies)
+	assert.Equal(t, 0, result.Fixed)
+	_, total, err = reconcileService.ListDiscrepancies(ctx, "", 0, 1, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), total)
+	assert.NoError(t, db.First(&got, user.ID).Error)
+	assert.Equal(t, 5, got.ContactVoucherNum)
+}
@@ -0,0 +1,251 @@
+package refund_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// refundProvider answers refunds with err, or as accepted and still processing.
+type refundProvider struct {
+	fakeProvider
+	err error
+}
+
+func (p refundProvider) Refund(ctx context.Context, req service.RefundRequest) (*service.RefundResult, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	return &service.RefundResult{RefundNo: req.RefundNo, TradeRefundNo: "W" + req.RefundNo, Status: model.RefundStatusProcessing}, nil
+}
+
+func newPaidOrder(t *testing.T, db *gorm.DB, cents int64) *model.Order {
+	now := time.Now()
+	user := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+	order := &model.Order{
+		OrderNo:     "ORD-" + now.Format("150405.000000"),
+		UserID:      user.ID,
+		AmountTotal: model.NewDecimalFromCents(cents),
+		AmountPaid:  model.NewDecimalFromCents(cents),
+		Currency:    "CNY",
+		Status:      model.OrderStatusPaid,
+		PaidAt:      &now,
+		CreateAt:    now,
+		UpdateAt:    now,
+	}
+	assert.NoError(t, db.Create(order).Error)
+	return order
+}
+
+func TestCreateRefund_ConcurrentRefundsStayWithinPaid(t *testing.T) {
+	db := newDB(t)
+	refundService := newRefundService(db, refundProvider{})
+	order := newPaidOrder(t, db, 1000)
+
+	const workers = 4
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		succeeded int
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := refundService.CreateRefund(context.Background(), 1, order.OrderNo, model.NewDecimalFromCents(500), "")
+			mu.Lock()
+			defer mu.Unlock()
+			if err == nil {
+				succeeded++
+			} else {
+				assert.Equal(t, service.ErrInvalidRefundAmount, err)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 2, succeeded)
+}
+
+func TestCreateRefund_KeepsRefundWhenOutcomeUnknown(t *testing.T) {
+	db := newDB(t)
+	ctx := context.Background()
+	order := newPaidOrder(t, db, 1000)
+
+	// A timeout may hide an accepted refund: the row stays for the notify to settle.
+	_, err := newRefundService(db, refundProvider{err: errors.New("timeout")}).CreateRefund(ctx, 1, order.OrderNo, model.Decimal{}, "")
+	assert.Error(t, err)
+	var refunds []*model.Refund
+	assert.NoError(t, db.Where("order_id = ?", order.ID).Find(&refunds).Error)
+	if assert.Len(t, refunds, 1) {
+		assert.Equal(t, model.RefundStatusProcessing, refunds[0].Status)
+		refund, err := newRefundService(db, refundProvider{}).ApplyRefundResult(ctx, &service.RefundResult{
+			RefundNo: refunds[0].RefundNo, Status: model.RefundStatusSuccess,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, model.RefundStatusSuccess, refund.Status)
+	}
+	var got model.Order
+	assert.NoError(t, db.First(&got, order.ID).Error)
+	assert.Equal(t, model.OrderStatusRefunded, got.Status)
+}
+
+func TestCreateRefund_RejectedRefundFreesTheAmount(t *testing.T) {
+	db := newDB(t)
+	ctx := context.Background()
+	order := newPaidOrder(t, db, 1000)
+
+	_, err := newRefundService(db, refundProvider{err: service.ErrRefundRejected}).CreateRefund(ctx, 1, order.OrderNo, model.Decimal{}, "")
+	assert.ErrorIs(t, err, service.ErrRefundRejected)
+	refund, err := newRefundService(db, refundProvider{}).CreateRefund(ctx, 1, order.OrderNo, model.Decimal{}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "10.00", refund.Amount.String())
+	assert.Equal(t, model.RefundStatusProcessing, refund.Status)
+}
+
+func TestApplyRefundResult_TakesVouchersFromTheOrderBatch(t *testing.T) {
+	db := newDB(t)
+	ctx := context.Background()
+	order := newPaidOrder(t, db, 1000)
+	now := time.Now()
+	assert.NoError(t, db.Create(&model.OrderItem{
+		OrderID: order.ID, ProductType: model.ProductTypeContactVoucher, ContactVoucherNum: 10, CreateAt: now, UpdateAt: now,
+	}).Error)
+
+	// An invite grant that expires first, and the order's batch with 6 of its 10 spent.
+	soon, later := now.Add(24*time.Hour), now.Add(30*24*time.Hour)
+	grant := &model.ContactVoucherBatch{
+		UserID: order.UserID, BizType: model.ContactVoucherHistoryInvite, TotalNum: 5, RemainNum: 5, ExpireAt: &soon, CreateAt: now, UpdateAt: now,
+	}
+	bought := &model.ContactVoucherBatch{
+		UserID: order.UserID, BizType: model.ContactVoucherHistoryBuy, OrderID: order.ID, TotalNum: 10, RemainNum: 4, ExpireAt: &later, CreateAt: now, UpdateAt: now,
+	}
+	assert.NoError(t, db.Create(grant).Error)
+	assert.NoError(t, db.Create(bought).Error)
+	assert.NoError(t, db.Model(&model.User{}).Where("id = ?", order.UserID).Update("contact_voucher_num", 9).Error)
+
+	created, err := newRefundService(db, refundProvider{}).CreateRefund(ctx, 1, order.OrderNo, model.Decimal{}, "")
+	assert.NoError(t, err)
+	refund, err := newRefundService(db, refundProvider{}).ApplyRefundResult(ctx, &service.RefundResult{
+		RefundNo: created.RefundNo, Status: model.RefundStatusSuccess,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 4, refund.RollbackVoucherNum)
+
+	var gotGrant, gotBought model.ContactVoucherBatch
+	assert.NoError(t, db.First(&gotGrant, grant.ID).Error)
+	assert.Equal(t, 5, gotGrant.RemainNum)
+	assert.NoError(t, db.First(&gotBought, bought.ID).Error)
+	assert.Equal(t, 0, gotBought.RemainNum)
+	var user model.User
+	assert.NoError(t, db.First(&user, order.UserID).Error)
+	assert.Equal(t, 5, user.ContactVoucherNum)
+}
+
+func TestCreateRefund_PartialThenTheRest(t *testing.T) {
+	db := newDB(t)
+	ctx := context.Background()
+	refundService := newRefundService(db, refundProvider{})
+	order := newPaidOrder(t, db, 1000)
+	now := time.Now()
+	end := now.Add(10 * time.Hour)
+	job := &model.Job{UserID: order.UserID, Positions: "厨师", Status: model.JobStatusActive, IsTop: true, TopStartTime: &now, TopEndTime: &end, CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(job).Error)
+	assert.NoError(t, db.Create(&model.OrderItem{
+		OrderID: order.ID, ProductType: model.ProductTypeTop, TargetType: model.OrderTargetJob, TargetID: job.ID, TopHour: 10, CreateAt: now, UpdateAt: now,
+	}).Error)
+	succeed := func(refund *model.Refund) *model.Refund {
+		refund, err := refundService.ApplyRefundResult(ctx, &service.RefundResult{RefundNo: refund.RefundNo, Status: model.RefundStatusSuccess})
+		assert.NoError(t, err)
+		return refund
+	}
+
+	// Half the money takes back half the hours and leaves the order paid.
+	created, err := refundService.CreateRefund(ctx, 1, order.OrderNo, model.NewDecimalFromCents(500), "")
+	assert.NoError(t, err)
+	assert.Equal(t, 5, succeed(created).RollbackTopHour)
+	var got model.Order
+	assert.NoError(t, db.First(&got, order.ID).Error)
+	assert.Equal(t, model.OrderStatusPaid, got.Status)
+	var gotJob model.Job
+	assert.NoError(t, db.First(&gotJob, job.ID).Error)
+	assert.True(t, gotJob.IsTop)
+	assert.WithinDuration(t, end.Add(-5*time.Hour), *gotJob.TopEndTime, time.Second)
+
+	_, err = refundService.CreateRefund(ctx, 1, order.OrderNo, model.NewDecimalFromCents(600), "")
+	assert.Equal(t, service.ErrInvalidRefundAmount, err)
+
+	// The rest ends the placement now and refunds the order.
+	created, err = refundService.CreateRefund(ctx, 1, order.OrderNo, model.Decimal{}, "")
+	assert.NoError(t, err)
+	assert.Equal(t, "5.00", created.Amount.String())
+	assert.Equal(t, 5, succeed(created).RollbackTopHour)
+	assert.NoError(t, db.First(&got, order.ID).Error)
+	assert.Equal(t, model.OrderStatusRefunded, got.Status)
+	assert.NoError(t, db.First(&gotJob, job.ID).Error)
+	assert.False(t, gotJob.IsTop)
+
+	_, err = refundService.CreateRefund(ctx, 1, order.OrderNo, model.Decimal{}, "")
+	assert.Equal(t, service.ErrOrderNotRefundable, err)
+}
+
+func TestApplyRefundResult_TakesTheMembershipAllowanceBack(t *testing.T) {
+	db := newDB(t)
+	ctx := context.Background()
+	refundService := newRefundService(db, refundProvider{})
+	order := newPaidOrder(t, db, 3000)
+	now := time.Now()
+	assert.NoError(t, db.Create(&model.OrderItem{
+		OrderID: order.ID, ProductType: model.ProductTypeMembership, MembershipDays: 30, CreateAt: now, UpdateAt: now,
+	}).Error)
+
+	// The month the order paid for, with 3 of its 10 allowance vouchers spent.
+	end := now.Add(30 * 24 * time.Hour)
+	assert.NoError(t, db.Create(&model.Membership{
+		UserID: order.UserID, StartAt: now, EndAt: end, NextGrantAt: end, CreateAt: now, UpdateAt: now,
+	}).Error)
+	allowance := &model.ContactVoucherBatch{
+		UserID: order.UserID, BizType: model.ContactVoucherHistoryMembership, TotalNum: 10, RemainNum: 7, ExpireAt: &end, CreateAt: now, UpdateAt: now,
+	}
+	assert.NoError(t, db.Create(allowance).Error)
+	assert.NoError(t, db.Model(&model.User{}).Where("id = ?", order.UserID).Update("contact_voucher_num", 7).Error)
+
+	refund := func(cents int64) {
+		created, err := refundService.CreateRefund(ctx, 1, order.OrderNo, model.NewDecimalFromCents(cents), "")
+		if assert.NoError(t, err) {
+			_, err = refundService.ApplyRefundResult(ctx, &service.RefundResult{RefundNo: created.RefundNo, Status: model.RefundStatusSuccess})
+			assert.NoError(t, err)
+		}
+	}
+
+	// Half the month back: the allowance stays but ends with the membership.
+	refund(1500)
+	var membership model.Membership
+	assert.NoError(t, db.Where("user_id = ?", order.UserID).First(&membership).Error)
+	var got model.ContactVoucherBatch
+	assert.NoError(t, db.First(&got, allowance.ID).Error)
+	assert.Equal(t, 7, got.RemainNum)
+	if assert.NotNil(t, got.ExpireAt) {
+		assert.WithinDuration(t, membership.EndAt, *got.ExpireAt, time.Second)
+		assert.WithinDuration(t, now.Add(15*24*time.Hour), *got.ExpireAt, time.Minute)
+	}
+
+	// The rest: the membership ends now and the unspent allowance goes with it.
+	refund(1500)
+	assert.NoError(t, db.First(&got, allowance.ID).Error)
+	assert.Equal(t, 0, got.RemainNum)
+	var user model.User
+	assert.NoError(t, db.First(&user, order.UserID).Error)
+	assert.Equal(t, 0, user.ContactVoucherNum)
+	var history model.ContactVoucherHistory
+	assert.NoError(t, db.Where("user_id = ?", order.UserID).Last(&history).Error)
+	assert.Equal(t, model.ContactVoucherHistoryRefund, history.BizType)
+	assert.Equal(t, -7, history.ChangeNum)
+}
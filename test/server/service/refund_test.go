@@ -0,0 +1,80 @@
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/test/mocks/repository"
+	"github.com/go-nunu/nunu-layout-advanced/test/mocks/service"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefundService_CreateRefund_AmountLeft(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mock_repository.NewMockOrderRepository(ctrl)
+	mockRefundRepo := mock_repository.NewMockRefundRepository(ctrl)
+	mockTm := mock_repository.NewMockTransaction(ctrl)
+	runTransaction(mockTm)
+	srv := service.NewService(mockTm, logger, sf, j)
+	refundService := service.NewRefundService(srv,
+		mockOrderRepo,
+		mock_repository.NewMockOrderItemRepository(ctrl),
+		mockRefundRepo,
+		mock_repository.NewMockJobRepository(ctrl),
+		mock_service.NewMockContactVoucherHistoryService(ctrl),
+		mock_service.NewMockMembershipService(ctrl),
+		mock_service.NewMockPaymentProvider(ctrl),
+	)
+
+	ctx := context.Background()
+	now := time.Now()
+	order := &model.Order{ID: 1, OrderNo: "CV1", AmountPaid: model.NewDecimalFromCents(1000), Status: model.OrderStatusPaid, PaidAt: &now}
+
+	mockOrderRepo.EXPECT().GetByOrderNo(ctx, order.OrderNo).Return(order, nil)
+	mockOrderRepo.EXPECT().GetByIDForUpdate(ctx, order.ID).Return(order, nil)
+	// A failed refund doesn't count against what is left.
+	mockRefundRepo.EXPECT().ListByOrderID(ctx, order.ID).Return([]*model.Refund{
+		{Amount: model.NewDecimalFromCents(600), Status: model.RefundStatusSuccess},
+		{Amount: model.NewDecimalFromCents(400), Status: model.RefundStatusFailed},
+	}, nil)
+
+	_, err := refundService.CreateRefund(ctx, 1, order.OrderNo, model.NewDecimalFromCents(500), "")
+
+	assert.Equal(t, service.ErrInvalidRefundAmount, err)
+}
+
+func TestRefundService_CreateRefund_NotRefundable(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockOrderRepo := mock_repository.NewMockOrderRepository(ctrl)
+	mockTm := mock_repository.NewMockTransaction(ctrl)
+	runTransaction(mockTm)
+	srv := service.NewService(mockTm, logger, sf, j)
+	refundService := service.NewRefundService(srv,
+		mockOrderRepo,
+		mock_repository.NewMockOrderItemRepository(ctrl),
+		mock_repository.NewMockRefundRepository(ctrl),
+		mock_repository.NewMockJobRepository(ctrl),
+		mock_service.NewMockContactVoucherHistoryService(ctrl),
+		mock_service.NewMockMembershipService(ctrl),
+		mock_service.NewMockPaymentProvider(ctrl),
+	)
+
+	ctx := context.Background()
+	// Canceled before it was ever paid.
+	order := &model.Order{ID: 1, OrderNo: "CV1", AmountTotal: model.NewDecimalFromCents(1000), Status: model.OrderStatusCanceled}
+
+	mockOrderRepo.EXPECT().GetByOrderNo(ctx, order.OrderNo).Return(order, nil)
+	mockOrderRepo.EXPECT().GetByIDForUpdate(ctx, order.ID).Return(order, nil)
+
+	_, err := refundService.CreateRefund(ctx, 1, order.OrderNo, model.Decimal{}, "")
+
+	assert.Equal(t, service.ErrOrderNotRefundable, err)
+}
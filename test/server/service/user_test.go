@@ -3,218 +3,203 @@ package service_test
 import (
 	"context"
 	"errors"
-	"flag"
-	"fmt"
-	v1 "github.com/go-nunu/nunu-layout-advanced/api/v1"
-	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
-	"github.com/go-nunu/nunu-layout-advanced/test/mocks/repository"
 	"os"
 	"testing"
 
 	"github.com/go-nunu/nunu-layout-advanced/internal/model"
 	"github.com/go-nunu/nunu-layout-advanced/internal/service"
-	"github.com/go-nunu/nunu-layout-advanced/pkg/config"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
 	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
-	"github.com/go-nunu/nunu-layout-advanced/pkg/sid"
+	"github.com/go-nunu/nunu-layout-advanced/test/mocks/repository"
+	"github.com/go-nunu/nunu-layout-advanced/test/mocks/service"
 	"github.com/golang/mock/gomock"
+	"github.com/sony/sonyflake"
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
-	"golang.org/x/crypto/bcrypt"
+	"go.uber.org/zap"
 )
 
 var (
 	logger *log.Logger
 	j      *jwt.JWT
-	sf     *sid.Sid
+	sf     service.IDGenerator
 )
 
-func TestMain(m *testing.M) {
-	fmt.Println("begin")
-
-	err := os.Setenv("APP_CONF", "../../../config/local.yml")
-	if err != nil {
-		panic(err)
-	}
-
-	var envConf = flag.String("conf", "config/local.yml", "config path, eg: -conf ./config/local.yml")
-	flag.Parse()
-	conf := config.NewConfig(*envConf)
+// fixedSid is a sonyflake with a fixed machine ID, so the tests don't need a private IP to
+// derive one from.
+type fixedSid struct {
+	*sonyflake.Sonyflake
+}
 
-	logger = log.NewLog(conf)
-	j = jwt.NewJwt(conf)
-	sf = sid.NewSid()
+func (s fixedSid) GenUint64() (uint64, error) {
+	return s.NextID()
+}
 
-	code := m.Run()
-	fmt.Println("test end")
+func TestMain(m *testing.M) {
+	logger = &log.Logger{Logger: zap.NewNop()}
+	j = jwt.NewJwt(viper.New())
+	sf = fixedSid{sonyflake.NewSonyflake(sonyflake.Settings{
+		MachineID: func() (uint16, error) { return 1, nil },
+	})}
 
-	os.Exit(code)
+	os.Exit(m.Run())
 }
 
-func TestUserService_Register(t *testing.T) {
+func TestUserService_GetInfo(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
+	mockIntegralService := mock_service.NewMockIntegralService(ctrl)
 	mockTm := mock_repository.NewMockTransaction(ctrl)
 	srv := service.NewService(mockTm, logger, sf, j)
-
-	userService := service.NewUserService(srv, mockUserRepo)
+	userService := service.NewUserService(srv, mockUserRepo, mockIntegralService)
 
 	ctx := context.Background()
-	req := &v1.RegisterRequest{
-		Password: "password",
-		Email:    "test@example.com",
-	}
+	var userID int64 = 123
 
-	mockUserRepo.EXPECT().GetByEmail(ctx, req.Email).Return(nil, nil)
-	mockTm.EXPECT().Transaction(ctx, gomock.Any()).Return(nil)
+	mockUserRepo.EXPECT().GetByID(ctx, userID).Return(&model.User{
+		ID:   userID,
+		Name: "test",
+	}, nil)
 
-	err := userService.Register(ctx, req)
+	user, err := userService.GetInfo(ctx, userID)
 
 	assert.NoError(t, err)
+	assert.Equal(t, userID, user.ID)
 }
 
-func TestUserService_Register_UsernameExists(t *testing.T) {
+func TestUserService_UpdateInfo(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
+	mockIntegralService := mock_service.NewMockIntegralService(ctrl)
 	mockTm := mock_repository.NewMockTransaction(ctrl)
 	srv := service.NewService(mockTm, logger, sf, j)
-	userService := service.NewUserService(srv, mockUserRepo)
+	userService := service.NewUserService(srv, mockUserRepo, mockIntegralService)
 
 	ctx := context.Background()
-	req := &v1.RegisterRequest{
-		Password: "password",
-		Email:    "test@example.com",
-	}
+	var userID int64 = 123
+	name := "testuser"
 
-	mockUserRepo.EXPECT().GetByEmail(ctx, req.Email).Return(&model.User{}, nil)
+	mockUserRepo.EXPECT().GetByID(ctx, userID).Return(&model.User{
+		ID:   userID,
+		Name: "old",
+	}, nil)
+	mockUserRepo.EXPECT().Update(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, user *model.User) error {
+		assert.Equal(t, name, user.Name)
+		return nil
+	})
 
-	err := userService.Register(ctx, req)
+	err := userService.UpdateInfo(ctx, userID, service.UpdateUserInfoInput{Name: &name})
 
-	assert.Error(t, err)
+	assert.NoError(t, err)
 }
 
-func TestUserService_Login(t *testing.T) {
+func TestUserService_UpdateInfo_ProfileComplete(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
+	mockIntegralService := mock_service.NewMockIntegralService(ctrl)
 	mockTm := mock_repository.NewMockTransaction(ctrl)
 	srv := service.NewService(mockTm, logger, sf, j)
-	userService := service.NewUserService(srv, mockUserRepo)
+	userService := service.NewUserService(srv, mockUserRepo, mockIntegralService)
 
 	ctx := context.Background()
-	req := &v1.LoginRequest{
-		Email:    "xxx@gmail.com",
-		Password: "password",
-	}
-	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
-	if err != nil {
-		t.Error("failed to hash password")
-	}
-
-	mockUserRepo.EXPECT().GetByEmail(ctx, req.Email).Return(&model.User{
-		Password: string(hashedPassword),
+	var userID int64 = 123
+	phone := "13800000000"
+
+	mockUserRepo.EXPECT().GetByID(ctx, userID).Return(&model.User{
+		ID:     userID,
+		Avatar: "avatar.png",
+		Name:   "test",
+		Sex:    1,
 	}, nil)
+	mockUserRepo.EXPECT().Update(ctx, gomock.Any()).Return(nil)
+	mockIntegralService.EXPECT().Earn(ctx, service.IntegralEarnInput{
+		UserID:  userID,
+		BizType: model.IntegralHistoryProfileComplete,
+		BizKey:  "profile_complete",
+		Remark:  "完善个人资料",
+	}).Return(&service.IntegralChangeResult{}, nil)
 
-	token, err := userService.Login(ctx, req)
+	err := userService.UpdateInfo(ctx, userID, service.UpdateUserInfoInput{Phone: &phone})
 
 	assert.NoError(t, err)
-	assert.NotEmpty(t, token)
 }
 
-func TestUserService_Login_UserNotFound(t *testing.T) {
+func TestUserService_UpdateInfo_UserNotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
+	mockIntegralService := mock_service.NewMockIntegralService(ctrl)
 	mockTm := mock_repository.NewMockTransaction(ctrl)
 	srv := service.NewService(mockTm, logger, sf, j)
-	userService := service.NewUserService(srv, mockUserRepo)
+	userService := service.NewUserService(srv, mockUserRepo, mockIntegralService)
 
 	ctx := context.Background()
-	req := &v1.LoginRequest{
-		Email:    "xxx@gmail.com",
-		Password: "password",
-	}
+	var userID int64 = 123
+	name := "testuser"
 
-	mockUserRepo.EXPECT().GetByEmail(ctx, req.Email).Return(nil, errors.New("user not found"))
+	mockUserRepo.EXPECT().GetByID(ctx, userID).Return(nil, errors.New("user not found"))
 
-	_, err := userService.Login(ctx, req)
+	err := userService.UpdateInfo(ctx, userID, service.UpdateUserInfoInput{Name: &name})
 
 	assert.Error(t, err)
 }
 
-func TestUserService_GetProfile(t *testing.T) {
-	ctrl := gomock.NewController(t)
-	defer ctrl.Finish()
-
-	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
-	mockTm := mock_repository.NewMockTransaction(ctrl)
-	srv := service.NewService(mockTm, logger, sf, j)
-	userService := service.NewUserService(srv, mockUserRepo)
-
-	ctx := context.Background()
-	userId := "123"
-
-	mockUserRepo.EXPECT().GetByID(ctx, userId).Return(&model.User{
-		UserId: userId,
-		Email:  "test@example.com",
-	}, nil)
-
-	user, err := userService.GetProfile(ctx, userId)
-
-	assert.NoError(t, err)
-	assert.Equal(t, userId, user.UserId)
-}
-
-func TestUserService_UpdateProfile(t *testing.T) {
+func TestUserService_UpdateGeo(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
+	mockIntegralService := mock_service.NewMockIntegralService(ctrl)
 	mockTm := mock_repository.NewMockTransaction(ctrl)
 	srv := service.NewService(mockTm, logger, sf, j)
-	userService := service.NewUserService(srv, mockUserRepo)
+	userService := service.NewUserService(srv, mockUserRepo, mockIntegralService)
 
 	ctx := context.Background()
-	userId := "123"
-	req := &v1.UpdateProfileRequest{
-		Nickname: "testuser",
-		Email:    "test@example.com",
-	}
-
-	mockUserRepo.EXPECT().GetByID(ctx, userId).Return(&model.User{
-		UserId: userId,
-		Email:  "old@example.com",
-	}, nil)
-	mockUserRepo.EXPECT().Update(ctx, gomock.Any()).Return(nil)
-
-	err := userService.UpdateProfile(ctx, userId, req)
+	var userID int64 = 123
+	address := "北京市东城区"
+	latitude, longitude := 39.9087, 116.3975
+
+	mockUserRepo.EXPECT().GetByID(ctx, userID).Return(&model.User{ID: userID}, nil)
+	mockUserRepo.EXPECT().Update(ctx, gomock.Any()).DoAndReturn(func(ctx context.Context, user *model.User) error {
+		assert.Equal(t, address, user.Address)
+		assert.Equal(t, latitude, user.Latitude)
+		assert.Equal(t, longitude, user.Longitude)
+		return nil
+	})
+
+	err := userService.UpdateGeo(ctx, userID, service.UpdateUserGeoInput{
+		Address:   &address,
+		Latitude:  &latitude,
+		Longitude: &longitude,
+	})
 
 	assert.NoError(t, err)
 }
 
-func TestUserService_UpdateProfile_UserNotFound(t *testing.T) {
+func TestUserService_UpdateGeo_UserNotFound(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
 
 	mockUserRepo := mock_repository.NewMockUserRepository(ctrl)
+	mockIntegralService := mock_service.NewMockIntegralService(ctrl)
 	mockTm := mock_repository.NewMockTransaction(ctrl)
 	srv := service.NewService(mockTm, logger, sf, j)
-	userService := service.NewUserService(srv, mockUserRepo)
+	userService := service.NewUserService(srv, mockUserRepo, mockIntegralService)
 
 	ctx := context.Background()
-	userId := "123"
-	req := &v1.UpdateProfileRequest{
-		Nickname: "testuser",
-		Email:    "test@example.com",
-	}
+	var userID int64 = 123
+	address := "北京市东城区"
 
-	mockUserRepo.EXPECT().GetByID(ctx, userId).Return(nil, errors.New("user not found"))
+	mockUserRepo.EXPECT().GetByID(ctx, userID).Return(nil, errors.New("user not found"))
 
-	err := userService.UpdateProfile(ctx, userId, req)
+	err := userService.UpdateGeo(ctx, userID, service.UpdateUserGeoInput{Address: &address})
 
 	assert.Error(t, err)
 }
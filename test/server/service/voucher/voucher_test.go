@@ -0,0 +1,164 @@
+package voucher_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/go-nunu/nunu-layout-advanced/internal/model"
+	"github.com/go-nunu/nunu-layout-advanced/internal/repository"
+	"github.com/go-nunu/nunu-layout-advanced/internal/service"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/jwt"
+	"github.com/go-nunu/nunu-layout-advanced/pkg/log"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestAdjustVoucher_Concurrent(t *testing.T) {
+	db := newDB(t)
+	logger := &log.Logger{Logger: zap.NewNop()}
+	repo := repository.NewRepository(logger, db)
+	srv := service.NewService(repository.NewTransaction(repo), logger, nil, jwt.NewJwt(viper.New()))
+	voucherService := newVoucherService(srv, repo)
+	ctx := context.Background()
+	now := time.Now()
+
+	user := &model.User{ContactVoucherNum: 5, CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+	expireAt := now.Add(time.Hour)
+	assert.NoError(t, db.Create(&model.ContactVoucherBatch{
+		UserID: user.ID, BizType: model.ContactVoucherHistoryBuy, TotalNum: 5, RemainNum: 5, ExpireAt: &expireAt, CreateAt: now, UpdateAt: now,
+	}).Error)
+
+	const workers = 12
+	var (
+		wg           sync.WaitGroup
+		mu           sync.Mutex
+		succeeded    int
+		insufficient int
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := voucherService.AdjustVoucher(ctx, user.ID, model.ContactVoucherHistoryCost, -1, "拨打电话")
+			mu.Lock()
+			defer mu.Unlock()
+			switch err {
+			case nil:
+				succeeded++
+			case service.ErrInsufficientVoucher:
+				insufficient++
+			default:
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	assert.Equal(t, 5, succeeded)
+	assert.Equal(t, workers-5, insufficient)
+	var got model.User
+	assert.NoError(t, db.First(&got, user.ID).Error)
+	assert.Equal(t, 0, got.ContactVoucherNum)
+
+	var histories []*model.ContactVoucherHistory
+	assert.NoError(t, db.Where("user_id = ?", user.ID).Order("id ASC").Find(&histories).Error)
+	assert.Len(t, histories, 5)
+	for i, history := range histories {
+		assert.Equal(t, 5-i, history.LastNum)
+		assert.Equal(t, 4-i, history.NextNum)
+	}
+
+	// A stale profile save must not roll the balance back.
+	user.Address = "stale"
+	assert.NoError(t, repository.NewUserRepository(repo).Update(ctx, user))
+	assert.NoError(t, db.First(&got, user.ID).Error)
+	assert.Equal(t, 0, got.ContactVoucherNum)
+}
+
+func TestVoucherBatches_FIFOAndExpiry(t *testing.T) {
+	db := newDB(t)
+	logger := &log.Logger{Logger: zap.NewNop()}
+	repo := repository.NewRepository(logger, db)
+	srv := service.NewService(repository.NewTransaction(repo), logger, nil, jwt.NewJwt(viper.New()))
+	voucherService := newVoucherService(srv, repo)
+	ctx := context.Background()
+	now := time.Now()
+
+	user := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+	late, err := voucherService.GrantVoucher(ctx, service.VoucherGrantInput{
+		UserID: user.ID, BizType: model.ContactVoucherHistoryBuy, Num: 5, ExpireAt: now.Add(48 * time.Hour),
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, late)
+	_, err = voucherService.GrantVoucher(ctx, service.VoucherGrantInput{
+		UserID: user.ID, BizType: model.ContactVoucherHistoryBuy, Num: 2, ExpireAt: now.Add(time.Hour),
+	})
+	assert.NoError(t, err)
+
+	// Spending 3 empties the batch expiring in an hour before touching the later one.
+	next, err := voucherService.AdjustVoucher(ctx, user.ID, model.ContactVoucherHistoryCost, -3, "拨打电话")
+	assert.NoError(t, err)
+	assert.Equal(t, 4, next)
+	batches, err := voucherService.ListBatches(ctx, user.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, batches, 1) {
+		assert.Equal(t, 5, batches[0].TotalNum)
+		assert.Equal(t, 4, batches[0].RemainNum)
+	}
+
+	expired, err := voucherService.ExpireVouchers(ctx, now.Add(72*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, expired)
+	num, err := voucherService.GetUserVoucherNum(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, num)
+
+	var history model.ContactVoucherHistory
+	assert.NoError(t, db.Where("user_id = ?", user.ID).Order("id DESC").First(&history).Error)
+	assert.Equal(t, model.ContactVoucherHistoryExpired, history.BizType)
+	assert.Equal(t, -4, history.ChangeNum)
+	assert.Equal(t, 4, history.LastNum)
+	assert.Equal(t, 0, history.NextNum)
+
+	// A second run finds nothing left to expire.
+	expired, err = voucherService.ExpireVouchers(ctx, now.Add(72*time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, expired)
+}
+
+func TestAdjustVoucher_ExpiredBeforeTheSweep(t *testing.T) {
+	db := newDB(t)
+	logger := &log.Logger{Logger: zap.NewNop()}
+	repo := repository.NewRepository(logger, db)
+	srv := service.NewService(repository.NewTransaction(repo), logger, nil, jwt.NewJwt(viper.New()))
+	voucherService := newVoucherService(srv, repo)
+	ctx := context.Background()
+	now := time.Now()
+
+	user := &model.User{CreateAt: now, UpdateAt: now}
+	assert.NoError(t, db.Create(user).Error)
+	_, err := voucherService.GrantVoucher(ctx, service.VoucherGrantInput{
+		UserID: user.ID, BizType: model.ContactVoucherHistoryBuy, Num: 2, ExpireAt: now.Add(-time.Minute),
+	})
+	assert.NoError(t, err)
+
+	// The cached balance still says 2, but nothing is left to spend.
+	_, err = voucherService.AdjustVoucher(ctx, user.ID, model.ContactVoucherHistoryCost, -1, "拨打电话")
+	assert.Equal(t, service.ErrInsufficientVoucher, err)
+	num, err := voucherService.GetUserVoucherNum(ctx, user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, num)
+
+	expired, err := voucherService.ExpireVouchers(ctx, now)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, expired)
+	var history model.ContactVoucherHistory
+	assert.NoError(t, db.Where("user_id = ?", user.ID).Order("id DESC").First(&history).Error)
+	assert.Equal(t, model.ContactVoucherHistoryExpired, history.BizType)
+	assert.Equal(t, -2, history.ChangeNum)
+}